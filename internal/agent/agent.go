@@ -27,6 +27,7 @@ import (
 	"charm.land/fantasy/providers/openai"
 	"charm.land/fantasy/providers/openrouter"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
@@ -58,6 +59,7 @@ type SessionAgentCall struct {
 type SessionAgent interface {
 	Run(context.Context, SessionAgentCall) (*fantasy.AgentResult, error)
 	SetModels(large Model, small Model)
+	SetSummaryModel(model Model, opts fantasy.ProviderOptions)
 	SetTools(tools []fantasy.AgentTool)
 	Cancel(sessionID string)
 	CancelAll()
@@ -67,6 +69,9 @@ type SessionAgent interface {
 	ClearQueue(sessionID string)
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
 	Model() Model
+	SummaryModel() Model
+	PromptTokens() int64
+	ContextUsage(ctx context.Context, sessionID string, contextFiles []prompt.ContextFile, attachments []message.Attachment) (ContextUsage, error)
 }
 
 type Model struct {
@@ -78,6 +83,8 @@ type Model struct {
 type sessionAgent struct {
 	largeModel           Model
 	smallModel           Model
+	summaryModel         Model
+	summaryOptions       fantasy.ProviderOptions
 	systemPromptPrefix   string
 	systemPrompt         string
 	tools                []fantasy.AgentTool
@@ -85,6 +92,7 @@ type sessionAgent struct {
 	messages             message.Service
 	disableAutoSummarize bool
 	isYolo               bool
+	promptCache          *config.PromptCache
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
 	activeRequests *csync.Map[string, context.CancelFunc]
@@ -100,14 +108,27 @@ type SessionAgentOptions struct {
 	Sessions             session.Service
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
+	// PromptCache places the cache breakpoints, on the system prompt, the
+	// tools and the last two messages when not set.
+	PromptCache *config.PromptCache
+	// SummaryModel summarizes sessions with SummaryProviderOptions, the
+	// large model when not set.
+	SummaryModel           Model
+	SummaryProviderOptions fantasy.ProviderOptions
 }
 
 func NewSessionAgent(
 	opts SessionAgentOptions,
 ) SessionAgent {
+	summaryModel := opts.SummaryModel
+	if summaryModel.Model == nil {
+		summaryModel = opts.LargeModel
+	}
 	return &sessionAgent{
 		largeModel:           opts.LargeModel,
 		smallModel:           opts.SmallModel,
+		summaryModel:         summaryModel,
+		summaryOptions:       opts.SummaryProviderOptions,
 		systemPromptPrefix:   opts.SystemPromptPrefix,
 		systemPrompt:         opts.SystemPrompt,
 		sessions:             opts.Sessions,
@@ -115,6 +136,7 @@ func NewSessionAgent(
 		disableAutoSummarize: opts.DisableAutoSummarize,
 		tools:                opts.Tools,
 		isYolo:               opts.IsYolo,
+		promptCache:          opts.PromptCache,
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
 	}
@@ -139,7 +161,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, nil
 	}
 
-	if len(a.tools) > 0 {
+	if len(a.tools) > 0 && a.promptCache.CacheTools() {
 		// Add Anthropic caching to the last tool.
 		a.tools[len(a.tools)-1].SetProviderOptions(a.getCacheControlOptions())
 	}
@@ -194,7 +216,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	var currentAssistant *message.Message
 	var shouldSummarize bool
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
-		Prompt:           call.Prompt,
+		Prompt:           message.PromptWithAttachments(call.Prompt, call.Attachments),
 		Files:            files,
 		Messages:         history,
 		ProviderOptions:  call.ProviderOptions,
@@ -223,7 +245,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			}
 
 			lastSystemRoleInx := 0
-			systemMessageUpdated := false
+			systemMessageUpdated := !a.promptCache.CacheSystemPrompt()
+			cachedMessages := a.promptCache.CachedMessages()
 			for i, msg := range prepared.Messages {
 				// Only add cache control to the last message.
 				if msg.Role == fantasy.MessageRoleSystem {
@@ -232,8 +255,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 					prepared.Messages[lastSystemRoleInx].ProviderOptions = a.getCacheControlOptions()
 					systemMessageUpdated = true
 				}
-				// Than add cache control to the last 2 messages.
-				if i > len(prepared.Messages)-3 {
+				// Than add cache control to the last messages.
+				if i >= len(prepared.Messages)-cachedMessages {
 					prepared.Messages[i].ProviderOptions = a.getCacheControlOptions()
 				}
 			}
@@ -242,6 +265,11 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(a.systemPromptPrefix)}, prepared.Messages...)
 			}
 
+			// Images of the session may have been sent to a previous model.
+			if !a.largeModel.CatwalkCfg.SupportsImages {
+				prepared.Messages = withoutImages(prepared.Messages)
+			}
+
 			var assistantMsg message.Message
 			assistantMsg, err = a.messages.Create(callContext, call.SessionID, message.CreateMessageParams{
 				Role:     message.Assistant,
@@ -367,6 +395,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			}
 			currentAssistant.AddFinish(finishReason, "", "")
 			a.updateSessionUsage(a.largeModel, &currentSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			currentSession.CacheReadTokens = stepResult.Usage.CacheReadTokens
+			currentSession.CacheWriteTokens = stepResult.Usage.CacheCreationTokens
 			sessionLock.Lock()
 			_, sessionErr := a.sessions.Save(genCtx, currentSession)
 			sessionLock.Unlock()
@@ -487,7 +517,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
-		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
+		if summarizeErr := a.Summarize(genCtx, call.SessionID, a.summaryOptions); summarizeErr != nil {
 			return nil, summarizeErr
 		}
 		// If the agent wasn't done...
@@ -541,13 +571,13 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	defer a.activeRequests.Del(sessionID)
 	defer cancel()
 
-	agent := fantasy.NewAgent(a.largeModel.Model,
+	agent := fantasy.NewAgent(a.summaryModel.Model,
 		fantasy.WithSystemPrompt(string(summaryPrompt)),
 	)
 	summaryMessage, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
 		Role:             message.Assistant,
-		Model:            a.largeModel.Model.Model(),
-		Provider:         a.largeModel.Model.Provider(),
+		Model:            a.summaryModel.Model.Model(),
+		Provider:         a.summaryModel.Model.Provider(),
 		IsSummaryMessage: true,
 	})
 	if err != nil {
@@ -612,13 +642,15 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}
 
-	a.updateSessionUsage(a.largeModel, &currentSession, resp.TotalUsage, openrouterCost)
+	a.updateSessionUsage(a.summaryModel, &currentSession, resp.TotalUsage, openrouterCost)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
 	currentSession.SummaryMessageID = summaryMessage.ID
 	currentSession.CompletionTokens = usage.OutputTokens
 	currentSession.PromptTokens = 0
+	currentSession.CacheReadTokens = 0
+	currentSession.CacheWriteTokens = 0
 	_, err = a.sessions.Save(genCtx, currentSession)
 	return err
 }
@@ -670,6 +702,9 @@ func (a *sessionAgent) preparePrompt(msgs []message.Message, attachments ...mess
 
 	var files []fantasy.FilePart
 	for _, attachment := range attachments {
+		if attachment.IsText() {
+			continue
+		}
 		files = append(files, fantasy.FilePart{
 			Filename:  attachment.FileName,
 			Data:      attachment.Content,
@@ -796,11 +831,24 @@ func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session,
 	} else {
 		session.Cost += cost
 	}
+	session.CacheSavings += cacheSavings(modelConfig, usage)
 
 	session.CompletionTokens = usage.OutputTokens + usage.CacheReadTokens
 	session.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
 }
 
+// cacheSavings returns what the cache reads of the usage saved, minus what
+// its cache writes cost over uncached input, or zero when the price of the
+// model is unknown.
+func cacheSavings(model catwalk.Model, usage fantasy.Usage) float64 {
+	if model.CostPer1MIn == 0 {
+		return 0
+	}
+	saved := (model.CostPer1MIn - model.CostPer1MOutCached) / 1e6 * float64(usage.CacheReadTokens)
+	spent := (model.CostPer1MInCached - model.CostPer1MIn) / 1e6 * float64(usage.CacheCreationTokens)
+	return saved - spent
+}
+
 func (a *sessionAgent) Cancel(sessionID string) {
 	// Cancel regular requests.
 	if cancel, ok := a.activeRequests.Take(sessionID); ok && cancel != nil {
@@ -875,6 +923,11 @@ func (a *sessionAgent) SetModels(large Model, small Model) {
 	a.smallModel = small
 }
 
+func (a *sessionAgent) SetSummaryModel(model Model, opts fantasy.ProviderOptions) {
+	a.summaryModel = model
+	a.summaryOptions = opts
+}
+
 func (a *sessionAgent) SetTools(tools []fantasy.AgentTool) {
 	a.tools = tools
 }
@@ -882,3 +935,7 @@ func (a *sessionAgent) SetTools(tools []fantasy.AgentTool) {
 func (a *sessionAgent) Model() Model {
 	return a.largeModel
 }
+
+func (a *sessionAgent) SummaryModel() Model {
+	return a.summaryModel
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/permission"
 )
 
@@ -132,19 +133,19 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				return fantasy.ToolResponse{}, fmt.Errorf("error creating prompt: %s", err)
 			}
 
-			_, small, err := c.buildAgentModels(ctx)
+			model, err := c.buildModel(ctx, c.cfg.Routing.Model(config.ModelTaskAgenticFetch))
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error building models: %s", err)
 			}
 
-			systemPrompt, err := promptTemplate.Build(ctx, small.Model.Provider(), small.Model.Model(), *c.cfg)
+			systemPrompt, err := promptTemplate.Build(ctx, model.Model.Provider(), model.Model.Model(), *c.cfg)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error building system prompt: %s", err)
 			}
 
-			smallProviderCfg, ok := c.cfg.Providers.Get(small.ModelCfg.Provider)
+			providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
 			if !ok {
-				return fantasy.ToolResponse{}, errors.New("small model provider not configured")
+				return fantasy.ToolResponse{}, errors.New("model provider not configured")
 			}
 
 			webFetchTool := tools.NewWebFetchTool(tmpDir, client)
@@ -156,15 +157,17 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 			}
 
 			agent := NewSessionAgent(SessionAgentOptions{
-				LargeModel:           small, // Use small model for both (fetch doesn't need large)
-				SmallModel:           small,
-				SystemPromptPrefix:   smallProviderCfg.SystemPromptPrefix,
-				SystemPrompt:         systemPrompt,
-				DisableAutoSummarize: c.cfg.Options.DisableAutoSummarize,
-				IsYolo:               c.permissions.SkipRequests(),
-				Sessions:             c.sessions,
-				Messages:             c.messages,
-				Tools:                fetchTools,
+				LargeModel:             model, // Use the routed model for both
+				SmallModel:             model,
+				SystemPromptPrefix:     providerCfg.SystemPromptPrefix,
+				SystemPrompt:           systemPrompt,
+				DisableAutoSummarize:   c.cfg.Options.DisableAutoSummarize,
+				IsYolo:                 c.permissions.SkipRequests(),
+				Sessions:               c.sessions,
+				Messages:               c.messages,
+				Tools:                  fetchTools,
+				SummaryModel:           model,
+				SummaryProviderOptions: getProviderOptions(model, providerCfg),
 			})
 
 			agentToolSessionID := c.sessions.CreateAgentToolSessionID(validationResult.AgentMessageID, call.ID)
@@ -175,22 +178,22 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 
 			c.permissions.AutoApproveSession(session.ID)
 
-			// Use small model for web content analysis (faster and cheaper)
-			maxTokens := small.CatwalkCfg.DefaultMaxTokens
-			if small.ModelCfg.MaxTokens != 0 {
-				maxTokens = small.ModelCfg.MaxTokens
+			// Use the model routed to web content analysis, small by default
+			maxTokens := model.CatwalkCfg.DefaultMaxTokens
+			if model.ModelCfg.MaxTokens != 0 {
+				maxTokens = model.ModelCfg.MaxTokens
 			}
 
 			result, err := agent.Run(ctx, SessionAgentCall{
 				SessionID:        session.ID,
 				Prompt:           fullPrompt,
 				MaxOutputTokens:  maxTokens,
-				ProviderOptions:  getProviderOptions(small, smallProviderCfg),
-				Temperature:      small.ModelCfg.Temperature,
-				TopP:             small.ModelCfg.TopP,
-				TopK:             small.ModelCfg.TopK,
-				FrequencyPenalty: small.ModelCfg.FrequencyPenalty,
-				PresencePenalty:  small.ModelCfg.PresencePenalty,
+				ProviderOptions:  getProviderOptions(model, providerCfg),
+				Temperature:      model.ModelCfg.Temperature,
+				TopP:             model.ModelCfg.TopP,
+				TopK:             model.ModelCfg.TopK,
+				FrequencyPenalty: model.ModelCfg.FrequencyPenalty,
+				PresencePenalty:  model.ModelCfg.PresencePenalty,
 			})
 			if err != nil {
 				return fantasy.NewTextErrorResponse("error generating response"), nil
@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheSavings(t *testing.T) {
+	t.Parallel()
+
+	// Claude Sonnet 4 pricing: cache writes cost 25% more than input and
+	// cache reads 90% less.
+	model := catwalk.Model{CostPer1MIn: 3, CostPer1MInCached: 3.75, CostPer1MOutCached: 0.3}
+	require.InDelta(t, -0.075, cacheSavings(model, fantasy.Usage{CacheCreationTokens: 100_000}), 1e-9)
+	require.InDelta(t, 0.27, cacheSavings(model, fantasy.Usage{CacheReadTokens: 100_000}), 1e-9)
+	require.Zero(t, cacheSavings(catwalk.Model{}, fantasy.Usage{CacheReadTokens: 100_000}))
+}
+
+func TestWithPromptCacheKey(t *testing.T) {
+	t.Parallel()
+
+	opts := &openai.ProviderOptions{}
+	options := fantasy.ProviderOptions{openai.Name: opts}
+	keyed := withPromptCacheKey(options, "session")
+	require.Equal(t, "session", *keyed[openai.Name].(*openai.ProviderOptions).PromptCacheKey)
+	// The options are shared by the calls of every session.
+	require.Nil(t, opts.PromptCacheKey)
+
+	configured := "team"
+	options = fantasy.ProviderOptions{openai.Name: &openai.ResponsesProviderOptions{PromptCacheKey: &configured}}
+	require.Equal(t, "team", *withPromptCacheKey(options, "session")[openai.Name].(*openai.ResponsesProviderOptions).PromptCacheKey)
+}
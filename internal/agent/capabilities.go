@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+)
+
+// withoutImages replaces the images of the messages, attached files and
+// tool results alike, with a note for models that do not support them.
+func withoutImages(msgs []fantasy.Message) []fantasy.Message {
+	out := make([]fantasy.Message, len(msgs))
+	for i, msg := range msgs {
+		parts := make([]fantasy.MessagePart, 0, len(msg.Content))
+		for _, part := range msg.Content {
+			switch p := part.(type) {
+			case fantasy.FilePart:
+				if !strings.HasPrefix(p.MediaType, "text/") {
+					part = fantasy.TextPart{Text: fmt.Sprintf("[File %s omitted: the model does not support images]", p.Filename)}
+				}
+			case fantasy.ToolResultPart:
+				if _, ok := p.Output.(fantasy.ToolResultOutputContentMedia); ok {
+					p.Output = fantasy.ToolResultOutputContentText{Text: "[Image omitted: the model does not support images]"}
+					part = p
+				}
+			}
+			parts = append(parts, part)
+		}
+		msg.Content = parts
+		out[i] = msg
+	}
+	return out
+}
@@ -10,7 +10,9 @@ import (
 	"io"
 	"log/slog"
 	"maps"
+	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 
@@ -25,6 +27,7 @@ import (
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/plugin"
 	"github.com/charmbracelet/crush/internal/session"
 	"golang.org/x/sync/errgroup"
 
@@ -51,7 +54,14 @@ type Coordinator interface {
 	ClearQueue(sessionID string)
 	Summarize(context.Context, string) error
 	Model() Model
+	// PromptTokens estimates the number of tokens of the system prompt and
+	// the tools sent with every call.
+	PromptTokens() int64
+	// ContextUsage breaks down the context window the next call of the
+	// session takes, with the attachments about to be sent.
+	ContextUsage(ctx context.Context, sessionID string, attachments ...message.Attachment) (ContextUsage, error)
 	UpdateModels(ctx context.Context) error
+	RefreshTools(ctx context.Context) error
 }
 
 type coordinator struct {
@@ -61,6 +71,9 @@ type coordinator struct {
 	permissions permission.Service
 	history     history.Service
 	lspClients  *csync.Map[string, *lsp.Client]
+	// cassette records the exchanges with providers or replays them, when
+	// configured.
+	cassette *log.CassetteTransport
 
 	currentAgent SessionAgent
 	agents       map[string]SessionAgent
@@ -87,6 +100,22 @@ func NewCoordinator(
 		agents:      make(map[string]SessionAgent),
 	}
 
+	if cassette := cfg.Options.Cassette; cassette != nil {
+		if cassette.Path == "" {
+			return nil, errors.New("cassette path not set")
+		}
+		path := cassette.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cfg.WorkingDir(), path)
+		}
+		transport, err := log.NewCassetteTransport(log.CassetteMode(cassette.Mode), path)
+		if err != nil {
+			return nil, err
+		}
+		c.cassette = transport
+		slog.Info("Using provider cassette", "mode", cassette.Mode, "path", path)
+	}
+
 	agentCfg, ok := cfg.Agents[config.AgentCoder]
 	if !ok {
 		return nil, errors.New("coder agent not configured")
@@ -119,8 +148,14 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		maxTokens = model.ModelCfg.MaxTokens
 	}
 
-	if !model.CatwalkCfg.SupportsImages && attachments != nil {
-		attachments = nil
+	if !model.CatwalkCfg.SupportsImages {
+		kept := slices.DeleteFunc(slices.Clone(attachments), func(a message.Attachment) bool {
+			return !a.IsText()
+		})
+		if len(kept) != len(attachments) {
+			slog.Warn("Removing image attachments, the model does not support images", "model", model.CatwalkCfg.Name, "removed", len(attachments)-len(kept))
+		}
+		attachments = kept
 	}
 
 	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
@@ -129,6 +164,9 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	}
 
 	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
+	if c.cfg.Options.PromptCache.UseSessionKey() {
+		mergedOptions = withPromptCacheKey(mergedOptions, sessionID)
+	}
 
 	return c.currentAgent.Run(ctx, SessionAgentCall{
 		SessionID:        sessionID,
@@ -259,11 +297,40 @@ func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.
 		if err == nil {
 			options[openaicompat.Name] = parsed
 		}
+	case plugin.Name:
+		_, hasReasoningEffort := mergedOptions["reasoning_effort"]
+		if !hasReasoningEffort && model.ModelCfg.ReasoningEffort != "" {
+			mergedOptions["reasoning_effort"] = model.ModelCfg.ReasoningEffort
+		}
+		parsed := plugin.ProviderOptions(mergedOptions)
+		options[plugin.Name] = &parsed
 	}
 
 	return options
 }
 
+// withPromptCacheKey sets the cache key of OpenAI requests, for the ones of
+// the same session to hit the same cache, unless one is configured.
+func withPromptCacheKey(options fantasy.ProviderOptions, key string) fantasy.ProviderOptions {
+	switch opts := options[openai.Name].(type) {
+	case *openai.ProviderOptions:
+		if opts.PromptCacheKey == nil {
+			keyed := *opts
+			keyed.PromptCacheKey = &key
+			options = maps.Clone(options)
+			options[openai.Name] = &keyed
+		}
+	case *openai.ResponsesProviderOptions:
+		if opts.PromptCacheKey == nil {
+			keyed := *opts
+			keyed.PromptCacheKey = &key
+			options = maps.Clone(options)
+			options[openai.Name] = &keyed
+		}
+	}
+	return options
+}
+
 func mergeCallOptions(model Model, cfg config.ProviderConfig) (fantasy.ProviderOptions, *float64, *float64, *int64, *float64, *float64) {
 	modelOptions := getProviderOptions(model, cfg)
 	temp := cmp.Or(model.ModelCfg.Temperature, model.CatwalkCfg.Options.Temperature)
@@ -275,27 +342,29 @@ func mergeCallOptions(model Model, cfg config.ProviderConfig) (fantasy.ProviderO
 }
 
 func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, agent config.Agent) (SessionAgent, error) {
-	large, small, err := c.buildAgentModels(ctx)
+	models, err := c.buildAgentModels(ctx, agent)
 	if err != nil {
 		return nil, err
 	}
 
-	systemPrompt, err := prompt.Build(ctx, large.Model.Provider(), large.Model.Model(), *c.cfg)
+	systemPrompt, err := prompt.Build(ctx, models.main.Model.Provider(), models.main.Model.Model(), *c.cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	largeProviderCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
+	providerCfg, _ := c.cfg.Providers.Get(models.main.ModelCfg.Provider)
 	result := NewSessionAgent(SessionAgentOptions{
-		large,
-		small,
-		largeProviderCfg.SystemPromptPrefix,
-		systemPrompt,
-		c.cfg.Options.DisableAutoSummarize,
-		c.permissions.SkipRequests(),
-		c.sessions,
-		c.messages,
-		nil,
+		LargeModel:             models.main,
+		SmallModel:             models.title,
+		SystemPromptPrefix:     providerCfg.SystemPromptPrefix,
+		SystemPrompt:           systemPrompt,
+		DisableAutoSummarize:   c.cfg.Options.DisableAutoSummarize,
+		IsYolo:                 c.permissions.SkipRequests(),
+		Sessions:               c.sessions,
+		Messages:               c.messages,
+		SummaryModel:           models.summary,
+		SummaryProviderOptions: c.providerOptions(models.summary),
+		PromptCache:            c.cfg.Options.PromptCache,
 	})
 	c.readyWg.Go(func() error {
 		tools, err := c.buildTools(ctx, agent)
@@ -352,7 +421,15 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	)
 
 	if len(c.cfg.LSP) > 0 {
-		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspClients), tools.NewReferencesTool(c.lspClients))
+		allTools = append(allTools,
+			tools.NewDiagnosticsTool(c.lspClients),
+			tools.NewReferencesTool(c.lspClients),
+			tools.NewCodeActionsTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		)
+	}
+
+	if len(c.cfg.MCP) > 0 {
+		allTools = append(allTools, tools.NewMCPResourcesTool(agent.AllowedMCP))
 	}
 
 	var filteredTools []fantasy.AgentTool
@@ -390,88 +467,97 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	return filteredTools, nil
 }
 
-// TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
-func (c *coordinator) buildAgentModels(ctx context.Context) (Model, Model, error) {
-	largeModelCfg, ok := c.cfg.Models[config.SelectedModelTypeLarge]
-	if !ok {
-		return Model{}, Model{}, errors.New("large model not selected")
+// agentModels are the models a session agent runs on.
+type agentModels struct {
+	main    Model
+	title   Model
+	summary Model
+}
+
+// buildAgentModels builds the model the agent runs on and the ones the
+// routing picks to generate titles and summarize sessions.
+func (c *coordinator) buildAgentModels(ctx context.Context, agent config.Agent) (agentModels, error) {
+	main, err := c.buildModel(ctx, agent.Model)
+	if err != nil {
+		return agentModels{}, err
 	}
-	smallModelCfg, ok := c.cfg.Models[config.SelectedModelTypeSmall]
-	if !ok {
-		return Model{}, Model{}, errors.New("small model not selected")
+	title, err := c.buildModel(ctx, c.cfg.Routing.Model(config.ModelTaskTitle))
+	if err != nil {
+		return agentModels{}, err
 	}
-
-	largeProviderCfg, ok := c.cfg.Providers.Get(largeModelCfg.Provider)
-	if !ok {
-		return Model{}, Model{}, errors.New("large model provider not configured")
+	summary, err := c.buildModel(ctx, c.cfg.Routing.Model(config.ModelTaskSummarize))
+	if err != nil {
+		return agentModels{}, err
 	}
+	return agentModels{main: main, title: title, summary: summary}, nil
+}
 
-	largeProvider, err := c.buildProvider(largeProviderCfg, largeModelCfg)
-	if err != nil {
-		return Model{}, Model{}, err
+// buildModel builds the language model of an entry of the models.
+func (c *coordinator) buildModel(ctx context.Context, modelType config.SelectedModelType) (Model, error) {
+	modelCfg, ok := c.cfg.Models[modelType]
+	if !ok {
+		return Model{}, fmt.Errorf("%s model not selected", modelType)
 	}
 
-	smallProviderCfg, ok := c.cfg.Providers.Get(smallModelCfg.Provider)
+	providerCfg, ok := c.cfg.Providers.Get(modelCfg.Provider)
 	if !ok {
-		return Model{}, Model{}, errors.New("large model provider not configured")
+		return Model{}, fmt.Errorf("%s model provider not configured", modelType)
 	}
 
-	smallProvider, err := c.buildProvider(smallProviderCfg, largeModelCfg)
+	provider, err := c.buildProvider(providerCfg, modelCfg)
 	if err != nil {
-		return Model{}, Model{}, err
+		return Model{}, err
 	}
 
-	var largeCatwalkModel *catwalk.Model
-	var smallCatwalkModel *catwalk.Model
-
-	for _, m := range largeProviderCfg.Models {
-		if m.ID == largeModelCfg.Model {
-			largeCatwalkModel = &m
+	var catwalkModel *catwalk.Model
+	for _, m := range providerCfg.Models {
+		if m.ID == modelCfg.Model {
+			catwalkModel = &m
+			break
 		}
 	}
-	for _, m := range smallProviderCfg.Models {
-		if m.ID == smallModelCfg.Model {
-			smallCatwalkModel = &m
-		}
+	if catwalkModel == nil {
+		return Model{}, fmt.Errorf("%s model not found in provider config", modelType)
 	}
 
-	if largeCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("large model not found in provider config")
+	modelID := modelCfg.Model
+	if modelCfg.Provider == openrouter.Name && isExactoSupported(modelID) {
+		modelID += ":exacto"
 	}
 
-	if smallCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("snall model not found in provider config")
+	languageModel, err := provider.LanguageModel(ctx, modelID)
+	if err != nil {
+		return Model{}, err
 	}
+	textTools := slices.Contains(providerCfg.TextToolModels, modelCfg.Model)
+	return Model{
+		Model:      newTextToolModel(languageModel, textTools),
+		CatwalkCfg: *catwalkModel,
+		ModelCfg:   modelCfg,
+	}, nil
+}
 
-	largeModelID := largeModelCfg.Model
-	smallModelID := smallModelCfg.Model
-
-	if largeModelCfg.Provider == openrouter.Name && isExactoSupported(largeModelID) {
-		largeModelID += ":exacto"
-	}
+// providerOptions returns the provider options of the model.
+func (c *coordinator) providerOptions(model Model) fantasy.ProviderOptions {
+	providerCfg, _ := c.cfg.Providers.Get(model.ModelCfg.Provider)
+	return getProviderOptions(model, providerCfg)
+}
 
-	if smallModelCfg.Provider == openrouter.Name && isExactoSupported(smallModelID) {
-		smallModelID += ":exacto"
+// httpClient returns the HTTP client of the providers, which logs exchanges
+// in debug mode and goes through the cassette when configured. It returns nil
+// when the providers can use their default client.
+func (c *coordinator) httpClient() *http.Client {
+	transport := http.DefaultTransport
+	if c.cassette != nil {
+		transport = c.cassette
 	}
-
-	largeModel, err := largeProvider.LanguageModel(ctx, largeModelID)
-	if err != nil {
-		return Model{}, Model{}, err
+	if c.cfg.Options.Debug {
+		transport = &log.HTTPRoundTripLogger{Transport: transport}
 	}
-	smallModel, err := smallProvider.LanguageModel(ctx, smallModelID)
-	if err != nil {
-		return Model{}, Model{}, err
+	if transport == http.DefaultTransport {
+		return nil
 	}
-
-	return Model{
-			Model:      largeModel,
-			CatwalkCfg: *largeCatwalkModel,
-			ModelCfg:   largeModelCfg,
-		}, Model{
-			Model:      smallModel,
-			CatwalkCfg: *smallCatwalkModel,
-			ModelCfg:   smallModelCfg,
-		}, nil
+	return &http.Client{Transport: transport}
 }
 
 func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
@@ -507,8 +593,7 @@ func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map
 		opts = append(opts, anthropic.WithBaseURL(baseURL))
 	}
 
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.httpClient(); httpClient != nil {
 		opts = append(opts, anthropic.WithHTTPClient(httpClient))
 	}
 
@@ -520,8 +605,7 @@ func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[st
 		openai.WithAPIKey(apiKey),
 		openai.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.httpClient(); httpClient != nil {
 		opts = append(opts, openai.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -537,8 +621,7 @@ func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[stri
 	opts := []openrouter.Option{
 		openrouter.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.httpClient(); httpClient != nil {
 		opts = append(opts, openrouter.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -552,8 +635,7 @@ func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers
 		openaicompat.WithBaseURL(baseURL),
 		openaicompat.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.httpClient(); httpClient != nil {
 		opts = append(opts, openaicompat.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -573,8 +655,7 @@ func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[str
 		azure.WithAPIKey(apiKey),
 		azure.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.httpClient(); httpClient != nil {
 		opts = append(opts, azure.WithHTTPClient(httpClient))
 	}
 	if options == nil {
@@ -592,8 +673,7 @@ func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[str
 
 func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.Provider, error) {
 	var opts []bedrock.Option
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.httpClient(); httpClient != nil {
 		opts = append(opts, bedrock.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -611,8 +691,7 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 		google.WithBaseURL(baseURL),
 		google.WithGeminiAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.httpClient(); httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -623,8 +702,7 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 
 func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, options map[string]string) (fantasy.Provider, error) {
 	opts := []google.Option{}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.httpClient(); httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -639,6 +717,25 @@ func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, optio
 	return google.New(opts...)
 }
 
+func (c *coordinator) buildPluginProvider(providerCfg config.ProviderConfig, baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+	opts := plugin.Options{
+		ID:      providerCfg.ID,
+		Command: providerCfg.Command,
+		Args:    providerCfg.Args,
+		Env:     providerCfg.ResolvedEnv(),
+		URL:     baseURL,
+		Headers: headers,
+		APIKey:  apiKey,
+	}
+	if opts.Command != "" && !filepath.IsAbs(opts.Command) && strings.ContainsRune(opts.Command, filepath.Separator) {
+		opts.Command = filepath.Join(c.cfg.WorkingDir(), opts.Command)
+	}
+	if httpClient := c.httpClient(); httpClient != nil {
+		opts.Client = httpClient
+	}
+	return plugin.New(opts)
+}
+
 func (c *coordinator) isAnthropicThinking(model config.SelectedModel) bool {
 	if model.Think {
 		return true
@@ -693,6 +790,8 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 		return c.buildGoogleVertexProvider(headers, providerCfg.ExtraParams)
 	case openaicompat.Name:
 		return c.buildOpenaiCompatProvider(baseURL, apiKey, headers, providerCfg.ExtraBody)
+	case plugin.Name:
+		return c.buildPluginProvider(providerCfg, baseURL, apiKey, headers)
 	default:
 		return nil, fmt.Errorf("provider type not supported: %q", providerCfg.Type)
 	}
@@ -733,14 +832,45 @@ func (c *coordinator) Model() Model {
 	return c.currentAgent.Model()
 }
 
+func (c *coordinator) PromptTokens() int64 {
+	return c.currentAgent.PromptTokens()
+}
+
+func (c *coordinator) ContextUsage(ctx context.Context, sessionID string, attachments ...message.Attachment) (ContextUsage, error) {
+	if err := c.readyWg.Wait(); err != nil {
+		return ContextUsage{}, err
+	}
+	contextFiles := prompt.ContextFiles(*c.cfg)
+	for i, f := range contextFiles {
+		if rel, err := filepath.Rel(c.cfg.WorkingDir(), f.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			contextFiles[i].Path = rel
+		}
+	}
+	return c.currentAgent.ContextUsage(ctx, sessionID, contextFiles, attachments)
+}
+
 func (c *coordinator) UpdateModels(ctx context.Context) error {
 	// build the models again so we make sure we get the latest config
-	large, small, err := c.buildAgentModels(ctx)
+	agentCfg, ok := c.cfg.Agents[config.AgentCoder]
+	if !ok {
+		return errors.New("coder agent not configured")
+	}
+	models, err := c.buildAgentModels(ctx, agentCfg)
 	if err != nil {
 		return err
 	}
-	c.currentAgent.SetModels(large, small)
+	c.currentAgent.SetModels(models.main, models.title)
+	c.currentAgent.SetSummaryModel(models.summary, c.providerOptions(models.summary))
+	return c.RefreshTools(ctx)
+}
 
+// RefreshTools rebuilds the tool set of the current agent, e.g. after MCPs
+// were connected or disconnected. It waits for the initial tool set first, so
+// it doesn't get replaced by an outdated one.
+func (c *coordinator) RefreshTools(ctx context.Context) error {
+	if err := c.readyWg.Wait(); err != nil {
+		return err
+	}
 	agentCfg, ok := c.cfg.Agents[config.AgentCoder]
 	if !ok {
 		return errors.New("coder agent not configured")
@@ -759,9 +889,10 @@ func (c *coordinator) QueuedPrompts(sessionID string) int {
 }
 
 func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
-	providerCfg, ok := c.cfg.Providers.Get(c.currentAgent.Model().ModelCfg.Provider)
+	model := c.currentAgent.SummaryModel()
+	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
 	if !ok {
 		return errors.New("model provider not configured")
 	}
-	return c.currentAgent.Summarize(ctx, sessionID, getProviderOptions(c.currentAgent.Model(), providerCfg))
+	return c.currentAgent.Summarize(ctx, sessionID, getProviderOptions(model, providerCfg))
 }
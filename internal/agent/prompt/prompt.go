@@ -146,21 +146,26 @@ func expandPath(path string, cfg config.Config) string {
 	return path
 }
 
-func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg config.Config) (PromptDat, error) {
-	workingDir := cmp.Or(p.workingDir, cfg.WorkingDir())
-	platform := cmp.Or(p.platform, runtime.GOOS)
-
-	files := map[string][]ContextFile{}
-
+// ContextFiles returns the context files included in the system prompt, in
+// the order of the context paths.
+func ContextFiles(cfg config.Config) []ContextFile {
+	var contextFiles []ContextFile
+	seen := map[string]bool{}
 	for _, pth := range cfg.Options.ContextPaths {
 		expanded := expandPath(pth, cfg)
 		pathKey := strings.ToLower(expanded)
-		if _, ok := files[pathKey]; ok {
+		if seen[pathKey] {
 			continue
 		}
-		content := processContextPath(expanded, cfg)
-		files[pathKey] = content
+		seen[pathKey] = true
+		contextFiles = append(contextFiles, processContextPath(expanded, cfg)...)
 	}
+	return contextFiles
+}
+
+func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg config.Config) (PromptDat, error) {
+	workingDir := cmp.Or(p.workingDir, cfg.WorkingDir())
+	platform := cmp.Or(p.platform, runtime.GOOS)
 
 	isGit := isGitRepo(cfg.WorkingDir())
 	data := PromptDat{
@@ -180,9 +185,7 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 		}
 	}
 
-	for _, contextFiles := range files {
-		data.ContextFiles = append(data.ContextFiles, contextFiles...)
-	}
+	data.ContextFiles = ContextFiles(cfg)
 	return data, nil
 }
 
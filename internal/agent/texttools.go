@@ -0,0 +1,448 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"charm.land/fantasy"
+	"github.com/google/uuid"
+)
+
+const (
+	toolCallOpen  = "<tool_call>"
+	toolCallClose = "</tool_call>"
+)
+
+const textToolInstructions = `# Tool calls
+
+You can call the tools below. To call a tool, write a tool_call block holding a JSON object with the name of the tool and its arguments, following the input schema of the tool:
+
+<tool_call>
+{"name": "tool_name", "arguments": {"argument": "value"}}
+</tool_call>
+
+Call several tools at once with one block for each. Stop writing after your tool calls: their results come back in tool_result blocks in the next message.
+`
+
+// textToolModel lets models without native tool calling call tools through a
+// text protocol described in the system prompt. Models configured with native
+// tool calling fall back to it when their provider rejects the tools of a
+// call.
+type textToolModel struct {
+	fantasy.LanguageModel
+	textTools atomic.Bool
+}
+
+func newTextToolModel(model fantasy.LanguageModel, textTools bool) *textToolModel {
+	m := &textToolModel{LanguageModel: model}
+	m.textTools.Store(textTools)
+	return m
+}
+
+func (m *textToolModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	if len(call.Tools) == 0 {
+		return m.LanguageModel.Generate(ctx, call)
+	}
+	if !m.textTools.Load() {
+		resp, err := m.LanguageModel.Generate(ctx, call)
+		if err == nil || !isToolsUnsupported(err) {
+			return resp, err
+		}
+		m.fallBack(err)
+	}
+
+	resp, err := m.LanguageModel.Generate(ctx, textToolCall(call))
+	if err != nil {
+		return nil, err
+	}
+	var content fantasy.ResponseContent
+	for _, c := range resp.Content {
+		text, ok := c.(fantasy.TextContent)
+		if !ok {
+			content = append(content, c)
+			continue
+		}
+		content = append(content, parseToolCalls(text.Text)...)
+	}
+	resp.Content = content
+	if len(content.ToolCalls()) > 0 && resp.FinishReason == fantasy.FinishReasonStop {
+		resp.FinishReason = fantasy.FinishReasonToolCalls
+	}
+	return resp, nil
+}
+
+func (m *textToolModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	if len(call.Tools) == 0 {
+		return m.LanguageModel.Stream(ctx, call)
+	}
+	if m.textTools.Load() {
+		return m.textStream(ctx, call)
+	}
+
+	stream, err := m.LanguageModel.Stream(ctx, call)
+	if err != nil {
+		if !isToolsUnsupported(err) {
+			return nil, err
+		}
+		m.fallBack(err)
+		return m.textStream(ctx, call)
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		started := false
+		for part := range stream {
+			if !started && part.Type == fantasy.StreamPartTypeError && isToolsUnsupported(part.Error) {
+				m.fallBack(part.Error)
+				text, err := m.textStream(ctx, call)
+				if err != nil {
+					yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: err})
+					return
+				}
+				for part := range text {
+					if !yield(part) {
+						return
+					}
+				}
+				return
+			}
+			started = started || part.Type != fantasy.StreamPartTypeWarnings
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+// fallBack switches the model to text tool calls for the rest of the session.
+func (m *textToolModel) fallBack(err error) {
+	if m.textTools.CompareAndSwap(false, true) {
+		slog.Warn("Model does not support tool calling, calling tools through text instead", "model", m.Model(), "error", err)
+	}
+}
+
+// textStream streams the call with the tools described in the prompt,
+// turning the tool call blocks of the response into tool calls.
+func (m *textToolModel) textStream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	stream, err := m.LanguageModel.Stream(ctx, textToolCall(call))
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		p := &toolCallParser{yield: yield}
+		for part := range stream {
+			switch part.Type {
+			case fantasy.StreamPartTypeTextStart, fantasy.StreamPartTypeTextEnd:
+				// The parser delimits the text around the tool calls.
+			case fantasy.StreamPartTypeTextDelta:
+				if !p.write(part.Delta) {
+					return
+				}
+			case fantasy.StreamPartTypeFinish:
+				if !p.flush() {
+					return
+				}
+				if p.calls > 0 && part.FinishReason == fantasy.FinishReasonStop {
+					part.FinishReason = fantasy.FinishReasonToolCalls
+				}
+				yield(part)
+				return
+			default:
+				if !yield(part) {
+					return
+				}
+			}
+		}
+		p.flush()
+	}, nil
+}
+
+// isToolsUnsupported reports whether the provider rejected a call because
+// the model does not support tools.
+func isToolsUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) {
+		msg += " " + strings.ToLower(string(providerErr.ResponseBody))
+	}
+	if !strings.Contains(msg, "tool") {
+		return false
+	}
+	return strings.Contains(msg, "not support") || strings.Contains(msg, "unsupported")
+}
+
+// textToolCall returns the call with its tools described in the prompt
+// rather than passed to the provider.
+func textToolCall(call fantasy.Call) fantasy.Call {
+	call.Prompt = textToolPrompt(call.Prompt, call.Tools)
+	call.Tools = nil
+	call.ToolChoice = nil
+	return call
+}
+
+// textToolPrompt adds the description of the tools after the system
+// messages, and turns tool calls and results into text.
+func textToolPrompt(prompt fantasy.Prompt, tools []fantasy.Tool) fantasy.Prompt {
+	instructions := fantasy.NewSystemMessage(toolInstructions(tools))
+	names := make(map[string]string)
+	out := make(fantasy.Prompt, 0, len(prompt)+1)
+	added := false
+	lastResults := false
+	for _, msg := range prompt {
+		if msg.Role != fantasy.MessageRoleSystem && !added {
+			out = append(out, instructions)
+			added = true
+		}
+		switch msg.Role {
+		case fantasy.MessageRoleAssistant:
+			parts := make([]fantasy.MessagePart, 0, len(msg.Content))
+			for _, part := range msg.Content {
+				call, ok := part.(fantasy.ToolCallPart)
+				if !ok {
+					parts = append(parts, part)
+					continue
+				}
+				names[call.ToolCallID] = call.ToolName
+				parts = append(parts, fantasy.TextPart{Text: formatToolCall(call.ToolName, call.Input)})
+			}
+			msg.Content = parts
+		case fantasy.MessageRoleTool:
+			parts := make([]fantasy.MessagePart, 0, len(msg.Content))
+			for _, part := range msg.Content {
+				if result, ok := part.(fantasy.ToolResultPart); ok {
+					parts = append(parts, fantasy.TextPart{Text: formatToolResult(names[result.ToolCallID], result.Output)})
+				}
+			}
+			// Providers without tools often reject consecutive user messages.
+			if lastResults {
+				last := &out[len(out)-1]
+				last.Content = append(last.Content, parts...)
+				continue
+			}
+			msg = fantasy.Message{Role: fantasy.MessageRoleUser, Content: parts, ProviderOptions: msg.ProviderOptions}
+			out = append(out, msg)
+			lastResults = true
+			continue
+		case fantasy.MessageRoleUser:
+			if lastResults {
+				last := &out[len(out)-1]
+				last.Content = append(last.Content, msg.Content...)
+				lastResults = false
+				continue
+			}
+		}
+		lastResults = false
+		out = append(out, msg)
+	}
+	if !added {
+		out = append(out, instructions)
+	}
+	return out
+}
+
+func toolInstructions(tools []fantasy.Tool) string {
+	var sb strings.Builder
+	sb.WriteString(textToolInstructions)
+	for _, tool := range tools {
+		fn, ok := tool.(fantasy.FunctionTool)
+		if !ok {
+			continue
+		}
+		schema, err := json.Marshal(fn.InputSchema)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## %s\n\n%s\n\nInput schema: %s\n", fn.Name, strings.TrimSpace(fn.Description), schema)
+	}
+	return sb.String()
+}
+
+func formatToolCall(name, input string) string {
+	arguments := json.RawMessage(input)
+	if !json.Valid(arguments) {
+		arguments = json.RawMessage("{}")
+	}
+	data, _ := json.Marshal(struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}{name, arguments})
+	return toolCallOpen + "\n" + string(data) + "\n" + toolCallClose
+}
+
+func formatToolResult(name string, output fantasy.ToolResultOutputContent) string {
+	var content, attrs string
+	switch out := output.(type) {
+	case fantasy.ToolResultOutputContentText:
+		content = out.Text
+	case fantasy.ToolResultOutputContentError:
+		attrs = ` error="true"`
+		if out.Error != nil {
+			content = out.Error.Error()
+		}
+	case fantasy.ToolResultOutputContentMedia:
+		content = fmt.Sprintf("[%s content omitted]", out.MediaType)
+	}
+	return fmt.Sprintf("<tool_result name=%q%s>\n%s\n</tool_result>", name, attrs, content)
+}
+
+// parseToolCalls splits the text of a response into text and tool calls.
+func parseToolCalls(text string) fantasy.ResponseContent {
+	var content fantasy.ResponseContent
+	p := &toolCallParser{yield: func(part fantasy.StreamPart) bool {
+		switch part.Type {
+		case fantasy.StreamPartTypeTextStart:
+			content = append(content, fantasy.TextContent{})
+		case fantasy.StreamPartTypeTextDelta:
+			last := content[len(content)-1].(fantasy.TextContent)
+			last.Text += part.Delta
+			content[len(content)-1] = last
+		case fantasy.StreamPartTypeToolCall:
+			content = append(content, fantasy.ToolCallContent{
+				ToolCallID: part.ID,
+				ToolName:   part.ToolCallName,
+				Input:      part.ToolCallInput,
+			})
+		}
+		return true
+	}}
+	p.write(text)
+	p.flush()
+	return content
+}
+
+// toolCallParser turns the tool call blocks of streamed text into tool calls,
+// holding back the text that may start a block.
+type toolCallParser struct {
+	yield   func(fantasy.StreamPart) bool
+	pending string
+	inCall  bool
+	textID  string
+	blocks  int
+	calls   int
+}
+
+func (p *toolCallParser) write(delta string) bool {
+	p.pending += delta
+	for {
+		if p.inCall {
+			end := strings.Index(p.pending, toolCallClose)
+			if end < 0 {
+				return true
+			}
+			body := p.pending[:end]
+			p.pending = p.pending[end+len(toolCallClose):]
+			p.inCall = false
+			if !p.call(body) {
+				return false
+			}
+			continue
+		}
+		start := strings.Index(p.pending, toolCallOpen)
+		if start < 0 {
+			keep := partialSuffix(p.pending, toolCallOpen)
+			text := p.pending[:len(p.pending)-keep]
+			p.pending = p.pending[len(p.pending)-keep:]
+			return p.text(text)
+		}
+		if !p.text(p.pending[:start]) {
+			return false
+		}
+		p.pending = p.pending[start+len(toolCallOpen):]
+		p.inCall = true
+	}
+}
+
+// flush ends the response, with the call of an unterminated block if valid.
+func (p *toolCallParser) flush() bool {
+	pending := p.pending
+	p.pending = ""
+	if p.inCall {
+		p.inCall = false
+		return p.call(pending) && p.endText()
+	}
+	return p.text(pending) && p.endText()
+}
+
+func (p *toolCallParser) text(text string) bool {
+	if text == "" {
+		return true
+	}
+	if p.textID == "" {
+		p.blocks++
+		p.textID = strconv.Itoa(p.blocks)
+		if !p.yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextStart, ID: p.textID}) {
+			return false
+		}
+	}
+	return p.yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, ID: p.textID, Delta: text})
+}
+
+func (p *toolCallParser) endText() bool {
+	if p.textID == "" {
+		return true
+	}
+	id := p.textID
+	p.textID = ""
+	return p.yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextEnd, ID: id})
+}
+
+func (p *toolCallParser) call(body string) bool {
+	name, input, err := parseToolCall(body)
+	if err != nil {
+		// Kept as text, for the model to see the call it got wrong.
+		slog.Debug("Invalid text tool call", "error", err)
+		return p.text(toolCallOpen + body + toolCallClose)
+	}
+	p.calls++
+	return p.endText() && p.yield(fantasy.StreamPart{
+		Type:          fantasy.StreamPartTypeToolCall,
+		ID:            "call_" + uuid.NewString(),
+		ToolCallName:  name,
+		ToolCallInput: input,
+	})
+}
+
+// parseToolCall decodes the JSON of a tool call block, which models
+// sometimes wrap in a code fence or give the arguments of as a string.
+func parseToolCall(body string) (name, input string, err error) {
+	body = strings.TrimSpace(body)
+	body = strings.TrimPrefix(body, "```json")
+	body = strings.TrimPrefix(body, "```")
+	body = strings.TrimSpace(strings.TrimSuffix(body, "```"))
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal([]byte(body), &call); err != nil {
+		return "", "", err
+	}
+	if call.Name == "" {
+		return "", "", errors.New("tool call without a name")
+	}
+	input = string(call.Arguments)
+	var encoded string
+	if json.Unmarshal(call.Arguments, &encoded) == nil {
+		input = encoded
+	}
+	if input == "" || input == "null" {
+		input = "{}"
+	}
+	return call.Name, input, nil
+}
+
+// partialSuffix returns the length of the longest end of s that starts tag.
+func partialSuffix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
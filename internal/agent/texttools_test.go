@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel streams the text deltas, or fails calls with tools when
+// rejectTools is set.
+type fakeModel struct {
+	fantasy.LanguageModel
+	deltas      []string
+	rejectTools bool
+	calls       []fantasy.Call
+}
+
+func (m *fakeModel) Model() string { return "fake" }
+
+func (m *fakeModel) Provider() string { return "fake" }
+
+func (m *fakeModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.calls = append(m.calls, call)
+	return func(yield func(fantasy.StreamPart) bool) {
+		if m.rejectTools && len(call.Tools) > 0 {
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: errors.New(`registry.ollama.ai/library/gemma3:27b does not support tools`)})
+			return
+		}
+		if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextStart, ID: "0"}) {
+			return
+		}
+		for _, delta := range m.deltas {
+			if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: delta}) {
+				return
+			}
+		}
+		if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextEnd, ID: "0"}) {
+			return
+		}
+		yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop})
+	}, nil
+}
+
+func viewTool() fantasy.Tool {
+	return fantasy.FunctionTool{
+		Name:        "view",
+		Description: "View a file",
+		InputSchema: map[string]any{"type": "object"},
+	}
+}
+
+func TestTextToolModelStream(t *testing.T) {
+	t.Parallel()
+
+	// The block is split across deltas, the way models stream it.
+	fake := &fakeModel{deltas: []string{
+		"Let me look. <tool",
+		"_call>\n{\"name\": \"view\", \"argu",
+		"ments\": {\"file_path\": \"main.go\"}}\n</tool_call>",
+	}}
+	model := newTextToolModel(fake, true)
+
+	stream, err := model.Stream(t.Context(), fantasy.Call{
+		Prompt: fantasy.Prompt{fantasy.NewSystemMessage("You are Crush."), fantasy.NewUserMessage("What does main.go do?")},
+		Tools:  []fantasy.Tool{viewTool()},
+	})
+	require.NoError(t, err)
+
+	var text string
+	var calls []fantasy.StreamPart
+	var finish fantasy.StreamPart
+	for part := range stream {
+		switch part.Type {
+		case fantasy.StreamPartTypeTextDelta:
+			text += part.Delta
+		case fantasy.StreamPartTypeToolCall:
+			calls = append(calls, part)
+		case fantasy.StreamPartTypeFinish:
+			finish = part
+		}
+	}
+	require.Equal(t, "Let me look. ", text)
+	require.Len(t, calls, 1)
+	require.Equal(t, "view", calls[0].ToolCallName)
+	require.JSONEq(t, `{"file_path": "main.go"}`, calls[0].ToolCallInput)
+	require.Equal(t, fantasy.FinishReasonToolCalls, finish.FinishReason)
+
+	sent := fake.calls[0]
+	require.Empty(t, sent.Tools)
+	require.Len(t, sent.Prompt, 3)
+	require.Equal(t, fantasy.MessageRoleSystem, sent.Prompt[1].Role)
+	require.Contains(t, sent.Prompt[1].Content[0].(fantasy.TextPart).Text, "## view")
+}
+
+func TestTextToolModelFallBack(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeModel{rejectTools: true, deltas: []string{"Hello"}}
+	model := newTextToolModel(fake, false)
+	call := fantasy.Call{
+		Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")},
+		Tools:  []fantasy.Tool{viewTool()},
+	}
+
+	for range 2 {
+		stream, err := model.Stream(t.Context(), call)
+		require.NoError(t, err)
+		var text string
+		for part := range stream {
+			require.NotEqual(t, fantasy.StreamPartTypeError, part.Type)
+			text += part.Delta
+		}
+		require.Equal(t, "Hello", text)
+	}
+	// Once rejected, tools are no longer sent to the provider.
+	require.Len(t, fake.calls, 3)
+	require.Empty(t, fake.calls[2].Tools)
+}
+
+func TestTextToolPrompt(t *testing.T) {
+	t.Parallel()
+
+	prompt := textToolPrompt(fantasy.Prompt{
+		fantasy.NewSystemMessage("You are Crush."),
+		fantasy.NewUserMessage("What does main.go do?"),
+		{Role: fantasy.MessageRoleAssistant, Content: []fantasy.MessagePart{
+			fantasy.ToolCallPart{ToolCallID: "call_1", ToolName: "view", Input: `{"file_path":"main.go"}`},
+		}},
+		{Role: fantasy.MessageRoleTool, Content: []fantasy.MessagePart{
+			fantasy.ToolResultPart{ToolCallID: "call_1", Output: fantasy.ToolResultOutputContentText{Text: "package main"}},
+		}},
+		fantasy.NewUserMessage("Thanks"),
+	}, []fantasy.Tool{viewTool()})
+
+	roles := make([]fantasy.MessageRole, 0, len(prompt))
+	for _, msg := range prompt {
+		roles = append(roles, msg.Role)
+	}
+	require.Equal(t, []fantasy.MessageRole{
+		fantasy.MessageRoleSystem,
+		fantasy.MessageRoleSystem,
+		fantasy.MessageRoleUser,
+		fantasy.MessageRoleAssistant,
+		fantasy.MessageRoleUser,
+	}, roles)
+	require.Equal(t, "<tool_call>\n{\"name\":\"view\",\"arguments\":{\"file_path\":\"main.go\"}}\n</tool_call>", prompt[3].Content[0].(fantasy.TextPart).Text)
+	// The result and the next prompt share a message.
+	require.Len(t, prompt[4].Content, 2)
+	require.Equal(t, "<tool_result name=\"view\">\npackage main\n</tool_result>", prompt[4].Content[0].(fantasy.TextPart).Text)
+}
+
+func TestParseToolCalls(t *testing.T) {
+	t.Parallel()
+
+	content := parseToolCalls("Reading both.\n<tool_call>```json\n{\"name\": \"view\", \"arguments\": \"{\\\"file_path\\\": \\\"a.go\\\"}\"}\n```</tool_call><tool_call>not json</tool_call>")
+	require.Len(t, content.ToolCalls(), 1)
+	require.Equal(t, `{"file_path": "a.go"}`, content.ToolCalls()[0].Input)
+	require.Len(t, content, 3)
+	require.Equal(t, "Reading both.\n", content[0].(fantasy.TextContent).Text)
+	require.Equal(t, "<tool_call>not json</tool_call>", content[2].(fantasy.TextContent).Text)
+}
+
+func TestWithoutImages(t *testing.T) {
+	t.Parallel()
+
+	msgs := []fantasy.Message{
+		{Role: fantasy.MessageRoleUser, Content: []fantasy.MessagePart{
+			fantasy.TextPart{Text: "What is this?"},
+			fantasy.FilePart{Filename: "screenshot.png", MediaType: "image/png", Data: []byte("png")},
+		}},
+	}
+	stripped := withoutImages(msgs)
+	require.Equal(t, "[File screenshot.png omitted: the model does not support images]", stripped[0].Content[1].(fantasy.TextPart).Text)
+	// The original messages are left untouched.
+	require.IsType(t, fantasy.FilePart{}, msgs[0].Content[1])
+}
@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type CodeActionsParams struct {
+	FilePath string `json:"file_path" description:"The absolute path to the file to get quick-fixes for"`
+	Line     int    `json:"line,omitempty" description:"The line number (1-based) of the diagnostic to fix. Leave empty for the whole file"`
+	Apply    string `json:"apply,omitempty" description:"The exact title of the quick-fix to apply. Leave empty to list the available quick-fixes"`
+}
+
+type CodeActionsPermissionsParams struct {
+	FilePath string `json:"file_path"`
+	Title    string `json:"title"`
+}
+
+type CodeActionsResponseMetadata struct {
+	Title string `json:"title,omitempty"`
+	Diff  string `json:"diff,omitempty"`
+}
+
+const CodeActionsToolName = "lsp_code_actions"
+
+//go:embed code_actions.md
+var codeActionsDescription []byte
+
+func NewCodeActionsTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodeActionsToolName,
+		string(codeActionsDescription),
+		func(ctx context.Context, params CodeActionsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			// Servers are started lazily, so they may only be available
+			// once they know the file is about to be used.
+			lsp.NotifyFileAccess(ctx, filePath)
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
+				}
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+			}
+
+			rng := quickFixRange(string(content), params.Line)
+			type clientAction struct {
+				client *lsp.Client
+				action protocol.CodeAction
+			}
+			var available []clientAction
+			for name, client := range lspClients.Seq2() {
+				if !client.HandlesFile(filePath) {
+					continue
+				}
+				actions, err := client.CodeActions(ctx, filePath, rng, protocol.QuickFix)
+				if err != nil {
+					slog.Warn("Failed to get code actions", "name", name, "file", filePath, "error", err)
+					continue
+				}
+				for _, action := range actions {
+					if action.Disabled == nil {
+						available = append(available, clientAction{client, action})
+					}
+				}
+			}
+
+			if params.Apply == "" {
+				if len(available) == 0 {
+					return fantasy.NewTextResponse("No quick-fixes available"), nil
+				}
+				var output strings.Builder
+				output.WriteString("<quick_fixes>\n")
+				for i, ca := range available {
+					output.WriteString(formatCodeAction(i+1, ca.action))
+					output.WriteString("\n")
+				}
+				output.WriteString("</quick_fixes>\n")
+				return fantasy.NewTextResponse(output.String()), nil
+			}
+
+			idx := -1
+			for i, ca := range available {
+				if ca.action.Title == params.Apply {
+					idx = i
+					break
+				}
+			}
+			if idx == -1 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("quick-fix not found: %q. List the available quick-fixes first", params.Apply)), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for applying a quick-fix")
+			}
+
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        fsext.PathOrPrefix(filePath, workingDir),
+					ToolCallID:  call.ID,
+					ToolName:    CodeActionsToolName,
+					Action:      "write",
+					Description: fmt.Sprintf("Apply quick-fix %q to %s", params.Apply, filePath),
+					Params: CodeActionsPermissionsParams{
+						FilePath: filePath,
+						Title:    params.Apply,
+					},
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			ca := available[idx]
+			if err := ca.client.ApplyCodeAction(ctx, ca.action); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to apply quick-fix: %s", err)), nil
+			}
+
+			changes := recordLSPChange(ctx, files, workingDir, filePath, string(content))
+			notifyLSPs(ctx, lspClients, filePath)
+
+			text := fmt.Sprintf("<result>\nApplied quick-fix: %s\n</result>\n", params.Apply)
+			if changes != "" {
+				text += fmt.Sprintf("\n<changes>\n%s\n</changes>\n", changes)
+			}
+			text += getDiagnostics(filePath, lspClients)
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(text),
+				CodeActionsResponseMetadata{
+					Title: params.Apply,
+					Diff:  changes,
+				},
+			), nil
+		})
+}
+
+// formatOnEdit runs the formatting and code actions configured for the LSP
+// servers handling the file. When that changes the file, the result is
+// recorded as a new history version and described for the model.
+func formatOnEdit(ctx context.Context, lsps *csync.Map[string, *lsp.Client], files history.Service, workingDir, filePath string) string {
+	before, err := os.ReadFile(filePath)
+	if err != nil {
+		return ""
+	}
+
+	var steps []string
+	for name, client := range lsps.Seq2() {
+		applied, err := client.FormatOnEdit(ctx, filePath)
+		if err != nil {
+			slog.Warn("Failed to run LSP actions on edit", "name", name, "file", filePath, "error", err)
+		}
+		for _, step := range applied {
+			steps = append(steps, fmt.Sprintf("%s (%s)", step, name))
+		}
+	}
+	if len(steps) == 0 {
+		return ""
+	}
+
+	changes := recordLSPChange(ctx, files, workingDir, filePath, string(before))
+	if changes == "" {
+		return ""
+	}
+	notifyLSPs(ctx, lsps, filePath)
+
+	return fmt.Sprintf(
+		"\n<lsp_changes>\nAfter your edit the file was updated by: %s.\nThe file on disk now differs from what you wrote as follows:\n%s\n</lsp_changes>\n",
+		strings.Join(steps, ", "),
+		changes,
+	)
+}
+
+// recordLSPChange stores the current content of a file changed by an LSP
+// server as a new history version and returns the diff against before.
+func recordLSPChange(ctx context.Context, files history.Service, workingDir, filePath, before string) string {
+	after, err := os.ReadFile(filePath)
+	if err != nil {
+		return ""
+	}
+	oldContent, _ := fsext.ToUnixLineEndings(before)
+	newContent, _ := fsext.ToUnixLineEndings(string(after))
+	if oldContent == newContent {
+		return ""
+	}
+
+	if sessionID := GetSessionFromContext(ctx); sessionID != "" {
+		if _, err := files.GetByPathAndSession(ctx, filePath, sessionID); err != nil {
+			if _, err := files.Create(ctx, sessionID, filePath, oldContent); err != nil {
+				slog.Debug("Error creating file history", "error", err)
+			}
+		}
+		if _, err := files.CreateVersion(ctx, sessionID, filePath, newContent); err != nil {
+			slog.Debug("Error creating file history version", "error", err)
+		}
+	}
+
+	recordFileWrite(filePath)
+	recordFileRead(filePath)
+
+	changes, _, _ := diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, workingDir))
+	return changes
+}
+
+// quickFixRange returns the range of the given 1-based line, or the whole
+// content if line is not set.
+func quickFixRange(content string, line int) protocol.Range {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		last := len(lines) - 1
+		return protocol.Range{
+			End: protocol.Position{Line: uint32(last), Character: uint32(len(lines[last]))},
+		}
+	}
+	return protocol.Range{
+		Start: protocol.Position{Line: uint32(line - 1)},
+		End:   protocol.Position{Line: uint32(line - 1), Character: uint32(len(lines[line-1]))},
+	}
+}
+
+func formatCodeAction(n int, action protocol.CodeAction) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d. %s", n, action.Title)
+	if action.IsPreferred {
+		b.WriteString(" (preferred)")
+	}
+	for _, diag := range action.Diagnostics {
+		fmt.Fprintf(&b, "\n   fixes line %d: %s", diag.Range.Start.Line+1, diag.Message)
+	}
+	return b.String()
+}
@@ -42,6 +42,7 @@ func notifyLSPs(ctx context.Context, lsps *csync.Map[string, *lsp.Client], filep
 	if filepath == "" {
 		return
 	}
+	lsp.NotifyFileAccess(ctx, filepath)
 	for client := range lsps.Seq() {
 		if !client.HandlesFile(filepath) {
 			continue
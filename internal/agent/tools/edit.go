@@ -63,27 +63,22 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 			}
 
 			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			// Start the servers for the file, if needed, and mark them as used.
+			lsp.NotifyFileAccess(ctx, params.FilePath)
 
 			var response fantasy.ToolResponse
 			var err error
 
 			editCtx := editContext{ctx, permissions, files, workingDir}
 
-			if params.OldString == "" {
+			switch {
+			case params.OldString == "":
 				response, err = createNewFile(editCtx, params.FilePath, params.NewString, call)
-				if err != nil {
-					return response, err
-				}
-			}
-
-			if params.NewString == "" {
+			case params.NewString == "":
 				response, err = deleteContent(editCtx, params.FilePath, params.OldString, params.ReplaceAll, call)
-				if err != nil {
-					return response, err
-				}
+			default:
+				response, err = replaceContent(editCtx, params.FilePath, params.OldString, params.NewString, params.ReplaceAll, call)
 			}
-
-			response, err = replaceContent(editCtx, params.FilePath, params.OldString, params.NewString, params.ReplaceAll, call)
 			if err != nil {
 				return response, err
 			}
@@ -92,10 +87,16 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				// This prevents unnecessary LSP diagnostics processing
 				return response, nil
 			}
+			if _, err := os.Stat(params.FilePath); os.IsNotExist(err) {
+				// The user rejected the whole new file, so there is nothing to
+				// check.
+				return response, nil
+			}
 
 			notifyLSPs(ctx, lspClients, params.FilePath)
 
 			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
+			text += formatOnEdit(ctx, lspClients, files, workingDir, params.FilePath)
 			text += getDiagnostics(params.FilePath, lspClients)
 			response.Content = text
 			return response, nil
@@ -147,13 +148,24 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	content, reviewNote, create := reviewedContent(edit.permissions, call.ID, filePath, "", content, false)
+	if !create {
+		return fantasy.WithResponseMetadata(
+			fantasy.NewTextResponse("File not created: "+filePath+reviewNote),
+			EditResponseMetadata{},
+		), nil
+	}
+	if reviewNote != "" {
+		_, additions, removals = diff.GenerateDiff("", content, strings.TrimPrefix(filePath, edit.workingDir))
+	}
+
 	err = os.WriteFile(filePath, []byte(content), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
 	// File can't be in the history so we create a new file history
-	_, err = edit.files.Create(edit.ctx, sessionID, filePath, "")
+	_, err = edit.files.CreateNew(edit.ctx, sessionID, filePath)
 	if err != nil {
 		// Log error but don't fail the operation
 		return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -170,7 +182,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("File created: "+filePath),
+		fantasy.NewTextResponse("File created: "+filePath+reviewNote),
 		EditResponseMetadata{
 			OldContent: "",
 			NewContent: content,
@@ -268,6 +280,11 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	newContent, reviewNote, _ := reviewedContent(edit.permissions, call.ID, filePath, oldContent, newContent, true)
+	if reviewNote != "" {
+		_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, edit.workingDir))
+	}
+
 	if isCrlf {
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
@@ -303,7 +320,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content deleted from file: "+filePath),
+		fantasy.NewTextResponse("Content deleted from file: "+filePath+reviewNote),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -403,6 +420,11 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	newContent, reviewNote, _ := reviewedContent(edit.permissions, call.ID, filePath, oldContent, newContent, true)
+	if reviewNote != "" {
+		_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, edit.workingDir))
+	}
+
 	if isCrlf {
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
@@ -438,7 +460,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content replaced in file: "+filePath),
+		fantasy.NewTextResponse("Content replaced in file: "+filePath+reviewNote),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
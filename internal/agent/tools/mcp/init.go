@@ -64,6 +64,9 @@ const (
 	EventStateChanged EventType = iota
 	EventToolsListChanged
 	EventPromptsListChanged
+	EventResourcesListChanged
+	EventResourceUpdated
+	EventAuthorizationRequired
 )
 
 // Event represents an event in the MCP system
@@ -73,12 +76,16 @@ type Event struct {
 	State  State
 	Error  error
 	Counts Counts
+	// URI is the updated resource for EventResourceUpdated, or the URL the
+	// user needs to visit for EventAuthorizationRequired.
+	URI string
 }
 
 // Counts number of available tools, prompts, etc.
 type Counts struct {
-	Tools   int
-	Prompts int
+	Tools     int
+	Prompts   int
+	Resources int
 }
 
 // ClientInfo holds information about an MCP client's state
@@ -155,67 +162,150 @@ func Initialize(ctx context.Context, permissions permission.Service, cfg *config
 			}()
 
 			// createSession handles its own timeout internally.
-			session, err := createSession(ctx, name, m, cfg.Resolver())
-			if err != nil {
-				return
-			}
+			_, _ = connect(ctx, name, m, cfg.Resolver())
+		}(name, m)
+	}
+	wg.Wait()
+}
 
-			tools, err := getTools(ctx, session)
-			if err != nil {
-				slog.Error("error listing tools", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
+// Restart closes every MCP client and connects to the MCPs of the
+// configuration again, e.g. after it switched to another profile.
+func Restart(ctx context.Context, permissions permission.Service, cfg *config.Config) {
+	for name := range states.Seq2() {
+		if sess, ok := sessions.Take(name); ok {
+			if err := sess.Close(); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, context.Canceled) {
+				slog.Warn("error closing mcp client", "error", err, "name", name)
 			}
+		}
+		updateTools(name, nil)
+		updatePrompts(name, nil)
+		updateResources(name, nil)
+		states.Del(name)
+	}
+	Initialize(ctx, permissions, cfg)
+}
 
-			prompts, err := getPrompts(ctx, session)
-			if err != nil {
-				slog.Error("error listing prompts", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
+// Reconnect closes the current session of the given MCP, if any, and
+// connects to it again. It also re-enables MCPs disabled with [Disable] or in
+// the configuration.
+func Reconnect(ctx context.Context, name string) error {
+	if _, ok := config.Get().MCP[name]; !ok {
+		return fmt.Errorf("mcp '%s' not configured", name)
+	}
+	if sess, ok := sessions.Take(name); ok {
+		_ = sess.Close()
+	}
+	updateState(name, StateStarting, nil, nil, Counts{})
+	_, err := getOrRenewClient(ctx, name)
+	return err
+}
+
+// Disable closes the session of the given MCP and removes its tools, prompts
+// and resources until it's reconnected.
+func Disable(name string) error {
+	if _, ok := config.Get().MCP[name]; !ok {
+		return fmt.Errorf("mcp '%s' not configured", name)
+	}
+	if sess, ok := sessions.Take(name); ok {
+		if err := sess.Close(); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, context.Canceled) {
+			slog.Warn("error closing mcp client", "error", err, "name", name)
+		}
+	}
+	updateTools(name, nil)
+	updatePrompts(name, nil)
+	updateResources(name, nil)
+	updateState(name, StateDisabled, nil, nil, Counts{})
+	return nil
+}
 
-			updateTools(name, tools)
-			updatePrompts(name, prompts)
-			sessions.Set(name, session)
+// Test connects to the given MCP, lists what it provides and disconnects.
+func Test(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (Counts, error) {
+	session, err := createSession(ctx, name, m, resolver)
+	if err != nil {
+		return Counts{}, err
+	}
+	defer session.Close()
 
-			updateState(name, StateConnected, nil, session, Counts{
-				Tools:   len(tools),
-				Prompts: len(prompts),
-			})
-		}(name, m)
+	tools, prompts, resources, err := list(ctx, session)
+	if err != nil {
+		return Counts{}, err
 	}
-	wg.Wait()
+	return Counts{
+		Tools:     len(tools),
+		Prompts:   len(prompts),
+		Resources: len(resources),
+	}, nil
 }
 
 func getOrRenewClient(ctx context.Context, name string) (*mcp.ClientSession, error) {
-	sess, ok := sessions.Get(name)
+	cfg := config.Get()
+	m, ok := cfg.MCP[name]
 	if !ok {
 		return nil, fmt.Errorf("mcp '%s' not available", name)
 	}
-
-	cfg := config.Get()
-	m := cfg.MCP[name]
 	state, _ := states.Get(name)
+	if state.State == StateDisabled {
+		return nil, fmt.Errorf("mcp '%s' is disabled", name)
+	}
 
-	timeout := mcpTimeout(m)
-	pingCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-	err := sess.Ping(pingCtx, nil)
-	if err == nil {
-		return sess, nil
+	if sess, ok := sessions.Get(name); ok {
+		timeout := mcpTimeout(m)
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		err := sess.Ping(pingCtx, nil)
+		if err == nil {
+			return sess, nil
+		}
+		updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, state.Counts)
+	}
+
+	return connect(ctx, name, m, cfg.Resolver())
+}
+
+// connect creates a session with the given MCP and makes its tools, prompts
+// and resources available.
+func connect(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (*mcp.ClientSession, error) {
+	session, err := createSession(ctx, name, m, resolver)
+	if err != nil {
+		return nil, err
 	}
-	updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, state.Counts)
 
-	sess, err = createSession(ctx, name, m, cfg.Resolver())
+	tools, prompts, resources, err := list(ctx, session)
 	if err != nil {
+		slog.Error("error listing mcp capabilities", "error", err, "name", name)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
 		return nil, err
 	}
 
-	updateState(name, StateConnected, nil, sess, state.Counts)
-	sessions.Set(name, sess)
-	return sess, nil
+	updateTools(name, tools)
+	updatePrompts(name, prompts)
+	updateResources(name, resources)
+	sessions.Set(name, session)
+	resubscribe(ctx, name, session)
+
+	updateState(name, StateConnected, nil, session, Counts{
+		Tools:     len(tools),
+		Prompts:   len(prompts),
+		Resources: len(resources),
+	})
+	return session, nil
+}
+
+func list(ctx context.Context, session *mcp.ClientSession) ([]*Tool, []*Prompt, []*Resource, error) {
+	tools, err := getTools(ctx, session)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("list tools: %w", err)
+	}
+	prompts, err := getPrompts(ctx, session)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("list prompts: %w", err)
+	}
+	resources, err := getResources(ctx, session)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("list resources: %w", err)
+	}
+	return tools, prompts, resources, nil
 }
 
 // updateState updates the state of an MCP client and publishes an event
@@ -246,11 +336,22 @@ func updateState(name string, state State, err error, client *mcp.ClientSession,
 }
 
 func createSession(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (*mcp.ClientSession, error) {
+	rt := httpRoundTripper(name, m)
+	if oauth, ok := rt.(*oauthRoundTripper); ok {
+		// Authorization may wait on the user, so it's not subject to the
+		// connection timeout.
+		if err := oauth.authorize(ctx, m); err != nil {
+			updateState(name, StateError, err, nil, Counts{})
+			slog.Error("error authorizing mcp client", "error", err, "name", name)
+			return nil, err
+		}
+	}
+
 	timeout := mcpTimeout(m)
 	mcpCtx, cancel := context.WithCancel(ctx)
 	cancelTimer := time.AfterFunc(timeout, cancel)
 
-	transport, err := createTransport(mcpCtx, m, resolver)
+	transport, err := createTransport(mcpCtx, m, resolver, rt)
 	if err != nil {
 		updateState(name, StateError, err, nil, Counts{})
 		slog.Error("error creating mcp client", "error", err, "name", name)
@@ -278,6 +379,20 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 					Name: name,
 				})
 			},
+			ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) {
+				broker.Publish(pubsub.UpdatedEvent, Event{
+					Type: EventResourcesListChanged,
+					Name: name,
+				})
+			},
+			ResourceUpdatedHandler: func(_ context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+				markResourceUpdated(name, req.Params.URI)
+				broker.Publish(pubsub.UpdatedEvent, Event{
+					Type: EventResourceUpdated,
+					Name: name,
+					URI:  req.Params.URI,
+				})
+			},
 			LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
 				slog.Info("mcp log", "name", name, "data", req.Params.Data)
 			},
@@ -328,7 +443,27 @@ func maybeTimeoutErr(err error, timeout time.Duration) error {
 	return err
 }
 
-func createTransport(ctx context.Context, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
+// httpRoundTripper returns the round tripper for HTTP and SSE MCPs, which
+// adds the configured headers, and handles OAuth unless the headers already
+// take care of authorization.
+func httpRoundTripper(name string, m config.MCPConfig) http.RoundTripper {
+	if m.Type != config.MCPHttp && m.Type != config.MCPSSE {
+		return nil
+	}
+	headers := m.ResolvedHeaders()
+	rt := &headerRoundTripper{headers: headers}
+	if m.OAuth.Disabled {
+		return rt
+	}
+	for k := range headers {
+		if strings.EqualFold(k, "Authorization") {
+			return rt
+		}
+	}
+	return newOAuthRoundTripper(name, m.URL, rt)
+}
+
+func createTransport(ctx context.Context, m config.MCPConfig, resolver config.VariableResolver, rt http.RoundTripper) (mcp.Transport, error) {
 	switch m.Type {
 	case config.MCPStdio:
 		command, err := resolver.ResolveValue(m.Command)
@@ -348,9 +483,7 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 			return nil, fmt.Errorf("mcp http config requires a non-empty 'url' field")
 		}
 		client := &http.Client{
-			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
-			},
+			Transport: rt,
 		}
 		return &mcp.StreamableClientTransport{
 			Endpoint:   m.URL,
@@ -361,9 +494,7 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 			return nil, fmt.Errorf("mcp sse config requires a non-empty 'url' field")
 		}
 		client := &http.Client{
-			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
-			},
+			Transport: rt,
 		}
 		return &mcp.SSEClientTransport{
 			Endpoint:   m.URL,
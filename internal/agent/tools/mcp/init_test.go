@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/env"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTest(t *testing.T) {
+	t.Parallel()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "echo"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+	server.AddPrompt(&mcp.Prompt{Name: "hello"}, func(context.Context, *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return &mcp.GetPromptResult{}, nil
+	})
+	srv := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	t.Cleanup(srv.Close)
+
+	m := config.MCPConfig{
+		Type:  config.MCPHttp,
+		URL:   srv.URL,
+		OAuth: config.MCPOAuthConfig{Disabled: true},
+	}
+	resolver := config.NewShellVariableResolver(env.NewFromMap(nil))
+	counts, err := Test(t.Context(), "test-ok", m, resolver)
+	require.NoError(t, err)
+	require.Equal(t, Counts{Tools: 1, Prompts: 1}, counts)
+
+	_, ok := sessions.Get("test-ok")
+	require.False(t, ok, "testing must not keep the session around")
+
+	srv404 := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv404.Close)
+	m.URL = srv404.URL
+	_, err = Test(t.Context(), "test-error", m, resolver)
+	require.Error(t, err)
+}
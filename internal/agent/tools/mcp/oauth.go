@@ -0,0 +1,583 @@
+package mcp
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/version"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oauthCallbackPath = "/callback"
+	// oauthTimeout is how long we wait for the user to authorize Crush.
+	oauthTimeout = 5 * time.Minute
+	// oauthMetadataLimit caps the size of the metadata documents we fetch.
+	oauthMetadataLimit = 1 << 20
+)
+
+var (
+	// oauthDataDir returns the directory OAuth clients and tokens are stored
+	// in.
+	oauthDataDir = func() string {
+		return filepath.Join(filepath.Dir(config.GlobalConfigData()), "mcp-oauth")
+	}
+
+	// openBrowser opens the given URL in the user's browser.
+	openBrowser = func(ctx context.Context, u string) error {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.CommandContext(ctx, "open", u)
+		case "windows":
+			cmd = exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", u)
+		default:
+			cmd = exec.CommandContext(ctx, "xdg-open", u)
+		}
+		return cmd.Start()
+	}
+
+	// oauthMu serializes authorization flows, so we don't open a bunch of
+	// browser tabs at once.
+	oauthMu sync.Mutex
+
+	authParamRe = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// oauthEntry is what we store on disk for each authorized MCP.
+type oauthEntry struct {
+	ServerURL    string        `json:"server_url"`
+	ClientID     string        `json:"client_id"`
+	ClientSecret string        `json:"client_secret,omitempty"`
+	RedirectURI  string        `json:"redirect_uri"`
+	AuthURL      string        `json:"auth_url"`
+	TokenURL     string        `json:"token_url"`
+	Scopes       []string      `json:"scopes,omitempty"`
+	Token        *oauth2.Token `json:"token,omitempty"`
+}
+
+func (e *oauthEntry) config() *oauth2.Config {
+	style := oauth2.AuthStyleInParams
+	if e.ClientSecret != "" {
+		style = oauth2.AuthStyleAutoDetect
+	}
+	return &oauth2.Config{
+		ClientID:     e.ClientID,
+		ClientSecret: e.ClientSecret,
+		RedirectURL:  e.RedirectURI,
+		Scopes:       e.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   e.AuthURL,
+			TokenURL:  e.TokenURL,
+			AuthStyle: style,
+		},
+	}
+}
+
+func oauthEntryPath(name string) string {
+	return filepath.Join(oauthDataDir(), url.PathEscape(name)+".json")
+}
+
+// loadOAuthEntry loads the stored entry for the given MCP, if any and if it
+// still belongs to the same server.
+func loadOAuthEntry(name, serverURL string) *oauthEntry {
+	data, err := os.ReadFile(oauthEntryPath(name))
+	if err != nil {
+		return nil
+	}
+	var entry oauthEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		slog.Warn("invalid mcp oauth entry", "name", name, "error", err)
+		return nil
+	}
+	if entry.ServerURL != serverURL {
+		return nil
+	}
+	return &entry
+}
+
+func saveOAuthEntry(name string, entry *oauthEntry) error {
+	if err := os.MkdirAll(oauthDataDir(), 0o700); err != nil {
+		return fmt.Errorf("failed to create oauth directory: %w", err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(oauthEntryPath(name), data, 0o600)
+}
+
+// oauthRoundTripper adds the stored access token of an MCP to requests,
+// refreshing it when it expires.
+type oauthRoundTripper struct {
+	name      string
+	serverURL string
+	base      http.RoundTripper
+
+	mu    sync.Mutex
+	entry *oauthEntry
+}
+
+func newOAuthRoundTripper(name, serverURL string, base http.RoundTripper) *oauthRoundTripper {
+	return &oauthRoundTripper{
+		name:      name,
+		serverURL: serverURL,
+		base:      base,
+		entry:     loadOAuthEntry(name, serverURL),
+	}
+}
+
+func (rt *oauthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := rt.token(req.Context())
+	if token != nil {
+		req = req.Clone(req.Context())
+		token.SetAuthHeader(req)
+	}
+	resp, err := rt.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && token != nil {
+		// The token was revoked or is otherwise no good, forget it so we
+		// authorize again the next time we connect.
+		slog.Warn("mcp rejected oauth token", "name", rt.name)
+		rt.mu.Lock()
+		if rt.entry != nil && rt.entry.Token == token {
+			rt.entry.Token = nil
+			rt.save()
+		}
+		rt.mu.Unlock()
+	}
+	return resp, err
+}
+
+// token returns a valid access token, refreshing it if needed, or nil if we
+// don't have one.
+func (rt *oauthRoundTripper) token(ctx context.Context) *oauth2.Token {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.entry == nil || rt.entry.Token == nil {
+		return nil
+	}
+	if rt.entry.Token.Valid() {
+		return rt.entry.Token
+	}
+	if rt.entry.Token.RefreshToken == "" {
+		return nil
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: rt.base})
+	token, err := rt.entry.config().TokenSource(ctx, rt.entry.Token).Token()
+	if err != nil {
+		slog.Warn("failed to refresh mcp oauth token", "name", rt.name, "error", err)
+		rt.entry.Token = nil
+		rt.save()
+		return nil
+	}
+	slog.Debug("refreshed mcp oauth token", "name", rt.name)
+	rt.entry.Token = token
+	rt.save()
+	return token
+}
+
+func (rt *oauthRoundTripper) save() {
+	if err := saveOAuthEntry(rt.name, rt.entry); err != nil {
+		slog.Error("failed to save mcp oauth token", "name", rt.name, "error", err)
+	}
+}
+
+// authorize checks whether the MCP server requires authorization and, if we
+// don't have a valid token for it, runs the OAuth authorization code flow
+// with PKCE, asking the user to authorize Crush in their browser.
+func (rt *oauthRoundTripper) authorize(ctx context.Context, m config.MCPConfig) error {
+	challenge, err := rt.probe(ctx)
+	if err != nil || challenge == nil {
+		return err
+	}
+
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+
+	slog.Info("mcp requires authorization", "name", rt.name)
+	client := &http.Client{Transport: rt.base}
+	meta, err := discoverOAuth(ctx, client, rt.serverURL, challenge)
+	if err != nil {
+		return fmt.Errorf("oauth discovery failed: %w", err)
+	}
+
+	listener, err := listenOAuthCallback(m.OAuth.RedirectPort, rt.previousRedirectURI(m))
+	if err != nil {
+		return fmt.Errorf("failed to listen for oauth redirect: %w", err)
+	}
+	defer listener.Close()
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr().String(), oauthCallbackPath)
+
+	entry, err := rt.client(ctx, client, m.OAuth, meta, redirectURI)
+	if err != nil {
+		return err
+	}
+
+	authCtx, cancel := context.WithTimeout(ctx, oauthTimeout)
+	defer cancel()
+	token, err := rt.authorizationCode(authCtx, client, entry, meta.resource, listener)
+	if err != nil {
+		return err
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	entry.Token = token
+	rt.entry = entry
+	rt.save()
+	slog.Info("mcp authorized", "name", rt.name)
+	return nil
+}
+
+// probe requests the server URL with the current token, and returns the
+// WWW-Authenticate challenge if the server asks for authorization.
+func (rt *oauthRoundTripper) probe(ctx context.Context) (http.Header, error) {
+	probeCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, rt.serverURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		// Let the transport report connection errors.
+		return nil, nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil, nil
+	}
+	return resp.Header, nil
+}
+
+// previousRedirectURI returns the redirect URI of the stored client, so we
+// can reuse the client registration.
+func (rt *oauthRoundTripper) previousRedirectURI(m config.MCPConfig) string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.entry == nil || (m.OAuth.ClientID != "" && rt.entry.ClientID != m.OAuth.ClientID) {
+		return ""
+	}
+	return rt.entry.RedirectURI
+}
+
+// client returns the OAuth client to use, registering one if needed.
+func (rt *oauthRoundTripper) client(ctx context.Context, c *http.Client, cfg config.MCPOAuthConfig, meta *oauthMetadata, redirectURI string) (*oauthEntry, error) {
+	entry := &oauthEntry{
+		ServerURL:   rt.serverURL,
+		RedirectURI: redirectURI,
+		AuthURL:     meta.AuthorizationEndpoint,
+		TokenURL:    meta.TokenEndpoint,
+		Scopes:      cfg.Scopes,
+	}
+	if len(entry.Scopes) == 0 {
+		entry.Scopes = meta.scopes
+	}
+
+	if cfg.ClientID != "" {
+		entry.ClientID = cfg.ClientID
+		entry.ClientSecret = cfg.ClientSecret
+		return entry, nil
+	}
+
+	rt.mu.Lock()
+	prev := rt.entry
+	rt.mu.Unlock()
+	if prev != nil && prev.ClientID != "" && prev.RedirectURI == redirectURI {
+		entry.ClientID = prev.ClientID
+		entry.ClientSecret = prev.ClientSecret
+		return entry, nil
+	}
+
+	if meta.RegistrationEndpoint == "" {
+		return nil, errors.New("server does not support dynamic client registration, set oauth.client_id in the mcp config")
+	}
+	clientID, clientSecret, err := registerOAuthClient(ctx, c, meta.RegistrationEndpoint, redirectURI, entry.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("oauth client registration failed: %w", err)
+	}
+	entry.ClientID = clientID
+	entry.ClientSecret = clientSecret
+	return entry, nil
+}
+
+// oauthCallbackHandler handles the redirect of the authorization server,
+// sending the code or the error it got. Callbacks after the first one, like
+// the browser reloading the page, are answered without waiting for them to
+// be received.
+func oauthCallbackHandler(state string, codes chan<- string, errs chan<- error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != oauthCallbackPath {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			return
+		}
+		if oauthErr := query.Get("error"); oauthErr != "" {
+			writeOAuthPage(w, "Authorization failed", cmp.Or(query.Get("error_description"), oauthErr))
+			select {
+			case errs <- fmt.Errorf("authorization denied: %s", cmp.Or(query.Get("error_description"), oauthErr)):
+			default:
+			}
+			return
+		}
+		writeOAuthPage(w, "Authorization complete", "You can close this window and go back to Crush.")
+		select {
+		case codes <- query.Get("code"):
+		default:
+		}
+	})
+}
+
+// authorizationCode asks the user to authorize Crush and exchanges the
+// resulting code for a token.
+func (rt *oauthRoundTripper) authorizationCode(ctx context.Context, c *http.Client, entry *oauthEntry, resource string, listener net.Listener) (*oauth2.Token, error) {
+	conf := entry.config()
+	verifier := oauth2.GenerateVerifier()
+	state := oauth2.GenerateVerifier()
+	resourceParam := oauth2.SetAuthURLParam("resource", resource)
+	authURL := conf.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), resourceParam)
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler:           oauthCallbackHandler(state, codes, errs),
+	}
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Close()
+
+	slog.Info("waiting for mcp authorization", "name", rt.name, "url", authURL)
+	broker.Publish(pubsub.UpdatedEvent, Event{
+		Type: EventAuthorizationRequired,
+		Name: rt.name,
+		URI:  authURL,
+	})
+	if err := openBrowser(ctx, authURL); err != nil {
+		slog.Warn("failed to open browser", "error", err)
+	}
+
+	var code string
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("authorization not completed: %w", ctx.Err())
+	case err := <-errs:
+		return nil, err
+	case code = <-codes:
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c)
+	token, err := conf.Exchange(ctx, code, oauth2.VerifierOption(verifier), resourceParam)
+	if err != nil {
+		return nil, fmt.Errorf("oauth token exchange failed: %w", err)
+	}
+	return token, nil
+}
+
+// listenOAuthCallback listens on the loopback interface for the OAuth
+// redirect, on the given port, the port of the previous redirect URI, or any
+// free port.
+func listenOAuthCallback(port int, previousRedirectURI string) (net.Listener, error) {
+	if port > 0 {
+		return net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	}
+	if u, err := url.Parse(previousRedirectURI); err == nil && u.Host != "" {
+		if listener, err := net.Listen("tcp", u.Host); err == nil {
+			return listener, nil
+		}
+	}
+	return net.Listen("tcp", "127.0.0.1:0")
+}
+
+func writeOAuthPage(w http.ResponseWriter, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<!doctype html><title>Crush: %[1]s</title><h1>%[1]s</h1><p>%[2]s</p>", html.EscapeString(title), html.EscapeString(message))
+}
+
+// oauthMetadata holds the authorization server metadata (RFC 8414) along
+// with what we learned about the protected resource.
+type oauthMetadata struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	RegistrationEndpoint  string `json:"registration_endpoint,omitempty"`
+
+	resource string
+	scopes   []string
+}
+
+// protectedResourceMetadata is the OAuth protected resource metadata
+// (RFC 9728) served by MCP servers.
+type protectedResourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported,omitempty"`
+}
+
+// discoverOAuth finds the authorization server of an MCP server following
+// the MCP authorization spec, given the headers of its 401 response.
+func discoverOAuth(ctx context.Context, c *http.Client, serverURL string, header http.Header) (*oauthMetadata, error) {
+	server, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	params := parseWWWAuthenticate(header.Values("WWW-Authenticate"))
+
+	// Protected resource metadata, from the challenge or the well-known URI.
+	var prmURLs []string
+	if u := params["resource_metadata"]; u != "" {
+		prmURLs = append(prmURLs, u)
+	}
+	prmURLs = append(prmURLs, wellKnownURLs(server, "oauth-protected-resource")...)
+	var prm protectedResourceMetadata
+	for _, u := range prmURLs {
+		if err := getJSON(ctx, c, u, &prm); err == nil && len(prm.AuthorizationServers) > 0 {
+			break
+		}
+	}
+
+	issuer := &url.URL{Scheme: server.Scheme, Host: server.Host}
+	if len(prm.AuthorizationServers) > 0 {
+		if issuer, err = url.Parse(prm.AuthorizationServers[0]); err != nil {
+			return nil, fmt.Errorf("invalid authorization server: %w", err)
+		}
+	}
+
+	meta := &oauthMetadata{
+		resource: cmp.Or(prm.Resource, serverURL),
+		scopes:   prm.ScopesSupported,
+	}
+	if scope := params["scope"]; scope != "" {
+		meta.scopes = strings.Fields(scope)
+	}
+
+	urls := wellKnownURLs(issuer, "oauth-authorization-server")
+	urls = append(urls, wellKnownURLs(issuer, "openid-configuration")...)
+	for _, u := range urls {
+		if err := getJSON(ctx, c, u, meta); err == nil && meta.AuthorizationEndpoint != "" && meta.TokenEndpoint != "" {
+			return meta, nil
+		}
+	}
+
+	// Servers implementing older revisions of the spec may not serve any
+	// metadata, in which case the endpoints are at their default paths.
+	if len(prm.AuthorizationServers) == 0 {
+		base := issuer.JoinPath("/")
+		meta.AuthorizationEndpoint = base.JoinPath("authorize").String()
+		meta.TokenEndpoint = base.JoinPath("token").String()
+		meta.RegistrationEndpoint = base.JoinPath("register").String()
+		return meta, nil
+	}
+	return nil, fmt.Errorf("no authorization server metadata found for %s", issuer)
+}
+
+// wellKnownURLs returns the well-known URLs for the given suffix, with the
+// path of u inserted after it first and appended to it last.
+func wellKnownURLs(u *url.URL, suffix string) []string {
+	root := url.URL{Scheme: u.Scheme, Host: u.Host}
+	path := strings.TrimSuffix(u.Path, "/")
+	if path == "" {
+		return []string{root.JoinPath(".well-known", suffix).String()}
+	}
+	return []string{
+		root.JoinPath(".well-known", suffix, path).String(),
+		u.JoinPath(".well-known", suffix).String(),
+		root.JoinPath(".well-known", suffix).String(),
+	}
+}
+
+func parseWWWAuthenticate(values []string) map[string]string {
+	params := map[string]string{}
+	for _, value := range values {
+		for _, match := range authParamRe.FindAllStringSubmatch(value, -1) {
+			params[match[1]] = match[2]
+		}
+	}
+	return params
+}
+
+func getJSON(ctx context.Context, c *http.Client, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oauthMetadataLimit)).Decode(v)
+}
+
+// registerOAuthClient registers Crush as a public client with the
+// authorization server (RFC 7591).
+func registerOAuthClient(ctx context.Context, c *http.Client, endpoint, redirectURI string, scopes []string) (string, string, error) {
+	body, err := json.Marshal(map[string]any{
+		"client_name":                "Crush",
+		"client_uri":                 "https://github.com/charmbracelet/crush",
+		"software_id":                "crush",
+		"software_version":           version.Version,
+		"redirect_uris":              []string{redirectURI},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+		"scope":                      strings.Join(scopes, " "),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", "", fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var result struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oauthMetadataLimit)).Decode(&result); err != nil {
+		return "", "", err
+	}
+	if result.ClientID == "" {
+		return "", "", errors.New("no client_id in registration response")
+	}
+	return result.ClientID, result.ClientSecret, nil
+}
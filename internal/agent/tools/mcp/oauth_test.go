@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWWWAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	params := parseWWWAuthenticate([]string{
+		`Bearer realm="mcp", resource_metadata="https://example.com/.well-known/oauth-protected-resource", scope="read write"`,
+	})
+	require.Equal(t, "mcp", params["realm"])
+	require.Equal(t, "https://example.com/.well-known/oauth-protected-resource", params["resource_metadata"])
+	require.Equal(t, "read write", params["scope"])
+}
+
+func TestWellKnownURLs(t *testing.T) {
+	t.Parallel()
+
+	root, _ := url.Parse("https://auth.example.com")
+	require.Equal(t, []string{
+		"https://auth.example.com/.well-known/oauth-authorization-server",
+	}, wellKnownURLs(root, "oauth-authorization-server"))
+
+	tenant, _ := url.Parse("https://auth.example.com/tenant1/")
+	require.Equal(t, []string{
+		"https://auth.example.com/.well-known/oauth-authorization-server/tenant1",
+		"https://auth.example.com/tenant1/.well-known/oauth-authorization-server",
+		"https://auth.example.com/.well-known/oauth-authorization-server",
+	}, wellKnownURLs(tenant, "oauth-authorization-server"))
+}
+
+func TestOAuthAuthorize(t *testing.T) {
+	// Not parallel: replaces the data dir and browser globals.
+	dataDir := t.TempDir()
+	origDataDir, origOpenBrowser := oauthDataDir, openBrowser
+	t.Cleanup(func() { oauthDataDir, openBrowser = origDataDir, origOpenBrowser })
+	oauthDataDir = func() string { return dataDir }
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-2" && r.Header.Get("Authorization") != "Bearer access-1" {
+			w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="`+srv.URL+`/.well-known/oauth-protected-resource/mcp"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	mux.HandleFunc("/.well-known/oauth-protected-resource/mcp", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"resource":              srv.URL + "/mcp",
+			"authorization_servers": []string{srv.URL},
+			"scopes_supported":      []string{"mcp"},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"registration_endpoint":  srv.URL + "/register",
+		})
+	})
+	var redirectURI string
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			RedirectURIs []string `json:"redirect_uris"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		redirectURI = body.RedirectURIs[0]
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"client_id": "client-1"})
+	})
+	var challenge string
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		require.Equal(t, "client-1", q.Get("client_id"))
+		require.Equal(t, "S256", q.Get("code_challenge_method"))
+		require.Equal(t, srv.URL+"/mcp", q.Get("resource"))
+		require.Equal(t, "mcp", q.Get("scope"))
+		challenge = q.Get("code_challenge")
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code=code-1&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			require.Equal(t, "code-1", r.Form.Get("code"))
+			require.NotEmpty(t, r.Form.Get("code_verifier"))
+			require.NotEmpty(t, challenge)
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  "access-1",
+				"refresh_token": "refresh-1",
+				"token_type":    "Bearer",
+				"expires_in":    -1,
+			})
+		case "refresh_token":
+			require.Equal(t, "refresh-1", r.Form.Get("refresh_token"))
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token": "access-2",
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+			})
+		}
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	openBrowser = func(ctx context.Context, u string) error {
+		go func() {
+			resp, err := http.Get(u)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+
+	m := config.MCPConfig{Type: config.MCPHttp, URL: srv.URL + "/mcp"}
+	rt := newOAuthRoundTripper("test", m.URL, http.DefaultTransport)
+	require.NoError(t, rt.authorize(t.Context(), m))
+	require.Contains(t, redirectURI, "http://127.0.0.1:")
+
+	entry := loadOAuthEntry("test", m.URL)
+	require.NotNil(t, entry)
+	require.Equal(t, "client-1", entry.ClientID)
+	require.Equal(t, "access-1", entry.Token.AccessToken)
+
+	// The token expired right away, so the next request refreshes it.
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, m.URL, nil)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	require.Equal(t, "access-2", loadOAuthEntry("test", m.URL).Token.AccessToken)
+
+	// Authorized now, so there's nothing left to do.
+	require.NoError(t, newOAuthRoundTripper("test", m.URL, http.DefaultTransport).authorize(t.Context(), m))
+}
+
+func TestOAuthCallbackHandler(t *testing.T) {
+	t.Parallel()
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	handler := oauthCallbackHandler("state-1", codes, errs)
+	callback := func(query string) int {
+		done := make(chan int)
+		go func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, oauthCallbackPath+"?"+query, nil))
+			done <- w.Code
+		}()
+		select {
+		case code := <-done:
+			return code
+		case <-time.After(5 * time.Second):
+			t.Fatalf("callback %q blocked", query)
+			return 0
+		}
+	}
+
+	require.Equal(t, http.StatusBadRequest, callback("code=code-0&state=other"))
+	require.Equal(t, http.StatusOK, callback("code=code-1&state=state-1"))
+	// The browser reloads the page, or the server redirects twice.
+	require.Equal(t, http.StatusOK, callback("code=code-2&state=state-1"))
+	require.Equal(t, http.StatusOK, callback("error=access_denied&state=state-1"))
+	require.Equal(t, http.StatusOK, callback("error=server_error&state=state-1"))
+
+	require.Equal(t, "code-1", <-codes)
+	require.EqualError(t, <-errs, "authorization denied: access_denied")
+}
@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type (
+	Resource         = mcp.Resource
+	ResourceContents = mcp.ResourceContents
+)
+
+type resourceKey struct {
+	mcpName string
+	uri     string
+}
+
+var (
+	allResources = csync.NewMap[string, []*Resource]()
+	// subscribed holds the resources we asked to be notified about, and
+	// whether they were updated since they were last read.
+	subscribed = csync.NewMap[resourceKey, bool]()
+)
+
+// Resources returns all available MCP resources.
+func Resources() iter.Seq2[string, []*Resource] {
+	return allResources.Seq2()
+}
+
+// ReadResource reads the contents of an MCP resource. If the server supports
+// it, we also subscribe to updates of the resource.
+func ReadResource(ctx context.Context, name, uri string) ([]*ResourceContents, error) {
+	c, err := getOrRenewClient(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+
+	key := resourceKey{name, uri}
+	if _, ok := subscribed.Get(key); !ok && canSubscribe(c) {
+		if err := c.Subscribe(ctx, &mcp.SubscribeParams{URI: uri}); err != nil {
+			slog.Warn("error subscribing to mcp resource", "name", name, "uri", uri, "error", err)
+		} else {
+			slog.Debug("subscribed to mcp resource", "name", name, "uri", uri)
+		}
+	}
+	subscribed.Set(key, false)
+	return result.Contents, nil
+}
+
+// ResourceUpdated reports whether a resource that was read before has been
+// updated since.
+func ResourceUpdated(name, uri string) bool {
+	updated, _ := subscribed.Get(resourceKey{name, uri})
+	return updated
+}
+
+// RefreshResources gets the updated list of resources from the MCP and
+// updates the global state.
+func RefreshResources(ctx context.Context, name string) {
+	session, ok := sessions.Get(name)
+	if !ok {
+		slog.Warn("refresh resources: no session", "name", name)
+		return
+	}
+
+	resources, err := getResources(ctx, session)
+	if err != nil {
+		updateState(name, StateError, err, nil, Counts{})
+		return
+	}
+
+	updateResources(name, resources)
+
+	prev, _ := states.Get(name)
+	prev.Counts.Resources = len(resources)
+	updateState(name, StateConnected, nil, session, prev.Counts)
+}
+
+func getResources(ctx context.Context, c *mcp.ClientSession) ([]*Resource, error) {
+	if c.InitializeResult().Capabilities.Resources == nil {
+		return nil, nil
+	}
+	var resources []*Resource
+	for resource, err := range c.Resources(ctx, nil) {
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+func updateResources(name string, resources []*Resource) {
+	if len(resources) == 0 {
+		allResources.Del(name)
+		return
+	}
+	allResources.Set(name, resources)
+}
+
+// markResourceUpdated flags a subscribed resource as updated.
+func markResourceUpdated(name, uri string) {
+	key := resourceKey{name, uri}
+	if _, ok := subscribed.Get(key); ok {
+		subscribed.Set(key, true)
+	}
+}
+
+// resubscribe subscribes a new session of the given MCP to the resources we
+// were subscribed to.
+func resubscribe(ctx context.Context, name string, c *mcp.ClientSession) {
+	if !canSubscribe(c) {
+		return
+	}
+	for key := range subscribed.Seq2() {
+		if key.mcpName != name {
+			continue
+		}
+		if err := c.Subscribe(ctx, &mcp.SubscribeParams{URI: key.uri}); err != nil {
+			slog.Warn("error subscribing to mcp resource", "name", name, "uri", key.uri, "error", err)
+		}
+	}
+}
+
+func canSubscribe(c *mcp.ClientSession) bool {
+	caps := c.InitializeResult().Capabilities.Resources
+	return caps != nil && caps.Subscribe
+}
@@ -0,0 +1,127 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// newResourceServer starts an MCP server with a text and a binary resource,
+// configured and connected as the "res" MCP.
+func newResourceServer(t *testing.T) *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, &mcp.ServerOptions{
+		SubscribeHandler:   func(context.Context, *mcp.SubscribeRequest) error { return nil },
+		UnsubscribeHandler: func(context.Context, *mcp.UnsubscribeRequest) error { return nil },
+	})
+	server.AddResource(&mcp.Resource{URI: "test://notes", Name: "notes", MIMEType: "text/plain"}, func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+			{URI: req.Params.URI, MIMEType: "text/plain", Text: "hello"},
+		}}, nil
+	})
+	server.AddResource(&mcp.Resource{URI: "test://logo", Name: "logo", MIMEType: "image/png"}, func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+			{URI: req.Params.URI, MIMEType: "image/png", Blob: []byte{0x89, 'P', 'N', 'G'}},
+		}}, nil
+	})
+	srv := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	data, err := json.Marshal(map[string]any{
+		"mcp": map[string]any{
+			"res": map[string]any{
+				"type":  config.MCPHttp,
+				"url":   srv.URL,
+				"oauth": map[string]any{"disabled": true},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "crush.json"), data, 0o644))
+	cfg, err := config.Init(dir, filepath.Join(dir, "data"), "", false)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if sess, ok := sessions.Take("res"); ok {
+			_ = sess.Close()
+		}
+		updateResources("res", nil)
+		states.Del("res")
+		for key := range subscribed.Seq2() {
+			subscribed.Del(key)
+		}
+	})
+	Initialize(t.Context(), nil, cfg)
+	return server
+}
+
+func TestReadResource(t *testing.T) {
+	server := newResourceServer(t)
+
+	contents, err := ReadResource(t.Context(), "res", "test://notes")
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	require.Equal(t, "hello", contents[0].Text)
+
+	resources := maps.Collect(Resources())
+	require.Len(t, resources["res"], 2, "connecting should list the resources")
+
+	contents, err = ReadResource(t.Context(), "res", "test://logo")
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	require.Empty(t, contents[0].Text)
+	require.Equal(t, []byte{0x89, 'P', 'N', 'G'}, contents[0].Blob)
+
+	require.False(t, ResourceUpdated("res", "test://notes"))
+	require.NoError(t, server.ResourceUpdated(t.Context(), &mcp.ResourceUpdatedNotificationParams{URI: "test://notes"}))
+	require.Eventually(t, func() bool {
+		return ResourceUpdated("res", "test://notes")
+	}, 5*time.Second, 10*time.Millisecond, "subscribed resource should be flagged as updated")
+	require.False(t, ResourceUpdated("res", "test://logo"))
+
+	_, err = ReadResource(t.Context(), "res", "test://notes")
+	require.NoError(t, err)
+	require.False(t, ResourceUpdated("res", "test://notes"), "reading should clear the update flag")
+
+	_, err = ReadResource(t.Context(), "unknown", "test://notes")
+	require.EqualError(t, err, "mcp 'unknown' not available")
+}
+
+func TestMarkResourceUpdated(t *testing.T) {
+	t.Cleanup(func() {
+		subscribed.Del(resourceKey{"mark", "test://read"})
+	})
+	subscribed.Set(resourceKey{"mark", "test://read"}, false)
+
+	markResourceUpdated("mark", "test://read")
+	markResourceUpdated("mark", "test://unread")
+
+	require.True(t, ResourceUpdated("mark", "test://read"))
+	require.False(t, ResourceUpdated("mark", "test://unread"), "resources never read aren't tracked")
+	_, ok := subscribed.Get(resourceKey{"mark", "test://unread"})
+	require.False(t, ok)
+}
+
+func TestUpdateResources(t *testing.T) {
+	t.Cleanup(func() { updateResources("update", nil) })
+
+	updateResources("update", []*Resource{{URI: "test://a"}})
+	require.Len(t, maps.Collect(Resources())["update"], 1)
+
+	updateResources("update", nil)
+	_, ok := maps.Collect(Resources())["update"]
+	require.False(t, ok, "servers without resources shouldn't be listed")
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+)
+
+type MCPResourcesParams struct {
+	MCPName string `json:"mcp_name,omitempty" description:"The name of the MCP server. Leave empty to list the resources of all servers"`
+	URI     string `json:"uri,omitempty" description:"The URI of the resource to read. Leave empty to list the available resources"`
+}
+
+type MCPResourcesResponseMetadata struct {
+	MCPName string `json:"mcp_name,omitempty"`
+	URI     string `json:"uri,omitempty"`
+	Count   int    `json:"count"`
+}
+
+const MCPResourcesToolName = "mcp_resources"
+
+//go:embed mcp_resources.md
+var mcpResourcesDescription []byte
+
+// NewMCPResourcesTool creates a tool to list and read MCP resources. Only the
+// servers in allowedMCP are available, or all of them if it's nil.
+func NewMCPResourcesTool(allowedMCP map[string][]string) fantasy.AgentTool {
+	allowed := func(name string) bool {
+		if allowedMCP == nil {
+			return true
+		}
+		_, ok := allowedMCP[name]
+		return ok
+	}
+	return fantasy.NewAgentTool(
+		MCPResourcesToolName,
+		string(mcpResourcesDescription),
+		func(ctx context.Context, params MCPResourcesParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.URI == "" {
+				return listMCPResources(params.MCPName, allowed), nil
+			}
+
+			name := params.MCPName
+			if name == "" {
+				name = findMCPResource(params.URI, allowed)
+				if name == "" {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("resource not found: %s, specify mcp_name", params.URI)), nil
+				}
+			}
+			if !allowed(name) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("mcp %s is not available", name)), nil
+			}
+
+			contents, err := mcp.ReadResource(ctx, name, params.URI)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error reading resource: %s", err)), nil
+			}
+
+			var output strings.Builder
+			for _, content := range contents {
+				fmt.Fprintf(&output, "<resource uri=%q mime_type=%q>\n", content.URI, content.MIMEType)
+				if content.Text != "" || len(content.Blob) == 0 {
+					output.WriteString(content.Text)
+				} else {
+					fmt.Fprintf(&output, "[binary content, %d bytes]", len(content.Blob))
+				}
+				output.WriteString("\n</resource>\n")
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(output.String()),
+				MCPResourcesResponseMetadata{
+					MCPName: name,
+					URI:     params.URI,
+					Count:   len(contents),
+				},
+			), nil
+		})
+}
+
+func listMCPResources(mcpName string, allowed func(string) bool) fantasy.ToolResponse {
+	all := maps.Collect(mcp.Resources())
+	var names []string
+	for name := range all {
+		if allowed(name) && (mcpName == "" || mcpName == name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return fantasy.NewTextResponse("No MCP resources available")
+	}
+	slices.Sort(names)
+
+	var output strings.Builder
+	count := 0
+	for _, name := range names {
+		fmt.Fprintf(&output, "<mcp name=%q>\n", name)
+		for _, resource := range all[name] {
+			count++
+			fmt.Fprintf(&output, "- %s (%s)", resource.URI, cmp.Or(resource.Title, resource.Name))
+			if resource.MIMEType != "" {
+				fmt.Fprintf(&output, " [%s]", resource.MIMEType)
+			}
+			if mcp.ResourceUpdated(name, resource.URI) {
+				output.WriteString(" (updated since last read)")
+			}
+			if resource.Description != "" {
+				fmt.Fprintf(&output, ": %s", resource.Description)
+			}
+			output.WriteString("\n")
+		}
+		output.WriteString("</mcp>\n")
+	}
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(output.String()),
+		MCPResourcesResponseMetadata{
+			MCPName: mcpName,
+			Count:   count,
+		},
+	)
+}
+
+// findMCPResource returns the name of the server that lists the given URI.
+func findMCPResource(uri string, allowed func(string) bool) string {
+	for name, resources := range mcp.Resources() {
+		if !allowed(name) {
+			continue
+		}
+		for _, resource := range resources {
+			if resource.URI == uri {
+				return name
+			}
+		}
+	}
+	return ""
+}
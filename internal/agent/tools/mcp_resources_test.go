@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// connectResourceMCP connects to an MCP server with a text and a binary
+// resource, configured as the "res" MCP.
+func connectResourceMCP(t *testing.T) *gomcp.Server {
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test"}, &gomcp.ServerOptions{
+		SubscribeHandler:   func(context.Context, *gomcp.SubscribeRequest) error { return nil },
+		UnsubscribeHandler: func(context.Context, *gomcp.UnsubscribeRequest) error { return nil },
+	})
+	server.AddResource(&gomcp.Resource{URI: "test://notes", Name: "notes", Title: "Notes", MIMEType: "text/plain", Description: "Some notes"}, func(_ context.Context, req *gomcp.ReadResourceRequest) (*gomcp.ReadResourceResult, error) {
+		return &gomcp.ReadResourceResult{Contents: []*gomcp.ResourceContents{
+			{URI: req.Params.URI, MIMEType: "text/plain", Text: "hello"},
+		}}, nil
+	})
+	server.AddResource(&gomcp.Resource{URI: "test://logo", Name: "logo"}, func(_ context.Context, req *gomcp.ReadResourceRequest) (*gomcp.ReadResourceResult, error) {
+		return &gomcp.ReadResourceResult{Contents: []*gomcp.ResourceContents{
+			{URI: req.Params.URI, MIMEType: "image/png", Blob: []byte{0x89, 'P', 'N', 'G'}},
+		}}, nil
+	})
+	srv := httptest.NewServer(gomcp.NewStreamableHTTPHandler(func(*http.Request) *gomcp.Server { return server }, nil))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	data, err := json.Marshal(map[string]any{
+		"mcp": map[string]any{
+			"res": map[string]any{
+				"type":  config.MCPHttp,
+				"url":   srv.URL,
+				"oauth": map[string]any{"disabled": true},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "crush.json"), data, 0o644))
+	cfg, err := config.Init(dir, filepath.Join(dir, "data"), "", false)
+	require.NoError(t, err)
+
+	mcp.Initialize(t.Context(), nil, cfg)
+	t.Cleanup(func() { _ = mcp.Disable("res") })
+	return server
+}
+
+func runMCPResourcesTool(t *testing.T, tool fantasy.AgentTool, params MCPResourcesParams) fantasy.ToolResponse {
+	input, err := json.Marshal(params)
+	require.NoError(t, err)
+	resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call", Name: MCPResourcesToolName, Input: string(input)})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestMCPResourcesTool(t *testing.T) {
+	server := connectResourceMCP(t)
+	tool := NewMCPResourcesTool(nil)
+
+	t.Run("list", func(t *testing.T) {
+		resp := runMCPResourcesTool(t, tool, MCPResourcesParams{})
+		require.False(t, resp.IsError)
+		require.Contains(t, resp.Content, `<mcp name="res">`)
+		require.Contains(t, resp.Content, "- test://notes (Notes) [text/plain]: Some notes\n")
+		require.Contains(t, resp.Content, "- test://logo (logo)\n")
+
+		var meta MCPResourcesResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		require.Equal(t, 2, meta.Count)
+
+		resp = runMCPResourcesTool(t, tool, MCPResourcesParams{MCPName: "other"})
+		require.Equal(t, "No MCP resources available", resp.Content)
+	})
+
+	t.Run("read text", func(t *testing.T) {
+		resp := runMCPResourcesTool(t, tool, MCPResourcesParams{URI: "test://notes"})
+		require.False(t, resp.IsError)
+		require.Equal(t, "<resource uri=\"test://notes\" mime_type=\"text/plain\">\nhello\n</resource>\n", resp.Content)
+	})
+
+	t.Run("read binary", func(t *testing.T) {
+		resp := runMCPResourcesTool(t, tool, MCPResourcesParams{MCPName: "res", URI: "test://logo"})
+		require.False(t, resp.IsError)
+		require.Equal(t, "<resource uri=\"test://logo\" mime_type=\"image/png\">\n[binary content, 4 bytes]\n</resource>\n", resp.Content)
+	})
+
+	t.Run("updated", func(t *testing.T) {
+		runMCPResourcesTool(t, tool, MCPResourcesParams{URI: "test://notes"})
+		require.NoError(t, server.ResourceUpdated(t.Context(), &gomcp.ResourceUpdatedNotificationParams{URI: "test://notes"}))
+		require.Eventually(t, func() bool {
+			return mcp.ResourceUpdated("res", "test://notes")
+		}, 5*time.Second, 10*time.Millisecond)
+
+		resp := runMCPResourcesTool(t, tool, MCPResourcesParams{MCPName: "res"})
+		require.Contains(t, resp.Content, "- test://notes (Notes) [text/plain] (updated since last read): Some notes\n")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		resp := runMCPResourcesTool(t, tool, MCPResourcesParams{URI: "test://missing"})
+		require.True(t, resp.IsError)
+		require.Equal(t, "resource not found: test://missing, specify mcp_name", resp.Content)
+
+		resp = runMCPResourcesTool(t, tool, MCPResourcesParams{MCPName: "unknown", URI: "test://notes"})
+		require.True(t, resp.IsError)
+		require.Equal(t, "error reading resource: mcp 'unknown' not available", resp.Content)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		tool := NewMCPResourcesTool(map[string][]string{"other": nil})
+
+		resp := runMCPResourcesTool(t, tool, MCPResourcesParams{})
+		require.Equal(t, "No MCP resources available", resp.Content)
+
+		resp = runMCPResourcesTool(t, tool, MCPResourcesParams{URI: "test://notes"})
+		require.True(t, resp.IsError)
+		require.Contains(t, resp.Content, "resource not found")
+
+		resp = runMCPResourcesTool(t, tool, MCPResourcesParams{MCPName: "res", URI: "test://notes"})
+		require.True(t, resp.IsError)
+		require.Equal(t, "mcp res is not available", resp.Content)
+	})
+}
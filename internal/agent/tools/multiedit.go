@@ -71,6 +71,8 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 			}
 
 			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			// Start the servers for the file, if needed, and mark them as used.
+			lsp.NotifyFileAccess(ctx, params.FilePath)
 
 			// Validate all edits before applying any
 			if err := validateEdits(params.Edits); err != nil {
@@ -101,6 +103,7 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 
 			// Wait for LSP diagnostics and add them to the response
 			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
+			text += formatOnEdit(ctx, lspClients, files, workingDir, params.FilePath)
 			text += getDiagnostics(params.FilePath, lspClients)
 			response.Content = text
 			return response, nil
@@ -182,6 +185,17 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	currentContent, reviewNote, create := reviewedContent(edit.permissions, call.ID, params.FilePath, "", currentContent, false)
+	if !create {
+		return fantasy.WithResponseMetadata(
+			fantasy.NewTextResponse("File not created: "+params.FilePath+reviewNote),
+			MultiEditResponseMetadata{},
+		), nil
+	}
+	if reviewNote != "" {
+		_, additions, removals = diff.GenerateDiff("", currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
+	}
+
 	// Write the file
 	err := os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
 	if err != nil {
@@ -189,7 +203,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	// Update file history
-	_, err = edit.files.Create(edit.ctx, sessionID, params.FilePath, "")
+	_, err = edit.files.CreateNew(edit.ctx, sessionID, params.FilePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 	}
@@ -211,7 +225,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+reviewNote),
 		MultiEditResponseMetadata{
 			OldContent:   "",
 			NewContent:   currentContent,
@@ -316,6 +330,11 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	currentContent, reviewNote, _ := reviewedContent(edit.permissions, call.ID, params.FilePath, oldContent, currentContent, true)
+	if reviewNote != "" {
+		_, additions, removals = diff.GenerateDiff(oldContent, currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
+	}
+
 	if isCrlf {
 		currentContent, _ = fsext.ToWindowsLineEndings(currentContent)
 	}
@@ -360,7 +379,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+reviewNote),
 		MultiEditResponseMetadata{
 			OldContent:   oldContent,
 			NewContent:   currentContent,
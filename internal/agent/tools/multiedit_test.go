@@ -16,6 +16,7 @@ import (
 
 type mockPermissionService struct {
 	*pubsub.Broker[permission.PermissionRequest]
+	modified map[string]string
 }
 
 func (m *mockPermissionService) Request(req permission.CreatePermissionRequest) bool {
@@ -28,10 +29,25 @@ func (m *mockPermissionService) Deny(req permission.PermissionRequest) {}
 
 func (m *mockPermissionService) GrantPersistent(req permission.PermissionRequest) {}
 
+func (m *mockPermissionService) Modify(req permission.PermissionRequest, content string) {
+	if m.modified == nil {
+		m.modified = map[string]string{}
+	}
+	m.modified[req.ToolCallID] = content
+}
+
+func (m *mockPermissionService) ModifiedContent(toolCallID string) (string, bool) {
+	content, ok := m.modified[toolCallID]
+	delete(m.modified, toolCallID)
+	return content, ok
+}
+
 func (m *mockPermissionService) AutoApproveSession(sessionID string) {}
 
 func (m *mockPermissionService) SetSkipRequests(skip bool) {}
 
+func (m *mockPermissionService) SetAllowedTools(allowedTools []string) {}
+
 func (m *mockPermissionService) SkipRequests() bool {
 	return false
 }
@@ -48,6 +64,10 @@ func (m *mockHistoryService) Create(ctx context.Context, sessionID, path, conten
 	return history.File{Path: path, Content: content}, nil
 }
 
+func (m *mockHistoryService) CreateNew(ctx context.Context, sessionID, path string) (history.File, error) {
+	return history.File{Path: path, IsNew: true}, nil
+}
+
 func (m *mockHistoryService) CreateVersion(ctx context.Context, sessionID, path, content string) (history.File, error) {
 	return history.File{}, nil
 }
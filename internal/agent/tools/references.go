@@ -97,6 +97,8 @@ func find(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], symbo
 		return nil, fmt.Errorf("failed to get absolute path: %s", err)
 	}
 
+	lsp.NotifyFileAccess(ctx, absPath)
+
 	var client *lsp.Client
 	for c := range lspClients.Seq() {
 		if c.HandlesFile(absPath) {
@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// reviewedContent returns the content to write once the user reviewed the
+// proposed one, which they can change by rejecting hunks or editing it. When
+// they did, it also returns a note telling the model what was written instead.
+// It returns false when the user rejected all the changes to a file that
+// doesn't exist yet, which then shouldn't be created.
+func reviewedContent(permissions permission.Service, toolCallID, filePath, oldContent, proposed string, exists bool) (string, string, bool) {
+	content, ok := permissions.ModifiedContent(toolCallID)
+	if !ok || content == proposed {
+		return proposed, "", true
+	}
+	if content == oldContent {
+		if !exists {
+			return content, "\n<user_review>\nThe user rejected all of your proposed changes, so the file was not created.\n</user_review>", false
+		}
+		return content, "\n<user_review>\nThe user rejected all of your proposed changes, so the file was left unchanged.\n</user_review>", true
+	}
+	patch, _, _ := diff.GenerateDiff(proposed, content, filePath)
+	return content, fmt.Sprintf("\n<user_review>\nThe user changed your proposed content before it was written, by rejecting some hunks or editing it. This is the diff from your proposed content to the content actually written to the file:\n%s\n</user_review>", patch), true
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHistoryService records the files added to the history.
+type recordingHistoryService struct {
+	mockHistoryService
+	paths []string
+}
+
+func (m *recordingHistoryService) Create(ctx context.Context, sessionID, path, content string) (history.File, error) {
+	m.paths = append(m.paths, path)
+	return m.mockHistoryService.Create(ctx, sessionID, path, content)
+}
+
+func (m *recordingHistoryService) CreateNew(ctx context.Context, sessionID, path string) (history.File, error) {
+	m.paths = append(m.paths, path)
+	return m.mockHistoryService.CreateNew(ctx, sessionID, path)
+}
+
+func (m *recordingHistoryService) CreateVersion(ctx context.Context, sessionID, path, content string) (history.File, error) {
+	m.paths = append(m.paths, path)
+	return m.mockHistoryService.CreateVersion(ctx, sessionID, path, content)
+}
+
+func TestReviewedContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		modified   *string
+		oldContent string
+		exists     bool
+		content    string
+		note       string
+		write      bool
+	}{
+		{name: "not reviewed", oldContent: "old\n", exists: true, content: "new\n", write: true},
+		{name: "accepted", modified: ptr("new\n"), oldContent: "old\n", exists: true, content: "new\n", write: true},
+		{name: "edited", modified: ptr("other\n"), oldContent: "old\n", exists: true, content: "other\n", note: "actually written", write: true},
+		{name: "rejected", modified: ptr("old\n"), oldContent: "old\n", exists: true, content: "old\n", note: "left unchanged", write: true},
+		{name: "rejected empty file", modified: ptr(""), exists: true, note: "left unchanged", write: true},
+		{name: "rejected new file", modified: ptr(""), note: "not created"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			permissions := &mockPermissionService{}
+			if tt.modified != nil {
+				permissions.Modify(permission.PermissionRequest{ToolCallID: "call"}, *tt.modified)
+			}
+			content, note, write := reviewedContent(permissions, "call", "file.txt", tt.oldContent, "new\n", tt.exists)
+			require.Equal(t, tt.content, content)
+			require.Equal(t, tt.write, write)
+			if tt.note == "" {
+				require.Empty(t, note)
+			} else {
+				require.Contains(t, note, tt.note)
+			}
+		})
+	}
+}
+
+func TestRejectedNewFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		tool   func(permission.Service, history.Service, string) fantasy.AgentTool
+		params func(path string) any
+	}{
+		{
+			name: "write",
+			tool: func(p permission.Service, h history.Service, dir string) fantasy.AgentTool {
+				return NewWriteTool(csync.NewMap[string, *lsp.Client](), p, h, dir)
+			},
+			params: func(path string) any { return WriteParams{FilePath: path, Content: "new\n"} },
+		},
+		{
+			name: "edit",
+			tool: func(p permission.Service, h history.Service, dir string) fantasy.AgentTool {
+				return NewEditTool(csync.NewMap[string, *lsp.Client](), p, h, dir)
+			},
+			params: func(path string) any { return EditParams{FilePath: path, NewString: "new\n"} },
+		},
+		{
+			name: "multiedit",
+			tool: func(p permission.Service, h history.Service, dir string) fantasy.AgentTool {
+				return NewMultiEditTool(csync.NewMap[string, *lsp.Client](), p, h, dir)
+			},
+			params: func(path string) any {
+				return MultiEditParams{FilePath: path, Edits: []MultiEditOperation{{NewString: "new\n"}}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			path := filepath.Join(dir, "new.txt")
+			permissions := &mockPermissionService{}
+			permissions.Modify(permission.PermissionRequest{ToolCallID: "call"}, "")
+			files := &recordingHistoryService{}
+
+			input, err := json.Marshal(tt.params(path))
+			require.NoError(t, err)
+			ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+			ctx = context.WithValue(ctx, MessageIDContextKey, "message")
+			resp, err := tt.tool(permissions, files, dir).Run(ctx, fantasy.ToolCall{ID: "call", Input: string(input)})
+			require.NoError(t, err)
+			require.False(t, resp.IsError, resp.Content)
+			require.Contains(t, resp.Content, "the file was not created")
+
+			require.NoFileExists(t, path)
+			require.Empty(t, files.paths, "nothing should be added to the history")
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -154,6 +154,9 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("This is an image file of type: %s\n", imageType)), nil
 			}
 
+			// Start the servers for the file, if needed, and mark them as used.
+			lsp.NotifyFileAccess(ctx, filePath)
+
 			// Read the file content
 			content, lineCount, err := readTextFile(filePath, params.Offset, params.Limit)
 			isValidUt8 := utf8.ValidString(content)
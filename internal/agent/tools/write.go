@@ -64,6 +64,8 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			}
 
 			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			// Start the servers for the file, if needed, and mark them as used.
+			lsp.NotifyFileAccess(ctx, filePath)
 
 			fileInfo, err := os.Stat(filePath)
 			if err == nil {
@@ -104,7 +106,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				return fantasy.ToolResponse{}, fmt.Errorf("session_id is required")
 			}
 
-			diff, additions, removals := diff.GenerateDiff(
+			fileDiff, additions, removals := diff.GenerateDiff(
 				oldContent,
 				params.Content,
 				strings.TrimPrefix(filePath, workingDir),
@@ -129,7 +131,18 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
-			err = os.WriteFile(filePath, []byte(params.Content), 0o644)
+			content, reviewNote, write := reviewedContent(permissions, call.ID, filePath, oldContent, params.Content, fileInfo != nil)
+			if !write {
+				return fantasy.WithResponseMetadata(
+					fantasy.NewTextResponse(fmt.Sprintf("<result>\nFile not created: %s%s\n</result>", filePath, reviewNote)),
+					WriteResponseMetadata{},
+				), nil
+			}
+			if reviewNote != "" {
+				fileDiff, additions, removals = diff.GenerateDiff(oldContent, content, strings.TrimPrefix(filePath, workingDir))
+			}
+
+			err = os.WriteFile(filePath, []byte(content), 0o644)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 			}
@@ -137,7 +150,11 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			// Check if file exists in history
 			file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
 			if err != nil {
-				_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				if fileInfo == nil {
+					_, err = files.CreateNew(ctx, sessionID, filePath)
+				} else {
+					_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				}
 				if err != nil {
 					// Log error but don't fail the operation
 					return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -151,7 +168,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				}
 			}
 			// Store the new version
-			_, err = files.CreateVersion(ctx, sessionID, filePath, params.Content)
+			_, err = files.CreateVersion(ctx, sessionID, filePath, content)
 			if err != nil {
 				slog.Debug("Error creating file history version", "error", err)
 			}
@@ -162,11 +179,12 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			notifyLSPs(ctx, lspClients, params.FilePath)
 
 			result := fmt.Sprintf("File successfully written: %s", filePath)
-			result = fmt.Sprintf("<result>\n%s\n</result>", result)
+			result = fmt.Sprintf("<result>\n%s%s\n</result>", result, reviewNote)
+			result += formatOnEdit(ctx, lspClients, files, workingDir, filePath)
 			result += getDiagnostics(filePath, lspClients)
 			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result),
 				WriteResponseMetadata{
-					Diff:      diff,
+					Diff:      fileDiff,
 					Additions: additions,
 					Removals:  removals,
 				},
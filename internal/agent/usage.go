@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent/prompt"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tokenizer"
+)
+
+// Context categories.
+const (
+	ContextSystemPrompt = "System prompt"
+	ContextFiles        = "Context files"
+	ContextTools        = "Tools"
+	ContextMCPTools     = "MCP tools"
+	ContextHistory      = "History"
+	ContextAttachments  = "Attachments"
+)
+
+// ContextUsage breaks down the context window the next call of a session
+// takes, as counted locally.
+type ContextUsage struct {
+	Model         string
+	ContextWindow int64
+	Tokenizer     tokenizer.Family
+	Categories    []ContextCategory
+}
+
+// ContextCategory is a part of the context, made of the items it lists.
+type ContextCategory struct {
+	Name   string
+	Tokens int64
+	Items  []ContextItem
+}
+
+// ContextItem is an element of a category, such as a context file or the
+// tools of an MCP server.
+type ContextItem struct {
+	Name   string
+	Tokens int64
+}
+
+// Tokens returns the number of tokens of the whole context.
+func (u ContextUsage) Tokens() int64 {
+	var tokens int64
+	for _, c := range u.Categories {
+		tokens += c.Tokens
+	}
+	return tokens
+}
+
+// add counts the tokens in the item of the name, creating it if needed.
+func (c *ContextCategory) add(name string, tokens int64) {
+	if tokens == 0 {
+		return
+	}
+	c.Tokens += tokens
+	if i := slices.IndexFunc(c.Items, func(item ContextItem) bool { return item.Name == name }); i >= 0 {
+		c.Items[i].Tokens += tokens
+		return
+	}
+	c.Items = append(c.Items, ContextItem{Name: name, Tokens: tokens})
+}
+
+// sortItems puts the largest items first.
+func (c *ContextCategory) sortItems() {
+	slices.SortStableFunc(c.Items, func(a, b ContextItem) int {
+		return cmp.Compare(b.Tokens, a.Tokens)
+	})
+}
+
+// Tokenizer returns the tokenizer approximating the one of the model.
+func (m Model) Tokenizer() tokenizer.Tokenizer {
+	var provider string
+	if m.Model != nil {
+		provider = m.Model.Provider()
+	}
+	return tokenizer.ForModel(catwalk.Type(provider), m.CatwalkCfg.ID)
+}
+
+// PromptTokens counts the tokens of the system prompt and the tool
+// definitions sent with every call.
+func (a *sessionAgent) PromptTokens() int64 {
+	tok := a.largeModel.Tokenizer()
+	tokens := a.systemPromptTokens(tok)
+	for _, tool := range a.tools {
+		tokens += toolTokens(tok, tool.Info())
+	}
+	return tokens
+}
+
+func (a *sessionAgent) ContextUsage(ctx context.Context, sessionID string, contextFiles []prompt.ContextFile, attachments []message.Attachment) (ContextUsage, error) {
+	tok := a.largeModel.Tokenizer()
+	usage := ContextUsage{
+		Model:         a.largeModel.CatwalkCfg.Name,
+		ContextWindow: a.largeModel.CatwalkCfg.ContextWindow,
+		Tokenizer:     tok.Family,
+	}
+
+	// The context files are part of the system prompt.
+	files := ContextCategory{Name: ContextFiles}
+	for _, f := range contextFiles {
+		files.add(f.Path, tok.Count(f.Content))
+	}
+	files.sortItems()
+	system := ContextCategory{Name: ContextSystemPrompt, Tokens: max(a.systemPromptTokens(tok)-files.Tokens, 0)}
+
+	tools := ContextCategory{Name: ContextTools}
+	mcpTools := ContextCategory{Name: ContextMCPTools}
+	for _, tool := range a.tools {
+		info := tool.Info()
+		if mcpTool, ok := tool.(interface{ MCP() string }); ok {
+			mcpTools.add(mcpTool.MCP(), toolTokens(tok, info))
+			continue
+		}
+		tools.add(info.Name, toolTokens(tok, info))
+	}
+	tools.sortItems()
+	mcpTools.sortItems()
+
+	history := ContextCategory{Name: ContextHistory}
+	attached := ContextCategory{Name: ContextAttachments}
+	if sessionID != "" {
+		session, err := a.sessions.Get(ctx, sessionID)
+		if err != nil {
+			return ContextUsage{}, fmt.Errorf("failed to get session: %w", err)
+		}
+		msgs, err := a.getSessionMessages(ctx, session)
+		if err != nil {
+			return ContextUsage{}, err
+		}
+		for _, msg := range msgs {
+			a.countMessage(tok, msg, &history, &attached)
+		}
+	}
+	for _, attachment := range attachments {
+		attached.add(attachment.FileName, a.attachmentTokens(tok, attachment.MimeType, attachment.Content))
+	}
+	attached.sortItems()
+
+	usage.Categories = []ContextCategory{system, files, tools, mcpTools, history, attached}
+	return usage, nil
+}
+
+// countMessage counts the parts of the message in the history, but for the
+// files attached to it.
+func (a *sessionAgent) countMessage(tok tokenizer.Tokenizer, msg message.Message, history, attached *ContextCategory) {
+	history.add("Messages", tok.CountMessage())
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case message.TextContent:
+			history.add("Messages", tok.Count(p.Text))
+		case message.ReasoningContent:
+			history.add("Reasoning", tok.Count(p.Thinking))
+		case message.ToolCall:
+			history.add("Tool calls", tok.Count(p.Name)+tok.Count(p.Input))
+		case message.ToolResult:
+			tokens := tok.Count(p.Content)
+			if data, err := base64.StdEncoding.DecodeString(p.Data); err == nil && p.Data != "" {
+				tokens += a.attachmentTokens(tok, p.MIMEType, data)
+			}
+			history.add("Tool results", tokens)
+		case message.BinaryContent:
+			attached.add(filepath.Base(p.Path), a.attachmentTokens(tok, p.MIMEType, p.Data))
+		}
+	}
+}
+
+// attachmentTokens counts the tokens of a file, the images being replaced
+// with a note for models that do not support them.
+func (a *sessionAgent) attachmentTokens(tok tokenizer.Tokenizer, mimeType string, data []byte) int64 {
+	switch {
+	case strings.HasPrefix(mimeType, "text/"):
+		return tok.Count(string(data))
+	case a.largeModel.CatwalkCfg.SupportsImages:
+		return tok.CountImage(data)
+	default:
+		return tok.Count("[Image omitted: the model does not support images]")
+	}
+}
+
+func (a *sessionAgent) systemPromptTokens(tok tokenizer.Tokenizer) int64 {
+	tokens := tok.CountMessage(a.systemPrompt)
+	if a.systemPromptPrefix != "" {
+		tokens += tok.CountMessage(a.systemPromptPrefix)
+	}
+	return tokens
+}
+
+// toolTokens counts the tokens of the definition of a tool.
+func toolTokens(tok tokenizer.Tokenizer, info fantasy.ToolInfo) int64 {
+	schema, _ := json.Marshal(map[string]any{
+		"type":       "object",
+		"properties": info.Parameters,
+		"required":   info.Required,
+	})
+	return tok.CountMessage(info.Name, info.Description, string(schema))
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/prompt"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMCPTool is a tool served by the MCP server of the name.
+type fakeMCPTool struct {
+	fantasy.AgentTool
+	server string
+}
+
+func (t fakeMCPTool) MCP() string { return t.server }
+
+func TestContextUsage(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	type input struct {
+		Path string `json:"path" description:"The path of the file"`
+	}
+	run := func(context.Context, input, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.ToolResponse{}, nil
+	}
+	contextFile := prompt.ContextFile{Path: "AGENTS.md", Content: strings.Repeat("Run the tests before committing. ", 50)}
+	agent := testSessionAgent(env, &fakeModel{}, &fakeModel{}, "You are Crush.\n"+contextFile.Content,
+		fantasy.NewAgentTool("view", "View a file", run),
+		fakeMCPTool{fantasy.NewAgentTool("mcp_docs_search", "Search the docs", run), "docs"},
+		fakeMCPTool{fantasy.NewAgentTool("mcp_docs_fetch", "Fetch a page of the docs", run), "docs"},
+	)
+
+	session, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+	_, err = env.messages.Create(t.Context(), session.ID, message.CreateMessageParams{
+		Role: message.User,
+		Parts: []message.ContentPart{
+			message.TextContent{Text: "What does main.go do?"},
+			message.BinaryContent{Path: "/tmp/notes.txt", MIMEType: "text/plain", Data: []byte("some notes")},
+		},
+	})
+	require.NoError(t, err)
+
+	usage, err := agent.ContextUsage(t.Context(), session.ID, []prompt.ContextFile{contextFile}, []message.Attachment{
+		{FileName: "todo.md", MimeType: "text/markdown", Content: []byte("- write tests")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(200000), usage.ContextWindow)
+
+	categories := map[string]ContextCategory{}
+	for _, c := range usage.Categories {
+		categories[c.Name] = c
+	}
+	require.Len(t, categories, 6)
+	// The context files are not counted twice.
+	require.Less(t, categories[ContextSystemPrompt].Tokens, categories[ContextFiles].Tokens)
+	require.Equal(t, "AGENTS.md", categories[ContextFiles].Items[0].Name)
+	require.Equal(t, []string{"view"}, itemNames(categories[ContextTools]))
+	require.Equal(t, []string{"docs"}, itemNames(categories[ContextMCPTools]))
+	require.Equal(t, []string{"Messages"}, itemNames(categories[ContextHistory]))
+	require.ElementsMatch(t, []string{"notes.txt", "todo.md"}, itemNames(categories[ContextAttachments]))
+
+	var sum int64
+	for _, c := range usage.Categories {
+		sum += c.Tokens
+	}
+	require.Equal(t, sum, usage.Tokens())
+}
+
+func itemNames(c ContextCategory) []string {
+	names := make([]string, 0, len(c.Items))
+	for _, item := range c.Items {
+		names = append(names, item.Name)
+	}
+	return names
+}
@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
@@ -45,11 +46,17 @@ type App struct {
 	History     history.Service
 	Permissions permission.Service
 
-	AgentCoordinator agent.Coordinator
+	// coordinator is replaced when switching profiles, while other goroutines
+	// use it, see [App.AgentCoordinator].
+	coordinatorMu sync.RWMutex
+	coordinator   agent.Coordinator
 
 	LSPClients *csync.Map[string, *lsp.Client]
+	lspServers *csync.Map[string, *lspServer]
 
-	config *config.Config
+	// config is replaced when switching profiles, while other goroutines
+	// read it.
+	config atomic.Pointer[config.Config]
 
 	serviceEventsWG *sync.WaitGroup
 	eventsCtx       context.Context
@@ -79,16 +86,16 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		History:     files,
 		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
 		LSPClients:  csync.NewMap[string, *lsp.Client](),
+		lspServers:  csync.NewMap[string, *lspServer](),
 
 		globalCtx: ctx,
 
-		config: cfg,
-
 		events:          make(chan tea.Msg, 100),
 		serviceEventsWG: &sync.WaitGroup{},
 		tuiWG:           &sync.WaitGroup{},
 	}
 
+	app.config.Store(cfg)
 	app.setupEvents()
 
 	// Initialize LSP clients in the background.
@@ -118,7 +125,7 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 
 // Config returns the application configuration.
 func (app *App) Config() *config.Config {
-	return app.config
+	return app.config.Load()
 }
 
 // RunNonInteractive runs the application in non-interactive mode with the
@@ -190,7 +197,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 	done := make(chan response, 1)
 
 	go func(ctx context.Context, sessionID, prompt string) {
-		result, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt)
+		result, err := app.AgentCoordinator().Run(ctx, sess.ID, prompt)
 		if err != nil {
 			done <- response{
 				err: fmt.Errorf("failed to start agent processing stream: %w", err),
@@ -260,7 +267,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 }
 
 func (app *App) UpdateAgentModel(ctx context.Context) error {
-	return app.AgentCoordinator.UpdateModels(ctx)
+	return app.AgentCoordinator().UpdateModels(ctx)
 }
 
 func (app *App) setupEvents() {
@@ -315,14 +322,34 @@ func setupSubscriber[T any](
 }
 
 func (app *App) InitCoderAgent(ctx context.Context) error {
-	coderAgentCfg := app.config.Agents[config.AgentCoder]
+	coordinator, err := app.newCoordinator(ctx, app.Config())
+	if err != nil {
+		return err
+	}
+	app.coordinatorMu.Lock()
+	app.coordinator = coordinator
+	app.coordinatorMu.Unlock()
+	return nil
+}
+
+// AgentCoordinator returns the coordinator of the coder agent, or nil if it
+// isn't set up yet.
+func (app *App) AgentCoordinator() agent.Coordinator {
+	app.coordinatorMu.RLock()
+	defer app.coordinatorMu.RUnlock()
+	return app.coordinator
+}
+
+// newCoordinator creates the coordinator of the coder agent of the given
+// configuration.
+func (app *App) newCoordinator(ctx context.Context, cfg *config.Config) (agent.Coordinator, error) {
+	coderAgentCfg := cfg.Agents[config.AgentCoder]
 	if coderAgentCfg.ID == "" {
-		return fmt.Errorf("coder agent configuration is missing")
+		return nil, fmt.Errorf("coder agent configuration is missing")
 	}
-	var err error
-	app.AgentCoordinator, err = agent.NewCoordinator(
+	coordinator, err := agent.NewCoordinator(
 		ctx,
-		app.config,
+		cfg,
 		app.Sessions,
 		app.Messages,
 		app.Permissions,
@@ -331,9 +358,9 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 	)
 	if err != nil {
 		slog.Error("Failed to create coder agent", "err", err)
-		return err
+		return nil, err
 	}
-	return nil
+	return coordinator, nil
 }
 
 // Subscribe sends events to the TUI as tea.Msgs.
@@ -370,21 +397,15 @@ func (app *App) Subscribe(program *tea.Program) {
 
 // Shutdown performs a graceful shutdown of the application.
 func (app *App) Shutdown() {
-	if app.AgentCoordinator != nil {
-		app.AgentCoordinator.CancelAll()
+	if coordinator := app.AgentCoordinator(); coordinator != nil {
+		coordinator.CancelAll()
 	}
 
 	// Kill all background shells.
 	shell.GetBackgroundShellManager().KillAll()
 
 	// Shutdown all LSP clients.
-	for name, client := range app.LSPClients.Seq2() {
-		shutdownCtx, cancel := context.WithTimeout(app.globalCtx, 5*time.Second)
-		if err := client.Close(shutdownCtx); err != nil {
-			slog.Error("Failed to shutdown LSP client", "name", name, "error", err)
-		}
-		cancel()
-	}
+	app.shutdownLSPClients()
 
 	// Call call cleanup functions.
 	for _, cleanup := range app.cleanupFuncs {
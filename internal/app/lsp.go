@@ -2,45 +2,325 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/lsp"
 )
 
+// lspMaxRestarts is how many times in a row a crashed server is restarted
+// before giving up.
+const lspMaxRestarts = 5
+
+// The timings below are variables so tests can shorten them.
+var (
+	// lspRestartBaseDelay and lspRestartMaxDelay bound the backoff between
+	// restarts.
+	lspRestartBaseDelay = time.Second
+	lspRestartMaxDelay  = 30 * time.Second
+	// lspStableAfter is how long a server must run before a crash no longer
+	// counts towards lspMaxRestarts.
+	lspStableAfter = time.Minute
+	// lspIdleCheckInterval is how often servers are checked for idleness.
+	lspIdleCheckInterval = 30 * time.Second
+	// lspLazyStartTimeout is how long a file access waits for a lazily
+	// started server to become ready.
+	lspLazyStartTimeout = 30 * time.Second
+)
+
+// lspServer tracks the lifecycle of a configured LSP server.
+type lspServer struct {
+	name   string
+	config config.LSPConfig
+
+	mu       sync.Mutex
+	client   *lsp.Client
+	cancel   context.CancelFunc // set while the server is running or starting
+	ready    chan struct{}      // closed once the current start attempt is done
+	idle     bool               // start the server when a file it handles is used
+	restarts int
+	lastUsed time.Time
+}
+
 // initLSPClients initializes LSP clients.
 func (app *App) initLSPClients(ctx context.Context) {
-	for name, clientConfig := range app.config.LSP {
+	cfg := app.Config()
+	for name, clientConfig := range cfg.LSP {
 		if clientConfig.Disabled {
 			slog.Info("Skipping disabled LSP client", "name", name)
 			continue
 		}
-		go app.createAndStartLSPClient(ctx, name, clientConfig)
+
+		// Check if any root markers exist in the working directory (config now has defaults)
+		if !lsp.HasRootMarkers(cfg.WorkingDir(), clientConfig.RootMarkers) {
+			slog.Info("Skipping LSP client - no root markers found", "name", name, "rootMarkers", clientConfig.RootMarkers)
+			updateLSPState(name, lsp.StateDisabled, nil, nil, 0)
+			continue
+		}
+
+		server := &lspServer{
+			name:   name,
+			config: clientConfig,
+		}
+		app.lspServers.Set(name, server)
+
+		if clientConfig.Lazy {
+			slog.Info("Deferring LSP client start until a matching file is used", "name", name)
+			server.idle = true
+			updateLSPState(name, lsp.StateIdle, nil, nil, 0)
+			continue
+		}
+		app.startLSPServer(ctx, server)
 	}
+
+	lsp.RegisterFileAccessHandler(app.touchLSPServers)
+	go app.stopIdleLSPServers(ctx)
+
 	slog.Info("LSP clients initialization started in background")
 }
 
-// createAndStartLSPClient creates a new LSP client, initializes it, and starts its workspace watcher
-func (app *App) createAndStartLSPClient(ctx context.Context, name string, config config.LSPConfig) {
-	slog.Info("Creating LSP client", "name", name, "command", config.Command, "fileTypes", config.FileTypes, "args", config.Args)
+// RestartLSPClient stops the LSP server with the given name, if running, and
+// starts it again.
+func (app *App) RestartLSPClient(ctx context.Context, name string) error {
+	server, ok := app.lspServers.Get(name)
+	if !ok {
+		return fmt.Errorf("lsp server %s is not available", name)
+	}
+	app.stopLSPServer(ctx, server, lsp.StateStopped)
+	server.mu.Lock()
+	server.restarts = 0
+	server.mu.Unlock()
+	app.startLSPServer(app.globalCtx, server)
+	return nil
+}
+
+// StopLSPClient stops the LSP server with the given name. It won't be started
+// again until it's restarted explicitly.
+func (app *App) StopLSPClient(ctx context.Context, name string) error {
+	server, ok := app.lspServers.Get(name)
+	if !ok {
+		return fmt.Errorf("lsp server %s is not available", name)
+	}
+	app.stopLSPServer(ctx, server, lsp.StateStopped)
+	return nil
+}
+
+// shutdownLSPClients stops all LSP servers without restarting them.
+func (app *App) shutdownLSPClients() {
+	for server := range app.lspServers.Seq() {
+		shutdownCtx, cancel := context.WithTimeout(app.globalCtx, 5*time.Second)
+		app.stopLSPServer(shutdownCtx, server, lsp.StateStopped)
+		cancel()
+	}
+}
+
+// startLSPServer starts the given server in the background unless it's
+// already running, and returns a channel closed once it's ready or failed
+// to start.
+func (app *App) startLSPServer(ctx context.Context, server *lspServer) <-chan struct{} {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.cancel != nil {
+		return server.ready
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	server.cancel = cancel
+	server.ready = make(chan struct{})
+	server.idle = false
+	server.lastUsed = time.Now()
+	go app.runLSPServer(runCtx, server, server.ready)
+	return server.ready
+}
+
+// stopLSPServer stops the given server and reports it with the given state.
+func (app *App) stopLSPServer(ctx context.Context, server *lspServer, state lsp.ServerState) {
+	server.mu.Lock()
+	cancel, client := server.cancel, server.client
+	server.cancel = nil
+	server.client = nil
+	server.idle = state == lsp.StateIdle
+	server.mu.Unlock()
 
-	// Check if any root markers exist in the working directory (config now has defaults)
-	if !lsp.HasRootMarkers(app.config.WorkingDir(), config.RootMarkers) {
-		slog.Info("Skipping LSP client - no root markers found", "name", name, "rootMarkers", config.RootMarkers)
-		updateLSPState(name, lsp.StateDisabled, nil, nil, 0)
+	if cancel == nil {
 		return
 	}
+	cancel()
+	if client != nil {
+		app.LSPClients.Del(server.name)
+		if err := client.Close(ctx); err != nil {
+			slog.Error("Failed to shutdown LSP client", "name", server.name, "error", err)
+		}
+	}
+	slog.Info("LSP client stopped", "name", server.name, "state", state)
+	updateLSPState(server.name, state, nil, nil, 0)
+}
+
+// runLSPServer starts the server and keeps it running, restarting it with
+// an exponential backoff when it exits unexpectedly, until ctx is canceled.
+func (app *App) runLSPServer(ctx context.Context, server *lspServer, ready chan struct{}) {
+	signalReady := sync.OnceFunc(func() { close(ready) })
+	defer signalReady()
+
+	for {
+		client, err := app.createAndStartLSPClient(ctx, server.name, server.config)
+		if ctx.Err() != nil {
+			if client != nil {
+				client.Close(context.Background())
+			}
+			return
+		}
+
+		if err == nil {
+			startedAt := time.Now()
+			server.mu.Lock()
+			server.client = client
+			server.mu.Unlock()
+			app.LSPClients.Set(server.name, client)
+			signalReady()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-client.Done():
+			}
+
+			server.mu.Lock()
+			if server.client == client {
+				server.client = nil
+				app.LSPClients.Del(server.name)
+			}
+			if time.Since(startedAt) > lspStableAfter {
+				server.restarts = 0
+			}
+			server.mu.Unlock()
+			if ctx.Err() != nil {
+				return
+			}
+			closeCtx, cancel := context.WithTimeout(ctx, time.Second)
+			_ = client.Close(closeCtx)
+			cancel()
+			slog.Warn("LSP server exited unexpectedly", "name", server.name)
+			updateLSPState(server.name, lsp.StateError, errors.New("server exited unexpectedly"), nil, 0)
+		}
+		signalReady()
+
+		server.mu.Lock()
+		server.restarts++
+		attempt := server.restarts
+		server.mu.Unlock()
+		if attempt > lspMaxRestarts {
+			slog.Error("Giving up restarting LSP server", "name", server.name, "attempts", lspMaxRestarts)
+			server.mu.Lock()
+			if ctx.Err() == nil {
+				server.cancel()
+				server.cancel = nil
+			}
+			server.mu.Unlock()
+			return
+		}
+
+		delay := lspRestartDelay(attempt)
+		slog.Info("Restarting LSP server", "name", server.name, "attempt", attempt, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// lspRestartDelay returns the delay before the given restart attempt.
+func lspRestartDelay(attempt int) time.Duration {
+	delay := lspRestartBaseDelay
+	for range attempt - 1 {
+		delay *= 2
+		if delay >= lspRestartMaxDelay {
+			return lspRestartMaxDelay
+		}
+	}
+	return delay
+}
+
+// touchLSPServers marks the servers handling the given file as used, and
+// starts the idle ones, waiting for them to be ready.
+func (app *App) touchLSPServers(ctx context.Context, path string) {
+	var starting []<-chan struct{}
+	for server := range app.lspServers.Seq() {
+		if !lsp.HandlesFileType(server.config.FileTypes, path) {
+			continue
+		}
+		server.mu.Lock()
+		server.lastUsed = time.Now()
+		idle := server.idle
+		server.mu.Unlock()
+		if idle {
+			slog.Info("Starting LSP server on demand", "name", server.name, "file", path)
+			starting = append(starting, app.startLSPServer(app.globalCtx, server))
+		}
+	}
+
+	if len(starting) == 0 {
+		return
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, lspLazyStartTimeout)
+	defer cancel()
+	for _, ready := range starting {
+		select {
+		case <-waitCtx.Done():
+			return
+		case <-ready:
+		}
+	}
+}
+
+// stopIdleLSPServers periodically stops servers that haven't been used for
+// longer than their idle timeout.
+func (app *App) stopIdleLSPServers(ctx context.Context) {
+	ticker := time.NewTicker(lspIdleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for server := range app.lspServers.Seq() {
+			if server.config.IdleTimeout <= 0 {
+				continue
+			}
+			server.mu.Lock()
+			running := server.client != nil
+			unused := time.Since(server.lastUsed)
+			server.mu.Unlock()
+			if !running || unused < time.Duration(server.config.IdleTimeout)*time.Second {
+				continue
+			}
+			slog.Info("Stopping idle LSP server", "name", server.name, "unused", unused)
+			stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			app.stopLSPServer(stopCtx, server, lsp.StateIdle)
+			cancel()
+		}
+	}
+}
+
+// createAndStartLSPClient creates a new LSP client and initializes it.
+func (app *App) createAndStartLSPClient(ctx context.Context, name string, config config.LSPConfig) (*lsp.Client, error) {
+	slog.Info("Creating LSP client", "name", name, "command", config.Command, "fileTypes", config.FileTypes, "args", config.Args)
 
 	// Update state to starting
 	updateLSPState(name, lsp.StateStarting, nil, nil, 0)
 
 	// Create LSP client.
-	lspClient, err := lsp.New(ctx, name, config, app.config.Resolver())
+	cfg := app.Config()
+	lspClient, err := lsp.New(ctx, name, config, cfg.Resolver())
 	if err != nil {
 		slog.Error("Failed to create LSP client for", name, err)
 		updateLSPState(name, lsp.StateError, err, nil, 0)
-		return
+		return nil, err
 	}
 
 	// Set diagnostics callback
@@ -51,12 +331,12 @@ func (app *App) createAndStartLSPClient(ctx context.Context, name string, config
 	defer cancel()
 
 	// Initialize LSP client.
-	_, err = lspClient.Initialize(initCtx, app.config.WorkingDir())
+	_, err = lspClient.Initialize(initCtx, cfg.WorkingDir())
 	if err != nil {
 		slog.Error("Initialize failed", "name", name, "error", err)
 		updateLSPState(name, lsp.StateError, err, lspClient, 0)
 		lspClient.Close(ctx)
-		return
+		return nil, err
 	}
 
 	// Wait for the server to be ready.
@@ -66,7 +346,7 @@ func (app *App) createAndStartLSPClient(ctx context.Context, name string, config
 		// some functionality might still work.
 		lspClient.SetServerState(lsp.StateError)
 		updateLSPState(name, lsp.StateError, err, lspClient, 0)
-	} else {
+	} else if ctx.Err() == nil {
 		// Server reached a ready state scuccessfully.
 		slog.Info("LSP server is ready", "name", name)
 		lspClient.SetServerState(lsp.StateReady)
@@ -74,7 +354,5 @@ func (app *App) createAndStartLSPClient(ctx context.Context, name string, config
 	}
 
 	slog.Info("LSP client initialized", "name", name)
-
-	// Add to map with mutex protection before starting goroutine
-	app.LSPClients.Set(name, lspClient)
+	return lspClient, nil
 }
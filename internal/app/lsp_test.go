@@ -0,0 +1,268 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLSPServerEnv makes the test binary act as a language server when set
+// to either "ok" or "crash".
+const fakeLSPServerEnv = "CRUSH_TEST_LSP_SERVER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(fakeLSPServerEnv) {
+	case "":
+		os.Exit(m.Run())
+	case "crash":
+		os.Exit(1)
+	default:
+		serveFakeLSP()
+		os.Exit(0)
+	}
+}
+
+// serveFakeLSP answers just enough of the protocol for a client to start
+// and shut down, and publishes no diagnostics for opened and changed files
+// so clients don't wait for them.
+func serveFakeLSP() {
+	stream := jsonrpc2.NewBufferedStream(fakeLSPStdio{}, jsonrpc2.VSCodeObjectCodec{})
+	handler := jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "initialize":
+			return map[string]any{"capabilities": map[string]any{}}, nil
+		case "exit":
+			os.Exit(0)
+		case "textDocument/didOpen", "textDocument/didChange":
+			var params struct {
+				TextDocument struct {
+					URI string `json:"uri"`
+				} `json:"textDocument"`
+			}
+			if req.Params != nil {
+				_ = json.Unmarshal(*req.Params, &params)
+			}
+			_ = conn.Notify(ctx, "textDocument/publishDiagnostics", map[string]any{
+				"uri":         params.TextDocument.URI,
+				"diagnostics": []any{},
+			})
+		}
+		return json.RawMessage("null"), nil
+	})
+	conn := jsonrpc2.NewConn(context.Background(), stream, handler)
+	<-conn.DisconnectNotify()
+}
+
+type fakeLSPStdio struct{}
+
+func (fakeLSPStdio) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (fakeLSPStdio) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (fakeLSPStdio) Close() error                { return nil }
+
+// newLSPTestApp returns an app with a single "fake" server, backed by the
+// test binary running in the given mode, that handles Go files.
+func newLSPTestApp(t *testing.T, mode string) (*App, *lspServer) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	cfg, err := config.Load(t.TempDir(), t.TempDir(), "", false)
+	require.NoError(t, err)
+
+	executable, err := os.Executable()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	app := &App{
+		LSPClients: csync.NewMap[string, *lsp.Client](),
+		lspServers: csync.NewMap[string, *lspServer](),
+		globalCtx:  ctx,
+	}
+	app.config.Store(cfg)
+	server := &lspServer{
+		name: "fake",
+		config: config.LSPConfig{
+			Command:   executable,
+			Env:       map[string]string{fakeLSPServerEnv: mode},
+			FileTypes: []string{"go"},
+		},
+	}
+	app.lspServers.Set(server.name, server)
+	t.Cleanup(func() {
+		app.shutdownLSPClients()
+		cancel()
+	})
+	return app, server
+}
+
+// setLSPTiming shortens the given timing for the duration of the test.
+func setLSPTiming(t *testing.T, timing *time.Duration, d time.Duration) {
+	old := *timing
+	*timing = d
+	t.Cleanup(func() { *timing = old })
+}
+
+func TestLSPRestartDelay(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, time.Second, lspRestartDelay(1))
+	require.Equal(t, 2*time.Second, lspRestartDelay(2))
+	require.Equal(t, 8*time.Second, lspRestartDelay(4))
+	require.Equal(t, lspRestartMaxDelay, lspRestartDelay(6))
+	require.Equal(t, lspRestartMaxDelay, lspRestartDelay(50))
+}
+
+func TestRunLSPServerGivesUp(t *testing.T) {
+	setLSPTiming(t, &lspRestartBaseDelay, time.Millisecond)
+	setLSPTiming(t, &lspRestartMaxDelay, 4*time.Millisecond)
+	app, server := newLSPTestApp(t, "crash")
+
+	<-app.startLSPServer(app.globalCtx, server)
+
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return server.cancel == nil
+	}, 10*time.Second, 10*time.Millisecond, "server should stop being restarted")
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Equal(t, lspMaxRestarts+1, server.restarts)
+	require.Nil(t, server.client)
+	require.Zero(t, app.LSPClients.Len())
+}
+
+func TestTouchLSPServers(t *testing.T) {
+	app, server := newLSPTestApp(t, "ok")
+	server.idle = true
+
+	app.touchLSPServers(t.Context(), "README.md")
+	server.mu.Lock()
+	require.True(t, server.idle, "a file the server doesn't handle shouldn't start it")
+	require.Nil(t, server.cancel)
+	server.mu.Unlock()
+
+	app.touchLSPServers(t.Context(), "main.go")
+	server.mu.Lock()
+	require.False(t, server.idle)
+	require.NotNil(t, server.client)
+	require.WithinDuration(t, time.Now(), server.lastUsed, 10*time.Second)
+	server.mu.Unlock()
+	_, ok := app.LSPClients.Get(server.name)
+	require.True(t, ok)
+}
+
+func TestStopIdleLSPServers(t *testing.T) {
+	setLSPTiming(t, &lspIdleCheckInterval, 10*time.Millisecond)
+	app, server := newLSPTestApp(t, "ok")
+	server.config.IdleTimeout = 60
+
+	<-app.startLSPServer(app.globalCtx, server)
+	_, ok := app.LSPClients.Get(server.name)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.stopIdleLSPServers(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Recently used servers keep running.
+	time.Sleep(50 * time.Millisecond)
+	server.mu.Lock()
+	require.NotNil(t, server.client)
+	server.lastUsed = time.Now().Add(-time.Minute - time.Second)
+	server.mu.Unlock()
+
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return server.idle && server.client == nil
+	}, 5*time.Second, 10*time.Millisecond, "server should be stopped once idle")
+	_, ok = app.LSPClients.Get(server.name)
+	require.False(t, ok)
+}
+
+func TestEditUsesLSPServers(t *testing.T) {
+	setLSPTiming(t, &lspIdleCheckInterval, 10*time.Millisecond)
+	app, server := newLSPTestApp(t, "ok")
+	server.idle = true
+	server.config.IdleTimeout = 1
+	lsp.RegisterFileAccessHandler(app.touchLSPServers)
+	t.Cleanup(func() { lsp.RegisterFileAccessHandler(nil) })
+
+	workingDir := app.Config().WorkingDir()
+	path := filepath.Join(workingDir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sess, err := session.NewService(q).Create(t.Context(), "lsp")
+	require.NoError(t, err)
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, sess.ID)
+	ctx = context.WithValue(ctx, tools.MessageIDContextKey, "message")
+
+	permissions := permission.NewPermissionService(workingDir, true, nil)
+	view := tools.NewViewTool(app.LSPClients, permissions, workingDir)
+	edit := tools.NewEditTool(app.LSPClients, permissions, history.NewService(q, conn), workingDir)
+	run := func(tool fantasy.AgentTool, params any) {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: tool.Info().Name, Input: string(input)})
+		require.NoError(t, err)
+		require.False(t, resp.IsError, resp.Content)
+	}
+
+	run(view, tools.ViewParams{FilePath: path})
+	server.mu.Lock()
+	require.False(t, server.idle, "reading a file should start its lazy server")
+	require.NotNil(t, server.client)
+	server.mu.Unlock()
+
+	idleCtx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.stopIdleLSPServers(idleCtx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Edits keep the server in use past its idle timeout of a second.
+	for i := range 5 {
+		run(edit, tools.EditParams{
+			FilePath:  path,
+			OldString: "package main",
+			NewString: fmt.Sprintf("package main // %d", i),
+		})
+		time.Sleep(300 * time.Millisecond)
+	}
+	server.mu.Lock()
+	require.False(t, server.idle, "a server in use shouldn't be stopped")
+	require.NotNil(t, server.client)
+	server.mu.Unlock()
+}
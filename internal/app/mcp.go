@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+)
+
+// ReconnectMCP reconnects the given MCP, re-enabling it if it was disabled,
+// and refreshes the agent's tools.
+func (app *App) ReconnectMCP(ctx context.Context, name string) error {
+	if err := mcp.Reconnect(ctx, name); err != nil {
+		return err
+	}
+	return app.RefreshAgentTools(ctx)
+}
+
+// DisableMCP disconnects the given MCP until it's reconnected, and removes its
+// tools from the agent.
+func (app *App) DisableMCP(ctx context.Context, name string) error {
+	if err := mcp.Disable(name); err != nil {
+		return err
+	}
+	return app.RefreshAgentTools(ctx)
+}
+
+// RefreshAgentTools rebuilds the agent's tools, e.g. after an MCP changed.
+func (app *App) RefreshAgentTools(ctx context.Context) error {
+	if app.AgentCoordinator() == nil {
+		return nil
+	}
+	return app.AgentCoordinator().RefreshTools(ctx)
+}
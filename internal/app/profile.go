@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// SwitchProfile reloads the configuration with the named profile, or without
+// any when empty, and sets the permissions, the MCP servers and the coder
+// agent, with its providers and models, up again. Nothing changes if the
+// agent can't be set up with the new configuration.
+func (app *App) SwitchProfile(ctx context.Context, profile string) error {
+	if coordinator := app.AgentCoordinator(); coordinator != nil && coordinator.IsBusy() {
+		return errors.New("agent is busy, please wait")
+	}
+	cfg, err := app.Config().LoadProfile(profile)
+	if err != nil {
+		return err
+	}
+	coordinator, err := app.newCoordinator(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// A prompt may have started while the new agent was being set up.
+	app.coordinatorMu.Lock()
+	old := app.coordinator
+	if old != nil && old.IsBusy() {
+		app.coordinatorMu.Unlock()
+		coordinator.CancelAll()
+		return errors.New("agent is busy, please wait")
+	}
+	app.config.Store(cfg)
+	config.Set(cfg)
+	if cfg.Permissions != nil {
+		app.Permissions.SetAllowedTools(cfg.Permissions.AllowedTools)
+	} else {
+		app.Permissions.SetAllowedTools(nil)
+	}
+	app.coordinator = coordinator
+	app.coordinatorMu.Unlock()
+	if old != nil {
+		// Stop anything that got hold of the old agent before the switch.
+		old.CancelAll()
+	}
+	slog.Info("Switched configuration profile", "profile", profile)
+
+	go func() {
+		mcp.Restart(app.globalCtx, app.Permissions, cfg)
+		// Give the agent the tools of the MCPs now that they're connected.
+		if err := coordinator.RefreshTools(app.globalCtx); err != nil {
+			slog.Error("Failed to refresh the agent tools", "error", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+const profileTestConfig = `{
+	"providers": {
+		"local": {
+			"type": "openai-compat",
+			"base_url": "http://localhost:1/v1",
+			"models": [{"id": "small", "context_window": 8192, "default_max_tokens": 1024}]
+		}
+	},
+	"models": {
+		"large": {"provider": "local", "model": "small"},
+		"small": {"provider": "local", "model": "small"}
+	},
+	"permissions": {"allowed_tools": ["view"]},
+	"profiles": {
+		"big": {
+			"providers": {
+				"remote": {
+					"type": "openai-compat",
+					"base_url": "http://localhost:2/v1",
+					"models": [{"id": "big", "context_window": 131072, "default_max_tokens": 8192}]
+				}
+			},
+			"models": {"large": {"provider": "remote", "model": "big"}},
+			"permissions": {"allowed_tools": ["ls"]}
+		},
+		"none": {
+			"providers": {"local": {"disable": true}}
+		}
+	}
+}`
+
+func newProfileTestApp(t *testing.T) *App {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	// Keys from the environment would configure known providers.
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "crush.json"), []byte(profileTestConfig), 0o644))
+
+	cfg, err := config.Init(dir, filepath.Join(dir, ".crush"), "", false)
+	require.NoError(t, err)
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+
+	app := &App{
+		Sessions:    session.NewService(q),
+		Messages:    message.NewService(q),
+		History:     history.NewService(q, conn),
+		Permissions: permission.NewPermissionService(dir, false, cfg.Permissions.AllowedTools),
+		LSPClients:  csync.NewMap[string, *lsp.Client](),
+		lspServers:  csync.NewMap[string, *lspServer](),
+		globalCtx:   t.Context(),
+	}
+	app.config.Store(cfg)
+	require.NoError(t, app.InitCoderAgent(t.Context()))
+	return app
+}
+
+func TestSwitchProfile(t *testing.T) {
+	app := newProfileTestApp(t)
+	cfg := app.Config()
+	coordinator := app.AgentCoordinator()
+
+	require.NoError(t, app.SwitchProfile(t.Context(), "big"))
+	require.NotSame(t, cfg, app.Config())
+	require.Same(t, app.Config(), config.Get())
+	require.NotSame(t, coordinator, app.AgentCoordinator())
+	require.Equal(t, "big", app.AgentCoordinator().Model().ModelCfg.Model)
+	require.Equal(t, "small", cfg.Models[config.SelectedModelTypeLarge].Model, "the previous configuration shouldn't change")
+	require.Equal(t, []string{"view", "ls"}, app.Config().Permissions.AllowedTools)
+
+	require.NoError(t, app.SwitchProfile(t.Context(), ""))
+	require.Equal(t, "small", app.AgentCoordinator().Model().ModelCfg.Model)
+}
+
+func TestSwitchProfileFails(t *testing.T) {
+	app := newProfileTestApp(t)
+	cfg := app.Config()
+	coordinator := app.AgentCoordinator()
+
+	for _, profile := range []string{"none", "personal"} {
+		require.Error(t, app.SwitchProfile(t.Context(), profile))
+		require.Same(t, cfg, app.Config())
+		require.Same(t, cfg, config.Get())
+		require.Same(t, coordinator, app.AgentCoordinator())
+	}
+
+	// The cassette is kept when switching, so the new agent can't be built.
+	cfg.Options.Cassette = &config.Cassette{Mode: "replay"}
+	require.EqualError(t, app.SwitchProfile(t.Context(), "big"), "cassette path not set")
+	require.Same(t, cfg, app.Config())
+	require.Same(t, cfg, config.Get())
+	require.Same(t, coordinator, app.AgentCoordinator())
+	require.Equal(t, []string{"view"}, cfg.Permissions.AllowedTools)
+}
+
+// stubCoordinator is an agent coordinator that can be made busy.
+type stubCoordinator struct {
+	agent.Coordinator
+	busy      bool
+	cancelled atomic.Bool
+}
+
+func (c *stubCoordinator) IsBusy() bool { return c.busy }
+
+func (c *stubCoordinator) CancelAll() { c.cancelled.Store(true) }
+
+func TestSwitchProfileStopsOldAgent(t *testing.T) {
+	app := newProfileTestApp(t)
+	cfg := app.Config()
+
+	busy := &stubCoordinator{busy: true}
+	app.coordinator = busy
+	require.EqualError(t, app.SwitchProfile(t.Context(), "big"), "agent is busy, please wait")
+	require.Same(t, cfg, app.Config())
+	require.Same(t, busy, app.AgentCoordinator())
+	require.False(t, busy.cancelled.Load())
+
+	old := &stubCoordinator{}
+	app.coordinator = old
+	require.NoError(t, app.SwitchProfile(t.Context(), "big"))
+	require.NotSame(t, old, app.AgentCoordinator())
+	require.True(t, old.cancelled.Load(), "the old agent should be stopped")
+}
+
+func TestSwitchProfileConcurrentReads(t *testing.T) {
+	app := newProfileTestApp(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			_ = app.AgentCoordinator().IsBusy()
+			_ = app.Config().Models
+		}
+	}()
+	require.NoError(t, app.SwitchProfile(t.Context(), "big"))
+	require.NoError(t, app.SwitchProfile(t.Context(), ""))
+	cancel()
+	<-done
+}
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/secret"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect, validate and edit the configuration",
+	Long: `Crush merges its configuration from the global config file, the data
+config file it writes its own settings to, and every crush.json and
+.crush.json from the filesystem root down to the working directory, later
+files taking precedence. These commands show where each setting comes from,
+validate the files against the schema, and read and write settings.`,
+	Example: `
+# List the config files, in the order they are merged
+crush config show
+
+# Show every setting and the file it comes from
+crush config show --effective
+
+# Check the config files against the schema
+crush config validate
+
+# Read a setting
+crush config get options.tui.compact_mode
+
+# Write a setting to the project config file
+crush config set options.tui.compact_mode true --scope project
+  `,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the config files or the effective configuration",
+	Long: `List the config files Crush reads, in the order they are merged. With
+--effective, show every setting of the merged configuration and the file it
+comes from instead. API keys, headers, environment variables and other
+secrets are redacted.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		files := config.ConfigFiles(cwd)
+
+		if effective, _ := cmd.Flags().GetBool("effective"); !effective {
+			rows := make([][]string, 0, len(files))
+			for _, f := range files {
+				rows = append(rows, []string{home.Short(f), configFileStatus(f)})
+			}
+			printConfigTable(cmd, []string{"File", "Status"}, rows)
+			return nil
+		}
+
+		settings, err := config.EffectiveSettings(files)
+		if err != nil {
+			return err
+		}
+		if len(settings) == 0 {
+			cmd.Println("No settings found")
+			return nil
+		}
+		rows := make([][]string, 0, len(settings))
+		for _, s := range settings {
+			sources := make([]string, 0, len(s.Sources))
+			for _, src := range s.Sources {
+				sources = append(sources, home.Short(src))
+			}
+			rows = append(rows, []string{s.Key, redactConfigValue(s.Key, s.Value), strings.Join(sources, ", ")})
+		}
+		printConfigTable(cmd, []string{"Key", "Value", "Source"}, rows)
+		return nil
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "Validate config files against the schema",
+	Long: `Validate the given config files, or every config file Crush reads,
+against the configuration schema. Problems are reported with the file, line
+and column and the JSON pointer of the invalid value.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files := args
+		if len(files) == 0 {
+			cwd, err := ResolveCwd(cmd)
+			if err != nil {
+				return err
+			}
+			for _, f := range config.ConfigFiles(cwd) {
+				if _, err := os.Stat(f); err == nil {
+					files = append(files, f)
+				}
+			}
+		}
+		if len(files) == 0 {
+			cmd.Println("No config files found")
+			return nil
+		}
+
+		schema, err := config.JSONSchema()
+		if err != nil {
+			return err
+		}
+		var problems, invalid int
+		for _, f := range files {
+			errs, err := config.ValidateFile(f, schema)
+			if err != nil {
+				return err
+			}
+			if len(errs) == 0 {
+				cmd.Printf("%s: ok\n", home.Short(f))
+				continue
+			}
+			invalid++
+			problems += len(errs)
+			for _, e := range errs {
+				e.Path = home.Short(e.Path)
+				cmd.Println(e.Error())
+			}
+		}
+		if problems > 0 {
+			return fmt.Errorf("found %d problems in %d of %d config files", problems, invalid, len(files))
+		}
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting",
+	Long: `Print the value of a setting of the merged configuration, or of the
+config file of a scope with --scope. Keys are dot-separated paths, such as
+options.tui.compact_mode; escape dots in names with a backslash.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		files := config.ConfigFiles(cwd)
+		if name, _ := cmd.Flags().GetString("scope"); name != "" {
+			scope, err := config.ParseScope(name)
+			if err != nil {
+				return err
+			}
+			files = []string{scope.Path(cwd)}
+		}
+
+		value, ok, err := config.GetField(files, args[0])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+		cmd.Println(config.FormatValue(value))
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Write a setting",
+	Long: `Write a setting to the config file of a scope: global, data (the
+default, where Crush writes its own settings) or project. Values are parsed
+as JSON, so true, 42 and {"a": 1} are written as a boolean, a number and an
+object, and anything else is written as a string.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configScopePath(cmd)
+		if err != nil {
+			return err
+		}
+		if err := config.SetFileField(path, args[0], parseConfigValue(args[1])); err != nil {
+			return err
+		}
+		cmd.Printf("Set %s in %s\n", args[0], home.Short(path))
+		warnInvalidConfig(cmd, path)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting",
+	Long:  `Remove a setting from the config file of a scope: global, data (the default) or project.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configScopePath(cmd)
+		if err != nil {
+			return err
+		}
+		if err := config.RemoveFileField(path, args[0]); err != nil {
+			return err
+		}
+		cmd.Printf("Removed %s from %s\n", args[0], home.Short(path))
+		return nil
+	},
+}
+
+func init() {
+	configShowCmd.Flags().Bool("effective", false, "Show every setting of the merged configuration and where it comes from")
+	configGetCmd.Flags().String("scope", "", "Read from the config file of a scope instead of the merged configuration: global, data or project")
+	configSetCmd.Flags().String("scope", string(config.ScopeData), "Config file to write to: global, data or project")
+	configUnsetCmd.Flags().String("scope", string(config.ScopeData), "Config file to remove the setting from: global, data or project")
+	configCmd.AddCommand(configShowCmd, configValidateCmd, configGetCmd, configSetCmd, configUnsetCmd)
+}
+
+// configScopePath returns the config file of the scope given with --scope.
+func configScopePath(cmd *cobra.Command) (string, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return "", err
+	}
+	name, _ := cmd.Flags().GetString("scope")
+	scope, err := config.ParseScope(name)
+	if err != nil {
+		return "", err
+	}
+	return scope.Path(cwd), nil
+}
+
+// parseConfigValue returns the value as JSON when it is valid JSON, or as a
+// string otherwise.
+func parseConfigValue(value string) any {
+	if json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	return value
+}
+
+// redactConfigValue hides secrets, like API keys, headers and environment
+// variables, unless they are read from the environment or the secret store.
+func redactConfigValue(key string, value json.RawMessage) string {
+	s := config.FormatValue(value)
+	if s == "" || strings.HasPrefix(s, "$") || strings.HasPrefix(s, secret.Prefix) {
+		return s
+	}
+	names := configKeyNames(key)
+	if len(names) > 1 && slices.Contains([]string{"headers", "extra_headers", "env"}, names[len(names)-2]) {
+		return "********"
+	}
+	if isSecretName(names[len(names)-1]) {
+		return "********"
+	}
+	return s
+}
+
+// configKeyNames splits a setting key, in which dots in names are escaped,
+// into the names it's made of.
+func configKeyNames(key string) []string {
+	var names []string
+	var name strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key):
+			i++
+			name.WriteByte(key[i])
+		case key[i] == '.':
+			names = append(names, name.String())
+			name.Reset()
+		default:
+			name.WriteByte(key[i])
+		}
+	}
+	return append(names, name.String())
+}
+
+// isSecretName reports whether a setting with the given name holds a secret.
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"api_key", "secret", "token", "password"} {
+		if name == s || strings.HasSuffix(name, "_"+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// warnInvalidConfig reports the problems of the config file, after it was
+// written.
+func warnInvalidConfig(cmd *cobra.Command, path string) {
+	schema, err := config.JSONSchema()
+	if err != nil {
+		return
+	}
+	errs, err := config.ValidateFile(path, schema)
+	if err != nil {
+		return
+	}
+	for _, e := range errs {
+		e.Path = home.Short(e.Path)
+		cmd.PrintErrf("warning: %s\n", e.Error())
+	}
+}
+
+func configFileStatus(path string) string {
+	if _, err := os.Stat(path); err != nil {
+		return "missing"
+	}
+	return "found"
+}
+
+func printConfigTable(cmd *cobra.Command, headers []string, rows [][]string) {
+	if term.IsTerminal(os.Stdout.Fd()) {
+		// We're in a TTY: make it fancy.
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				return lipgloss.NewStyle().Padding(0, 2)
+			}).
+			Headers(headers...).
+			Rows(rows...)
+		lipgloss.Println(t)
+		return
+	}
+	// Not a TTY.
+	for _, row := range rows {
+		cmd.Println(strings.Join(row, "\t"))
+	}
+}
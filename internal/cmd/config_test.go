@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactConfigValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{key: "providers.openai.api_key", value: `"sk-123"`, want: "********"},
+		{key: "providers.openai.api_key", value: `"$OPENAI_API_KEY"`, want: "$OPENAI_API_KEY"},
+		{key: "providers.openai.api_key", value: `"secret:openai"`, want: "secret:openai"},
+		{key: "providers.openai.api_key", value: `""`, want: ""},
+		{key: "providers.openai.extra_headers.X-Api-Key", value: `"123"`, want: "********"},
+		{key: "providers.local.env.HF_TOKEN", value: `"hf-123"`, want: "********"},
+		{key: "mcp.github.headers.Authorization", value: `"Bearer 123"`, want: "********"},
+		{key: "mcp.github.headers.Authorization", value: `"$GITHUB_AUTH"`, want: "$GITHUB_AUTH"},
+		{key: "mcp.github.env.GITHUB_PERSONAL_ACCESS_TOKEN", value: `"ghp-123"`, want: "********"},
+		{key: "mcp.my\\.headers.url", value: `"https://example.com"`, want: "https://example.com"},
+		{key: "mcp.linear.oauth.client_secret", value: `"abc"`, want: "********"},
+		{key: "mcp.linear.oauth.client_id", value: `"abc"`, want: "abc"},
+		{key: "profiles.work.mcp.db.token", value: `"abc"`, want: "********"},
+		{key: "profiles.work.lsp.db.options.access_token", value: `"abc"`, want: "********"},
+		{key: "options.proxy.password", value: `"abc"`, want: "********"},
+		{key: "providers.local.models.0.default_max_tokens", value: `4096`, want: "4096"},
+		{key: "options.tui.compact_mode", value: `true`, want: "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, redactConfigValue(tt.key, json.RawMessage(tt.value)))
+		})
+	}
+}
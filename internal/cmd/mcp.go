@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Manage MCP servers",
+	Long:  `List, add, remove and test the MCP servers Crush connects to.`,
+	Example: `
+# List the configured MCP servers
+crush mcp list
+
+# Add a stdio MCP server
+crush mcp add filesystem -- npx -y @modelcontextprotocol/server-filesystem /tmp
+
+# Add an HTTP MCP server
+crush mcp add github --type http --url https://api.githubcopilot.com/mcp/
+
+# Check that a server connects and what it provides
+crush mcp test github
+
+# Remove an MCP server
+crush mcp remove github
+  `,
+}
+
+var mcpListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured MCP servers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadMCPConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		mcps := cfg.MCP.Sorted()
+		if term.IsTerminal(os.Stdout.Fd()) {
+			// We're in a TTY: make it fancy.
+			if len(mcps) == 0 {
+				cmd.Println("No MCP servers configured")
+				return nil
+			}
+			t := table.New().
+				Border(lipgloss.RoundedBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return lipgloss.NewStyle().Padding(0, 2)
+				}).
+				Headers("Name", "Type", "Command/URL", "Status")
+			for _, m := range mcps {
+				t = t.Row(m.Name, string(m.MCP.Type), mcpTarget(m.MCP), mcpStatus(m.MCP))
+			}
+			lipgloss.Println(t)
+			return nil
+		}
+		// Not a TTY.
+		for _, m := range mcps {
+			cmd.Printf("%s\t%s\t%s\t%s\n", m.Name, m.MCP.Type, mcpTarget(m.MCP), mcpStatus(m.MCP))
+		}
+		return nil
+	},
+}
+
+var mcpAddCmd = &cobra.Command{
+	Use:   "add <name> [-- <command> [args...]]",
+	Short: "Add an MCP server",
+	Long: `Add an MCP server to the global configuration. Stdio servers take the
+command to run after --, HTTP and SSE servers need a --url.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		m, err := mcpConfigFromFlags(cmd, args[1:])
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadMCPConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.MCP[name]; ok {
+			return fmt.Errorf("MCP %q already exists, remove it first", name)
+		}
+		if err := cfg.SetConfigField(mcpConfigKey(name), m); err != nil {
+			return err
+		}
+		cmd.Printf("Added MCP %q to %s\n", name, cfg.DataConfigPath())
+		return nil
+	},
+}
+
+var mcpRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove an MCP server",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		cfg, err := loadMCPConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.MCP[name]; !ok {
+			return fmt.Errorf("MCP %q not found", name)
+		}
+		if err := cfg.RemoveConfigField(mcpConfigKey(name)); err != nil {
+			return err
+		}
+
+		// The server may come from a project or user config file, which we
+		// don't touch.
+		cfg, err = loadMCPConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.MCP[name]; ok {
+			return fmt.Errorf("MCP %q is defined in another config file, remove it from there", name)
+		}
+		cmd.Printf("Removed MCP %q from %s\n", name, cfg.DataConfigPath())
+		return nil
+	},
+}
+
+var mcpTestCmd = &cobra.Command{
+	Use:   "test [name]",
+	Short: "Connect to MCP servers and report what they provide",
+	Long: `Connect to the given MCP server, or to all enabled ones, and report the
+number of tools, prompts and resources each one provides.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadMCPConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		var mcps []config.MCP
+		for _, m := range cfg.MCP.Sorted() {
+			if len(args) > 0 && m.Name != args[0] {
+				continue
+			}
+			if len(args) == 0 && m.MCP.Disabled {
+				continue
+			}
+			mcps = append(mcps, m)
+		}
+		if len(args) > 0 && len(mcps) == 0 {
+			return fmt.Errorf("MCP %q not found", args[0])
+		}
+		if len(mcps) == 0 {
+			cmd.Println("No MCP servers configured")
+			return nil
+		}
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+		events := mcp.SubscribeEvents(ctx)
+		go func() {
+			for event := range events {
+				if event.Payload.Type == mcp.EventAuthorizationRequired {
+					cmd.PrintErrf("Authorize MCP %q in your browser: %s\n", event.Payload.Name, event.Payload.URI)
+				}
+			}
+		}()
+
+		var failed int
+		for _, m := range mcps {
+			counts, err := mcp.Test(ctx, m.Name, m.MCP, cfg.Resolver())
+			if err != nil {
+				failed++
+				cmd.Printf("%s: error: %v\n", m.Name, err)
+				continue
+			}
+			cmd.Printf("%s: ok, %d tools, %d prompts, %d resources\n", m.Name, counts.Tools, counts.Prompts, counts.Resources)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d MCP servers failed", failed, len(mcps))
+		}
+		return nil
+	},
+}
+
+func init() {
+	addMCPFlags(mcpAddCmd)
+	mcpCmd.AddCommand(mcpListCmd, mcpAddCmd, mcpRemoveCmd, mcpTestCmd)
+}
+
+func addMCPFlags(cmd *cobra.Command) {
+	cmd.Flags().String("type", "", "Type of the MCP server: stdio, http or sse (default: stdio, or http with --url)")
+	cmd.Flags().String("url", "", "URL of HTTP or SSE MCP servers")
+	cmd.Flags().StringArray("header", nil, "HTTP header to send, as KEY=VALUE (can be repeated)")
+	cmd.Flags().StringArray("env", nil, "Environment variable to set, as KEY=VALUE (can be repeated)")
+	cmd.Flags().Int("timeout", 0, "Connection timeout in seconds")
+	cmd.Flags().Bool("disabled", false, "Add the server disabled")
+}
+
+func loadMCPConfig(cmd *cobra.Command) (*config.Config, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	cfg, err := config.Load(cwd, dataDir, ResolveProfile(cmd), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	return cfg, nil
+}
+
+func mcpConfigFromFlags(cmd *cobra.Command, command []string) (config.MCPConfig, error) {
+	typ, _ := cmd.Flags().GetString("type")
+	url, _ := cmd.Flags().GetString("url")
+	headers, _ := cmd.Flags().GetStringArray("header")
+	env, _ := cmd.Flags().GetStringArray("env")
+	timeout, _ := cmd.Flags().GetInt("timeout")
+	disabled, _ := cmd.Flags().GetBool("disabled")
+
+	m := config.MCPConfig{
+		Type:     config.MCPType(typ),
+		URL:      url,
+		Timeout:  timeout,
+		Disabled: disabled,
+	}
+	if m.Type == "" {
+		m.Type = config.MCPStdio
+		if url != "" {
+			m.Type = config.MCPHttp
+		}
+	}
+	if len(command) > 0 {
+		m.Command = command[0]
+		m.Args = command[1:]
+	}
+
+	var err error
+	if m.Headers, err = parseKeyValues(headers); err != nil {
+		return m, fmt.Errorf("invalid header: %w", err)
+	}
+	if m.Env, err = parseKeyValues(env); err != nil {
+		return m, fmt.Errorf("invalid env: %w", err)
+	}
+
+	switch m.Type {
+	case config.MCPStdio:
+		if m.Command == "" {
+			return m, fmt.Errorf("stdio MCP servers need a command, pass it after --")
+		}
+		if m.URL != "" || len(m.Headers) > 0 {
+			return m, fmt.Errorf("--url and --header only apply to http and sse MCP servers")
+		}
+	case config.MCPHttp, config.MCPSSE:
+		if m.URL == "" {
+			return m, fmt.Errorf("%s MCP servers need a --url", m.Type)
+		}
+		if m.Command != "" || len(m.Env) > 0 {
+			return m, fmt.Errorf("a command and --env only apply to stdio MCP servers")
+		}
+	default:
+		return m, fmt.Errorf("unsupported MCP type: %s", m.Type)
+	}
+	return m, nil
+}
+
+func parseKeyValues(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%q is not KEY=VALUE", v)
+		}
+		result[strings.TrimSpace(key)] = value
+	}
+	return result, nil
+}
+
+// mcpConfigKey returns the path of the given MCP in the config file, escaping
+// the characters sjson treats specially.
+func mcpConfigKey(name string) string {
+	return "mcp." + gjson.Escape(name)
+}
+
+func mcpTarget(m config.MCPConfig) string {
+	if m.Type == config.MCPHttp || m.Type == config.MCPSSE {
+		return m.URL
+	}
+	return strings.Join(append([]string{m.Command}, m.Args...), " ")
+}
+
+func mcpStatus(m config.MCPConfig) string {
+	if m.Disabled {
+		return "disabled"
+	}
+	return "enabled"
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPConfigFromFlags(t *testing.T) {
+	t.Parallel()
+
+	parse := func(t *testing.T, args []string, command ...string) (config.MCPConfig, error) {
+		t.Helper()
+		cmd := &cobra.Command{}
+		addMCPFlags(cmd)
+		require.NoError(t, cmd.ParseFlags(args))
+		return mcpConfigFromFlags(cmd, command)
+	}
+
+	t.Run("stdio", func(t *testing.T) {
+		t.Parallel()
+		m, err := parse(t, []string{"--env", "TOKEN=a=b"}, "npx", "-y", "server")
+		require.NoError(t, err)
+		require.Equal(t, config.MCPConfig{
+			Type:    config.MCPStdio,
+			Command: "npx",
+			Args:    []string{"-y", "server"},
+			Env:     map[string]string{"TOKEN": "a=b"},
+		}, m)
+	})
+
+	t.Run("http from url", func(t *testing.T) {
+		t.Parallel()
+		m, err := parse(t, []string{"--url", "https://example.com/mcp", "--header", "X-Key=1", "--timeout", "30"})
+		require.NoError(t, err)
+		require.Equal(t, config.MCPConfig{
+			Type:    config.MCPHttp,
+			URL:     "https://example.com/mcp",
+			Headers: map[string]string{"X-Key": "1"},
+			Timeout: 30,
+		}, m)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := parse(t, nil)
+		require.ErrorContains(t, err, "need a command")
+		_, err = parse(t, []string{"--type", "sse"})
+		require.ErrorContains(t, err, "need a --url")
+		_, err = parse(t, []string{"--header", "nope"}, "server")
+		require.ErrorContains(t, err, "invalid header")
+		_, err = parse(t, []string{"--type", "ws"}, "server")
+		require.ErrorContains(t, err, "unsupported MCP type")
+	})
+}
+
+func TestMCPConfigKey(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "mcp.github", mcpConfigKey("github"))
+	require.Equal(t, `mcp.my\.server`, mcpConfigKey("my.server"))
+
+	// Names with special characters are written and removed as they are.
+	path := filepath.Join(t.TempDir(), "crush.json")
+	for _, name := range []string{"my.server", "a|b", "my.server|v2", "#@*?"} {
+		require.NoError(t, config.SetFileField(path, mcpConfigKey(name), config.MCPConfig{Command: "server"}))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var file struct {
+			MCP map[string]config.MCPConfig `json:"mcp"`
+		}
+		require.NoError(t, json.Unmarshal(data, &file))
+		require.Equal(t, map[string]config.MCPConfig{name: {Command: "server"}}, file.MCP)
+
+		require.NoError(t, config.RemoveFileField(path, mcpConfigKey(name)))
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"mcp": {}}`, string(data))
+	}
+}
@@ -34,15 +34,19 @@ func init() {
 	rootCmd.PersistentFlags().StringP("cwd", "c", "", "Current working directory")
 	rootCmd.PersistentFlags().StringP("data-dir", "D", "", "Custom crush data directory")
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
+	rootCmd.PersistentFlags().StringP("profile", "p", "", "Configuration profile to use, defaults to $CRUSH_PROFILE")
 	rootCmd.Flags().BoolP("help", "h", false, "Help")
 	rootCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
 
 	rootCmd.AddCommand(
 		runCmd,
+		configCmd,
 		dirsCmd,
+		mcpCmd,
 		updateProvidersCmd,
 		logsCmd,
 		schemaCmd,
+		secretCmd,
 	)
 }
 
@@ -65,6 +69,9 @@ crush -d -c /path/to/project
 # Run with custom data directory
 crush -D /path/to/custom/.crush
 
+# Run with the settings of the work profile
+crush --profile work
+
 # Print version
 crush -v
 
@@ -83,6 +90,10 @@ crush -y
 
 		event.AppInitialized()
 
+		if err := tui.LoadKeybindings(app.Config()); err != nil {
+			return err
+		}
+
 		// Set up the TUI.
 		var env uv.Environ = os.Environ()
 		ui := tui.New(app)
@@ -172,7 +183,7 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 		return nil, err
 	}
 
-	cfg, err := config.Init(cwd, dataDir, debug)
+	cfg, err := config.Init(cwd, dataDir, ResolveProfile(cmd), debug)
 	if err != nil {
 		return nil, err
 	}
@@ -182,6 +193,10 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 	}
 	cfg.Permissions.SkipRequests = yolo
 
+	if err := setCassette(cmd, cfg); err != nil {
+		return nil, err
+	}
+
 	if err := createDotCrushDir(cfg.Options.DataDirectory); err != nil {
 		return nil, err
 	}
@@ -205,6 +220,23 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 	return appInstance, nil
 }
 
+// setCassette records or replays the provider exchanges to or from the
+// cassette given with the --record or --replay flags.
+func setCassette(cmd *cobra.Command, cfg *config.Config) error {
+	for _, mode := range []string{"record", "replay"} {
+		path, _ := cmd.Flags().GetString(mode)
+		if path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		cfg.Options.Cassette = &config.Cassette{Mode: mode, Path: abs}
+	}
+	return nil
+}
+
 func shouldEnableMetrics() bool {
 	if v, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_METRICS")); v {
 		return false
@@ -252,6 +284,15 @@ func ResolveCwd(cmd *cobra.Command) (string, error) {
 	return cwd, nil
 }
 
+// ResolveProfile returns the configuration profile given with --profile, or
+// in the CRUSH_PROFILE environment variable.
+func ResolveProfile(cmd *cobra.Command) string {
+	if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+		return profile
+	}
+	return os.Getenv(config.ProfileEnv)
+}
+
 func createDotCrushDir(dir string) error {
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return fmt.Errorf("failed to create data directory: %q %w", dir, err)
@@ -26,6 +26,10 @@ crush run "What is this code doing?" <<< prrr.go
 
 # Run in quiet mode (hide the spinner)
 crush run --quiet "Generate a README for this project"
+
+# Record the exchanges with the provider, then replay them offline
+crush run --record testdata/readme.yaml "Generate a README for this project"
+crush run --replay testdata/readme.yaml "Generate a README for this project"
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
@@ -64,4 +68,7 @@ crush run --quiet "Generate a README for this project"
 
 func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().String("record", "", "Record the exchanges with providers to a cassette file")
+	runCmd.Flags().String("replay", "", "Replay the exchanges with providers from a cassette file")
+	runCmd.MarkFlagsMutuallyExclusive("record", "replay")
 }
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/secret"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage secrets referenced from the configuration",
+	Long: `Store credentials in the system keyring, or in an encrypted file when
+there is none, and reference them from the configuration as secret:<name>
+anywhere values are resolved: provider api_key and base_url, MCP headers and
+env, and LSP commands.`,
+	Example: `
+# Store a token, typed without echo
+crush secret set github-token
+
+# Store a value read from stdin
+echo -n "$TOKEN" | crush secret set github-token
+
+# Reference it from an MCP header
+crush config set 'mcp.github.headers.Authorization' 'secret:github-token'
+
+# Remove it
+crush secret delete github-token
+  `,
+}
+
+var secretSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Store a secret",
+	Long:  `Store a secret, read from the terminal without echo or from stdin.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := loadSecretStore(cmd)
+		if err != nil {
+			return err
+		}
+		value, err := readSecret(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return fmt.Errorf("secret %q is empty", args[0])
+		}
+		if err := store.Set(args[0], value); err != nil {
+			return fmt.Errorf("failed to store secret: %w", err)
+		}
+		cmd.Printf("Stored %q in the %s, reference it as %s\n", args[0], store.Name(), secret.Ref(args[0]))
+		return nil
+	},
+}
+
+var secretDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a secret",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := loadSecretStore(cmd)
+		if err != nil {
+			return err
+		}
+		if err := store.Delete(args[0]); err != nil {
+			return fmt.Errorf("failed to delete secret: %w", err)
+		}
+		cmd.Printf("Deleted %q from the %s\n", args[0], store.Name())
+		return nil
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretSetCmd, secretDeleteCmd)
+}
+
+// loadSecretStore opens the secret store chosen in the configuration,
+// without loading the whole configuration.
+func loadSecretStore(cmd *cobra.Command) (secret.Store, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	kind := secret.KindAuto
+	value, ok, err := config.GetField(config.ConfigFiles(cwd), "options.secret_store")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		kind = config.FormatValue(value)
+	}
+	return secret.New(kind, filepath.Dir(config.GlobalConfigData()))
+}
+
+// readSecret reads the secret from the terminal without echo, or the whole
+// of stdin when it is not a terminal.
+func readSecret(cmd *cobra.Command, name string) (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		cmd.PrintErrf("Value of %s: ", name)
+		value, err := term.ReadPassword(os.Stdin.Fd())
+		cmd.PrintErrln()
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %w", err)
+		}
+		return string(value), nil
+	}
+	value, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	return strings.TrimRight(string(value), "\r\n"), nil
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
-	"os"
 	"slices"
 	"strings"
 	"time"
@@ -14,8 +14,9 @@ import (
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/secret"
 	"github.com/invopop/jsonschema"
-	"github.com/tidwall/sjson"
+	"github.com/tidwall/gjson"
 )
 
 const (
@@ -24,6 +25,10 @@ const (
 	defaultInitializeAs  = "AGENTS.md"
 )
 
+// ProfileEnv is the environment variable selecting the configuration profile
+// when the --profile flag is not given.
+const ProfileEnv = "CRUSH_PROFILE"
+
 var defaultContextPaths = []string{
 	".github/copilot-instructions.md",
 	".cursorrules",
@@ -55,6 +60,10 @@ const (
 	AgentTask  string = "task"
 )
 
+// TypePlugin is the type of the providers served by an executable or an HTTP
+// adapter speaking the plugin protocol.
+const TypePlugin catwalk.Type = "plugin"
+
 type SelectedModel struct {
 	// The model id as used by the provider API.
 	// Required.
@@ -89,7 +98,11 @@ type ProviderConfig struct {
 	// The provider's API endpoint.
 	BaseURL string `json:"base_url,omitempty" jsonschema:"description=Base URL for the provider's API,format=uri,example=https://api.openai.com/v1"`
 	// The provider type, e.g. "openai", "anthropic", etc. if empty it defaults to openai.
-	Type catwalk.Type `json:"type,omitempty" jsonschema:"description=Provider type that determines the API format,enum=openai,enum=openai-compat,enum=anthropic,enum=gemini,enum=azure,enum=vertexai,default=openai"`
+	Type catwalk.Type `json:"type,omitempty" jsonschema:"description=Provider type that determines the API format,enum=openai,enum=openai-compat,enum=anthropic,enum=gemini,enum=azure,enum=vertexai,enum=plugin,default=openai"`
+	// The executable serving the models of plugin providers.
+	Command string            `json:"command,omitempty" jsonschema:"description=Executable serving the models of a plugin provider over stdin and stdout,example=crush-gateway"`
+	Args    []string          `json:"args,omitempty" jsonschema:"description=Arguments to pass to the plugin command"`
+	Env     map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set for the plugin command"`
 	// The provider's API key.
 	APIKey string `json:"api_key,omitempty" jsonschema:"description=API key for authentication with the provider,example=$OPENAI_API_KEY"`
 	// Marks the provider as disabled.
@@ -110,6 +123,18 @@ type ProviderConfig struct {
 
 	// The provider models
 	Models []catwalk.Model `json:"models,omitempty" jsonschema:"description=List of models available from this provider"`
+
+	// TextToolModels are the models calling tools through text rather than
+	// the tool calling API of the provider.
+	TextToolModels []string `json:"text_tool_models,omitempty" jsonschema:"description=IDs of the models without native tool calling which call tools through a text protocol described in the system prompt,example=gemma3:27b"`
+
+	// Discover lists the models of the provider from its server at startup.
+	Discover bool `json:"discover,omitempty" jsonschema:"description=Discover the models served by this provider at startup from its Ollama or LM Studio or OpenAI-compatible /v1/models endpoint,default=false"`
+	// Discovered marks the local servers found at their default address.
+	Discovered bool `json:"-"`
+	// Unreachable marks the discovered local servers that stopped answering
+	// while one of their models is selected, so they're kept.
+	Unreachable bool `json:"-"`
 }
 
 type MCPType string
@@ -131,6 +156,18 @@ type MCPConfig struct {
 
 	// TODO: maybe make it possible to get the value from the env
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+
+	OAuth MCPOAuthConfig `json:"oauth,omitzero" jsonschema:"description=OAuth settings for HTTP/SSE MCP servers that require authorization"`
+}
+
+// MCPOAuthConfig configures the OAuth authorization of remote MCP servers.
+// None of it is needed for servers that support dynamic client registration.
+type MCPOAuthConfig struct {
+	Disabled     bool     `json:"disabled,omitempty" jsonschema:"description=Disable OAuth authorization for this MCP server,default=false"`
+	ClientID     string   `json:"client_id,omitempty" jsonschema:"description=Pre-registered OAuth client ID to use instead of dynamic client registration"`
+	ClientSecret string   `json:"client_secret,omitempty" jsonschema:"description=Secret of the pre-registered OAuth client, if any"`
+	Scopes       []string `json:"scopes,omitempty" jsonschema:"description=OAuth scopes to request instead of the ones advertised by the server,example=read,example=write"`
+	RedirectPort int      `json:"redirect_port,omitempty" jsonschema:"description=Local port to receive the OAuth redirect on (required by most pre-registered clients),example=8765"`
 }
 
 type LSPConfig struct {
@@ -142,15 +179,64 @@ type LSPConfig struct {
 	RootMarkers []string          `json:"root_markers,omitempty" jsonschema:"description=Files or directories that indicate the project root,example=go.mod,example=package.json,example=Cargo.toml"`
 	InitOptions map[string]any    `json:"init_options,omitempty" jsonschema:"description=Initialization options passed to the LSP server during initialize request"`
 	Options     map[string]any    `json:"options,omitempty" jsonschema:"description=LSP server-specific settings passed during initialization"`
+
+	// Actions run by the server on files after they are changed by the
+	// edit, multiedit and write tools.
+	FormatOnEdit      bool     `json:"format_on_edit,omitempty" jsonschema:"description=Format files with this LSP server after they are edited by the agent,default=false"`
+	CodeActionsOnEdit []string `json:"code_actions_on_edit,omitempty" jsonschema:"description=Code action kinds to apply after files are edited by the agent,example=source.organizeImports,example=source.fixAll"`
+
+	Lazy        bool `json:"lazy,omitempty" jsonschema:"description=Only start this LSP server once a file it handles is used,default=false"`
+	IdleTimeout int  `json:"idle_timeout,omitempty" jsonschema:"description=Stop this LSP server after it has been unused for this many seconds (0 keeps it running),default=0,example=600"`
 }
 
 type TUIOptions struct {
-	CompactMode bool   `json:"compact_mode,omitempty" jsonschema:"description=Enable compact mode for the TUI interface,default=false"`
-	DiffMode    string `json:"diff_mode,omitempty" jsonschema:"description=Diff mode for the TUI interface,enum=unified,enum=split"`
-	// Here we can add themes later or any TUI related options
-	//
+	CompactMode  bool                `json:"compact_mode,omitempty" jsonschema:"description=Enable compact mode for the TUI interface,default=false"`
+	DiffMode     string              `json:"diff_mode,omitempty" jsonschema:"description=Diff mode for the TUI interface,enum=unified,enum=split"`
+	Theme        string              `json:"theme,omitempty" jsonschema:"description=Theme for the TUI interface: a built-in one or one defined in the themes directory of the config directory,default=charmtone,example=light,example=high-contrast"`
+	Keybindings  map[string][]string `json:"keybindings,omitempty" jsonschema:"description=Keys to bind TUI actions to by action name such as app.commands or editor.newline; an empty list unbinds the action,example={\"app.commands\":[\"ctrl+k\"]}"`
+	VimMode      bool                `json:"vim_mode,omitempty" jsonschema:"description=Enable vim-style modal editing in the chat editor,default=false"`
+	ScreenReader bool                `json:"screen_reader,omitempty" jsonschema:"description=Render plain, linear output for screen readers: no animations, gradients, box-drawing or sidebar,default=false"`
+
+	Completions   Completions   `json:"completions,omitzero" jsonschema:"description=Completions UI options"`
+	Notifications Notifications `json:"notifications,omitzero" jsonschema:"description=Notifications sent when the agent needs attention while the terminal is not focused"`
+}
+
+// Notifications defines when and how the TUI tells the user the agent needs
+// attention. They are only sent while the terminal is not focused.
+type Notifications struct {
+	TurnComplete *bool `json:"turn_complete,omitempty" jsonschema:"description=Notify when the agent finishes a turn,default=true"`
+	Error        *bool `json:"error,omitempty" jsonschema:"description=Notify when a turn ends with an error,default=true"`
+	Permission   *bool `json:"permission,omitempty" jsonschema:"description=Notify when a permission is requested,default=true"`
+
+	Terminal string `json:"terminal,omitempty" jsonschema:"description=Terminal escape sequence used to send notifications,enum=osc9,enum=osc777,enum=none,default=osc9"`
+	Bell     *bool  `json:"bell,omitempty" jsonschema:"description=Ring the terminal bell,default=true"`
+	Desktop  bool   `json:"desktop,omitempty" jsonschema:"description=Also send desktop notifications with notify-send,default=false"`
+}
+
+const (
+	NotificationTerminalOSC9   = "osc9"
+	NotificationTerminalOSC777 = "osc777"
+	NotificationTerminalNone   = "none"
+)
 
-	Completions Completions `json:"completions,omitzero" jsonschema:"description=Completions UI options"`
+// Events returns whether turn completions, errors and permission requests
+// are notified.
+func (n Notifications) Events() (turnComplete, err, permission bool) {
+	return ptrValOr(n.TurnComplete, true), ptrValOr(n.Error, true), ptrValOr(n.Permission, true)
+}
+
+// TerminalSequence returns the escape sequence used to send notifications
+// from the terminal, osc9 by default.
+func (n Notifications) TerminalSequence() string {
+	if n.Terminal == "" {
+		return NotificationTerminalOSC9
+	}
+	return n.Terminal
+}
+
+// RingBell returns whether the terminal bell is rung with notifications.
+func (n Notifications) RingBell() bool {
+	return ptrValOr(n.Bell, true)
 }
 
 // Completions defines options for the completions UI.
@@ -191,6 +277,82 @@ func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 	}
 }
 
+// Cassette records the HTTP exchanges with providers to a file, or replays
+// them without network access.
+type Cassette struct {
+	Mode string `json:"mode" jsonschema:"required,description=Whether to record the exchanges or replay them,enum=record,enum=replay"`
+	Path string `json:"path" jsonschema:"required,description=Path of the YAML cassette file relative to the working directory,example=testdata/cassettes/review.yaml"`
+}
+
+// PromptCache controls the caching of prompts by providers. Anthropic and
+// Bedrock cache the prompt up to the breakpoints placed on the system prompt,
+// the tools and the last messages, at most four per request; OpenAI routes
+// the requests with the same cache key to the same cache.
+type PromptCache struct {
+	Disabled     bool  `json:"disabled,omitempty" jsonschema:"description=Do not ask providers to cache prompts,default=false"`
+	SystemPrompt *bool `json:"system_prompt,omitempty" jsonschema:"description=Place a cache breakpoint on the system prompt,default=true"`
+	Tools        *bool `json:"tools,omitempty" jsonschema:"description=Place a cache breakpoint on the tool definitions,default=true"`
+	Messages     *int  `json:"messages,omitempty" jsonschema:"description=Number of the last messages to place cache breakpoints on,default=2,minimum=0,maximum=4"`
+	SessionKey   *bool `json:"session_key,omitempty" jsonschema:"description=Send the session ID as the prompt cache key of OpenAI requests,default=true"`
+}
+
+// maxCacheBreakpoints is the number of cache breakpoints Anthropic accepts
+// in a request.
+const maxCacheBreakpoints = 4
+
+// Enabled reports whether prompts are cached.
+func (c *PromptCache) Enabled() bool {
+	return c == nil || !c.Disabled
+}
+
+// CacheSystemPrompt reports whether the system prompt is cached.
+func (c *PromptCache) CacheSystemPrompt() bool {
+	return c.Enabled() && (c == nil || c.SystemPrompt == nil || *c.SystemPrompt)
+}
+
+// CacheTools reports whether the tool definitions are cached.
+func (c *PromptCache) CacheTools() bool {
+	return c.Enabled() && (c == nil || c.Tools == nil || *c.Tools)
+}
+
+// CachedMessages returns the number of the last messages cached, within the
+// breakpoints left by the system prompt and the tools.
+func (c *PromptCache) CachedMessages() int {
+	if !c.Enabled() {
+		return 0
+	}
+	messages := 2
+	if c != nil && c.Messages != nil {
+		messages = max(*c.Messages, 0)
+	}
+	left := maxCacheBreakpoints
+	if c.CacheSystemPrompt() {
+		left--
+	}
+	if c.CacheTools() {
+		left--
+	}
+	return min(messages, left)
+}
+
+// UseSessionKey reports whether the session ID is sent as the prompt cache
+// key of OpenAI requests.
+func (c *PromptCache) UseSessionKey() bool {
+	return c.Enabled() && (c == nil || c.SessionKey == nil || *c.SessionKey)
+}
+
+// Profile overrides the configuration when it's selected, with the
+// --profile flag, the CRUSH_PROFILE environment variable or from the command
+// palette. Its settings are merged over the ones of the config files, the
+// way a config file is merged over the ones before it.
+type Profile struct {
+	Providers   map[string]ProviderConfig           `json:"providers,omitempty" jsonschema:"description=AI provider configurations to add or override"`
+	Models      map[SelectedModelType]SelectedModel `json:"models,omitempty" jsonschema:"description=Model configurations to override"`
+	MCP         MCPs                                `json:"mcp,omitempty" jsonschema:"description=MCP server configurations to add or override"`
+	Permissions *Permissions                        `json:"permissions,omitempty" jsonschema:"description=Permission settings to override"`
+	Options     *Options                            `json:"options,omitempty" jsonschema:"description=General application options to override"`
+}
+
 type Options struct {
 	ContextPaths              []string     `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
 	TUI                       *TUIOptions  `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
@@ -198,11 +360,15 @@ type Options struct {
 	DebugLSP                  bool         `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
 	DisableAutoSummarize      bool         `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
 	DataDirectory             string       `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
-	DisabledTools             []string     `json:"disabled_tools" jsonschema:"description=Tools to disable"`
+	DisabledTools             []string     `json:"disabled_tools,omitempty" jsonschema:"description=Tools to disable"`
 	DisableProviderAutoUpdate bool         `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
 	Attribution               *Attribution `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
 	DisableMetrics            bool         `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
 	InitializeAs              string       `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	SecretStore               string       `json:"secret_store,omitempty" jsonschema:"description=Where API keys entered in Crush are stored and secret: references are read from. auto uses the system keyring when available and an encrypted file otherwise,enum=auto,enum=keyring,enum=file,default=auto"`
+	LocalDiscovery            bool         `json:"local_discovery,omitempty" jsonschema:"description=Look for Ollama and LM Studio and llama.cpp servers running at their default addresses,default=false"`
+	Cassette                  *Cassette    `json:"cassette,omitempty" jsonschema:"description=Record provider HTTP exchanges to a cassette file or replay them from it"`
+	PromptCache               *PromptCache `json:"prompt_cache,omitempty" jsonschema:"description=Where to place the cache breakpoints of prompts and whether to key the OpenAI cache by session"`
 }
 
 type MCPs map[string]MCPConfig
@@ -251,6 +417,10 @@ func (l LSPConfig) ResolvedEnv() []string {
 	return resolveEnvs(l.Env)
 }
 
+func (p ProviderConfig) ResolvedEnv() []string {
+	return resolveEnvs(p.Env)
+}
+
 func (m MCPConfig) ResolvedEnv() []string {
 	return resolveEnvs(m.Env)
 }
@@ -275,7 +445,8 @@ type Agent struct {
 	// This is the id of the system prompt used by the agent
 	Disabled bool `json:"disabled,omitempty"`
 
-	Model SelectedModelType `json:"model" jsonschema:"required,description=The model type to use for this agent,enum=large,enum=small,default=large"`
+	// The entry of the models the agent runs on, picked by the routing.
+	Model SelectedModelType `json:"model" jsonschema:"required,description=The model type to use for this agent,default=large"`
 
 	// The available tools for the agent
 	//  if this is nil, all tools are available
@@ -308,7 +479,7 @@ func (t ToolLs) Limits() (depth, items int) {
 type Config struct {
 	Schema string `json:"$schema,omitempty"`
 
-	// We currently only support large/small as values here.
+	// large and small are always set, other entries can be routed to.
 	Models map[SelectedModelType]SelectedModel `json:"models,omitempty" jsonschema:"description=Model configurations for different model types,example={\"large\":{\"model\":\"gpt-4o\",\"provider\":\"openai\"}}"`
 	// Recently used models stored in the data directory config.
 	RecentModels map[SelectedModelType][]SelectedModel `json:"recent_models,omitempty" jsonschema:"description=Recently used models sorted by most recent first"`
@@ -326,20 +497,43 @@ type Config struct {
 
 	Tools Tools `json:"tools,omitzero" jsonschema:"description=Tool configurations"`
 
+	Routing Routing `json:"routing,omitzero" jsonschema:"description=Models each agent and task runs on by name in models or as provider/model"`
+
+	Profiles map[string]Profile `json:"profiles,omitempty" jsonschema:"description=Named sets of settings overriding the configuration when selected with --profile or CRUSH_PROFILE"`
+
 	Agents map[string]Agent `json:"-"`
 
 	// Internal
 	workingDir string `json:"-"`
+	profile    string `json:"-"`
 	// TODO: find a better way to do this this should probably not be part of the config
 	resolver       VariableResolver
 	dataConfigDir  string             `json:"-"`
 	knownProviders []catwalk.Provider `json:"-"`
+	// discovery caches the discovery of local models, nil to not cache it.
+	discovery *localDiscovery
 }
 
 func (c *Config) WorkingDir() string {
 	return c.workingDir
 }
 
+// DataConfigPath returns the path of the configuration file the settings
+// changed from Crush are written to.
+func (c *Config) DataConfigPath() string {
+	return c.dataConfigDir
+}
+
+// Profile returns the name of the profile in use, empty when none is.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// ProfileNames returns the names of the profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	return slices.Sorted(maps.Keys(c.Profiles))
+}
+
 func (c *Config) EnabledProviders() []ProviderConfig {
 	var enabled []ProviderConfig
 	for p := range c.Providers.Seq() {
@@ -418,7 +612,7 @@ func (c *Config) Resolve(key string) (string, error) {
 
 func (c *Config) UpdatePreferredModel(modelType SelectedModelType, model SelectedModel) error {
 	c.Models[modelType] = model
-	if err := c.SetConfigField(fmt.Sprintf("models.%s", modelType), model); err != nil {
+	if err := c.SetConfigField("models."+gjson.Escape(string(modelType)), model); err != nil {
 		return fmt.Errorf("failed to update preferred model: %w", err)
 	}
 	if err := c.recordRecentModel(modelType, model); err != nil {
@@ -428,29 +622,17 @@ func (c *Config) UpdatePreferredModel(modelType SelectedModelType, model Selecte
 }
 
 func (c *Config) SetConfigField(key string, value any) error {
-	// read the data
-	data, err := os.ReadFile(c.dataConfigDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			data = []byte("{}")
-		} else {
-			return fmt.Errorf("failed to read config file: %w", err)
-		}
-	}
+	return SetFileField(c.dataConfigDir, key, value)
+}
 
-	newValue, err := sjson.Set(string(data), key, value)
-	if err != nil {
-		return fmt.Errorf("failed to set config field %s: %w", key, err)
-	}
-	if err := os.WriteFile(c.dataConfigDir, []byte(newValue), 0o600); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
-	}
-	return nil
+func (c *Config) RemoveConfigField(key string) error {
+	return RemoveFileField(c.dataConfigDir, key)
 }
 
 func (c *Config) SetProviderAPIKey(providerID, apiKey string) error {
-	// First save to the config file
-	err := c.SetConfigField("providers."+providerID+".api_key", apiKey)
+	// First save to the config file, keeping the key itself in the secret
+	// store when there is one.
+	err := c.SetConfigField("providers."+providerID+".api_key", storeAPIKey(providerID, apiKey))
 	if err != nil {
 		return fmt.Errorf("failed to save API key to config file: %w", err)
 	}
@@ -491,6 +673,25 @@ func (c *Config) SetProviderAPIKey(providerID, apiKey string) error {
 	return nil
 }
 
+// storeAPIKey stores the API key in the secret store and returns the
+// reference to write to the config file, or the key itself when it cannot be
+// stored or is already a reference.
+func storeAPIKey(providerID, apiKey string) string {
+	store := secret.Default()
+	if store == nil || strings.HasPrefix(apiKey, "$") {
+		return apiKey
+	}
+	if _, ok := secret.ParseRef(apiKey); ok {
+		return apiKey
+	}
+	name := providerID + "-api-key"
+	if err := store.Set(name, apiKey); err != nil {
+		slog.Warn("Failed to store API key, writing it to the config file", "store", store.Name(), "error", err)
+		return apiKey
+	}
+	return secret.Ref(name)
+}
+
 const maxRecentModelsPerType = 5
 
 func (c *Config) recordRecentModel(modelType SelectedModelType, model SelectedModel) error {
@@ -527,7 +728,7 @@ func (c *Config) recordRecentModel(modelType SelectedModelType, model SelectedMo
 
 	c.RecentModels[modelType] = updated
 
-	if err := c.SetConfigField(fmt.Sprintf("recent_models.%s", modelType), updated); err != nil {
+	if err := c.SetConfigField("recent_models."+gjson.Escape(string(modelType)), updated); err != nil {
 		return fmt.Errorf("failed to persist recent models: %w", err)
 	}
 
@@ -545,11 +746,13 @@ func allToolNames() []string {
 		"multiedit",
 		"lsp_diagnostics",
 		"lsp_references",
+		"lsp_code_actions",
 		"fetch",
 		"agentic_fetch",
 		"glob",
 		"grep",
 		"ls",
+		"mcp_resources",
 		"sourcegraph",
 		"view",
 		"write",
@@ -583,6 +786,7 @@ func filterSlice(data []string, mask []string, include bool) []string {
 }
 
 func (c *Config) SetupAgents() {
+	c.configureRouting()
 	allowedTools := resolveAllowedTools(allToolNames(), c.Options.DisabledTools)
 
 	agents := map[string]Agent{
@@ -590,7 +794,7 @@ func (c *Config) SetupAgents() {
 			ID:           AgentCoder,
 			Name:         "Coder",
 			Description:  "An agent that helps with executing coding tasks.",
-			Model:        SelectedModelTypeLarge,
+			Model:        c.Routing.Model(ModelTaskCoder),
 			ContextPaths: c.Options.ContextPaths,
 			AllowedTools: allowedTools,
 		},
@@ -599,7 +803,7 @@ func (c *Config) SetupAgents() {
 			ID:           AgentCoder,
 			Name:         "Task",
 			Description:  "An agent that helps with searching for context and finding implementation details.",
-			Model:        SelectedModelTypeLarge,
+			Model:        c.Routing.Model(ModelTaskTask),
 			ContextPaths: c.Options.ContextPaths,
 			AllowedTools: resolveReadOnlyTools(allowedTools),
 			// NO MCPs or LSPs by default
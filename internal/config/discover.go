@@ -0,0 +1,381 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/env"
+)
+
+const (
+	// localDiscoveryTimeout bounds the time spent probing local servers.
+	localDiscoveryTimeout = 2 * time.Second
+	// localDiscoveryCacheTTL is how long the discovered models are reused
+	// before probing the servers again.
+	localDiscoveryCacheTTL = 30 * time.Second
+	// defaultLocalContextWindow is the context window of discovered models
+	// whose server does not report it.
+	defaultLocalContextWindow = 8192
+	// maxLocalMaxTokens caps the default max tokens of discovered models.
+	maxLocalMaxTokens = 16384
+)
+
+// localServer is a local inference server probed at its default address.
+type localServer struct {
+	id   string
+	name string
+	// url is the root of the server, without the /v1 of its OpenAI
+	// compatible API.
+	url string
+	// hostEnv is the environment variable overriding the address.
+	hostEnv string
+}
+
+// localDiscovery records when local models were last discovered.
+type localDiscovery struct {
+	mu sync.Mutex
+	at time.Time
+}
+
+var localServers = []localServer{
+	{id: "ollama", name: "Ollama", url: "http://localhost:11434", hostEnv: "OLLAMA_HOST"},
+	{id: "lmstudio", name: "LM Studio", url: "http://localhost:1234"},
+	{id: "llamacpp", name: "llama.cpp", url: "http://localhost:8080"},
+}
+
+// DiscoverLocalModels lists the models of the providers set to discover
+// them and, when enabled, adds the Ollama, LM Studio and llama.cpp servers
+// running at their default addresses as providers. It returns whether any
+// provider changed. The servers aren't probed again for a short while after a
+// discovery.
+func (c *Config) DiscoverLocalModels(ctx context.Context) bool {
+	if c.discovery != nil {
+		c.discovery.mu.Lock()
+		defer c.discovery.mu.Unlock()
+		if time.Since(c.discovery.at) < localDiscoveryCacheTTL {
+			return false
+		}
+		defer func() { c.discovery.at = time.Now() }()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, localDiscoveryTimeout)
+	defer cancel()
+
+	type target struct {
+		provider ProviderConfig
+		// configured is the configuration file entry of the provider, nil
+		// for default local servers.
+		configured bool
+	}
+	var targets []target
+	for id, p := range c.Providers.Seq2() {
+		if p.Discover && !p.Disable {
+			p.ID = id
+			targets = append(targets, target{provider: p, configured: true})
+		}
+	}
+	if c.Options != nil && c.Options.LocalDiscovery {
+		e := env.New()
+		for _, s := range localServers {
+			if p, ok := c.Providers.Get(s.id); ok && !p.Discovered {
+				continue
+			}
+			url := s.url
+			if host := e.Get(s.hostEnv); s.hostEnv != "" && host != "" {
+				url = host
+				if !strings.Contains(url, "://") {
+					url = "http://" + url
+				}
+			}
+			targets = append(targets, target{provider: ProviderConfig{
+				ID:         s.id,
+				Name:       s.name,
+				BaseURL:    strings.TrimSuffix(url, "/") + "/v1",
+				Type:       catwalk.TypeOpenAICompat,
+				Discovered: true,
+			}})
+		}
+	}
+	if len(targets) == 0 {
+		return false
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		changed bool
+	)
+	resolver := c.resolver
+	if resolver == nil {
+		resolver = NewShellVariableResolver(env.New())
+	}
+	for _, t := range targets {
+		wg.Go(func() {
+			p := t.provider
+			baseURL, err := resolver.ResolveValue(p.BaseURL)
+			if err != nil {
+				slog.Warn("Failed to resolve the base URL of the provider to discover models from", "provider", p.ID, "error", err)
+				return
+			}
+			models, textTools, err := discoverModels(ctx, http.DefaultClient, baseURL)
+			if err != nil {
+				if t.configured {
+					slog.Warn("Failed to discover models", "provider", p.ID, "error", err)
+				} else if current, ok := c.Providers.Get(p.ID); ok {
+					// The server stopped since it was discovered, keep it
+					// if a model of it is selected so it can still be used
+					// once it's back.
+					switch {
+					case c.usesProvider(p.ID):
+						if current.Unreachable {
+							return
+						}
+						slog.Warn("Local server of a selected model is unreachable", "provider", p.ID, "error", err)
+						current.Unreachable = true
+						c.Providers.Set(p.ID, current)
+					default:
+						c.Providers.Del(p.ID)
+					}
+					mu.Lock()
+					changed = true
+					mu.Unlock()
+				}
+				return
+			}
+			if !t.configured && len(models) == 0 {
+				return
+			}
+			p.Models = mergeModels(p.Models, models)
+			for _, id := range textTools {
+				if !slices.Contains(p.TextToolModels, id) {
+					p.TextToolModels = append(p.TextToolModels, id)
+				}
+			}
+			if !slices.ContainsFunc(c.knownProviders, func(k catwalk.Provider) bool { return string(k.ID) == p.ID }) {
+				var ok bool
+				if p, ok = configureCustomProvider(p.ID, p, resolver); !ok {
+					return
+				}
+			}
+			if !t.configured {
+				if current, ok := c.Providers.Get(p.ID); ok && !current.Unreachable && slices.EqualFunc(current.Models, p.Models, func(a, b catwalk.Model) bool {
+					return a.ID == b.ID
+				}) {
+					return
+				}
+				slog.Info("Discovered local models", "provider", p.ID, "models", len(p.Models))
+			}
+			c.Providers.Set(p.ID, p)
+			mu.Lock()
+			changed = true
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return changed
+}
+
+// usesProvider reports whether a model of the provider is selected.
+func (c *Config) usesProvider(id string) bool {
+	for _, m := range c.Models {
+		if m.Provider == id {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeModels adds the discovered models to the configured ones, which take
+// precedence.
+func mergeModels(configured, discovered []catwalk.Model) []catwalk.Model {
+	models := slices.Clone(configured)
+	for _, m := range discovered {
+		if !slices.ContainsFunc(configured, func(c catwalk.Model) bool { return c.ID == m.ID }) {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// discoverModels lists the models served at the base URL, with the native
+// APIs of Ollama and LM Studio when available as they report context windows
+// and capabilities, and the OpenAI compatible one otherwise. It also returns
+// the IDs of the models the server reports without tool calling, to call
+// tools through text.
+func discoverModels(ctx context.Context, client *http.Client, baseURL string) ([]catwalk.Model, []string, error) {
+	root := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1")
+
+	if models, textTools, err := discoverOllama(ctx, client, root); err == nil {
+		return models, textTools, nil
+	} else if ctx.Err() != nil {
+		return nil, nil, err
+	}
+	if models, textTools, err := discoverLMStudio(ctx, client, root); err == nil {
+		return models, textTools, nil
+	} else if ctx.Err() != nil {
+		return nil, nil, err
+	}
+	models, err := discoverOpenAI(ctx, client, root)
+	return models, nil, err
+}
+
+// discoverOllama lists the models of an Ollama server, with the context
+// length and capabilities each one reports.
+func discoverOllama(ctx context.Context, client *http.Client, root string) ([]catwalk.Model, []string, error) {
+	var tags struct {
+		Models *[]struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := getJSON(ctx, client, http.MethodGet, root+"/api/tags", nil, &tags); err != nil {
+		return nil, nil, err
+	}
+	if tags.Models == nil {
+		return nil, nil, fmt.Errorf("not an Ollama server")
+	}
+
+	var (
+		models    []catwalk.Model
+		textTools []string
+	)
+	for _, t := range *tags.Models {
+		var show struct {
+			ModelInfo    map[string]any `json:"model_info"`
+			Capabilities []string       `json:"capabilities"`
+		}
+		body := map[string]string{"model": t.Name}
+		if err := getJSON(ctx, client, http.MethodPost, root+"/api/show", body, &show); err != nil {
+			slog.Debug("Failed to get Ollama model details", "model", t.Name, "error", err)
+		}
+		if len(show.Capabilities) > 0 && !slices.Contains(show.Capabilities, "completion") {
+			// Embedding models.
+			continue
+		}
+		var contextWindow int64
+		for key, value := range show.ModelInfo {
+			if n, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+				contextWindow = int64(n)
+			}
+		}
+		m := localModel(t.Name, contextWindow)
+		m.CanReason = slices.Contains(show.Capabilities, "thinking")
+		m.SupportsImages = slices.Contains(show.Capabilities, "vision")
+		models = append(models, m)
+		if len(show.Capabilities) > 0 && !slices.Contains(show.Capabilities, "tools") {
+			textTools = append(textTools, t.Name)
+		}
+	}
+	return models, textTools, nil
+}
+
+// discoverLMStudio lists the language models of an LM Studio server with its
+// native API.
+func discoverLMStudio(ctx context.Context, client *http.Client, root string) ([]catwalk.Model, []string, error) {
+	var list struct {
+		Data *[]struct {
+			ID               string `json:"id"`
+			Type             string `json:"type"`
+			MaxContextLength int64  `json:"max_context_length"`
+			// Capabilities is only reported by recent versions.
+			Capabilities *[]string `json:"capabilities"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, client, http.MethodGet, root+"/api/v0/models", nil, &list); err != nil {
+		return nil, nil, err
+	}
+	if list.Data == nil {
+		return nil, nil, fmt.Errorf("not an LM Studio server")
+	}
+
+	var (
+		models    []catwalk.Model
+		textTools []string
+	)
+	for _, d := range *list.Data {
+		if d.Type != "llm" && d.Type != "vlm" {
+			continue
+		}
+		m := localModel(d.ID, d.MaxContextLength)
+		m.SupportsImages = d.Type == "vlm"
+		models = append(models, m)
+		if d.Capabilities != nil && !slices.Contains(*d.Capabilities, "tool_use") {
+			textTools = append(textTools, d.ID)
+		}
+	}
+	return models, textTools, nil
+}
+
+// discoverOpenAI lists the models of an OpenAI compatible server. llama.cpp
+// reports the context length the model was trained with.
+func discoverOpenAI(ctx context.Context, client *http.Client, root string) ([]catwalk.Model, error) {
+	var list struct {
+		Data []struct {
+			ID   string `json:"id"`
+			Meta struct {
+				NCtxTrain int64 `json:"n_ctx_train"`
+			} `json:"meta"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, client, http.MethodGet, root+"/v1/models", nil, &list); err != nil {
+		return nil, err
+	}
+
+	models := make([]catwalk.Model, 0, len(list.Data))
+	for _, d := range list.Data {
+		models = append(models, localModel(d.ID, d.Meta.NCtxTrain))
+	}
+	return models, nil
+}
+
+// localModel returns a model served locally, at no cost.
+func localModel(id string, contextWindow int64) catwalk.Model {
+	if contextWindow <= 0 {
+		contextWindow = defaultLocalContextWindow
+	}
+	return catwalk.Model{
+		ID:               id,
+		Name:             id,
+		ContextWindow:    contextWindow,
+		DefaultMaxTokens: min(contextWindow/4, maxLocalMaxTokens),
+	}
+}
+
+// getJSON sends the request, with the body encoded as JSON if any, and
+// decodes the JSON response.
+func getJSON(ctx context.Context, client *http.Client, method, url string, body, v any) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", method, url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/stretchr/testify/require"
+)
+
+func serveJSON(t *testing.T, routes map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if r.Method == http.MethodPost {
+			var body struct {
+				Model string `json:"model"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			route += " " + body.Model
+		}
+		v, ok := routes[route]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDiscoverModels(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ollama", func(t *testing.T) {
+		t.Parallel()
+		srv := serveJSON(t, map[string]any{
+			"/api/tags": map[string]any{"models": []map[string]any{{"name": "qwen3:30b"}, {"name": "gemma3:27b"}, {"name": "nomic-embed-text"}}},
+			"/api/show qwen3:30b": map[string]any{
+				"model_info":   map[string]any{"qwen3moe.context_length": 262144},
+				"capabilities": []string{"completion", "tools", "thinking"},
+			},
+			"/api/show gemma3:27b": map[string]any{
+				"model_info":   map[string]any{"gemma3.context_length": 131072},
+				"capabilities": []string{"completion", "vision"},
+			},
+			"/api/show nomic-embed-text": map[string]any{"capabilities": []string{"embedding"}},
+		})
+		models, textTools, err := discoverModels(t.Context(), srv.Client(), srv.URL+"/v1/")
+		require.NoError(t, err)
+		require.Equal(t, []catwalk.Model{{
+			ID:               "qwen3:30b",
+			Name:             "qwen3:30b",
+			ContextWindow:    262144,
+			DefaultMaxTokens: maxLocalMaxTokens,
+			CanReason:        true,
+		}, {
+			ID:               "gemma3:27b",
+			Name:             "gemma3:27b",
+			ContextWindow:    131072,
+			DefaultMaxTokens: maxLocalMaxTokens,
+			SupportsImages:   true,
+		}}, models)
+		require.Equal(t, []string{"gemma3:27b"}, textTools)
+	})
+
+	t.Run("lm studio", func(t *testing.T) {
+		t.Parallel()
+		srv := serveJSON(t, map[string]any{
+			"/api/v0/models": map[string]any{"data": []map[string]any{
+				{"id": "qwen/qwen3-30b-a3b", "type": "llm", "max_context_length": 32768, "capabilities": []string{"tool_use"}},
+				{"id": "gemma-3", "type": "vlm", "max_context_length": 8192, "capabilities": []string{}},
+				{"id": "text-embedding", "type": "embeddings"},
+			}},
+		})
+		models, textTools, err := discoverModels(t.Context(), srv.Client(), srv.URL+"/v1")
+		require.NoError(t, err)
+		require.Equal(t, []catwalk.Model{
+			{ID: "qwen/qwen3-30b-a3b", Name: "qwen/qwen3-30b-a3b", ContextWindow: 32768, DefaultMaxTokens: 8192},
+			{ID: "gemma-3", Name: "gemma-3", ContextWindow: 8192, DefaultMaxTokens: 2048, SupportsImages: true},
+		}, models)
+		require.Equal(t, []string{"gemma-3"}, textTools)
+	})
+
+	t.Run("llama.cpp", func(t *testing.T) {
+		t.Parallel()
+		srv := serveJSON(t, map[string]any{
+			"/v1/models": map[string]any{"data": []map[string]any{
+				{"id": "model.gguf", "meta": map[string]any{"n_ctx_train": 131072}},
+				{"id": "other"},
+			}},
+		})
+		models, textTools, err := discoverModels(t.Context(), srv.Client(), srv.URL+"/v1")
+		require.NoError(t, err)
+		require.Empty(t, textTools)
+		require.Equal(t, []catwalk.Model{
+			{ID: "model.gguf", Name: "model.gguf", ContextWindow: 131072, DefaultMaxTokens: maxLocalMaxTokens},
+			{ID: "other", Name: "other", ContextWindow: defaultLocalContextWindow, DefaultMaxTokens: defaultLocalContextWindow / 4},
+		}, models)
+	})
+
+	t.Run("not a model server", func(t *testing.T) {
+		t.Parallel()
+		srv := serveJSON(t, nil)
+		_, _, err := discoverModels(t.Context(), srv.Client(), srv.URL)
+		require.Error(t, err)
+	})
+}
+
+func TestConfig_DiscoverLocalModels(t *testing.T) {
+	t.Parallel()
+
+	srv := serveJSON(t, map[string]any{
+		"/v1/models": map[string]any{"data": []map[string]any{{"id": "a"}, {"id": "b"}}},
+	})
+	cfg := &Config{
+		Options: &Options{},
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"local": {
+				BaseURL:  srv.URL + "/v1",
+				Discover: true,
+				Models:   []catwalk.Model{{ID: "a", Name: "Configured A", ContextWindow: 4096}},
+			},
+			"other": {BaseURL: srv.URL + "/v1"},
+		}),
+	}
+
+	require.True(t, cfg.DiscoverLocalModels(context.Background()))
+	local, ok := cfg.Providers.Get("local")
+	require.True(t, ok)
+	require.Equal(t, catwalk.TypeOpenAICompat, local.Type)
+	require.Equal(t, "local", local.Name, "discovered providers should be configured like custom ones")
+	require.Len(t, local.Models, 2)
+	require.Equal(t, "Configured A", local.Models[0].Name)
+	require.Equal(t, "b", local.Models[1].ID)
+
+	other, _ := cfg.Providers.Get("other")
+	require.Empty(t, other.Models)
+}
+
+func TestConfig_DiscoverLocalModelsDefaultServers(t *testing.T) {
+	srv := serveJSON(t, map[string]any{
+		"/v1/models": map[string]any{"data": []map[string]any{{"id": "a"}}},
+	})
+	old := localServers
+	localServers = []localServer{{id: "ollama", name: "Ollama", url: srv.URL}}
+	t.Cleanup(func() { localServers = old })
+
+	cfg := &Config{
+		Options:   &Options{},
+		Providers: csync.NewMap[string, ProviderConfig](),
+	}
+	require.False(t, cfg.DiscoverLocalModels(context.Background()), "default addresses should only be probed when enabled")
+	require.Zero(t, cfg.Providers.Len())
+
+	cfg.Options.LocalDiscovery = true
+	require.True(t, cfg.DiscoverLocalModels(context.Background()))
+	ollama, ok := cfg.Providers.Get("ollama")
+	require.True(t, ok)
+	require.Equal(t, "Ollama", ollama.Name)
+	require.Equal(t, srv.URL+"/v1", ollama.BaseURL)
+	require.True(t, ollama.Discovered)
+	require.Len(t, ollama.Models, 1)
+
+	require.False(t, cfg.DiscoverLocalModels(context.Background()), "nothing changed")
+	srv.Close()
+	require.True(t, cfg.DiscoverLocalModels(context.Background()))
+	_, ok = cfg.Providers.Get("ollama")
+	require.False(t, ok, "servers that stopped should be removed")
+}
+
+func TestConfig_DiscoverLocalModelsSelectedServer(t *testing.T) {
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() || r.URL.Path != "/v1/models" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{"id": "a"}}})
+	}))
+	t.Cleanup(srv.Close)
+	old := localServers
+	localServers = []localServer{{id: "ollama", name: "Ollama", url: srv.URL}}
+	t.Cleanup(func() { localServers = old })
+
+	cfg := &Config{
+		Options:   &Options{LocalDiscovery: true},
+		Providers: csync.NewMap[string, ProviderConfig](),
+		Models: map[SelectedModelType]SelectedModel{
+			SelectedModelTypeLarge: {Provider: "ollama", Model: "a"},
+		},
+	}
+	require.True(t, cfg.DiscoverLocalModels(context.Background()))
+
+	down.Store(true)
+	require.True(t, cfg.DiscoverLocalModels(context.Background()))
+	ollama, ok := cfg.Providers.Get("ollama")
+	require.True(t, ok, "the server of a selected model should be kept")
+	require.True(t, ollama.Unreachable)
+	require.Len(t, ollama.Models, 1)
+	require.False(t, cfg.DiscoverLocalModels(context.Background()), "nothing changed")
+
+	down.Store(false)
+	require.True(t, cfg.DiscoverLocalModels(context.Background()))
+	ollama, _ = cfg.Providers.Get("ollama")
+	require.False(t, ollama.Unreachable)
+}
+
+func TestConfig_DiscoverLocalModelsCache(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	cfg := &Config{
+		Options: &Options{},
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"local": {BaseURL: srv.URL + "/v1", Discover: true},
+		}),
+		discovery: &localDiscovery{},
+	}
+
+	cfg.DiscoverLocalModels(context.Background())
+	probed := requests.Load()
+	require.NotZero(t, probed)
+	cfg.DiscoverLocalModels(context.Background())
+	require.Equal(t, probed, requests.Load(), "the servers shouldn't be probed again right away")
+
+	cfg.discovery.at = time.Now().Add(-localDiscoveryCacheTTL)
+	cfg.DiscoverLocalModels(context.Background())
+	require.Greater(t, requests.Load(), probed)
+}
@@ -22,8 +22,8 @@ type ProjectInitFlag struct {
 // TODO: we need to remove the global config instance keeping it now just until everything is migrated
 var instance atomic.Pointer[Config]
 
-func Init(workingDir, dataDir string, debug bool) (*Config, error) {
-	cfg, err := Load(workingDir, dataDir, debug)
+func Init(workingDir, dataDir, profile string, debug bool) (*Config, error) {
+	cfg, err := Load(workingDir, dataDir, profile, debug)
 	if err != nil {
 		return nil, err
 	}
@@ -36,6 +36,11 @@ func Get() *Config {
 	return cfg
 }
 
+// Set replaces the global configuration, e.g. after switching profiles.
+func Set(cfg *Config) {
+	instance.Store(cfg)
+}
+
 func ProjectNeedsInitialization() (bool, error) {
 	cfg := Get()
 	if cfg == nil {
@@ -0,0 +1,396 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	schemavalidator "github.com/kaptinlin/jsonschema"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Scope is a configuration file settings are written to.
+type Scope string
+
+const (
+	// ScopeGlobal is the configuration file in the user's config directory.
+	ScopeGlobal Scope = "global"
+	// ScopeData is the configuration file Crush writes its own settings to,
+	// such as the selected models.
+	ScopeData Scope = "data"
+	// ScopeProject is the configuration file of the working directory.
+	ScopeProject Scope = "project"
+)
+
+// Scopes lists the scopes settings can be written to.
+var Scopes = []Scope{ScopeGlobal, ScopeData, ScopeProject}
+
+// ParseScope returns the scope with the given name.
+func ParseScope(name string) (Scope, error) {
+	scope := Scope(name)
+	if !slices.Contains(Scopes, scope) {
+		return "", fmt.Errorf("unknown scope %q, expected one of global, data or project", name)
+	}
+	return scope, nil
+}
+
+// Path returns the configuration file of the scope. For the project scope,
+// this is the crush.json or .crush.json of the working directory, crush.json
+// when neither exists.
+func (s Scope) Path(workingDir string) string {
+	switch s {
+	case ScopeGlobal:
+		return GlobalConfig()
+	case ScopeData:
+		return GlobalConfigData()
+	}
+	path := filepath.Join(workingDir, appName+".json")
+	if hidden := filepath.Join(workingDir, "."+appName+".json"); !fileExists(path) && fileExists(hidden) {
+		return hidden
+	}
+	return path
+}
+
+// ConfigFiles returns the configuration files read in the working directory,
+// whether they exist or not, in the order they are merged: later files take
+// precedence.
+func ConfigFiles(workingDir string) []string {
+	return lookupConfigs(workingDir)
+}
+
+// JSONSchema returns the JSON schema of the configuration file.
+func JSONSchema() ([]byte, error) {
+	reflector := new(jsonschema.Reflector)
+	schema := reflector.Reflect(&Config{})
+	optionalOmitZero(schema, reflect.TypeFor[Config](), map[reflect.Type]bool{})
+	bts, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return bts, nil
+}
+
+// optionalOmitZero removes the fields tagged omitzero from the required
+// properties of the schema, which the reflector does not know about.
+func optionalOmitZero(schema *jsonschema.Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+	def := schema.Definitions[t.Name()]
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if def != nil && slices.Contains(strings.Split(opts, ","), "omitzero") {
+			def.Required = slices.DeleteFunc(def.Required, func(r string) bool {
+				return r == name
+			})
+		}
+		optionalOmitZero(schema, field.Type, seen)
+	}
+}
+
+// Setting is a configuration value and the files it comes from.
+type Setting struct {
+	// Key is the path of the value, as accepted by GetField and
+	// SetFileField.
+	Key string
+	// Value is the merged JSON value.
+	Value json.RawMessage
+	// Sources are the files setting the value. The last one wins, except
+	// for arrays, which are concatenated from all of them.
+	Sources []string
+}
+
+// EffectiveSettings merges the given configuration files like Load does and
+// returns every value set, sorted by key, with the files it comes from.
+// Missing files are skipped.
+func EffectiveSettings(paths []string) ([]Setting, error) {
+	merged, files, err := mergeFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := map[string][]string{}
+	for _, f := range files {
+		for key := range flatten(f.data) {
+			sources[key] = append(sources[key], f.path)
+		}
+	}
+
+	values := flatten(merged)
+	settings := make([]Setting, 0, len(values))
+	for key, value := range values {
+		files := sources[key]
+		if len(files) > 1 && !bytes.HasPrefix(value, []byte("[")) {
+			files = files[len(files)-1:]
+		}
+		settings = append(settings, Setting{Key: key, Value: value, Sources: files})
+	}
+	slices.SortFunc(settings, func(a, b Setting) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return settings, nil
+}
+
+// GetField returns the JSON value of the key merged from the given
+// configuration files, and whether it is set.
+func GetField(paths []string, key string) (json.RawMessage, bool, error) {
+	merged, _, err := mergeFiles(paths)
+	if err != nil {
+		return nil, false, err
+	}
+	result := gjson.GetBytes(merged, key)
+	if !result.Exists() {
+		return nil, false, nil
+	}
+	return json.RawMessage(result.Raw), true, nil
+}
+
+// SetFileField sets the key of the configuration file to the value, creating
+// the file when needed.
+func SetFileField(path, key string, value any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		data = []byte("{}")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create config directory: %w", err)
+		}
+	}
+
+	newValue, err := sjson.Set(string(data), key, value)
+	if err != nil {
+		return fmt.Errorf("failed to set config field %s: %w", key, err)
+	}
+	if err := os.WriteFile(path, []byte(newValue), 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// RemoveFileField removes the key from the configuration file.
+func RemoveFileField(path, key string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	newValue, err := sjson.Delete(string(data), key)
+	if err != nil {
+		return fmt.Errorf("failed to remove config field %s: %w", key, err)
+	}
+	if err := os.WriteFile(path, []byte(newValue), 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// ValidationError is a problem found in a configuration file.
+type ValidationError struct {
+	Path   string
+	Line   int
+	Column int
+	// Pointer is the JSON pointer to the invalid value.
+	Pointer string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	pointer := e.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", e.Path, e.Line, e.Column, pointer, e.Message)
+}
+
+// ValidateFile checks the configuration file is valid JSON matching the
+// schema, and returns the problems found, sorted by position.
+func ValidateFile(path string, schema []byte) ([]ValidationError, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		verr := ValidationError{Path: path, Line: 1, Column: 1, Message: err.Error()}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			verr.Line, verr.Column = position(data, int(syntaxErr.Offset))
+		}
+		return []ValidationError{verr}, nil
+	}
+
+	compiled, err := schemavalidator.NewCompiler().Compile(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+
+	var errs []ValidationError
+	seen := map[string]bool{}
+	// Instance locations of nested results are relative to their parent.
+	var collect func(base string, result *schemavalidator.EvaluationResult)
+	collect = func(base string, result *schemavalidator.EvaluationResult) {
+		if result.IsValid() {
+			return
+		}
+		pointer := base + result.InstanceLocation
+		for _, evalErr := range result.Errors {
+			msg := evalErr.Error()
+			if evalErr.Code == "false_schema_mismatch" {
+				// The schema of properties that are not allowed is false.
+				msg = "Unknown property"
+			}
+			if seen[pointer+msg] {
+				continue
+			}
+			seen[pointer+msg] = true
+			verr := ValidationError{Path: path, Pointer: pointer, Message: msg}
+			verr.Line, verr.Column = position(data, valueOffset(data, pointer))
+			errs = append(errs, verr)
+		}
+		for _, detail := range result.Details {
+			collect(pointer, detail)
+		}
+	}
+	collect("", compiled.Validate(instance))
+
+	// Errors on a value are more precise than the ones on the objects
+	// containing it, which repeat them, so only keep the deepest ones.
+	errs = slices.DeleteFunc(errs, func(e ValidationError) bool {
+		return slices.ContainsFunc(errs, func(other ValidationError) bool {
+			return strings.HasPrefix(other.Pointer, e.Pointer+"/")
+		})
+	})
+	slices.SortStableFunc(errs, func(a, b ValidationError) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return errs, nil
+}
+
+// configFile is a configuration file read for merging.
+type configFile struct {
+	path string
+	data []byte
+}
+
+// mergeFiles reads the existing configuration files and merges them.
+func mergeFiles(paths []string) ([]byte, []configFile, error) {
+	var files []configFile
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		files = append(files, configFile{path: path, data: data})
+	}
+	if len(files) == 0 {
+		return []byte("{}"), nil, nil
+	}
+
+	readers := make([]io.Reader, 0, len(files))
+	for _, f := range files {
+		readers = append(readers, bytes.NewReader(f.data))
+	}
+	merged, err := Merge(readers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to merge configuration files: %w", err)
+	}
+	data, err := io.ReadAll(merged)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to merge configuration files: %w", err)
+	}
+	return data, files, nil
+}
+
+// flatten returns the values of the JSON document by key, descending into
+// objects. Arrays and empty objects are values.
+func flatten(data []byte) map[string]json.RawMessage {
+	values := map[string]json.RawMessage{}
+	var walk func(prefix string, value gjson.Result)
+	walk = func(prefix string, value gjson.Result) {
+		if !value.IsObject() || len(value.Map()) == 0 {
+			if prefix != "" {
+				values[prefix] = json.RawMessage(value.Raw)
+			}
+			return
+		}
+		value.ForEach(func(k, v gjson.Result) bool {
+			key := gjson.Escape(k.String())
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walk(key, v)
+			return true
+		})
+	}
+	walk("", gjson.ParseBytes(data))
+	return values
+}
+
+// valueOffset returns the offset of the value the JSON pointer refers to, or
+// 0 when it cannot be found.
+func valueOffset(data []byte, pointer string) int {
+	pointer = strings.TrimPrefix(pointer, "#")
+	if pointer == "" || pointer == "/" {
+		return 0
+	}
+	parts := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, "~1", "/")
+		part = strings.ReplaceAll(part, "~0", "~")
+		parts[i] = gjson.Escape(part)
+	}
+	return gjson.GetBytes(data, strings.Join(parts, ".")).Index
+}
+
+// position returns the 1-based line and column of the offset.
+func position(data []byte, offset int) (line, column int) {
+	offset = max(0, min(offset, len(data)))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = offset - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// FormatValue renders a JSON value for display: strings without quotes,
+// anything else as JSON.
+func FormatValue(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	return string(value)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveSettings(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global.json")
+	project := filepath.Join(dir, "crush.json")
+	require.NoError(t, os.WriteFile(global, []byte(`{"options": {"debug": true, "context_paths": ["a.md"], "tui": {"compact_mode": true}}}`), 0o600))
+	require.NoError(t, os.WriteFile(project, []byte(`{"options": {"debug": false, "context_paths": ["b.md"]}, "mcp": {"my.server": {"command": "x"}}}`), 0o600))
+
+	settings, err := EffectiveSettings([]string{global, filepath.Join(dir, "missing.json"), project})
+	require.NoError(t, err)
+	require.Equal(t, []Setting{
+		{Key: `mcp.my\.server.command`, Value: json.RawMessage(`"x"`), Sources: []string{project}},
+		{Key: "options.context_paths", Value: json.RawMessage(`["a.md","b.md"]`), Sources: []string{global, project}},
+		{Key: "options.debug", Value: json.RawMessage(`false`), Sources: []string{project}},
+		{Key: "options.tui.compact_mode", Value: json.RawMessage(`true`), Sources: []string{global}},
+	}, settings)
+
+	value, ok, err := GetField([]string{global, project}, `mcp.my\.server`)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.JSONEq(t, `{"command": "x"}`, string(value))
+
+	_, ok, err = GetField([]string{global, project}, "options.tui.vim_mode")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSetFileField(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "crush.json")
+	require.NoError(t, SetFileField(path, "options.tui.compact_mode", json.RawMessage(`true`)))
+	require.NoError(t, SetFileField(path, "options.debug", true))
+
+	value, ok, err := GetField([]string{path}, "options.tui.compact_mode")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "true", FormatValue(value))
+
+	require.NoError(t, RemoveFileField(path, "options.tui"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"options": {"debug": true}}`, string(data))
+}
+
+func TestValidateFile(t *testing.T) {
+	t.Parallel()
+
+	schema, err := JSONSchema()
+	require.NoError(t, err)
+
+	validate := func(t *testing.T, content string) []ValidationError {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "crush.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		errs, err := ValidateFile(path, schema)
+		require.NoError(t, err)
+		for i := range errs {
+			require.Equal(t, path, errs[i].Path)
+			errs[i].Path = ""
+		}
+		return errs
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		require.Empty(t, validate(t, `{"options": {"tui": {"compact_mode": true}}, "lsp": {"go": {"command": "gopls"}}}`))
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		t.Parallel()
+		errs := validate(t, "{\n  \"options\": {\n    \"tui\": {\"compact_mode\": \"yes\"},\n    \"bogus\": 1\n  }\n}")
+		require.Len(t, errs, 2)
+		require.Equal(t, ValidationError{Line: 3, Column: 29, Pointer: "/options/tui/compact_mode", Message: "Value is string but should be boolean"}, errs[0])
+		require.Equal(t, ValidationError{Line: 4, Column: 14, Pointer: "/options/bogus", Message: "Unknown property"}, errs[1])
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		t.Parallel()
+		errs := validate(t, "{\n  \"options\": {,}\n}")
+		require.Len(t, errs, 1)
+		require.Equal(t, 2, errs[0].Line)
+	})
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -21,7 +23,9 @@ import (
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/secret"
 	powernapConfig "github.com/charmbracelet/x/powernap/pkg/config"
+	"github.com/tidwall/gjson"
 )
 
 const defaultCatwalkURL = "https://catwalk.charm.sh"
@@ -41,19 +45,27 @@ func LoadReader(fd io.Reader) (*Config, error) {
 	return &config, err
 }
 
-// Load loads the configuration from the default paths.
-func Load(workingDir, dataDir string, debug bool) (*Config, error) {
+// Load loads the configuration from the default paths, with the overrides of
+// the named profile when not empty.
+func Load(workingDir, dataDir, profile string, debug bool) (*Config, error) {
 	configPaths := lookupConfigs(workingDir)
 
-	cfg, err := loadFromConfigPaths(configPaths)
+	cfg, err := loadFromConfigPaths(configPaths, profile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config from paths %v: %w", configPaths, err)
 	}
 
 	cfg.dataConfigDir = GlobalConfigData()
+	cfg.profile = profile
 
 	cfg.setDefaults(workingDir, dataDir)
 
+	store, err := secret.New(cfg.Options.SecretStore, filepath.Dir(cfg.dataConfigDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open secret store: %w", err)
+	}
+	secret.SetDefault(store)
+
 	if debug {
 		cfg.Options.Debug = true
 	}
@@ -85,6 +97,8 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 	// Configure providers
 	valueResolver := NewShellVariableResolver(env)
 	cfg.resolver = valueResolver
+	cfg.discovery = &localDiscovery{}
+	cfg.DiscoverLocalModels(context.Background())
 	if err := cfg.configureProviders(env, valueResolver, cfg.knownProviders); err != nil {
 		return nil, fmt.Errorf("failed to configure providers: %w", err)
 	}
@@ -101,6 +115,31 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 	return cfg, nil
 }
 
+// LoadProfile loads the configuration again with the overrides of the named
+// profile, or without any when empty, keeping the settings given on the
+// command line. The current configuration is left untouched, see [Set] to
+// replace it.
+func (c *Config) LoadProfile(profile string) (*Config, error) {
+	loaded, err := Load(c.workingDir, c.Options.DataDirectory, profile, c.Options.Debug)
+	if err != nil {
+		return nil, err
+	}
+	if !loaded.IsConfigured() {
+		if profile == "" {
+			return nil, errors.New("no provider is configured without a profile")
+		}
+		return nil, fmt.Errorf("no provider is configured with profile %q", profile)
+	}
+	if c.Permissions != nil {
+		if loaded.Permissions == nil {
+			loaded.Permissions = &Permissions{}
+		}
+		loaded.Permissions.SkipRequests = c.Permissions.SkipRequests
+	}
+	loaded.Options.Cassette = c.Options.Cassette
+	return loaded, nil
+}
+
 func PushPopCrushEnv() func() {
 	found := []string{}
 	for _, ev := range os.Environ() {
@@ -253,54 +292,69 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 		if knownProviderNames[id] {
 			continue
 		}
-
-		// Make sure the provider ID is set
-		providerConfig.ID = id
-		if providerConfig.Name == "" {
-			providerConfig.Name = id // Use ID as name if not set
-		}
-		// default to OpenAI if not set
-		if providerConfig.Type == "" {
-			providerConfig.Type = catwalk.TypeOpenAICompat
-		}
-		if !slices.Contains(catwalk.KnownProviderTypes(), providerConfig.Type) {
-			slog.Warn("Skipping custom provider due to unsupported provider type", "provider", id)
+		if providerConfig, ok := configureCustomProvider(id, providerConfig, resolver); ok {
+			c.Providers.Set(id, providerConfig)
+		} else {
 			c.Providers.Del(id)
-			continue
 		}
+	}
+	return nil
+}
 
-		if providerConfig.Disable {
-			slog.Debug("Skipping custom provider due to disable flag", "provider", id)
-			c.Providers.Del(id)
-			continue
-		}
-		if providerConfig.APIKey == "" {
-			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
-		}
-		if providerConfig.BaseURL == "" {
-			slog.Warn("Skipping custom provider due to missing API endpoint", "provider", id)
-			c.Providers.Del(id)
-			continue
+// configureCustomProvider fills in the defaults of a provider unknown to
+// catwalk, and reports whether it can be used.
+func configureCustomProvider(id string, providerConfig ProviderConfig, resolver VariableResolver) (ProviderConfig, bool) {
+	// Make sure the provider ID is set
+	providerConfig.ID = id
+	if providerConfig.Name == "" {
+		providerConfig.Name = id // Use ID as name if not set
+	}
+	// default to OpenAI if not set
+	if providerConfig.Type == "" {
+		providerConfig.Type = catwalk.TypeOpenAICompat
+	}
+	isPlugin := providerConfig.Type == TypePlugin
+	if !isPlugin && !slices.Contains(catwalk.KnownProviderTypes(), providerConfig.Type) {
+		slog.Warn("Skipping custom provider due to unsupported provider type", "provider", id)
+		return providerConfig, false
+	}
+
+	if providerConfig.Disable {
+		slog.Debug("Skipping custom provider due to disable flag", "provider", id)
+		return providerConfig, false
+	}
+	if isPlugin {
+		if providerConfig.Command == "" && providerConfig.BaseURL == "" {
+			slog.Warn("Skipping plugin provider due to missing command or adapter URL", "provider", id)
+			return providerConfig, false
 		}
 		if len(providerConfig.Models) == 0 {
 			slog.Warn("Skipping custom provider because the provider has no models", "provider", id)
-			c.Providers.Del(id)
-			continue
-		}
-		apiKey, err := resolver.ResolveValue(providerConfig.APIKey)
-		if apiKey == "" || err != nil {
-			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
+			return providerConfig, false
 		}
-		baseURL, err := resolver.ResolveValue(providerConfig.BaseURL)
-		if baseURL == "" || err != nil {
-			slog.Warn("Skipping custom provider due to missing API endpoint", "provider", id, "error", err)
-			c.Providers.Del(id)
-			continue
-		}
-
-		c.Providers.Set(id, providerConfig)
+		return providerConfig, true
 	}
-	return nil
+	if providerConfig.APIKey == "" {
+		slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
+	}
+	if providerConfig.BaseURL == "" {
+		slog.Warn("Skipping custom provider due to missing API endpoint", "provider", id)
+		return providerConfig, false
+	}
+	if len(providerConfig.Models) == 0 {
+		slog.Warn("Skipping custom provider because the provider has no models", "provider", id)
+		return providerConfig, false
+	}
+	apiKey, err := resolver.ResolveValue(providerConfig.APIKey)
+	if apiKey == "" || err != nil {
+		slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
+	}
+	baseURL, err := resolver.ResolveValue(providerConfig.BaseURL)
+	if baseURL == "" || err != nil {
+		slog.Warn("Skipping custom provider due to missing API endpoint", "provider", id, "error", err)
+		return providerConfig, false
+	}
+	return providerConfig, true
 }
 
 func (c *Config) setDefaults(workingDir, dataDir string) {
@@ -605,7 +659,7 @@ func lookupConfigs(cwd string) []string {
 	return append(configPaths, foundConfigs...)
 }
 
-func loadFromConfigPaths(configPaths []string) (*Config, error) {
+func loadFromConfigPaths(configPaths []string, profile string) (*Config, error) {
 	var configs []io.Reader
 
 	for _, path := range configPaths {
@@ -621,9 +675,34 @@ func loadFromConfigPaths(configPaths []string) (*Config, error) {
 		configs = append(configs, fd)
 	}
 
+	if profile != "" {
+		var err error
+		configs, err = withProfile(configs, profile)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return loadFromReaders(configs)
 }
 
+// withProfile adds the overrides of the named profile, found in the merged
+// configuration, after the configuration readers.
+func withProfile(readers []io.Reader, profile string) ([]io.Reader, error) {
+	merged, err := Merge(readers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge configuration readers: %w", err)
+	}
+	data, err := io.ReadAll(merged)
+	if err != nil {
+		return nil, err
+	}
+	overrides := gjson.GetBytes(data, "profiles."+gjson.Escape(profile))
+	if !overrides.IsObject() {
+		return nil, fmt.Errorf("profile %q not found", profile)
+	}
+	return []io.Reader{bytes.NewReader(data), strings.NewReader(overrides.Raw)}, nil
+}
+
 func loadFromReaders(readers []io.Reader) (*Config, error) {
 	if len(readers) == 0 {
 		return &Config{}, nil
@@ -682,6 +761,12 @@ func GlobalConfig() string {
 	return filepath.Join(home.Dir(), ".config", appName, fmt.Sprintf("%s.json", appName))
 }
 
+// GlobalThemesDir returns the directory user-defined TUI themes are loaded
+// from.
+func GlobalThemesDir() string {
+	return filepath.Join(filepath.Dir(GlobalConfig()), "themes")
+}
+
 // GlobalConfigData returns the path to the main data directory for the application.
 // this config is used when the app overrides configurations instead of updating the global config.
 func GlobalConfigData() string {
@@ -487,7 +487,7 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "view", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_code_actions", "fetch", "agentic_fetch", "glob", "ls", "mcp_resources", "sourcegraph", "view", "write"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
@@ -510,7 +510,7 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_code_actions", "fetch", "agentic_fetch", "mcp_resources", "write"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
@@ -730,6 +730,48 @@ func TestConfig_configureProvidersCustomProviderValidation(t *testing.T) {
 		_, exists := cfg.Providers.Get("custom")
 		require.False(t, exists)
 	})
+
+	t.Run("plugin provider needs a command or an adapter URL", func(t *testing.T) {
+		cfg := &Config{
+			Providers: csync.NewMapFrom(map[string]ProviderConfig{
+				"gateway": {
+					Type:    TypePlugin,
+					Command: "crush-gateway",
+					Models: []catwalk.Model{{
+						ID: "test-model",
+					}},
+				},
+				"adapter": {
+					Type:    TypePlugin,
+					BaseURL: "http://localhost:8080/generate",
+					Models: []catwalk.Model{{
+						ID: "test-model",
+					}},
+				},
+				"broken": {
+					Type: TypePlugin,
+					Models: []catwalk.Model{{
+						ID: "test-model",
+					}},
+				},
+			}),
+		}
+		cfg.setDefaults("/tmp", "")
+
+		env := env.NewFromMap(map[string]string{})
+		resolver := NewEnvironmentVariableResolver(env)
+		err := cfg.configureProviders(env, resolver, []catwalk.Provider{})
+		require.NoError(t, err)
+
+		require.Equal(t, 2, cfg.Providers.Len())
+		gateway, exists := cfg.Providers.Get("gateway")
+		require.True(t, exists)
+		require.Equal(t, "crush-gateway", gateway.Command)
+		_, exists = cfg.Providers.Get("adapter")
+		require.True(t, exists)
+		_, exists = cfg.Providers.Get("broken")
+		require.False(t, exists)
+	})
 }
 
 func TestConfig_configureProvidersEnhancedCredentialValidation(t *testing.T) {
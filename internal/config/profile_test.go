@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeProfileConfigs(t *testing.T) []string {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global.json")
+	project := filepath.Join(dir, "crush.json")
+	require.NoError(t, os.WriteFile(global, []byte(`{
+		"models": {"large": {"provider": "anthropic", "model": "claude-sonnet-4"}},
+		"mcp": {"docs": {"type": "http", "url": "https://docs.example.com/mcp"}},
+		"profiles": {
+			"work": {
+				"providers": {"bedrock": {"type": "bedrock", "base_url": "https://gateway.example.com"}},
+				"models": {"large": {"provider": "bedrock", "model": "anthropic.claude-sonnet-4"}},
+				"permissions": {"allowed_tools": ["view"]}
+			}
+		}
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(project, []byte(`{
+		"profiles": {
+			"offline": {
+				"models": {"large": {"provider": "ollama", "model": "qwen3-coder:30b"}},
+				"mcp": {"docs": {"type": "http", "url": "https://docs.example.com/mcp", "disabled": true}},
+				"options": {"disable_metrics": true}
+			}
+		}
+	}`), 0o644))
+	return []string{global, project}
+}
+
+func TestConfig_loadWithProfile(t *testing.T) {
+	t.Parallel()
+
+	paths := writeProfileConfigs(t)
+
+	cfg, err := loadFromConfigPaths(paths, "")
+	require.NoError(t, err)
+	require.Equal(t, "claude-sonnet-4", cfg.Models[SelectedModelTypeLarge].Model)
+	require.Equal(t, []string{"offline", "work"}, cfg.ProfileNames())
+
+	cfg, err = loadFromConfigPaths(paths, "work")
+	require.NoError(t, err)
+	require.Equal(t, "bedrock", cfg.Models[SelectedModelTypeLarge].Provider)
+	bedrock, ok := cfg.Providers.Get("bedrock")
+	require.True(t, ok)
+	require.Equal(t, "https://gateway.example.com", bedrock.BaseURL)
+	require.Equal(t, []string{"view"}, cfg.Permissions.AllowedTools)
+	require.False(t, cfg.MCP["docs"].Disabled)
+
+	// Profiles from any config file can be selected, and override the
+	// settings they set only.
+	cfg, err = loadFromConfigPaths(paths, "offline")
+	require.NoError(t, err)
+	require.Equal(t, "qwen3-coder:30b", cfg.Models[SelectedModelTypeLarge].Model)
+	require.True(t, cfg.MCP["docs"].Disabled)
+	require.Equal(t, "https://docs.example.com/mcp", cfg.MCP["docs"].URL)
+	require.True(t, cfg.Options.DisableMetrics)
+}
+
+func TestConfig_loadWithUnknownProfile(t *testing.T) {
+	t.Parallel()
+
+	_, err := loadFromConfigPaths(writeProfileConfigs(t), "personal")
+	require.ErrorContains(t, err, `profile "personal" not found`)
+}
+
+func TestConfig_LoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	// Keys from the environment would configure known providers.
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "crush.json"), []byte(`{
+		"providers": {
+			"local": {
+				"type": "openai-compat",
+				"base_url": "http://localhost:1/v1",
+				"models": [{"id": "small", "context_window": 8192, "default_max_tokens": 1024}]
+			}
+		},
+		"models": {
+			"large": {"provider": "local", "model": "small"},
+			"small": {"provider": "local", "model": "small"}
+		},
+		"profiles": {
+			"big": {
+				"providers": {
+					"remote": {
+						"type": "openai-compat",
+						"base_url": "http://localhost:2/v1",
+						"models": [{"id": "big", "context_window": 131072, "default_max_tokens": 8192}]
+					}
+				},
+				"models": {"large": {"provider": "remote", "model": "big"}}
+			},
+			"none": {
+				"providers": {"local": {"disable": true}}
+			}
+		}
+	}`), 0o644))
+
+	cfg, err := Load(dir, filepath.Join(dir, ".crush"), "", false)
+	require.NoError(t, err)
+	cfg.Permissions = &Permissions{SkipRequests: true}
+	cfg.Options.Cassette = &Cassette{Mode: "replay", Path: "session.yaml"}
+
+	loaded, err := cfg.LoadProfile("big")
+	require.NoError(t, err)
+	require.NotSame(t, cfg, loaded)
+	require.Equal(t, "big", loaded.Models[SelectedModelTypeLarge].Model)
+	require.True(t, loaded.Permissions.SkipRequests, "settings given on the command line should be kept")
+	require.Equal(t, cfg.Options.Cassette, loaded.Options.Cassette)
+	require.Equal(t, "small", cfg.Models[SelectedModelTypeLarge].Model, "the current configuration shouldn't change")
+	_, ok := cfg.Providers.Get("remote")
+	require.False(t, ok)
+
+	loaded, err = loaded.LoadProfile("")
+	require.NoError(t, err)
+	require.Equal(t, "small", loaded.Models[SelectedModelTypeLarge].Model)
+
+	_, err = cfg.LoadProfile("none")
+	require.EqualError(t, err, `no provider is configured with profile "none"`)
+	_, err = cfg.LoadProfile("personal")
+	require.ErrorContains(t, err, `profile "personal" not found`)
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptCache(t *testing.T) {
+	t.Parallel()
+
+	var unset *PromptCache
+	require.True(t, unset.CacheSystemPrompt())
+	require.True(t, unset.CacheTools())
+	require.Equal(t, 2, unset.CachedMessages())
+	require.True(t, unset.UseSessionKey())
+
+	disabled := &PromptCache{Disabled: true}
+	require.False(t, disabled.CacheSystemPrompt())
+	require.False(t, disabled.CacheTools())
+	require.Zero(t, disabled.CachedMessages())
+	require.False(t, disabled.UseSessionKey())
+
+	// The breakpoints the system prompt and tools leave to the messages.
+	messages := 4
+	require.Equal(t, 2, (&PromptCache{Messages: &messages}).CachedMessages())
+	require.Equal(t, 3, (&PromptCache{Messages: &messages, Tools: new(bool)}).CachedMessages())
+	require.Equal(t, 4, (&PromptCache{Messages: &messages, Tools: new(bool), SystemPrompt: new(bool)}).CachedMessages())
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/secret"
 	"github.com/charmbracelet/crush/internal/shell"
 )
 
@@ -38,7 +39,13 @@ func NewShellVariableResolver(env env.Env) VariableResolver {
 // it will resolve shell-like variable substitution anywhere in the string, including:
 // - $(command) for command substitution
 // - $VAR or ${VAR} for environment variables
+// - secret:name for the whole value, for secrets from the secret store
 func (r *shellVariableResolver) ResolveValue(value string) (string, error) {
+	// A secret: reference is the whole value.
+	if name, ok := secret.ParseRef(value); ok {
+		return secret.Resolve(name)
+	}
+
 	// Special case: lone $ is an error (backward compatibility)
 	if value == "$" {
 		return "", fmt.Errorf("invalid value format: %s", value)
@@ -163,6 +170,9 @@ func NewEnvironmentVariableResolver(env env.Env) VariableResolver {
 
 // ResolveValue resolves environment variables from the provided env.Env.
 func (r *environmentVariableResolver) ResolveValue(value string) (string, error) {
+	if name, ok := secret.ParseRef(value); ok {
+		return secret.Resolve(name)
+	}
 	if !strings.HasPrefix(value, "$") {
 		return value, nil
 	}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/secret"
 	"github.com/stretchr/testify/require"
 )
 
@@ -330,3 +331,41 @@ func TestNewEnvironmentVariableResolver(t *testing.T) {
 	require.NotNil(t, resolver)
 	require.Implements(t, (*VariableResolver)(nil), resolver)
 }
+
+func TestResolveSecretReference(t *testing.T) {
+	t.Setenv(secret.PassphraseEnv, "")
+	t.Cleanup(func() { secret.SetDefault(nil) })
+
+	store := secret.NewFile(t.TempDir())
+	require.NoError(t, store.Set("openai-api-key", "sk-test"))
+	secret.SetDefault(store)
+
+	testEnv := env.NewFromMap(nil)
+	for _, resolver := range []VariableResolver{
+		NewShellVariableResolver(testEnv),
+		NewEnvironmentVariableResolver(testEnv),
+	} {
+		value, err := resolver.ResolveValue("secret:openai-api-key")
+		require.NoError(t, err)
+		require.Equal(t, "sk-test", value)
+
+		_, err = resolver.ResolveValue("secret:missing")
+		require.ErrorContains(t, err, `secret "missing" not found`)
+	}
+}
+
+func TestStoreAPIKey(t *testing.T) {
+	t.Setenv(secret.PassphraseEnv, "")
+	t.Cleanup(func() { secret.SetDefault(nil) })
+
+	require.Equal(t, "sk-test", storeAPIKey("openai", "sk-test"))
+
+	store := secret.NewFile(t.TempDir())
+	secret.SetDefault(store)
+	require.Equal(t, "secret:openai-api-key", storeAPIKey("openai", "sk-test"))
+	value, err := store.Get("openai-api-key")
+	require.NoError(t, err)
+	require.Equal(t, "sk-test", value)
+
+	require.Equal(t, "$OPENAI_API_KEY", storeAPIKey("openai", "$OPENAI_API_KEY"))
+}
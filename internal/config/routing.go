@@ -0,0 +1,119 @@
+package config
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// ModelTask is a kind of work a model is routed to.
+type ModelTask string
+
+const (
+	// ModelTaskCoder is the main agent editing code.
+	ModelTaskCoder ModelTask = "coder"
+	// ModelTaskTask is the sub-agent searching for context.
+	ModelTaskTask ModelTask = "task"
+	// ModelTaskAgenticFetch is the sub-agent fetching and analyzing web
+	// content.
+	ModelTaskAgenticFetch ModelTask = "agentic_fetch"
+	// ModelTaskTitle is the generation of session titles.
+	ModelTaskTitle ModelTask = "title"
+	// ModelTaskSummarize is the summarization of sessions.
+	ModelTaskSummarize ModelTask = "summarize"
+)
+
+// ModelTasks lists the tasks in the order they are documented.
+var ModelTasks = []ModelTask{
+	ModelTaskCoder,
+	ModelTaskTask,
+	ModelTaskAgenticFetch,
+	ModelTaskTitle,
+	ModelTaskSummarize,
+}
+
+// defaultRoutes are the models tasks run on when not routed.
+var defaultRoutes = map[ModelTask]SelectedModelType{
+	ModelTaskCoder:        SelectedModelTypeLarge,
+	ModelTaskTask:         SelectedModelTypeLarge,
+	ModelTaskAgenticFetch: SelectedModelTypeSmall,
+	ModelTaskTitle:        SelectedModelTypeSmall,
+	ModelTaskSummarize:    SelectedModelTypeLarge,
+}
+
+// Routing picks the model each task runs on. A route is either the name of
+// an entry of models, such as large, small or a name of your own, or a
+// provider/model pair.
+type Routing struct {
+	Coder        SelectedModelType `json:"coder,omitempty" jsonschema:"description=Model of the main coding agent,default=large,example=large"`
+	Task         SelectedModelType `json:"task,omitempty" jsonschema:"description=Model of the sub-agent searching for context,default=large,example=small"`
+	AgenticFetch SelectedModelType `json:"agentic_fetch,omitempty" jsonschema:"description=Model of the sub-agent fetching web content,default=small,example=small"`
+	Title        SelectedModelType `json:"title,omitempty" jsonschema:"description=Model generating session titles,default=small,example=openai/gpt-4o-mini"`
+	Summarize    SelectedModelType `json:"summarize,omitempty" jsonschema:"description=Model summarizing sessions,default=large,example=fast"`
+}
+
+// route returns a pointer to the route of the task.
+func (r *Routing) route(task ModelTask) *SelectedModelType {
+	switch task {
+	case ModelTaskCoder:
+		return &r.Coder
+	case ModelTaskTask:
+		return &r.Task
+	case ModelTaskAgenticFetch:
+		return &r.AgenticFetch
+	case ModelTaskTitle:
+		return &r.Title
+	case ModelTaskSummarize:
+		return &r.Summarize
+	}
+	return nil
+}
+
+// Model returns the entry of models the task runs on, the default one when
+// the task is not routed.
+func (r Routing) Model(task ModelTask) SelectedModelType {
+	if route := r.route(task); route != nil && *route != "" {
+		return *route
+	}
+	return defaultRoutes[task]
+}
+
+// configureRouting checks the route of each task, adding the provider/model
+// pairs to the models so every route names one of them. Invalid routes fall
+// back to the default model of the task.
+func (c *Config) configureRouting() {
+	for _, task := range ModelTasks {
+		route := c.Routing.route(task)
+		if *route == "" || *route == defaultRoutes[task] {
+			continue
+		}
+		if !c.resolveRoute(*route) {
+			slog.Warn("Model routed to is not configured, using the default", "task", task, "model", *route, "default", defaultRoutes[task])
+			*route = ""
+		}
+	}
+}
+
+// resolveRoute makes sure the route names a model of a configured provider,
+// adding it to the models when it is a provider/model pair.
+func (c *Config) resolveRoute(route SelectedModelType) bool {
+	selected, ok := c.Models[route]
+	if !ok {
+		provider, model, found := strings.Cut(string(route), "/")
+		if !found {
+			return false
+		}
+		selected = SelectedModel{Provider: provider, Model: model}
+	}
+	m := c.GetModel(selected.Provider, selected.Model)
+	if m == nil {
+		return false
+	}
+	if selected.MaxTokens == 0 {
+		selected.MaxTokens = m.DefaultMaxTokens
+	}
+	if c.Models == nil {
+		c.Models = make(map[SelectedModelType]SelectedModel)
+	}
+	c.Models[route] = selected
+	return true
+}
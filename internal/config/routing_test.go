@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/stretchr/testify/require"
+)
+
+func routingTestConfig(routing Routing) *Config {
+	return &Config{
+		Options: &Options{},
+		Models: map[SelectedModelType]SelectedModel{
+			SelectedModelTypeLarge: {Provider: "anthropic", Model: "claude-sonnet-4", MaxTokens: 8000},
+			SelectedModelTypeSmall: {Provider: "anthropic", Model: "claude-haiku-4", MaxTokens: 4000},
+			"fast":                 {Provider: "openai", Model: "gpt-4.1-mini"},
+			"gone":                 {Provider: "openai", Model: "gpt-2"},
+		},
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"anthropic": {Models: []catwalk.Model{{ID: "claude-sonnet-4"}, {ID: "claude-haiku-4"}}},
+			"openai":    {Models: []catwalk.Model{{ID: "gpt-4.1", DefaultMaxTokens: 32000}, {ID: "gpt-4.1-mini", DefaultMaxTokens: 16000}}},
+		}),
+		Routing: routing,
+	}
+}
+
+func TestRouting_Model(t *testing.T) {
+	t.Parallel()
+
+	var r Routing
+	require.Equal(t, SelectedModelTypeLarge, r.Model(ModelTaskCoder))
+	require.Equal(t, SelectedModelTypeLarge, r.Model(ModelTaskTask))
+	require.Equal(t, SelectedModelTypeSmall, r.Model(ModelTaskAgenticFetch))
+	require.Equal(t, SelectedModelTypeSmall, r.Model(ModelTaskTitle))
+	require.Equal(t, SelectedModelTypeLarge, r.Model(ModelTaskSummarize))
+
+	r.Task = "fast"
+	require.Equal(t, SelectedModelType("fast"), r.Model(ModelTaskTask))
+}
+
+func TestConfig_SetupAgentsRouting(t *testing.T) {
+	t.Parallel()
+
+	cfg := routingTestConfig(Routing{
+		Coder:     "openai/gpt-4.1",
+		Task:      "fast",
+		Title:     "gone",
+		Summarize: "nope/model",
+	})
+	cfg.SetupAgents()
+
+	require.Equal(t, SelectedModelType("openai/gpt-4.1"), cfg.Agents[AgentCoder].Model)
+	require.Equal(t, SelectedModel{Provider: "openai", Model: "gpt-4.1", MaxTokens: 32000}, cfg.Models["openai/gpt-4.1"])
+
+	require.Equal(t, SelectedModelType("fast"), cfg.Agents[AgentTask].Model)
+	require.Equal(t, int64(16000), cfg.Models["fast"].MaxTokens)
+
+	// Routes to models that are not configured fall back to the defaults.
+	require.Equal(t, SelectedModelTypeSmall, cfg.Routing.Model(ModelTaskTitle))
+	require.Equal(t, SelectedModelTypeLarge, cfg.Routing.Model(ModelTaskSummarize))
+	require.Equal(t, SelectedModelTypeSmall, cfg.Routing.Model(ModelTaskAgenticFetch))
+}
+
+func TestConfig_UpdatePreferredModelRouted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := routingTestConfig(Routing{Coder: "openai/gpt-4.1"})
+	cfg.setDefaults(dir, "")
+	cfg.dataConfigDir = filepath.Join(dir, "config.json")
+	cfg.SetupAgents()
+
+	model := cfg.Models["openai/gpt-4.1"]
+	model.ReasoningEffort = "high"
+	require.NoError(t, cfg.UpdatePreferredModel(cfg.Agents[AgentCoder].Model, model))
+
+	data, err := os.ReadFile(cfg.dataConfigDir)
+	require.NoError(t, err)
+	var saved struct {
+		Models map[string]SelectedModel `json:"models"`
+	}
+	require.NoError(t, json.Unmarshal(data, &saved))
+	require.Equal(t, "high", saved.Models["openai/gpt-4.1"].ReasoningEffort)
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN cache_read_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_read_tokens >= 0);
+ALTER TABLE sessions ADD COLUMN cache_write_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_write_tokens >= 0);
+ALTER TABLE sessions ADD COLUMN cache_savings REAL NOT NULL DEFAULT 0.0;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN cache_savings;
+ALTER TABLE sessions DROP COLUMN cache_write_tokens;
+ALTER TABLE sessions DROP COLUMN cache_read_tokens;
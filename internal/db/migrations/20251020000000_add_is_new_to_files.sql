@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE files ADD COLUMN is_new INTEGER DEFAULT 0 NOT NULL;
+
+-- +goose Down
+ALTER TABLE files DROP COLUMN is_new;
@@ -33,7 +33,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, cache_read_tokens, cache_write_tokens, cache_savings
 `
 
 type CreateSessionParams struct {
@@ -68,6 +68,9 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.CacheReadTokens,
+		&i.CacheWriteTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
@@ -83,7 +86,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, cache_read_tokens, cache_write_tokens, cache_savings
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -102,12 +105,15 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.CacheReadTokens,
+		&i.CacheWriteTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, cache_read_tokens, cache_write_tokens, cache_savings
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY created_at DESC
@@ -133,6 +139,9 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.UpdatedAt,
 			&i.CreatedAt,
 			&i.SummaryMessageID,
+			&i.CacheReadTokens,
+			&i.CacheWriteTokens,
+			&i.CacheSavings,
 		); err != nil {
 			return nil, err
 		}
@@ -153,18 +162,24 @@ SET
     title = ?,
     prompt_tokens = ?,
     completion_tokens = ?,
+    cache_read_tokens = ?,
+    cache_write_tokens = ?,
     summary_message_id = ?,
-    cost = ?
+    cost = ?,
+    cache_savings = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, cache_read_tokens, cache_write_tokens, cache_savings
 `
 
 type UpdateSessionParams struct {
 	Title            string         `json:"title"`
 	PromptTokens     int64          `json:"prompt_tokens"`
 	CompletionTokens int64          `json:"completion_tokens"`
+	CacheReadTokens  int64          `json:"cache_read_tokens"`
+	CacheWriteTokens int64          `json:"cache_write_tokens"`
 	SummaryMessageID sql.NullString `json:"summary_message_id"`
 	Cost             float64        `json:"cost"`
+	CacheSavings     float64        `json:"cache_savings"`
 	ID               string         `json:"id"`
 }
 
@@ -173,8 +188,11 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.Title,
 		arg.PromptTokens,
 		arg.CompletionTokens,
+		arg.CacheReadTokens,
+		arg.CacheWriteTokens,
 		arg.SummaryMessageID,
 		arg.Cost,
+		arg.CacheSavings,
 		arg.ID,
 	)
 	var i Session
@@ -189,6 +207,9 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.CacheReadTokens,
+		&i.CacheWriteTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
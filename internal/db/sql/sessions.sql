@@ -40,8 +40,11 @@ SET
     title = ?,
     prompt_tokens = ?,
     completion_tokens = ?,
+    cache_read_tokens = ?,
+    cache_write_tokens = ?,
     summary_message_id = ?,
-    cost = ?
+    cost = ?,
+    cache_savings = ?
 WHERE id = ?
 RETURNING *;
 
@@ -0,0 +1,266 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"go.yaml.in/yaml/v4"
+	"gopkg.in/dnaeon/go-vcr.v4/pkg/cassette"
+)
+
+// CassetteMode is whether provider HTTP exchanges are recorded to a cassette
+// or replayed from it.
+type CassetteMode string
+
+const (
+	// CassetteRecord writes every exchange to the cassette, replacing its
+	// content.
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay serves the exchanges of the cassette without reaching
+	// the network.
+	CassetteReplay CassetteMode = "replay"
+)
+
+// cassetteHeaders are the headers kept in cassettes, others may hold
+// credentials.
+var cassetteHeaders = map[string]struct{}{
+	"accept":       {},
+	"content-type": {},
+	"user-agent":   {},
+}
+
+// CassetteTransport is an http.RoundTripper recording exchanges, streamed
+// responses included, to a cassette file or replaying them from it.
+type CassetteTransport struct {
+	// Transport performs the requests being recorded.
+	Transport http.RoundTripper
+
+	mode     CassetteMode
+	mu       sync.Mutex
+	cassette *cassette.Cassette
+	replayed []bool
+}
+
+// NewCassetteTransport opens the cassette at path, a YAML file, to record
+// exchanges to or replay them from.
+func NewCassetteTransport(mode CassetteMode, path string) (*CassetteTransport, error) {
+	name := strings.TrimSuffix(path, ".yaml")
+	t := &CassetteTransport{
+		Transport: http.DefaultTransport,
+		mode:      mode,
+	}
+	switch mode {
+	case CassetteRecord:
+		t.cassette = cassette.New(name)
+	case CassetteReplay:
+		c, err := cassette.Load(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cassette: %w", err)
+		}
+		t.cassette = c
+		t.replayed = make([]bool, len(c.Interactions))
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+	t.cassette.MarshalFunc = marshalCassette
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if t.mode == CassetteReplay {
+		return t.replay(req, body)
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		record: func(respBody []byte) {
+			t.record(req, body, resp, respBody)
+		},
+	}
+	return resp, nil
+}
+
+// replay serves the first exchange not replayed yet whose request has the
+// same body, or else the same model, as the request.
+func (t *CassetteTransport) replay(req *http.Request, body []byte) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reqURL := cassetteURL(req.URL)
+	model := gjson.GetBytes(body, "model").String()
+	match := -1
+	for i, interaction := range t.cassette.Interactions {
+		r := interaction.Request
+		if t.replayed[i] || r.Method != req.Method || r.URL != reqURL {
+			continue
+		}
+		if jsonEqual(body, []byte(r.Body)) {
+			match = i
+			break
+		}
+		if match == -1 && gjson.Get(r.Body, "model").String() == model {
+			match = i
+		}
+	}
+	if match == -1 {
+		return nil, fmt.Errorf("no recorded exchange matches %s %s", req.Method, reqURL)
+	}
+	if !jsonEqual(body, []byte(t.cassette.Interactions[match].Request.Body)) {
+		// Replays are only deterministic as long as the requests are the same,
+		// so make it visible when one isn't.
+		slog.Warn("Replaying exchange recorded with a different request body", "method", req.Method, "url", reqURL, "model", model, "id", match)
+	}
+
+	t.replayed[match] = true
+	resp, err := t.cassette.Interactions[match].GetHTTPResponse()
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
+
+// record adds the exchange to the cassette and saves it, so that an
+// interrupted session keeps the exchanges completed so far.
+func (t *CassetteTransport) record(req *http.Request, body []byte, resp *http.Response, respBody []byte) {
+	t.cassette.AddInteraction(&cassette.Interaction{
+		Request: cassette.Request{
+			Proto:      req.Proto,
+			ProtoMajor: req.ProtoMajor,
+			ProtoMinor: req.ProtoMinor,
+			Method:     req.Method,
+			URL:        cassetteURL(req.URL),
+			Headers:    cassetteHeadersOf(req.Header),
+			Body:       string(body),
+		},
+		Response: cassette.Response{
+			Status:        resp.Status,
+			Code:          resp.StatusCode,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			ContentLength: int64(len(respBody)),
+			Headers:       cassetteHeadersOf(resp.Header),
+			Body:          string(respBody),
+		},
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.cassette.Save(); err != nil {
+		slog.Error("Failed to save cassette", "file", t.cassette.File, "error", err)
+	}
+}
+
+// recordingBody keeps a copy of the response body as it is read and hands it
+// over once complete.
+type recordingBody struct {
+	io.ReadCloser
+	buf    bytes.Buffer
+	done   bool
+	record func([]byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close reads what is left of the body, as clients often stop at the last
+// event of a stream, and records the exchange when the body is complete.
+func (b *recordingBody) Close() error {
+	if !b.done {
+		if _, err := io.Copy(&b.buf, b.ReadCloser); err == nil {
+			b.finish()
+		} else {
+			slog.Debug("Response interrupted, not recording it", "error", err)
+		}
+	}
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	if b.done {
+		return
+	}
+	b.done = true
+	b.record(bytes.Clone(b.buf.Bytes()))
+}
+
+// cassetteURL returns the URL without the API key some providers pass as a
+// query parameter.
+func cassetteURL(u *url.URL) string {
+	query := u.Query()
+	if !query.Has("key") {
+		return u.String()
+	}
+	query.Del("key")
+	clean := *u
+	clean.RawQuery = query.Encode()
+	return clean.String()
+}
+
+// cassetteHeadersOf returns the headers that can be stored in cassettes.
+func cassetteHeadersOf(headers http.Header) http.Header {
+	kept := make(http.Header)
+	for key, values := range headers {
+		if _, ok := cassetteHeaders[strings.ToLower(key)]; ok {
+			kept[key] = values
+		}
+	}
+	return kept
+}
+
+// jsonEqual reports whether both bodies are the same, ignoring the order of
+// JSON object keys.
+func jsonEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func marshalCassette(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	enc.CompactSeqIndent()
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
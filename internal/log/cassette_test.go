@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func cassetteRequest(t *testing.T, client *http.Client, url, body string) string {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCassetteTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Set-Cookie", "session=secret")
+		for _, word := range strings.Fields(string(body)) {
+			_, _ = io.WriteString(w, "data: "+word+"\n\n")
+			w.(http.Flusher).Flush()
+		}
+	}))
+	path := filepath.Join(t.TempDir(), "cassettes", "session.yaml")
+
+	recorder, err := NewCassetteTransport(CassetteRecord, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: recorder}
+	first := cassetteRequest(t, client, server.URL+"/v1/messages?key=secret-key", `{"model": "large", "prompt": "hello world"}`)
+	second := cassetteRequest(t, client, server.URL+"/v1/messages?key=secret-key", `{"model": "small", "prompt": "title"}`)
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-token", "secret-key", "session=secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	replayer, err := NewCassetteTransport(CassetteReplay, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: replayer}
+	// Requests with a different body replay the exchange of the same model.
+	if got := cassetteRequest(t, client, server.URL+"/v1/messages?key=other-key", `{"model": "small", "prompt": "another title"}`); got != second {
+		t.Errorf("expected %q, got %q", second, got)
+	}
+	if got := cassetteRequest(t, client, server.URL+"/v1/messages", `{"prompt": "hello world", "model": "large"}`); got != first {
+		t.Errorf("expected %q, got %q", first, got)
+	}
+
+	// Each exchange is replayed once.
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL+"/v1/messages", strings.NewReader(`{"model": "large"}`))
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "no recorded exchange") {
+		t.Errorf("expected no recorded exchange error, got %v", err)
+	}
+}
+
+func TestCassetteTransportMissingCassette(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewCassetteTransport(CassetteReplay, filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing cassette")
+	}
+}
+
+func TestCassetteTransportChangedRequest(t *testing.T) {
+	// Not parallel: replaces the default logger.
+	var logs bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	}))
+	path := filepath.Join(t.TempDir(), "session.yaml")
+	recorder, err := NewCassetteTransport(CassetteRecord, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: recorder}
+	first := cassetteRequest(t, client, server.URL+"/v1/messages", `{"model": "large", "prompt": "monday"}`)
+	second := cassetteRequest(t, client, server.URL+"/v1/messages", `{"model": "large", "prompt": "tuesday"}`)
+	server.Close()
+
+	replayer, err := NewCassetteTransport(CassetteReplay, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: replayer}
+	if got := cassetteRequest(t, client, server.URL+"/v1/messages", `{"model": "large", "prompt": "monday"}`); got != first {
+		t.Errorf("expected %q, got %q", first, got)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected no warning for the same request, got %q", logs.String())
+	}
+
+	if got := cassetteRequest(t, client, server.URL+"/v1/messages", `{"model": "large", "prompt": "wednesday"}`); got != second {
+		t.Errorf("expected %q, got %q", second, got)
+	}
+	if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), "different request body") {
+		t.Errorf("expected a warning for the changed request, got %q", logs.String())
+	}
+}
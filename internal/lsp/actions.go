@@ -0,0 +1,232 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+const (
+	methodFormatting        = "textDocument/formatting"
+	methodCodeAction        = "textDocument/codeAction"
+	methodCodeActionResolve = "codeAction/resolve"
+	methodExecuteCommand    = "workspace/executeCommand"
+)
+
+// Format requests formatting edits for the whole file from the server.
+func (c *Client) Format(ctx context.Context, path string) ([]protocol.TextEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, path); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	params := protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(path)},
+		Options:      formattingOptions(content),
+	}
+	var edits []protocol.TextEdit
+	if err := c.call(ctx, methodFormatting, params, &edits); err != nil {
+		return nil, fmt.Errorf("formatting request failed: %w", err)
+	}
+	return edits, nil
+}
+
+// CodeActions requests the code actions available in the given range of a
+// file. Only actions of the given kinds are returned, or all of them if no
+// kinds are given. Diagnostics overlapping the range are sent along so the
+// server can offer quick-fixes for them.
+func (c *Client) CodeActions(ctx context.Context, path string, rng protocol.Range, kinds ...protocol.CodeActionKind) ([]protocol.CodeAction, error) {
+	if err := c.OpenFileOnDemand(ctx, path); err != nil {
+		return nil, err
+	}
+	uri := protocol.URIFromPath(path)
+	var diagnostics []protocol.Diagnostic
+	for _, diag := range c.GetFileDiagnostics(uri) {
+		if rangesIntersect(diag.Range, rng) {
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        kinds,
+		},
+	}
+	var raw []json.RawMessage
+	if err := c.call(ctx, methodCodeAction, params, &raw); err != nil {
+		return nil, fmt.Errorf("code action request failed: %w", err)
+	}
+	return parseCodeActions(raw)
+}
+
+// ApplyCodeAction resolves the given code action if needed and applies its
+// workspace edit and command.
+func (c *Client) ApplyCodeAction(ctx context.Context, action protocol.CodeAction) error {
+	if action.Edit == nil && action.Command == nil && action.Data != nil {
+		var resolved protocol.CodeAction
+		if err := c.call(ctx, methodCodeActionResolve, action, &resolved); err != nil {
+			return fmt.Errorf("code action resolve failed: %w", err)
+		}
+		action = resolved
+	}
+	if action.Edit != nil {
+		if err := util.ApplyWorkspaceEdit(*action.Edit); err != nil {
+			return err
+		}
+	}
+	if action.Command != nil {
+		// Servers usually answer commands with a workspace/applyEdit request,
+		// which is handled by HandleApplyEdit.
+		params := protocol.ExecuteCommandParams{
+			Command:   action.Command.Command,
+			Arguments: action.Command.Arguments,
+		}
+		if err := c.call(ctx, methodExecuteCommand, params, nil); err != nil {
+			return fmt.Errorf("execute command %s failed: %w", action.Command.Command, err)
+		}
+	}
+	return nil
+}
+
+// FormatOnEdit runs the formatting and code actions configured for this
+// server on a file that was just edited, applying the results to disk. It
+// returns a short description of each step that changed the file.
+func (c *Client) FormatOnEdit(ctx context.Context, path string) ([]string, error) {
+	if !c.config.FormatOnEdit && len(c.config.CodeActionsOnEdit) == 0 {
+		return nil, nil
+	}
+	if !c.HandlesFile(path) {
+		return nil, nil
+	}
+
+	var steps []string
+	if c.config.FormatOnEdit {
+		edits, err := c.Format(ctx, path)
+		if err != nil {
+			return steps, err
+		}
+		if len(edits) > 0 {
+			if err := util.ApplyTextEdits(protocol.URIFromPath(path), edits); err != nil {
+				return steps, fmt.Errorf("failed to apply formatting: %w", err)
+			}
+			if err := c.NotifyChange(ctx, path); err != nil {
+				return steps, err
+			}
+			steps = append(steps, "formatting")
+		}
+	}
+
+	for _, kind := range c.config.CodeActionsOnEdit {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return steps, fmt.Errorf("error reading file: %w", err)
+		}
+		actions, err := c.CodeActions(ctx, path, wholeDocument(content), protocol.CodeActionKind(kind))
+		if err != nil {
+			return steps, err
+		}
+		for _, action := range actions {
+			if action.Disabled != nil {
+				continue
+			}
+			if err := c.ApplyCodeAction(ctx, action); err != nil {
+				slog.Warn("Failed to apply code action", "name", c.name, "action", action.Title, "error", err)
+				continue
+			}
+			if err := c.NotifyChange(ctx, path); err != nil {
+				return steps, err
+			}
+			steps = append(steps, action.Title)
+		}
+	}
+	return steps, nil
+}
+
+// parseCodeActions decodes a textDocument/codeAction response, which may mix
+// CodeAction literals and bare Commands.
+func parseCodeActions(raw []json.RawMessage) ([]protocol.CodeAction, error) {
+	actions := make([]protocol.CodeAction, 0, len(raw))
+	for _, item := range raw {
+		var probe struct {
+			Command json.RawMessage `json:"command"`
+		}
+		if err := json.Unmarshal(item, &probe); err != nil {
+			return nil, fmt.Errorf("invalid code action: %w", err)
+		}
+		if len(probe.Command) > 0 && probe.Command[0] == '"' {
+			var cmd protocol.Command
+			if err := json.Unmarshal(item, &cmd); err != nil {
+				return nil, fmt.Errorf("invalid command: %w", err)
+			}
+			actions = append(actions, protocol.CodeAction{
+				Title:   cmd.Title,
+				Command: &cmd,
+			})
+			continue
+		}
+		var action protocol.CodeAction
+		if err := json.Unmarshal(item, &action); err != nil {
+			return nil, fmt.Errorf("invalid code action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// formattingOptions guesses the indentation style of the given content.
+func formattingOptions(content []byte) protocol.FormattingOptions {
+	opts := protocol.FormattingOptions{
+		TabSize:      4,
+		InsertSpaces: true,
+	}
+	for line := range bytes.Lines(content) {
+		if bytes.HasPrefix(line, []byte("\t")) {
+			opts.InsertSpaces = false
+			break
+		}
+		if bytes.HasPrefix(line, []byte("  ")) {
+			indent := len(line) - len(bytes.TrimLeft(line, " "))
+			if indent == 2 {
+				opts.TabSize = 2
+			}
+			break
+		}
+	}
+	return opts
+}
+
+// wholeDocument returns a range that spans the entire content.
+func wholeDocument(content []byte) protocol.Range {
+	lines := strings.Split(string(content), "\n")
+	return protocol.Range{
+		Start: protocol.Position{Line: 0, Character: 0},
+		End: protocol.Position{
+			Line:      uint32(len(lines) - 1),
+			Character: uint32(len(lines[len(lines)-1])),
+		},
+	}
+}
+
+func rangesIntersect(a, b protocol.Range) bool {
+	if a.End.Line < b.Start.Line || b.End.Line < a.Start.Line {
+		return false
+	}
+	if a.End.Line == b.Start.Line && a.End.Character < b.Start.Character {
+		return false
+	}
+	if b.End.Line == a.Start.Line && b.End.Character < a.Start.Character {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCodeActions(t *testing.T) {
+	t.Parallel()
+
+	raw := []json.RawMessage{
+		json.RawMessage(`{"title":"Organize Imports","kind":"source.organizeImports","edit":{"changes":{}}}`),
+		json.RawMessage(`{"title":"Run fix","command":"gopls.apply_fix","arguments":["x"]}`),
+		json.RawMessage(`{"title":"Fill struct","kind":"refactor.rewrite","command":{"title":"Fill","command":"gopls.fill"}}`),
+	}
+
+	actions, err := parseCodeActions(raw)
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+
+	require.Equal(t, "Organize Imports", actions[0].Title)
+	require.Equal(t, protocol.SourceOrganizeImports, actions[0].Kind)
+	require.NotNil(t, actions[0].Edit)
+	require.Nil(t, actions[0].Command)
+
+	require.Equal(t, "Run fix", actions[1].Title)
+	require.NotNil(t, actions[1].Command)
+	require.Equal(t, "gopls.apply_fix", actions[1].Command.Command)
+	require.Len(t, actions[1].Command.Arguments, 1)
+
+	require.Equal(t, "Fill struct", actions[2].Title)
+	require.NotNil(t, actions[2].Command)
+	require.Equal(t, "gopls.fill", actions[2].Command.Command)
+}
+
+func TestFormattingOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		content      string
+		tabSize      uint32
+		insertSpaces bool
+	}{
+		{"tabs", "func main() {\n\tprintln()\n}\n", 4, false},
+		{"two spaces", "a:\n  b: 1\n", 2, true},
+		{"four spaces", "def f():\n    pass\n", 4, true},
+		{"no indentation", "hello\n", 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts := formattingOptions([]byte(tt.content))
+			require.Equal(t, tt.tabSize, opts.TabSize)
+			require.Equal(t, tt.insertSpaces, opts.InsertSpaces)
+		})
+	}
+}
+
+func TestWholeDocument(t *testing.T) {
+	t.Parallel()
+
+	rng := wholeDocument([]byte("package main\n\nfunc main() {}"))
+	require.Equal(t, uint32(0), rng.Start.Line)
+	require.Equal(t, uint32(2), rng.End.Line)
+	require.Equal(t, uint32(len("func main() {}")), rng.End.Character)
+}
@@ -16,14 +16,19 @@ import (
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/home"
-	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+	"github.com/charmbracelet/crush/internal/version"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 	"github.com/charmbracelet/x/powernap/pkg/transport"
 )
 
 type Client struct {
-	client *powernap.Client
-	name   string
+	conn *serverConn
+	name string
+
+	// Server capabilities, set once initialized.
+	capabilities protocol.ServerCapabilities
+	initialized  atomic.Bool
+	shutdown     atomic.Bool
 
 	// File types this LSP server handles (e.g., .go, .rs, .py)
 	fileTypes []string
@@ -31,6 +36,10 @@ type Client struct {
 	// Configuration for this LSP client
 	config config.LSPConfig
 
+	// Workspace the server is started in.
+	rootURI string
+	workDir string
+
 	// Diagnostic change callback
 	onDiagnosticsChanged func(name string, count int)
 
@@ -44,7 +53,7 @@ type Client struct {
 	serverState atomic.Value
 }
 
-// New creates a new LSP client using the powernap implementation.
+// New starts the language server and returns a client connected to it.
 func New(ctx context.Context, name string, config config.LSPConfig, resolver config.VariableResolver) (*Client, error) {
 	// Convert working directory to file URI
 	workDir, err := os.Getwd()
@@ -59,35 +68,16 @@ func New(ctx context.Context, name string, config config.LSPConfig, resolver con
 		return nil, fmt.Errorf("invalid lsp command: %w", err)
 	}
 
-	// Create powernap client config
-	clientConfig := powernap.ClientConfig{
-		Command: home.Long(command),
-		Args:    config.Args,
-		RootURI: rootURI,
-		Environment: func() map[string]string {
-			env := make(map[string]string)
-			maps.Copy(env, config.Env)
-			return env
-		}(),
-		Settings:    config.Options,
-		InitOptions: config.InitOptions,
-		WorkspaceFolders: []protocol.WorkspaceFolder{
-			{
-				URI:  rootURI,
-				Name: filepath.Base(workDir),
-			},
-		},
-	}
-
-	// Create the powernap client
-	powernapClient, err := powernap.NewClient(clientConfig)
+	conn, err := startServer(name, home.Long(command), config.Args, config.Env)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create lsp client: %w", err)
 	}
 
 	client := &Client{
-		client:      powernapClient,
+		conn:        conn,
 		name:        name,
+		rootURI:     rootURI,
+		workDir:     workDir,
 		fileTypes:   config.FileTypes,
 		diagnostics: csync.NewVersionedMap[protocol.DocumentURI, []protocol.Diagnostic](),
 		openFiles:   csync.NewMap[string, *OpenFileInfo](),
@@ -102,30 +92,7 @@ func New(ctx context.Context, name string, config config.LSPConfig, resolver con
 
 // Initialize initializes the LSP client and returns the server capabilities.
 func (c *Client) Initialize(ctx context.Context, workspaceDir string) (*protocol.InitializeResult, error) {
-	if err := c.client.Initialize(ctx, false); err != nil {
-		return nil, fmt.Errorf("failed to initialize the lsp client: %w", err)
-	}
-
-	// Convert powernap capabilities to protocol capabilities
-	caps := c.client.GetCapabilities()
-	protocolCaps := protocol.ServerCapabilities{
-		TextDocumentSync: caps.TextDocumentSync,
-		CompletionProvider: func() *protocol.CompletionOptions {
-			if caps.CompletionProvider != nil {
-				return &protocol.CompletionOptions{
-					TriggerCharacters:   caps.CompletionProvider.TriggerCharacters,
-					AllCommitCharacters: caps.CompletionProvider.AllCommitCharacters,
-					ResolveProvider:     caps.CompletionProvider.ResolveProvider,
-				}
-			}
-			return nil
-		}(),
-	}
-
-	result := &protocol.InitializeResult{
-		Capabilities: protocolCaps,
-	}
-
+	// The server can send requests while it initializes.
 	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
 	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
 	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
@@ -134,7 +101,45 @@ func (c *Client) Initialize(ctx context.Context, workspaceDir string) (*protocol
 		HandleDiagnostics(c, params)
 	})
 
-	return result, nil
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"clientInfo": map[string]any{
+			"name":    "crush",
+			"version": version.Version,
+		},
+		"locale":       "en-us",
+		"rootPath":     c.workDir, // Deprecated but some servers still use it
+		"rootUri":      c.rootURI,
+		"capabilities": clientCapabilities(),
+		"workspaceFolders": []protocol.WorkspaceFolder{
+			{
+				URI:  c.rootURI,
+				Name: filepath.Base(c.workDir),
+			},
+		},
+		"initializationOptions": c.config.InitOptions,
+		"trace":                 "off",
+	}
+	var result protocol.InitializeResult
+	if err := c.conn.Call(ctx, "initialize", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to initialize the lsp client: %w", err)
+	}
+	c.capabilities = result.Capabilities
+	if err := c.conn.Notify(ctx, "initialized", map[string]any{}); err != nil {
+		return nil, fmt.Errorf("failed to initialize the lsp client: %w", err)
+	}
+	c.initialized.Store(true)
+
+	if strings.Contains(filepath.Base(c.conn.cmd.Path), "gopls") {
+		// gopls only sets up its workspace views once it has its settings
+		// and sees the workspace change.
+		_ = c.conn.Notify(ctx, "workspace/didChangeConfiguration", map[string]any{"settings": c.config.Options})
+		_ = c.conn.Notify(ctx, "workspace/didChangeWatchedFiles", protocol.DidChangeWatchedFilesParams{
+			Changes: []protocol.FileEvent{{URI: protocol.DocumentURI(c.rootURI), Type: protocol.Created}},
+		})
+	}
+
+	return &result, nil
 }
 
 // Close closes the LSP client.
@@ -145,12 +150,48 @@ func (c *Client) Close(ctx context.Context) error {
 
 	c.CloseAllFiles(ctx)
 
-	// Shutdown and exit the client
-	if err := c.client.Shutdown(ctx); err != nil {
-		slog.Warn("Failed to shutdown LSP client", "error", err)
+	// Shutdown and exit the server
+	if c.shutdown.CompareAndSwap(false, true) {
+		if err := c.conn.Call(ctx, "shutdown", nil, nil); err != nil {
+			slog.Warn("Failed to shutdown LSP client", "error", err)
+		}
+		if err := c.conn.Notify(ctx, "exit", nil); err != nil {
+			slog.Warn("Failed to exit LSP client", "error", err)
+		}
+	}
+	return c.conn.Close()
+}
+
+// IsRunning reports whether the server is initialized and still connected.
+func (c *Client) IsRunning() bool {
+	select {
+	case <-c.conn.Done():
+		return false
+	default:
+		return c.initialized.Load() && !c.shutdown.Load()
+	}
+}
+
+// Done returns a channel that is closed once the connection to the server is
+// lost, either because it was closed or because the server process exited.
+func (c *Client) Done() <-chan struct{} {
+	return c.conn.Done()
+}
+
+// call sends a request to the server and decodes the response into result.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("lsp server %s is not running", c.name)
 	}
+	return c.conn.Call(ctx, method, params, result)
+}
 
-	return c.client.Exit()
+// notify sends a notification to the server, once it's initialized.
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	if !c.initialized.Load() {
+		return fmt.Errorf("lsp server %s is not initialized", c.name)
+	}
+	return c.conn.Notify(ctx, method, params)
 }
 
 // ServerState represents the state of an LSP server
@@ -161,6 +202,11 @@ const (
 	StateReady
 	StateError
 	StateDisabled
+	// StateIdle means the server is not running but will be started as soon
+	// as a file it handles is used.
+	StateIdle
+	// StateStopped means the server was stopped by the user.
+	StateStopped
 )
 
 // GetServerState returns the current state of the LSP server
@@ -214,7 +260,7 @@ func (c *Client) WaitForServerReady(ctx context.Context) error {
 			return fmt.Errorf("timeout waiting for LSP server to be ready")
 		case <-ticker.C:
 			// Check if client is running
-			if !c.client.IsRunning() {
+			if !c.IsRunning() {
 				if cfg != nil && cfg.Options.DebugLSP {
 					slog.Debug("LSP server not ready yet", "server", c.name)
 				}
@@ -239,23 +285,29 @@ type OpenFileInfo struct {
 
 // HandlesFile checks if this LSP client handles the given file based on its extension.
 func (c *Client) HandlesFile(path string) bool {
+	handles := HandlesFileType(c.fileTypes, path)
+	slog.Debug("handles file", "name", c.name, "file", filepath.Base(path), "handles", handles)
+	return handles
+}
+
+// HandlesFileType checks if a server for the given file types handles the
+// given file based on its extension.
+func HandlesFileType(fileTypes []string, path string) bool {
 	// If no file types are specified, handle all files (backward compatibility)
-	if len(c.fileTypes) == 0 {
+	if len(fileTypes) == 0 {
 		return true
 	}
 
 	name := strings.ToLower(filepath.Base(path))
-	for _, filetype := range c.fileTypes {
+	for _, filetype := range fileTypes {
 		suffix := strings.ToLower(filetype)
 		if !strings.HasPrefix(suffix, ".") {
 			suffix = "." + suffix
 		}
 		if strings.HasSuffix(name, suffix) {
-			slog.Debug("handles file", "name", c.name, "file", name, "filetype", filetype)
 			return true
 		}
 	}
-	slog.Debug("doesn't handle file", "name", c.name, "file", name)
 	return false
 }
 
@@ -278,7 +330,14 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 	}
 
 	// Notify the server about the opened document
-	if err = c.client.NotifyDidOpenTextDocument(ctx, uri, string(DetectLanguageID(uri)), 1, string(content)); err != nil {
+	if err = c.notify(ctx, "textDocument/didOpen", protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:        protocol.DocumentURI(uri),
+			LanguageID: DetectLanguageID(uri),
+			Version:    1,
+			Text:       string(content),
+		},
+	}); err != nil {
 		return err
 	}
 
@@ -316,7 +375,15 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 		},
 	}
 
-	return c.client.NotifyDidChangeTextDocument(ctx, uri, int(fileInfo.Version), changes)
+	return c.notify(ctx, "textDocument/didChange", protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			Version: fileInfo.Version,
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+				URI: protocol.DocumentURI(uri),
+			},
+		},
+		ContentChanges: changes,
+	})
 }
 
 // IsFileOpen checks if a file is currently open.
@@ -334,7 +401,9 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 		if debugLSP {
 			slog.Debug("Closing file", "file", uri)
 		}
-		if err := c.client.NotifyDidCloseTextDocument(ctx, uri); err != nil {
+		if err := c.notify(ctx, "textDocument/didClose", protocol.DidCloseTextDocumentParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentURI(uri)},
+		}); err != nil {
 			slog.Warn("Error closing rile", "uri", uri, "error", err)
 			continue
 		}
@@ -391,17 +460,17 @@ func (c *Client) ClearDiagnosticsForURI(uri protocol.DocumentURI) {
 
 // RegisterNotificationHandler registers a notification handler.
 func (c *Client) RegisterNotificationHandler(method string, handler transport.NotificationHandler) {
-	c.client.RegisterNotificationHandler(method, handler)
+	c.conn.router.HandleNotification(method, handler)
 }
 
 // RegisterServerRequestHandler handles server requests.
 func (c *Client) RegisterServerRequestHandler(method string, handler transport.Handler) {
-	c.client.RegisterHandler(method, handler)
+	c.conn.router.Handle(method, handler)
 }
 
 // DidChangeWatchedFiles sends a workspace/didChangeWatchedFiles notification to the server.
 func (c *Client) DidChangeWatchedFiles(ctx context.Context, params protocol.DidChangeWatchedFilesParams) error {
-	return c.client.NotifyDidChangeWatchedFiles(ctx, params.Changes)
+	return c.notify(ctx, "workspace/didChangeWatchedFiles", params)
 }
 
 // openKeyConfigFiles opens important configuration files that help initialize the server.
@@ -452,7 +521,21 @@ func (c *Client) FindReferences(ctx context.Context, filepath string, line, char
 	}
 	// NOTE: line and character should be 0-based.
 	// See: https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#position
-	return c.client.FindReferences(ctx, filepath, line-1, character-1, includeDeclaration)
+	var locations []protocol.Location
+	err := c.call(ctx, "textDocument/references", protocol.ReferenceParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(character - 1),
+			},
+		},
+		Context: protocol.ReferenceContext{IncludeDeclaration: includeDeclaration},
+	}, &locations)
+	if err != nil {
+		return nil, fmt.Errorf("find references request failed: %w", err)
+	}
+	return locations, nil
 }
 
 // HasRootMarkers checks if any of the specified root marker patterns exist in the given directory.
@@ -470,3 +553,93 @@ func HasRootMarkers(dir string, rootMarkers []string) bool {
 	}
 	return false
 }
+
+// clientCapabilities returns the capabilities Crush advertises to servers.
+func clientCapabilities() map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{
+			"synchronization": map[string]any{
+				"dynamicRegistration": true,
+				"didSave":             true,
+			},
+			"hover": map[string]any{
+				"dynamicRegistration": true,
+				"contentFormat":       []string{"markdown", "plaintext"},
+			},
+			"definition": map[string]any{
+				"dynamicRegistration": true,
+				"linkSupport":         true,
+			},
+			"references": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"documentSymbol": map[string]any{
+				"dynamicRegistration":               true,
+				"hierarchicalDocumentSymbolSupport": true,
+			},
+			"formatting": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"publishDiagnostics": map[string]any{
+				"relatedInformation":     true,
+				"versionSupport":         true,
+				"tagSupport":             map[string]any{"valueSet": []int{1, 2}},
+				"codeDescriptionSupport": true,
+				"dataSupport":            true,
+			},
+			"codeAction": map[string]any{
+				"dynamicRegistration": true,
+				"codeActionLiteralSupport": map[string]any{
+					"codeActionKind": map[string]any{
+						"valueSet": []string{
+							"quickfix",
+							"refactor",
+							"refactor.extract",
+							"refactor.inline",
+							"refactor.rewrite",
+							"source",
+							"source.organizeImports",
+						},
+					},
+				},
+				"isPreferredSupport": true,
+				"dataSupport":        true,
+				"resolveSupport": map[string]any{
+					"properties": []string{"edit"},
+				},
+			},
+		},
+		"workspace": map[string]any{
+			"applyEdit": true,
+			"workspaceEdit": map[string]any{
+				"documentChanges":       true,
+				"resourceOperations":    []string{"create", "rename", "delete"},
+				"failureHandling":       "textOnlyTransactional",
+				"normalizesLineEndings": true,
+			},
+			"didChangeConfiguration": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"didChangeWatchedFiles": map[string]any{
+				"dynamicRegistration":    true,
+				"relativePatternSupport": true,
+			},
+			"symbol": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"configuration":    true,
+			"workspaceFolders": true,
+		},
+		"window": map[string]any{
+			"workDoneProgress": true,
+			"showMessage": map[string]any{
+				"messageActionItem": map[string]any{
+					"additionalPropertiesSupport": true,
+				},
+			},
+		},
+		"general": map[string]any{
+			"positionEncodings": []string{"utf-16"},
+		},
+	}
+}
@@ -55,3 +55,25 @@ func TestClient(t *testing.T) {
 		t.Logf("Close failed as expected with dummy command: %v", err)
 	}
 }
+
+func TestHandlesFileType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fileTypes []string
+		path      string
+		want      bool
+	}{
+		{nil, "main.go", true},
+		{[]string{"go", "mod"}, "/src/main.go", true},
+		{[]string{"go", "mod"}, "go.mod", true},
+		{[]string{".ts"}, "index.TS", true},
+		{[]string{"go"}, "main.py", false},
+		{[]string{"go"}, "mango", false},
+	}
+	for _, tt := range tests {
+		if got := HandlesFileType(tt.fileTypes, tt.path); got != tt.want {
+			t.Errorf("HandlesFileType(%v, %q) = %v, want %v", tt.fileTypes, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/powernap/pkg/transport"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// exitTimeout is how long a server has to exit once its connection is
+// closed before it is killed.
+const exitTimeout = 5 * time.Second
+
+// serverConn is the JSON-RPC connection to a language server process. Crush
+// starts the process itself, rather than through powernap, so it can send
+// any request and knows when the server goes away.
+type serverConn struct {
+	cmd    *exec.Cmd
+	rpc    *jsonrpc2.Conn
+	router *transport.Router
+	// exited is closed once the process has exited.
+	exited chan struct{}
+}
+
+// startServer starts the language server and connects to it over its
+// standard input and output.
+func startServer(name, command string, args []string, env map[string]string) (*serverConn, error) {
+	cmd := exec.Command(command, args...)
+	if len(env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	cmd.Stderr = &stderrLogger{name: name}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start language server: %w", err)
+	}
+
+	s := &serverConn{
+		cmd:    cmd,
+		router: transport.NewRouter(),
+		exited: make(chan struct{}),
+	}
+	stream := jsonrpc2.NewBufferedStream(&stdio{stdout: stdout, stdin: stdin}, jsonrpc2.VSCodeObjectCodec{})
+	s.rpc = jsonrpc2.NewConn(context.Background(), stream, jsonrpc2.HandlerWithError(s.handle))
+
+	go func() {
+		// The connection is lost once the server closes its output, and
+		// the process can only be waited for after it's fully read.
+		<-s.rpc.DisconnectNotify()
+		if err := cmd.Wait(); err != nil {
+			slog.Warn("Language server exited with error", "name", name, "error", err)
+		} else {
+			slog.Info("Language server exited", "name", name)
+		}
+		close(s.exited)
+	}()
+	return s, nil
+}
+
+func (s *serverConn) handle(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+	if req.Params == nil {
+		params := json.RawMessage("null")
+		req.Params = &params
+	}
+	return s.router.Route(ctx, req)
+}
+
+// Call sends a request to the server and decodes the response into result.
+func (s *serverConn) Call(ctx context.Context, method string, params, result any) error {
+	return s.rpc.Call(ctx, method, params, result)
+}
+
+// Notify sends a notification to the server.
+func (s *serverConn) Notify(ctx context.Context, method string, params any) error {
+	return s.rpc.Notify(ctx, method, params)
+}
+
+// Done returns a channel that is closed once the connection to the server is
+// lost, either because it was closed or because the server process exited.
+func (s *serverConn) Done() <-chan struct{} {
+	return s.rpc.DisconnectNotify()
+}
+
+// Close closes the connection and waits for the server to exit, killing it
+// if it doesn't in time.
+func (s *serverConn) Close() error {
+	err := s.rpc.Close()
+	if errors.Is(err, jsonrpc2.ErrClosed) {
+		err = nil
+	}
+	select {
+	case <-s.exited:
+	case <-time.After(exitTimeout):
+		if killErr := s.cmd.Process.Kill(); killErr != nil {
+			err = errors.Join(err, killErr)
+		}
+		<-s.exited
+	}
+	return err
+}
+
+// stdio is the stream to the server over its standard input and output.
+type stdio struct {
+	stdout io.ReadCloser
+	stdin  io.WriteCloser
+}
+
+func (s *stdio) Read(p []byte) (int, error) {
+	return s.stdout.Read(p)
+}
+
+func (s *stdio) Write(p []byte) (int, error) {
+	return s.stdin.Write(p)
+}
+
+func (s *stdio) Close() error {
+	return errors.Join(s.stdin.Close(), s.stdout.Close())
+}
+
+// stderrLogger logs what the server writes to its standard error, line by
+// line.
+type stderrLogger struct {
+	name string
+	mu   sync.Mutex
+	buf  []byte
+}
+
+func (l *stderrLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := bytes.TrimSpace(l.buf[:i]); len(line) > 0 {
+			slog.Debug("Language server stderr", "name", l.name, "output", string(line))
+		}
+		l.buf = l.buf[i+1:]
+	}
+	return len(p), nil
+}
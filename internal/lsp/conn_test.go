@@ -0,0 +1,101 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/require"
+)
+
+// testServerEnv makes the test binary act as a language server.
+const testServerEnv = "CRUSH_TEST_LSP_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testServerEnv) == "1" {
+		serveTestServer()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// serveTestServer echoes requests, notifies the client on "ping" and exits
+// on "crash".
+func serveTestServer() {
+	stream := jsonrpc2.NewBufferedStream(&stdio{stdout: os.Stdin, stdin: os.Stdout}, jsonrpc2.VSCodeObjectCodec{})
+	conn := jsonrpc2.NewConn(context.Background(), stream, jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "crash":
+			os.Exit(1)
+		case "ping":
+			return nil, conn.Notify(ctx, "pong", req.Params)
+		}
+		return req.Params, nil
+	}))
+	<-conn.DisconnectNotify()
+}
+
+func startTestServer(t *testing.T) *serverConn {
+	t.Helper()
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	s, err := startServer("test", exe, nil, map[string]string{testServerEnv: "1"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestServerConn(t *testing.T) {
+	t.Parallel()
+
+	t.Run("calls and notifications", func(t *testing.T) {
+		t.Parallel()
+		s := startTestServer(t)
+
+		var echoed map[string]string
+		require.NoError(t, s.Call(t.Context(), "echo", map[string]string{"a": "b"}, &echoed))
+		require.Equal(t, map[string]string{"a": "b"}, echoed)
+
+		pong := make(chan json.RawMessage, 1)
+		s.router.HandleNotification("pong", func(_ context.Context, _ string, params json.RawMessage) {
+			pong <- params
+		})
+		require.NoError(t, s.Call(t.Context(), "ping", []int{1}, nil))
+		select {
+		case params := <-pong:
+			require.JSONEq(t, "[1]", string(params))
+		case <-time.After(5 * time.Second):
+			t.Fatal("no notification from the server")
+		}
+	})
+
+	t.Run("server exit", func(t *testing.T) {
+		t.Parallel()
+		s := startTestServer(t)
+
+		_ = s.Call(t.Context(), "crash", nil, nil)
+		select {
+		case <-s.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("the connection is still up after the server exited")
+		}
+		<-s.exited
+		require.Error(t, s.Call(t.Context(), "echo", nil, nil))
+	})
+
+	t.Run("close", func(t *testing.T) {
+		t.Parallel()
+		s := startTestServer(t)
+
+		require.NoError(t, s.Close())
+		select {
+		case <-s.Done():
+		default:
+			t.Fatal("the connection is still up after closing it")
+		}
+		<-s.exited
+	})
+}
@@ -78,6 +78,26 @@ func notifyFileWatchRegistration(id string, watchers []protocol.FileSystemWatche
 	}
 }
 
+// FileAccessHandler is a function that will be called before a file is used
+// with the LSP clients, so servers can be started on demand
+type FileAccessHandler func(ctx context.Context, path string)
+
+// fileAccessHandler holds the current handler for file accesses
+var fileAccessHandler FileAccessHandler
+
+// RegisterFileAccessHandler sets the handler for file accesses
+func RegisterFileAccessHandler(handler FileAccessHandler) {
+	fileAccessHandler = handler
+}
+
+// NotifyFileAccess notifies the handler that a file is about to be used with
+// the LSP clients
+func NotifyFileAccess(ctx context.Context, path string) {
+	if fileAccessHandler != nil {
+		fileAccessHandler(ctx, path)
+	}
+}
+
 // HandleServerMessage handles server messages
 func HandleServerMessage(_ context.Context, method string, params json.RawMessage) {
 	cfg := config.Get()
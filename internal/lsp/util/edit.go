@@ -10,7 +10,8 @@ import (
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 )
 
-func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
+// ApplyTextEdits applies the given text edits to the file at uri.
+func ApplyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	path, err := uri.Path()
 	if err != nil {
 		return fmt.Errorf("invalid URI: %w", err)
@@ -222,7 +223,7 @@ func applyDocumentChange(change protocol.DocumentChange) error {
 				return fmt.Errorf("invalid edit type: %w", err)
 			}
 		}
-		return applyTextEdits(change.TextDocumentEdit.TextDocument.URI, textEdits)
+		return ApplyTextEdits(change.TextDocumentEdit.TextDocument.URI, textEdits)
 	}
 
 	return nil
@@ -232,7 +233,7 @@ func applyDocumentChange(change protocol.DocumentChange) error {
 func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
 	// Handle Changes field
 	for uri, textEdits := range edit.Changes {
-		if err := applyTextEdits(uri, textEdits); err != nil {
+		if err := ApplyTextEdits(uri, textEdits); err != nil {
 			return fmt.Errorf("failed to apply text edits: %w", err)
 		}
 	}
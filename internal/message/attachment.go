@@ -1,8 +1,41 @@
 package message
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Attachment struct {
 	FilePath string
 	FileName string
 	MimeType string
 	Content  []byte
 }
+
+// IsText reports whether the attachment holds text, which is sent to the
+// model inline with the prompt rather than as a file.
+func (a Attachment) IsText() bool {
+	return isTextMimeType(a.MimeType)
+}
+
+// PromptWithAttachments appends the content of the text attachments to the
+// prompt.
+func PromptWithAttachments(prompt string, attachments []Attachment) string {
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	for _, attachment := range attachments {
+		if attachment.IsText() {
+			sb.WriteString("\n\n")
+			sb.WriteString(formatTextAttachment(attachment.FilePath, attachment.Content))
+		}
+	}
+	return sb.String()
+}
+
+func isTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/")
+}
+
+func formatTextAttachment(path string, content []byte) string {
+	return fmt.Sprintf("<attachment path=%q>\n%s\n</attachment>", path, content)
+}
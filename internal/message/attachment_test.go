@@ -0,0 +1,42 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentIsText(t *testing.T) {
+	t.Parallel()
+
+	for mimeType, want := range map[string]bool{
+		"text/plain":       true,
+		"text/markdown":    true,
+		"image/png":        false,
+		"application/json": false,
+		"":                 false,
+	} {
+		require.Equal(t, want, Attachment{MimeType: mimeType}.IsText(), mimeType)
+	}
+}
+
+func TestPromptWithAttachments(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no attachments", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "hello", PromptWithAttachments("hello", nil))
+	})
+
+	t.Run("only text attachments are inlined", func(t *testing.T) {
+		t.Parallel()
+		got := PromptWithAttachments("explain these", []Attachment{
+			{FilePath: "notes.md", MimeType: "text/markdown", Content: []byte("# Notes")},
+			{FilePath: "logo.png", MimeType: "image/png", Content: []byte{0x89, 'P', 'N', 'G'}},
+			{FilePath: "mcp://docs/readme", MimeType: "text/plain", Content: []byte("read me")},
+		})
+		require.Equal(t, "explain these\n\n"+
+			"<attachment path=\"notes.md\">\n# Notes\n</attachment>\n\n"+
+			"<attachment path=\"mcp://docs/readme\">\nread me\n</attachment>", got)
+	})
+}
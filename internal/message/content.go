@@ -434,6 +434,10 @@ func (m *Message) ToAIMessage() []fantasy.Message {
 			parts = append(parts, fantasy.TextPart{Text: text})
 		}
 		for _, content := range m.BinaryContent() {
+			if isTextMimeType(content.MIMEType) {
+				parts = append(parts, fantasy.TextPart{Text: formatTextAttachment(content.Path, content.Data)})
+				continue
+			}
 			parts = append(parts, fantasy.FilePart{
 				Filename:  content.Path,
 				Data:      content.Data,
@@ -47,10 +47,13 @@ type Service interface {
 	GrantPersistent(permission PermissionRequest)
 	Grant(permission PermissionRequest)
 	Deny(permission PermissionRequest)
+	Modify(permission PermissionRequest, content string)
+	ModifiedContent(toolCallID string) (string, bool)
 	Request(opts CreatePermissionRequest) bool
 	AutoApproveSession(sessionID string)
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
+	SetAllowedTools(allowedTools []string)
 	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
 }
 
@@ -62,6 +65,7 @@ type permissionService struct {
 	sessionPermissions    []PermissionRequest
 	sessionPermissionsMu  sync.RWMutex
 	pendingRequests       *csync.Map[string, chan bool]
+	modifiedContents      *csync.Map[string, string]
 	autoApproveSessions   map[string]bool
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
@@ -122,6 +126,19 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 	}
 }
 
+// Modify records the content the user changed while reviewing the
+// permission, e.g. by rejecting some hunks of an edit, for the tool to write
+// instead of the one it proposed. Call it before granting the permission.
+func (s *permissionService) Modify(permission PermissionRequest, content string) {
+	s.modifiedContents.Set(permission.ToolCallID, content)
+}
+
+// ModifiedContent returns the content the user changed for the tool call, if
+// any, and forgets it.
+func (s *permissionService) ModifiedContent(toolCallID string) (string, bool) {
+	return s.modifiedContents.Take(toolCallID)
+}
+
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	if s.skip {
 		return true
@@ -220,6 +237,13 @@ func (s *permissionService) SkipRequests() bool {
 	return s.skip
 }
 
+// SetAllowedTools replaces the tools that don't require permission prompts.
+func (s *permissionService) SetAllowedTools(allowedTools []string) {
+	s.requestMu.Lock()
+	defer s.requestMu.Unlock()
+	s.allowedTools = allowedTools
+}
+
 func NewPermissionService(workingDir string, skip bool, allowedTools []string) Service {
 	return &permissionService{
 		Broker:              pubsub.NewBroker[PermissionRequest](),
@@ -230,5 +254,6 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string) S
 		skip:                skip,
 		allowedTools:        allowedTools,
 		pendingRequests:     csync.NewMap[string, chan bool](),
+		modifiedContents:    csync.NewMap[string, string](),
 	}
 }
@@ -0,0 +1,451 @@
+// Package plugin implements providers served by an external executable or
+// a local HTTP adapter, to integrate gateways with their own authentication,
+// request signing or response shaping.
+//
+// For every call, Crush writes a [Request] as a single JSON object to the
+// standard input of the executable, then closes it, or posts it to the
+// adapter URL. The executable writes the response to its standard output, or
+// the adapter in the body of its response, as [Event] objects, one JSON
+// object per line:
+//
+//	{"type":"reasoning_delta","delta":"The user wants"}
+//	{"type":"text_delta","delta":"Let me look at the file."}
+//	{"type":"tool_call","id":"call_1","name":"view","input":"{\"file_path\":\"main.go\"}"}
+//	{"type":"finish","finish_reason":"tool-calls","usage":{"input_tokens":1200,"output_tokens":40}}
+//
+// The response ends with a finish event, or an error event with a message.
+// An executable exiting with an error status before the finish event fails
+// the call with its standard error. The API key of the provider is passed in
+// the CRUSH_API_KEY environment variable to executables and as a bearer
+// token to adapters.
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"charm.land/fantasy"
+)
+
+// Name is the provider type of plugins.
+const Name = "plugin"
+
+// APIKeyEnv is the environment variable holding the API key for executables.
+const APIKeyEnv = "CRUSH_API_KEY"
+
+// maxEventSize bounds the size of a response line.
+const maxEventSize = 16 << 20
+
+// Options configures a plugin provider.
+type Options struct {
+	// ID of the provider, reported as the provider of its models.
+	ID string
+	// Command is the executable serving the models, with its arguments and
+	// extra environment.
+	Command string
+	Args    []string
+	Env     []string
+	// URL is the address of the HTTP adapter, used when Command is empty.
+	URL     string
+	Headers map[string]string
+	APIKey  string
+	// Client sends the requests to the adapter.
+	Client *http.Client
+}
+
+// ProviderOptions are the options of the provider and model configuration,
+// sent as the options of the request.
+type ProviderOptions map[string]any
+
+// Options implements fantasy.ProviderOptionsData.
+func (ProviderOptions) Options() {}
+
+func (o ProviderOptions) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any(o))
+}
+
+func (o *ProviderOptions) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*map[string]any)(o))
+}
+
+type provider struct {
+	opts Options
+}
+
+// New returns a provider whose models are served by the executable or the
+// adapter of the options.
+func New(opts Options) (fantasy.Provider, error) {
+	if opts.Command == "" && opts.URL == "" {
+		return nil, errors.New("plugin provider needs a command or a URL")
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &provider{opts: opts}, nil
+}
+
+func (p *provider) Name() string {
+	return Name
+}
+
+func (p *provider) LanguageModel(_ context.Context, modelID string) (fantasy.LanguageModel, error) {
+	return &languageModel{provider: p, id: modelID}, nil
+}
+
+type languageModel struct {
+	provider *provider
+	id       string
+}
+
+func (m *languageModel) Provider() string {
+	return cmp.Or(m.provider.opts.ID, Name)
+}
+
+func (m *languageModel) Model() string {
+	return m.id
+}
+
+func (m *languageModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	stream, err := m.Stream(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	resp := &fantasy.Response{}
+	var text, reasoning strings.Builder
+	for part := range stream {
+		switch part.Type {
+		case fantasy.StreamPartTypeTextDelta:
+			text.WriteString(part.Delta)
+		case fantasy.StreamPartTypeReasoningDelta:
+			reasoning.WriteString(part.Delta)
+		case fantasy.StreamPartTypeToolCall:
+			resp.Content = append(resp.Content, fantasy.ToolCallContent{
+				ToolCallID: part.ID,
+				ToolName:   part.ToolCallName,
+				Input:      part.ToolCallInput,
+			})
+		case fantasy.StreamPartTypeFinish:
+			resp.FinishReason = part.FinishReason
+			resp.Usage = part.Usage
+		case fantasy.StreamPartTypeError:
+			return nil, part.Error
+		}
+	}
+	if reasoning.Len() > 0 {
+		resp.Content = append(fantasy.ResponseContent{fantasy.ReasoningContent{Text: reasoning.String()}}, resp.Content...)
+	}
+	if text.Len() > 0 {
+		resp.Content = append(resp.Content, fantasy.TextContent{Text: text.String()})
+	}
+	return resp, nil
+}
+
+func (m *languageModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	req, err := newRequest(m.id, call)
+	if err != nil {
+		return nil, err
+	}
+	body, err := m.provider.open(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		readEvents(body, yield)
+	}, nil
+}
+
+func (m *languageModel) GenerateObject(context.Context, fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return nil, errors.New("plugin providers do not support object generation")
+}
+
+func (m *languageModel) StreamObject(context.Context, fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return nil, errors.New("plugin providers do not support object generation")
+}
+
+// open sends the request to the executable or the adapter and returns the
+// response lines. Closing the body reports how the executable exited.
+func (p *provider) open(ctx context.Context, req Request) (io.ReadCloser, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if p.opts.Command != "" {
+		return p.run(ctx, data)
+	}
+	return p.post(ctx, data)
+}
+
+func (p *provider) run(ctx context.Context, data []byte) (io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, p.opts.Command, p.opts.Args...)
+	cmd.Env = append(os.Environ(), p.opts.Env...)
+	cmd.Env = append(cmd.Env, APIKeyEnv+"="+p.opts.APIKey)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	stderr := &limitedBuffer{max: 4096}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", p.opts.Command, err)
+	}
+	return &processBody{ReadCloser: stdout, cmd: cmd, stderr: stderr}, nil
+}
+
+func (p *provider) post(ctx context.Context, data []byte) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.opts.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range p.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/x-ndjson")
+	if p.opts.APIKey != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+p.opts.APIKey)
+	}
+	resp, err := p.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &fantasy.ProviderError{
+			Title:      "plugin adapter error",
+			Message:    strings.TrimSpace(string(msg)),
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return resp.Body, nil
+}
+
+// processBody is the standard output of the executable.
+type processBody struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *limitedBuffer
+}
+
+// Close drains the output left and waits for the executable, reporting its
+// standard error when it failed. The output must be drained first, as the
+// executable can't exit while blocked writing it.
+func (b *processBody) Close() error {
+	_, _ = io.Copy(io.Discard, b.ReadCloser)
+	err := b.cmd.Wait()
+	if err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(b.stderr.String()); msg != "" {
+		return fmt.Errorf("plugin %s: %w: %s", b.cmd.Path, err, msg)
+	}
+	return fmt.Errorf("plugin %s: %w", b.cmd.Path, err)
+}
+
+// limitedBuffer keeps the first bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// readEvents turns the response lines into stream parts, opening and closing
+// the text and reasoning blocks around their deltas.
+func readEvents(body io.ReadCloser, yield func(fantasy.StreamPart) bool) {
+	var (
+		open      fantasy.StreamPartType
+		blocks    int
+		toolCalls bool
+		finished  bool
+	)
+	// closeBlock ends the open text or reasoning block.
+	closeBlock := func() bool {
+		id := strconv.Itoa(blocks)
+		switch open {
+		case fantasy.StreamPartTypeTextStart:
+			open = ""
+			return yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextEnd, ID: id})
+		case fantasy.StreamPartTypeReasoningStart:
+			open = ""
+			return yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeReasoningEnd, ID: id})
+		}
+		return true
+	}
+	// delta appends to the block of the kind, opening it first if needed.
+	delta := func(start, deltaType fantasy.StreamPartType, text string) bool {
+		if open != start {
+			if !closeBlock() {
+				return false
+			}
+			blocks++
+			open = start
+			if !yield(fantasy.StreamPart{Type: start, ID: strconv.Itoa(blocks)}) {
+				return false
+			}
+		}
+		return yield(fantasy.StreamPart{Type: deltaType, ID: strconv.Itoa(blocks), Delta: text})
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	ok := true
+	for ok && !finished && scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			_ = body.Close()
+			yield(errorPart(fmt.Errorf("invalid plugin event %q: %w", line, err)))
+			return
+		}
+		switch ev.Type {
+		case EventTextDelta:
+			ok = delta(fantasy.StreamPartTypeTextStart, fantasy.StreamPartTypeTextDelta, ev.Delta)
+		case EventReasoningDelta:
+			ok = delta(fantasy.StreamPartTypeReasoningStart, fantasy.StreamPartTypeReasoningDelta, ev.Delta)
+		case EventToolCall:
+			toolCalls = true
+			ok = closeBlock() && yield(fantasy.StreamPart{
+				Type:          fantasy.StreamPartTypeToolCall,
+				ID:            ev.ID,
+				ToolCallName:  ev.Name,
+				ToolCallInput: cmp.Or(ev.Input, "{}"),
+			})
+		case EventFinish:
+			finished = true
+			reason := fantasy.FinishReason(ev.FinishReason)
+			if reason == "" {
+				reason = fantasy.FinishReasonStop
+				if toolCalls {
+					reason = fantasy.FinishReasonToolCalls
+				}
+			}
+			ok = closeBlock() && yield(fantasy.StreamPart{
+				Type:         fantasy.StreamPartTypeFinish,
+				FinishReason: reason,
+				Usage:        ev.Usage.fantasy(),
+			})
+		case EventError:
+			_ = body.Close()
+			yield(errorPart(errors.New(cmp.Or(ev.Message, "plugin error"))))
+			return
+		}
+	}
+	scanErr := scanner.Err()
+	closeErr := body.Close()
+	if !ok || finished {
+		return
+	}
+	err := cmp.Or(scanErr, closeErr, errors.New("plugin response ended without a finish event"))
+	yield(errorPart(err))
+}
+
+func errorPart(err error) fantasy.StreamPart {
+	return fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: err}
+}
+
+func (u Usage) fantasy() fantasy.Usage {
+	return fantasy.Usage{
+		InputTokens:         u.InputTokens,
+		OutputTokens:        u.OutputTokens,
+		TotalTokens:         u.InputTokens + u.OutputTokens,
+		ReasoningTokens:     u.ReasoningTokens,
+		CacheCreationTokens: u.CacheCreationTokens,
+		CacheReadTokens:     u.CacheReadTokens,
+	}
+}
+
+// newRequest converts the call to the request of the protocol.
+func newRequest(model string, call fantasy.Call) (Request, error) {
+	req := Request{
+		Version:          ProtocolVersion,
+		Model:            model,
+		MaxOutputTokens:  call.MaxOutputTokens,
+		Temperature:      call.Temperature,
+		TopP:             call.TopP,
+		TopK:             call.TopK,
+		PresencePenalty:  call.PresencePenalty,
+		FrequencyPenalty: call.FrequencyPenalty,
+	}
+	if call.ToolChoice != nil {
+		req.ToolChoice = string(*call.ToolChoice)
+	}
+	if opts, ok := call.ProviderOptions[Name].(*ProviderOptions); ok {
+		req.Options = *opts
+	}
+	for _, tool := range call.Tools {
+		fn, ok := tool.(fantasy.FunctionTool)
+		if !ok {
+			continue
+		}
+		schema, err := json.Marshal(fn.InputSchema)
+		if err != nil {
+			return Request{}, err
+		}
+		req.Tools = append(req.Tools, Tool{Name: fn.Name, Description: fn.Description, InputSchema: schema})
+	}
+	for _, msg := range call.Prompt {
+		m := Message{Role: string(msg.Role)}
+		for _, part := range msg.Content {
+			switch p := part.(type) {
+			case fantasy.TextPart:
+				m.Content = append(m.Content, Part{Type: PartText, Text: p.Text})
+			case fantasy.ReasoningPart:
+				m.Content = append(m.Content, Part{Type: PartReasoning, Text: p.Text})
+			case fantasy.FilePart:
+				m.Content = append(m.Content, Part{Type: PartFile, Filename: p.Filename, MediaType: p.MediaType, Data: p.Data})
+			case fantasy.ToolCallPart:
+				m.Content = append(m.Content, Part{Type: PartToolCall, ID: p.ToolCallID, Name: p.ToolName, Input: p.Input})
+			case fantasy.ToolResultPart:
+				m.Content = append(m.Content, toolResult(p))
+			}
+		}
+		req.Messages = append(req.Messages, m)
+	}
+	return req, nil
+}
+
+func toolResult(p fantasy.ToolResultPart) Part {
+	part := Part{Type: PartToolResult, ID: p.ToolCallID}
+	switch out := p.Output.(type) {
+	case fantasy.ToolResultOutputContentText:
+		part.Output = out.Text
+	case fantasy.ToolResultOutputContentError:
+		part.IsError = true
+		if out.Error != nil {
+			part.Output = out.Error.Error()
+		}
+	case fantasy.ToolResultOutputContentMedia:
+		// The data is already base64 encoded, as it is in the protocol.
+		data, err := base64.StdEncoding.DecodeString(out.Data)
+		if err != nil {
+			part.IsError = true
+			part.Output = fmt.Sprintf("invalid media data: %v", err)
+			break
+		}
+		part.MediaType = out.MediaType
+		part.Data = data
+	}
+	return part
+}
@@ -0,0 +1,247 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// The test binary doubles as the plugin executable when helperEnv is set.
+const helperEnv = "CRUSH_PLUGIN_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(helperEnv) {
+	case "":
+		os.Exit(m.Run())
+	case "echo":
+		var req Request
+		if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		writeEvents(os.Stdout, req, os.Getenv(APIKeyEnv))
+	case "trailing":
+		var req Request
+		if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		writeEvents(os.Stdout, req, "")
+		// More than fits in the pipe, after the finish event.
+		_, _ = os.Stdout.Write(bytes.Repeat([]byte("\n"), 1<<20))
+	case "fail":
+		fmt.Fprintln(os.Stderr, "gateway refused the request")
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+// writeEvents answers with the text of the last message, the API key as
+// reasoning and a tool call.
+func writeEvents(w io.Writer, req Request, apiKey string) {
+	last := req.Messages[len(req.Messages)-1]
+	enc := json.NewEncoder(w)
+	_ = enc.Encode(Event{Type: EventReasoningDelta, Delta: "key " + apiKey})
+	_ = enc.Encode(Event{Type: EventTextDelta, Delta: req.Model + ": "})
+	_ = enc.Encode(Event{Type: EventTextDelta, Delta: last.Content[0].Text})
+	_ = enc.Encode(Event{Type: EventToolCall, ID: "call_1", Name: "view", Input: `{"file_path":"main.go"}`})
+	_ = enc.Encode(Event{Type: EventFinish, Usage: Usage{InputTokens: 10, OutputTokens: 5}})
+}
+
+func testCall() fantasy.Call {
+	return fantasy.Call{
+		Prompt: fantasy.Prompt{
+			fantasy.NewSystemMessage("You are a helpful assistant."),
+			fantasy.NewUserMessage("hello"),
+		},
+		Tools: []fantasy.Tool{fantasy.FunctionTool{
+			Name:        "view",
+			Description: "View a file",
+			InputSchema: map[string]any{"type": "object"},
+		}},
+		ProviderOptions: fantasy.ProviderOptions{
+			Name: &ProviderOptions{"region": "eu"},
+		},
+	}
+}
+
+func collect(t *testing.T, stream fantasy.StreamResponse) []fantasy.StreamPart {
+	t.Helper()
+	var parts []fantasy.StreamPart
+	for part := range stream {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+func partTypes(parts []fantasy.StreamPart) []fantasy.StreamPartType {
+	types := make([]fantasy.StreamPartType, 0, len(parts))
+	for _, part := range parts {
+		types = append(types, part.Type)
+	}
+	return types
+}
+
+func TestCommandStream(t *testing.T) {
+	t.Setenv(helperEnv, "echo")
+
+	provider, err := New(Options{ID: "gateway", Command: os.Args[0], APIKey: "secret"})
+	require.NoError(t, err)
+	model, err := provider.LanguageModel(t.Context(), "gateway-large")
+	require.NoError(t, err)
+	require.Equal(t, "gateway", model.Provider())
+
+	stream, err := model.Stream(t.Context(), testCall())
+	require.NoError(t, err)
+	parts := collect(t, stream)
+
+	require.Equal(t, []fantasy.StreamPartType{
+		fantasy.StreamPartTypeReasoningStart,
+		fantasy.StreamPartTypeReasoningDelta,
+		fantasy.StreamPartTypeReasoningEnd,
+		fantasy.StreamPartTypeTextStart,
+		fantasy.StreamPartTypeTextDelta,
+		fantasy.StreamPartTypeTextDelta,
+		fantasy.StreamPartTypeTextEnd,
+		fantasy.StreamPartTypeToolCall,
+		fantasy.StreamPartTypeFinish,
+	}, partTypes(parts))
+	require.Equal(t, "key secret", parts[1].Delta)
+	require.Equal(t, "hello", parts[5].Delta)
+	require.Equal(t, "view", parts[7].ToolCallName)
+	finish := parts[8]
+	require.Equal(t, fantasy.FinishReasonToolCalls, finish.FinishReason)
+	require.Equal(t, int64(15), finish.Usage.TotalTokens)
+}
+
+func TestCommandFailure(t *testing.T) {
+	t.Setenv(helperEnv, "fail")
+
+	provider, err := New(Options{Command: os.Args[0]})
+	require.NoError(t, err)
+	model, err := provider.LanguageModel(t.Context(), "gateway-large")
+	require.NoError(t, err)
+
+	_, err = model.Generate(t.Context(), testCall())
+	require.ErrorContains(t, err, "gateway refused the request")
+}
+
+func TestCommandTrailingOutput(t *testing.T) {
+	t.Setenv(helperEnv, "trailing")
+
+	provider, err := New(Options{Command: os.Args[0]})
+	require.NoError(t, err)
+	model, err := provider.LanguageModel(t.Context(), "gateway-large")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	resp, err := model.Generate(ctx, testCall())
+	require.NoError(t, err)
+	require.NoError(t, ctx.Err(), "output after the finish event shouldn't keep the plugin from exiting")
+	require.Equal(t, "gateway-large: hello", resp.Content.Text())
+}
+
+func TestToolResult(t *testing.T) {
+	t.Parallel()
+
+	png := []byte{0x89, 'P', 'N', 'G'}
+	part := toolResult(fantasy.ToolResultPart{
+		ToolCallID: "call_1",
+		Output: fantasy.ToolResultOutputContentMedia{
+			Data:      base64.StdEncoding.EncodeToString(png),
+			MediaType: "image/png",
+		},
+	})
+	require.Equal(t, Part{Type: PartToolResult, ID: "call_1", MediaType: "image/png", Data: png}, part)
+
+	data, err := json.Marshal(part)
+	require.NoError(t, err)
+	var wire struct {
+		Data string `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(data, &wire))
+	require.Equal(t, base64.StdEncoding.EncodeToString(png), wire.Data, "media should be base64 encoded once")
+
+	part = toolResult(fantasy.ToolResultPart{
+		ToolCallID: "call_2",
+		Output:     fantasy.ToolResultOutputContentMedia{Data: "not base64!", MediaType: "image/png"},
+	})
+	require.True(t, part.IsError)
+	require.Contains(t, part.Output, "invalid media data")
+	require.Empty(t, part.Data)
+
+	part = toolResult(fantasy.ToolResultPart{
+		ToolCallID: "call_3",
+		Output:     fantasy.ToolResultOutputContentText{Text: "done"},
+	})
+	require.Equal(t, Part{Type: PartToolResult, ID: "call_3", Output: "done"}, part)
+}
+
+func TestAdapterGenerate(t *testing.T) {
+	t.Parallel()
+
+	var got Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Team") != "platform" {
+			http.Error(w, "missing team header", http.StatusForbidden)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		writeEvents(w, got, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}))
+	defer server.Close()
+
+	provider, err := New(Options{URL: server.URL, APIKey: "secret", Headers: map[string]string{"X-Team": "platform"}})
+	require.NoError(t, err)
+	model, err := provider.LanguageModel(t.Context(), "gateway-small")
+	require.NoError(t, err)
+
+	resp, err := model.Generate(t.Context(), testCall())
+	require.NoError(t, err)
+	require.Equal(t, "gateway-small: hello", resp.Content.Text())
+	require.Equal(t, "key secret", resp.Content.ReasoningText())
+	require.Len(t, resp.Content.ToolCalls(), 1)
+	require.Equal(t, fantasy.FinishReasonToolCalls, resp.FinishReason)
+
+	require.Equal(t, ProtocolVersion, got.Version)
+	require.Equal(t, "eu", got.Options["region"])
+	require.Len(t, got.Messages, 2)
+	require.Equal(t, "system", got.Messages[0].Role)
+	require.Len(t, got.Tools, 1)
+	require.JSONEq(t, `{"type":"object"}`, string(got.Tools[0].InputSchema))
+}
+
+func TestAdapterError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Event{Type: EventTextDelta, Delta: "partial"})
+		_ = json.NewEncoder(w).Encode(Event{Type: EventError, Message: "upstream timed out"})
+	}))
+	defer server.Close()
+
+	provider, err := New(Options{URL: server.URL})
+	require.NoError(t, err)
+	model, err := provider.LanguageModel(t.Context(), "gateway-small")
+	require.NoError(t, err)
+
+	_, err = model.Generate(t.Context(), testCall())
+	require.EqualError(t, err, "upstream timed out")
+}
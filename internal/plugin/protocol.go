@@ -0,0 +1,94 @@
+package plugin
+
+import "encoding/json"
+
+// ProtocolVersion is the version of the protocol, sent with every request.
+const ProtocolVersion = 1
+
+// Request is the call to the model, written to the standard input of the
+// plugin or posted to the adapter.
+type Request struct {
+	Version          int            `json:"version"`
+	Model            string         `json:"model"`
+	Messages         []Message      `json:"messages"`
+	Tools            []Tool         `json:"tools,omitempty"`
+	ToolChoice       string         `json:"tool_choice,omitempty"`
+	MaxOutputTokens  *int64         `json:"max_output_tokens,omitempty"`
+	Temperature      *float64       `json:"temperature,omitempty"`
+	TopP             *float64       `json:"top_p,omitempty"`
+	TopK             *int64         `json:"top_k,omitempty"`
+	PresencePenalty  *float64       `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64       `json:"frequency_penalty,omitempty"`
+	Options          map[string]any `json:"options,omitempty"`
+}
+
+// Message is a message of the conversation, with the system, user,
+// assistant or tool role.
+type Message struct {
+	Role    string `json:"role"`
+	Content []Part `json:"content"`
+}
+
+// Part types.
+const (
+	PartText       = "text"
+	PartReasoning  = "reasoning"
+	PartFile       = "file"
+	PartToolCall   = "tool_call"
+	PartToolResult = "tool_result"
+)
+
+// Part is a part of a message. Text and reasoning parts have a text, file
+// parts a base64 encoded data and a media type, tool calls an id, a name and
+// a JSON input, and tool results the id of their call and an output.
+type Part struct {
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	Filename  string `json:"filename,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      []byte `json:"data,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Input     string `json:"input,omitempty"`
+	Output    string `json:"output,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+// Tool is a tool the model can call.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// Event types.
+const (
+	EventTextDelta      = "text_delta"
+	EventReasoningDelta = "reasoning_delta"
+	EventToolCall       = "tool_call"
+	EventFinish         = "finish"
+	EventError          = "error"
+)
+
+// Event is a line of the response, one JSON object per line. Deltas append
+// to the text or reasoning of the response, tool calls are sent whole, and
+// the response ends with a finish or an error event.
+type Event struct {
+	Type         string `json:"type"`
+	Delta        string `json:"delta,omitempty"`
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Input        string `json:"input,omitempty"`
+	FinishReason string `json:"finish_reason,omitempty"`
+	Usage        Usage  `json:"usage,omitzero"`
+	Message      string `json:"message,omitempty"`
+}
+
+// Usage is the token usage of the call, reported with the finish event.
+type Usage struct {
+	InputTokens         int64 `json:"input_tokens,omitempty"`
+	OutputTokens        int64 `json:"output_tokens,omitempty"`
+	ReasoningTokens     int64 `json:"reasoning_tokens,omitempty"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int64 `json:"cache_read_tokens,omitempty"`
+}
@@ -0,0 +1,224 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// fileName is the encrypted file secrets are stored in.
+	fileName = "secrets.enc"
+	// keyFileName is the file holding the key of the encrypted file, when
+	// no passphrase is given.
+	keyFileName = "secrets.key"
+	// PassphraseEnv is the environment variable holding the passphrase the
+	// key of the encrypted file is derived from. Without it, a random key is
+	// kept next to the file, which only protects the secrets from being read
+	// with the configuration files.
+	PassphraseEnv = "CRUSH_SECRETS_PASSPHRASE"
+	// keySize is the size of the AES-256 key.
+	keySize = 32
+	// pbkdf2Iterations is the number of PBKDF2-SHA256 iterations deriving
+	// the key from the passphrase.
+	pbkdf2Iterations = 600_000
+)
+
+// encryptedFile is the content of the encrypted file.
+type encryptedFile struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// file stores secrets in a file encrypted with AES-256-GCM.
+type file struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFile returns a store keeping secrets in an encrypted file in dir.
+func NewFile(dir string) Store {
+	return &file{dir: dir}
+}
+
+func (f *file) Name() string {
+	return "encrypted secrets file"
+}
+
+func (f *file) Get(name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secrets, _, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *file) Set(name, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secrets, salt, err := f.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return f.save(secrets, salt)
+}
+
+func (f *file) Delete(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secrets, salt, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return nil
+	}
+	delete(secrets, name)
+	return f.save(secrets, salt)
+}
+
+// load decrypts the secrets, returning an empty map when there is no file
+// yet.
+func (f *file) load() (map[string]string, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(f.dir, fileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var enc encryptedFile
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+	aead, err := f.cipher(enc.Salt, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := aead.Open(nil, enc.Nonce, enc.Ciphertext, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt secrets file, check %s: %w", PassphraseEnv, err)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+	return secrets, enc.Salt, nil
+}
+
+// save encrypts the secrets with a new nonce.
+func (f *file) save(secrets map[string]string, salt []byte) error {
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(f.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	aead, err := f.cipher(salt, true)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.Marshal(encryptedFile{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed write does not lose the
+	// existing secrets.
+	path := filepath.Join(f.dir, fileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+// cipher returns the AES-GCM cipher of the file, deriving the key from the
+// passphrase or reading it from the key file, which is created when create
+// is set.
+func (f *file) cipher(salt []byte, create bool) (cipher.AEAD, error) {
+	var key []byte
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		var err error
+		key, err = pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, keySize)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		key, err = f.key(create)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// key reads the random key of the file, creating it when asked to.
+func (f *file) key(create bool) ([]byte, error) {
+	path := filepath.Join(f.dir, keyFileName)
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("invalid secrets key file %s", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, fmt.Errorf("failed to read secrets key file: %w", err)
+	}
+
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write secrets key file: %w", err)
+	}
+	return key, nil
+}
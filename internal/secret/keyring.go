@@ -0,0 +1,180 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// keyringTimeout bounds the time the keyring tools can take, which includes
+// the user unlocking the keyring.
+const keyringTimeout = time.Minute
+
+const (
+	// securityItemNotFound is the exit code of security when the item is
+	// missing, errSecItemNotFound.
+	securityItemNotFound = 44
+	// securityMaxCommand is the length of the longest command security reads
+	// in interactive mode.
+	securityMaxCommand = 4096
+)
+
+// runFunc runs a command with the given input and returns its output. It
+// fails with a *toolError when the command exits with an error.
+type runFunc func(ctx context.Context, stdin string, name string, args ...string) (string, error)
+
+// keyring stores secrets in the system keyring with its command line tool:
+// secret-tool for the Secret Service on Linux and the BSDs, security for the
+// macOS keychain.
+type keyring struct {
+	tool string
+	run  runFunc
+}
+
+// newKeyring returns the system keyring, and whether one is available.
+func newKeyring() (Store, bool) {
+	var tool string
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "windows":
+		return nil, false
+	default:
+		// The Secret Service is reached over the session bus.
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return nil, false
+		}
+		tool = "secret-tool"
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, false
+	}
+	return &keyring{tool: tool, run: run}, true
+}
+
+func (k *keyring) Name() string {
+	return "system keyring"
+}
+
+func (k *keyring) Get(name string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringTimeout)
+	defer cancel()
+
+	var (
+		out string
+		err error
+	)
+	if k.tool == "security" {
+		out, err = k.run(ctx, "", k.tool, "find-generic-password", "-s", service, "-a", name, "-w")
+	} else {
+		out, err = k.run(ctx, "", k.tool, "lookup", "service", service, "account", name)
+	}
+	out = strings.TrimSuffix(out, "\n")
+	if k.isNotFound(err) || (err == nil && out == "") {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func (k *keyring) Set(name, value string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringTimeout)
+	defer cancel()
+
+	var err error
+	if k.tool == "security" {
+		// Arguments are visible to other processes, so the secret goes through
+		// the standard input of the interactive mode, hex encoded to keep it
+		// out of the quoting of the command.
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", quote(service), quote(name), hex.EncodeToString([]byte(value)))
+		if len(command) > securityMaxCommand {
+			return errors.New("secret too long for the keychain")
+		}
+		_, err = k.run(ctx, command, k.tool, "-i")
+	} else {
+		_, err = k.run(ctx, value, k.tool, "store", "--label=Crush: "+name, "service", service, "account", name)
+	}
+	return err
+}
+
+func (k *keyring) Delete(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringTimeout)
+	defer cancel()
+
+	var err error
+	if k.tool == "security" {
+		_, err = k.run(ctx, "", k.tool, "delete-generic-password", "-s", service, "-a", name)
+	} else {
+		_, err = k.run(ctx, "", k.tool, "clear", "service", service, "account", name)
+	}
+	if k.tool == "security" && k.isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// isNotFound reports whether the tool failed because the secret is missing:
+// security exits with errSecItemNotFound, and secret-tool exits with 1
+// without any message.
+func (k *keyring) isNotFound(err error) bool {
+	var toolErr *toolError
+	if !errors.As(err, &toolErr) {
+		return false
+	}
+	if k.tool == "security" {
+		return toolErr.code == securityItemNotFound
+	}
+	return toolErr.code == 1 && toolErr.stderr == ""
+}
+
+// quote quotes s for the interactive mode of security, which splits commands
+// like a shell.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// toolError is the failure of a keyring tool.
+type toolError struct {
+	tool string
+	// code is the exit code, -1 when the tool did not exit.
+	code   int
+	stderr string
+	err    error
+}
+
+func (e *toolError) Error() string {
+	if e.stderr != "" {
+		return fmt.Sprintf("%s: %v: %s", e.tool, e.err, e.stderr)
+	}
+	return fmt.Sprintf("%s: %v", e.tool, e.err)
+}
+
+func (e *toolError) Unwrap() error {
+	return e.err
+}
+
+func run(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		toolErr := &toolError{tool: name, code: -1, stderr: strings.TrimSpace(stderr.String()), err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			toolErr.code = exitErr.ExitCode()
+		}
+		return "", toolErr
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,114 @@
+// Package secret stores credentials, such as provider API keys, outside of
+// the configuration files: in the system keyring, or in an encrypted file
+// when there is none. Configuration values reference them as secret:<name>.
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Prefix starts configuration values referencing a secret.
+const Prefix = "secret:"
+
+// Kinds of stores.
+const (
+	// KindAuto uses the system keyring when available, the encrypted file
+	// otherwise.
+	KindAuto = "auto"
+	// KindKeyring uses the system keyring.
+	KindKeyring = "keyring"
+	// KindFile uses an encrypted file in the data directory.
+	KindFile = "file"
+)
+
+// service is the name secrets are stored under in the system keyring.
+const service = "crush"
+
+// ErrNotFound is returned when a secret is not in the store.
+var ErrNotFound = errors.New("secret not found")
+
+// Store stores secrets by name.
+type Store interface {
+	// Name describes where secrets are stored, for the user.
+	Name() string
+	// Get returns the secret, or ErrNotFound.
+	Get(name string) (string, error)
+	// Set stores the secret, replacing any previous value.
+	Set(name, value string) error
+	// Delete removes the secret. Deleting a missing secret is not an error.
+	Delete(name string) error
+}
+
+// New returns the store of the given kind. The file store keeps its files in
+// dir.
+func New(kind, dir string) (Store, error) {
+	switch kind {
+	case "", KindAuto:
+		if s, ok := newKeyring(); ok {
+			return s, nil
+		}
+		return NewFile(dir), nil
+	case KindKeyring:
+		if s, ok := newKeyring(); ok {
+			return s, nil
+		}
+		return nil, errors.New("no system keyring available")
+	case KindFile:
+		return NewFile(dir), nil
+	}
+	return nil, fmt.Errorf("unknown secret store %q, expected one of auto, keyring or file", kind)
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultStore Store
+)
+
+// SetDefault sets the store secret references are resolved from.
+func SetDefault(s Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = s
+}
+
+// Default returns the store secret references are resolved from, or nil
+// when none was set.
+func Default() Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+// Ref returns the configuration value referencing the secret.
+func Ref(name string) string {
+	return Prefix + name
+}
+
+// ParseRef returns the name of the secret the value references, and whether
+// it references one.
+func ParseRef(value string) (string, bool) {
+	name, ok := strings.CutPrefix(value, Prefix)
+	if !ok || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// Resolve returns the secret from the default store.
+func Resolve(name string) (string, error) {
+	s := Default()
+	if s == nil {
+		return "", fmt.Errorf("cannot read secret %q: no secret store configured", name)
+	}
+	value, err := s.Get(name)
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("secret %q not found in the %s", name, s.Name())
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %q: %w", name, err)
+	}
+	return value, nil
+}
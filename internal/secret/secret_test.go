@@ -0,0 +1,157 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRef(t *testing.T) {
+	t.Parallel()
+
+	name, ok := ParseRef(Ref("openai-api-key"))
+	require.True(t, ok)
+	require.Equal(t, "openai-api-key", name)
+
+	_, ok = ParseRef("secret:")
+	require.False(t, ok)
+	_, ok = ParseRef("$OPENAI_API_KEY")
+	require.False(t, ok)
+}
+
+func TestFile(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	dir := t.TempDir()
+	s := NewFile(dir)
+
+	_, err := s.Get("missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Set("a", "one"))
+	require.NoError(t, s.Set("b", "two"))
+	require.NoError(t, s.Set("a", "three"))
+
+	// Another store reads the same file.
+	value, err := NewFile(dir).Get("a")
+	require.NoError(t, err)
+	require.Equal(t, "three", value)
+
+	data, err := os.ReadFile(filepath.Join(dir, fileName))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "three")
+
+	require.NoError(t, s.Delete("a"))
+	require.NoError(t, s.Delete("a"))
+	_, err = s.Get("a")
+	require.ErrorIs(t, err, ErrNotFound)
+	value, err = s.Get("b")
+	require.NoError(t, err)
+	require.Equal(t, "two", value)
+}
+
+func TestFilePassphrase(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(PassphraseEnv, "correct horse")
+	require.NoError(t, NewFile(dir).Set("a", "one"))
+	require.NoFileExists(t, filepath.Join(dir, keyFileName))
+
+	value, err := NewFile(dir).Get("a")
+	require.NoError(t, err)
+	require.Equal(t, "one", value)
+
+	t.Setenv(PassphraseEnv, "battery staple")
+	_, err = NewFile(dir).Get("a")
+	require.ErrorContains(t, err, "failed to decrypt")
+}
+
+func TestKeyring(t *testing.T) {
+	t.Parallel()
+
+	secrets := map[string]string{}
+	fake := func(_ context.Context, stdin string, name string, args ...string) (string, error) {
+		require.Equal(t, "secret-tool", name)
+		account := args[len(args)-1]
+		switch args[0] {
+		case "store":
+			secrets[account] = stdin
+		case "lookup":
+			value, ok := secrets[account]
+			if !ok {
+				return "", &toolError{tool: name, code: 1}
+			}
+			return value + "\n", nil
+		case "clear":
+			delete(secrets, account)
+		}
+		return "", nil
+	}
+	k := &keyring{tool: "secret-tool", run: fake}
+
+	_, err := k.Get("a")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, k.Set("a", "one"))
+	value, err := k.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, "one", value)
+	require.NoError(t, k.Delete("a"))
+	require.Empty(t, secrets)
+}
+
+func TestKeyringSecurity(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	fake := func(_ context.Context, stdin string, name string, args ...string) (string, error) {
+		require.Equal(t, "security", name)
+		calls = append(calls, append([]string{stdin}, args...))
+		switch args[0] {
+		case "find-generic-password":
+			if args[len(args)-2] == "missing" {
+				return "", &toolError{tool: name, code: securityItemNotFound}
+			}
+			return "", &toolError{tool: name, code: 51, stderr: "User interaction is not allowed."}
+		case "delete-generic-password":
+			return "", &toolError{tool: name, code: securityItemNotFound}
+		}
+		return "", nil
+	}
+	k := &keyring{tool: "security", run: fake}
+
+	// The secret is passed on the standard input, never as an argument.
+	require.NoError(t, k.Set("it's", "sk-secret"))
+	require.Equal(t, []string{
+		"add-generic-password -U -s 'crush' -a 'it'\"'\"'s' -X 736b2d736563726574\n",
+		"-i",
+	}, calls[0])
+	require.ErrorContains(t, k.Set("a", strings.Repeat("x", securityMaxCommand)), "too long")
+
+	_, err := k.Get("missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = k.Get("locked")
+	require.NotErrorIs(t, err, ErrNotFound)
+	require.ErrorContains(t, err, "User interaction is not allowed")
+
+	require.NoError(t, k.Delete("missing"))
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	t.Cleanup(func() { SetDefault(nil) })
+
+	_, err := Resolve("a")
+	require.ErrorContains(t, err, "no secret store configured")
+
+	s := NewFile(t.TempDir())
+	SetDefault(s)
+	require.NoError(t, s.Set("a", "one"))
+	value, err := Resolve("a")
+	require.NoError(t, err)
+	require.Equal(t, "one", value)
+
+	_, err = Resolve("b")
+	require.ErrorContains(t, err, "not found")
+}
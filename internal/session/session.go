@@ -19,10 +19,17 @@ type Session struct {
 	MessageCount     int64
 	PromptTokens     int64
 	CompletionTokens int64
+	// CacheReadTokens and CacheWriteTokens are the prompt tokens the last
+	// call read from and wrote to the cache of the provider.
+	CacheReadTokens  int64
+	CacheWriteTokens int64
 	SummaryMessageID string
 	Cost             float64
-	CreatedAt        int64
-	UpdatedAt        int64
+	// CacheSavings is what prompt caching saved over the session, negative
+	// while the cache writes cost more than the cache reads saved.
+	CacheSavings float64
+	CreatedAt    int64
+	UpdatedAt    int64
 }
 
 type Service interface {
@@ -116,11 +123,14 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 		Title:            session.Title,
 		PromptTokens:     session.PromptTokens,
 		CompletionTokens: session.CompletionTokens,
+		CacheReadTokens:  session.CacheReadTokens,
+		CacheWriteTokens: session.CacheWriteTokens,
 		SummaryMessageID: sql.NullString{
 			String: session.SummaryMessageID,
 			Valid:  session.SummaryMessageID != "",
 		},
-		Cost: session.Cost,
+		Cost:         session.Cost,
+		CacheSavings: session.CacheSavings,
 	})
 	if err != nil {
 		return Session{}, err
@@ -150,8 +160,11 @@ func (s service) fromDBItem(item db.Session) Session {
 		MessageCount:     item.MessageCount,
 		PromptTokens:     item.PromptTokens,
 		CompletionTokens: item.CompletionTokens,
+		CacheReadTokens:  item.CacheReadTokens,
+		CacheWriteTokens: item.CacheWriteTokens,
 		SummaryMessageID: item.SummaryMessageID.String,
 		Cost:             item.Cost,
+		CacheSavings:     item.CacheSavings,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
 	}
@@ -0,0 +1,216 @@
+// Package tokenizer approximates the tokenizers of the main model families,
+// to count tokens locally before anything is sent to the provider.
+//
+// Text is split the way byte pair encoding and SentencePiece tokenizers
+// pre-tokenize it, into words, numbers, punctuation, whitespace and CJK
+// characters, and each piece is counted with the parameters of the family.
+package tokenizer
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	_ "image/gif"  // Decode the size of GIF images.
+	_ "image/jpeg" // Decode the size of JPEG images.
+	_ "image/png"  // Decode the size of PNG images.
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+// Family is a family of models sharing a tokenizer.
+type Family string
+
+const (
+	// FamilyOpenAI is the o200k and cl100k byte pair encodings of GPT
+	// models.
+	FamilyOpenAI Family = "openai"
+	// FamilyAnthropic is the byte pair encoding of Claude models.
+	FamilyAnthropic Family = "anthropic"
+	// FamilyGemini is the SentencePiece tokenizer of Gemini and Gemma
+	// models.
+	FamilyGemini Family = "gemini"
+	// FamilyLlama is the byte pair encoding of Llama, Qwen, Mistral and
+	// DeepSeek models, used for the models of unknown families.
+	FamilyLlama Family = "llama"
+)
+
+// params are the counts of characters per token of a family.
+type params struct {
+	// wordHead is the number of letters of a word its first token covers,
+	// the space before the word included.
+	wordHead int
+	// wordTail is the number of letters per token past the first one.
+	wordTail float64
+	// digits is the number of digits per token.
+	digits int
+	// punctuation is the number of punctuation characters per token.
+	punctuation int
+	// cjk is the number of tokens per CJK character.
+	cjk float64
+	// other is the number of bytes per token of other non-ASCII text.
+	other float64
+}
+
+var families = map[Family]params{
+	FamilyOpenAI:    {wordHead: 7, wordTail: 4, digits: 3, punctuation: 2, cjk: 0.8, other: 3},
+	FamilyAnthropic: {wordHead: 6, wordTail: 3.5, digits: 2, punctuation: 2, cjk: 1.2, other: 2.5},
+	FamilyGemini:    {wordHead: 7, wordTail: 4, digits: 1, punctuation: 2, cjk: 0.8, other: 3},
+	FamilyLlama:     {wordHead: 6, wordTail: 4, digits: 3, punctuation: 2, cjk: 1, other: 2.5},
+}
+
+// messageOverhead is the number of tokens marking the role and the
+// boundaries of a message.
+const messageOverhead = 4
+
+// Tokenizer counts tokens like the tokenizer of a family of models.
+type Tokenizer struct {
+	Family Family
+	params params
+}
+
+// New returns the tokenizer of the family, the Llama one for unknown
+// families.
+func New(family Family) Tokenizer {
+	p, ok := families[family]
+	if !ok {
+		family = FamilyLlama
+		p = families[family]
+	}
+	return Tokenizer{Family: family, params: p}
+}
+
+// ForModel returns the tokenizer of the model, by the type of its provider
+// or, for providers serving models of several families, by its ID.
+func ForModel(providerType catwalk.Type, modelID string) Tokenizer {
+	switch providerType {
+	case catwalk.TypeAnthropic, catwalk.TypeBedrock:
+		return New(FamilyAnthropic)
+	case catwalk.TypeOpenAI, catwalk.TypeAzure:
+		return New(FamilyOpenAI)
+	case catwalk.TypeGoogle, catwalk.TypeVertexAI:
+		return New(FamilyGemini)
+	}
+	id := strings.ToLower(modelID)
+	switch {
+	case strings.Contains(id, "claude"):
+		return New(FamilyAnthropic)
+	case strings.Contains(id, "gpt"), strings.Contains(id, "openai/"),
+		strings.HasPrefix(id, "o1"), strings.HasPrefix(id, "o3"), strings.HasPrefix(id, "o4"):
+		return New(FamilyOpenAI)
+	case strings.Contains(id, "gemini"), strings.Contains(id, "gemma"):
+		return New(FamilyGemini)
+	}
+	return New(FamilyLlama)
+}
+
+// Count returns the number of tokens of the text.
+func (t Tokenizer) Count(text string) int64 {
+	var tokens float64
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == ' ' && i+1 < len(text) && isASCIILetter(rune(text[i+1])):
+			// The space is part of the word that follows.
+			i++
+		case isASCIILetter(r):
+			n := run(text[i:], isASCIILetter)
+			tokens += 1 + math.Ceil(float64(max(n-t.params.wordHead, 0))/t.params.wordTail)
+			i += n
+		case r >= '0' && r <= '9':
+			n := run(text[i:], func(r rune) bool { return r >= '0' && r <= '9' })
+			tokens += math.Ceil(float64(n) / float64(t.params.digits))
+			i += n
+		case r == '\n' || r == '\r':
+			i += run(text[i:], func(r rune) bool { return r == '\n' || r == '\r' })
+			tokens++
+		case r == ' ' || r == '\t':
+			// Indentation comes in runs of a few spaces per token.
+			n := run(text[i:], func(r rune) bool { return r == ' ' || r == '\t' })
+			tokens += math.Ceil(float64(n) / 8)
+			i += n
+		case r < utf8.RuneSelf:
+			n := run(text[i:], isASCIIPunct)
+			tokens += math.Ceil(float64(n) / float64(t.params.punctuation))
+			i += n
+		case isCJK(r):
+			tokens += t.params.cjk
+			i += size
+		default:
+			n := run(text[i:], func(r rune) bool { return r >= utf8.RuneSelf && !isCJK(r) })
+			tokens += math.Ceil(float64(n) / t.params.other)
+			i += n
+		}
+	}
+	return int64(math.Ceil(tokens))
+}
+
+// CountMessage returns the number of tokens of a message made of the texts,
+// the role and boundaries of the message included.
+func (t Tokenizer) CountMessage(texts ...string) int64 {
+	tokens := int64(messageOverhead)
+	for _, text := range texts {
+		tokens += t.Count(text)
+	}
+	return tokens
+}
+
+// CountImage returns the number of tokens of an image, from its size when it
+// can be decoded.
+func (t Tokenizer) CountImage(data []byte) int64 {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// The size of a typical screenshot.
+		cfg.Width, cfg.Height = 1280, 800
+	}
+	w, h := float64(cfg.Width), float64(cfg.Height)
+	switch t.Family {
+	case FamilyOpenAI:
+		// Scaled to fit 2048x2048, then for the short side to be at most
+		// 768, and counted in 512 pixels tiles.
+		if scale := 2048 / max(w, h); scale < 1 {
+			w, h = w*scale, h*scale
+		}
+		if scale := 768 / min(w, h); scale < 1 {
+			w, h = w*scale, h*scale
+		}
+		return 85 + 170*int64(math.Ceil(w/512)*math.Ceil(h/512))
+	case FamilyGemini:
+		return 258
+	default:
+		// Scaled for the long side to be at most 1568 pixels, at about 750
+		// pixels per token.
+		if scale := 1568 / max(w, h); scale < 1 {
+			w, h = w*scale, h*scale
+		}
+		return int64(math.Ceil(w * h / 750))
+	}
+}
+
+func isASCIILetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// isASCIIPunct reports whether the rune is an ASCII character other than a
+// letter, a digit or whitespace.
+func isASCIIPunct(r rune) bool {
+	return r < utf8.RuneSelf && !isASCIILetter(r) && (r < '0' || r > '9') && !strings.ContainsRune(" \t\r\n", r)
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// run returns the length in bytes of the run of runes at the start of s
+// matching the predicate.
+func run(s string, match func(rune) bool) int {
+	for i, r := range s {
+		if !match(r) {
+			return i
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,61 @@
+package tokenizer
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+
+	openai := New(FamilyOpenAI)
+	// Counts of the o200k encoding.
+	for text, want := range map[string]int64{
+		"": 0,
+		"The quick brown fox jumps over the lazy dog.": 10,
+		"Hello, world!": 4,
+		"func main() {\n\tfmt.Println(\"hi\")\n}": 12,
+	} {
+		got := openai.Count(text)
+		require.InDelta(t, want, got, float64(want)/4+1, "text %q", text)
+	}
+}
+
+func TestCountProse(t *testing.T) {
+	t.Parallel()
+
+	// English prose averages about four characters per token.
+	text := strings.Repeat("Crush is a terminal coding assistant that reads your code, runs commands and edits files with the model of your choice. ", 20)
+	for _, family := range []Family{FamilyOpenAI, FamilyAnthropic, FamilyGemini, FamilyLlama} {
+		got := New(family).Count(text)
+		require.InDelta(t, float64(len(text))/4, got, float64(len(text))/4*0.3, "family %s", family)
+	}
+}
+
+func TestForModel(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, FamilyAnthropic, ForModel(catwalk.TypeBedrock, "anthropic.claude-sonnet-4").Family)
+	require.Equal(t, FamilyAnthropic, ForModel(catwalk.TypeOpenRouter, "anthropic/claude-sonnet-4").Family)
+	require.Equal(t, FamilyOpenAI, ForModel(catwalk.TypeAzure, "my-deployment").Family)
+	require.Equal(t, FamilyGemini, ForModel(catwalk.TypeOpenAICompat, "gemma3:27b").Family)
+	require.Equal(t, FamilyLlama, ForModel(catwalk.TypeOpenAICompat, "qwen3-coder:30b").Family)
+	require.Equal(t, FamilyLlama, New("unknown").Family)
+}
+
+func TestCountImage(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1024, 1024))))
+
+	require.Equal(t, int64(85+170*4), New(FamilyOpenAI).CountImage(buf.Bytes()))
+	require.Equal(t, int64(1399), New(FamilyAnthropic).CountImage(buf.Bytes()))
+	require.Equal(t, int64(258), New(FamilyGemini).CountImage(buf.Bytes()))
+}
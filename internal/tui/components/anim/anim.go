@@ -16,6 +16,7 @@ import (
 	"github.com/lucasb-eyer/go-colorful"
 
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 )
 
@@ -116,6 +117,10 @@ type Anim struct {
 	ellipsisStep     atomic.Int64         // current ellipsis frame step
 	ellipsisFrames   *csync.Slice[string] // ellipsis animation frames
 	id               int
+
+	// static renders the label without animating, for screen readers.
+	static   bool
+	rawLabel string
 }
 
 // New creates a new Anim instance with the specified width and label.
@@ -139,6 +144,8 @@ func New(opts Settings) *Anim {
 	a.startTime = time.Now()
 	a.cyclingCharWidth = opts.Size
 	a.labelColor = opts.LabelColor
+	a.static = styles.ScreenReader()
+	a.rawLabel = opts.Label
 
 	// Check cache first
 	cacheKey := settingsHash(opts)
@@ -256,6 +263,7 @@ func New(opts Settings) *Anim {
 
 // SetLabel updates the label text and re-renders it.
 func (a *Anim) SetLabel(newLabel string) {
+	a.rawLabel = newLabel
 	a.labelWidth = lipgloss.Width(newLabel)
 
 	// Update total width
@@ -297,6 +305,9 @@ func (a *Anim) renderLabel(label string) {
 
 // Width returns the total width of the animation.
 func (a *Anim) Width() (w int) {
+	if a.static {
+		return lipgloss.Width(a.staticView())
+	}
 	w = a.width
 	if a.labelWidth > 0 {
 		w += labelGapWidth + a.labelWidth
@@ -315,6 +326,9 @@ func (a *Anim) Width() (w int) {
 
 // Init starts the animation.
 func (a *Anim) Init() tea.Cmd {
+	if a.static {
+		return nil
+	}
 	return a.Step()
 }
 
@@ -322,7 +336,7 @@ func (a *Anim) Init() tea.Cmd {
 func (a *Anim) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case StepMsg:
-		if msg.id != a.id {
+		if msg.id != a.id || a.static {
 			// Reject messages that are not for this instance.
 			return a, nil
 		}
@@ -349,6 +363,9 @@ func (a *Anim) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 
 // View renders the current state of the animation.
 func (a *Anim) View() string {
+	if a.static {
+		return a.staticView()
+	}
 	var b strings.Builder
 	step := int(a.step.Load())
 	for i := range a.width {
@@ -381,6 +398,15 @@ func (a *Anim) View() string {
 	return b.String()
 }
 
+// staticView renders the label followed by an ellipsis, without animation.
+func (a *Anim) staticView() string {
+	label := a.rawLabel
+	if label == "" {
+		label = "Working"
+	}
+	return lipgloss.NewStyle().Foreground(a.labelColor).Render(label + "...")
+}
+
 // Step is a command that triggers the next step in the animation.
 func (a *Anim) Step() tea.Cmd {
 	return tea.Tick(time.Second/time.Duration(fps), func(t time.Time) tea.Msg {
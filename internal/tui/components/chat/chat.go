@@ -2,6 +2,7 @@ package chat
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -31,6 +32,12 @@ type SessionSelectedMsg = session.Session
 
 type SessionClearedMsg struct{}
 
+// EditPromptMsg puts a previous prompt back in the editor to edit it.
+type EditPromptMsg struct {
+	Text        string
+	Attachments []message.Attachment
+}
+
 type SelectionCopyMsg struct {
 	clickCount   int
 	endSelection bool
@@ -67,6 +74,7 @@ type messageListCmp struct {
 
 	lastUserMessageTime int64
 	defaultListKeyMap   list.KeyMap
+	keyMap              messages.KeyMap
 
 	// Click tracking for double/triple click detection
 	lastClickTime time.Time
@@ -93,6 +101,7 @@ func New(app *app.App) MessageListCmp {
 		listCmp:           listCmp,
 		previousSelected:  "",
 		defaultListKeyMap: defaultListKeyMap,
+		keyMap:            messages.DefaultKeyMap(),
 	}
 }
 
@@ -104,8 +113,8 @@ func (m *messageListCmp) Init() tea.Cmd {
 // Update handles incoming messages and updates the component state.
 func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	var cmds []tea.Cmd
-	if m.session.ID != "" && m.app.AgentCoordinator != nil {
-		queueSize := m.app.AgentCoordinator.QueuedPrompts(m.session.ID)
+	if m.session.ID != "" && m.app.AgentCoordinator() != nil {
+		queueSize := m.app.AgentCoordinator().QueuedPrompts(m.session.ID)
 		if queueSize != m.promptQueue {
 			m.promptQueue = queueSize
 			cmds = append(cmds, m.SetSize(m.width, m.height))
@@ -115,14 +124,20 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case tea.KeyPressMsg:
 		if m.listCmp.IsFocused() && m.listCmp.HasSelection() {
 			switch {
-			case key.Matches(msg, messages.CopyKey):
+			case key.Matches(msg, m.keyMap.Copy), key.Matches(msg, m.keyMap.CopyCode):
 				cmds = append(cmds, m.CopySelectedText(true))
 				return m, tea.Batch(cmds...)
-			case key.Matches(msg, messages.ClearSelectionKey):
+			case key.Matches(msg, m.keyMap.ClearSelection):
 				cmds = append(cmds, m.SelectionClear())
 				return m, tea.Batch(cmds...)
 			}
 		}
+		if m.listCmp.IsFocused() {
+			if cmd, ok := m.handleItemAction(msg); ok {
+				cmds = append(cmds, cmd)
+				return m, tea.Batch(cmds...)
+			}
+		}
 	case tea.MouseClickMsg:
 		x := msg.X - 1 // Adjust for padding
 		y := msg.Y - 1 // Adjust for padding
@@ -187,6 +202,11 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				m.listCmp.EndSelection(msg.x, msg.y)
 			}
 			m.listCmp.SelectionStop()
+			// A single click that selected no text acts on the item.
+			if msg.clickCount == 1 && msg.endSelection && !m.listCmp.HasSelection() {
+				cmds = append(cmds, m.handleItemClick(msg.y))
+				return m, tea.Batch(cmds...)
+			}
 			cmds = append(cmds, m.CopySelectedText(true))
 			return m, tea.Batch(cmds...)
 		}
@@ -236,7 +256,7 @@ func (m *messageListCmp) View() string {
 				m.listCmp.View(),
 			),
 	}
-	if m.app.AgentCoordinator != nil && m.promptQueue > 0 {
+	if m.app.AgentCoordinator() != nil && m.promptQueue > 0 {
 		queuePill := queuePill(m.promptQueue, t)
 		view = append(view, t.S().Base.PaddingLeft(4).PaddingTop(1).Render(queuePill))
 	}
@@ -673,6 +693,76 @@ func (m *messageListCmp) buildToolCallOptions(tc message.ToolCall, msg message.M
 	return options
 }
 
+// handleItemAction runs the action of the key on the selected message or
+// tool call. It reports whether the key matched an action.
+func (m *messageListCmp) handleItemAction(msg tea.KeyPressMsg) (tea.Cmd, bool) {
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return nil, false
+	}
+	switch item := (*selected).(type) {
+	case messages.MessageCmp:
+		switch {
+		case key.Matches(msg, m.keyMap.Copy):
+			return item.Copy(), true
+		case key.Matches(msg, m.keyMap.CopyCode):
+			return item.CopyCode(), true
+		case key.Matches(msg, m.keyMap.EditPrompt):
+			if item.GetMessage().Role != message.User {
+				return util.ReportInfo("Only prompts can be edited"), true
+			}
+			text, attachments := promptOf(item.GetMessage())
+			return util.CmdHandler(EditPromptMsg{Text: text, Attachments: attachments}), true
+		case key.Matches(msg, m.keyMap.RerunPrompt):
+			if item.GetMessage().Role != message.User {
+				return util.ReportInfo("Only prompts can be re-run"), true
+			}
+			text, attachments := promptOf(item.GetMessage())
+			return util.CmdHandler(SendMsg{Text: text, Attachments: attachments}), true
+		}
+	case messages.ToolCallCmp:
+		switch {
+		case key.Matches(msg, m.keyMap.Copy), key.Matches(msg, m.keyMap.CopyCode):
+			return item.Copy(), true
+		case key.Matches(msg, m.keyMap.ToggleExpand):
+			item.ToggleExpanded()
+			return m.listCmp.UpdateItem(item.ID(), item), true
+		}
+	}
+	return nil, false
+}
+
+// handleItemClick selects the item at the given line of the list, and shows
+// the whole output of a tool call or collapses it again.
+func (m *messageListCmp) handleItemClick(y int) tea.Cmd {
+	m.SelectionClear()
+	item, ok := m.listCmp.ItemAt(y)
+	if !ok {
+		return nil
+	}
+	cmds := []tea.Cmd{m.listCmp.SetSelected(item.ID())}
+	if toolCall, ok := item.(messages.ToolCallCmp); ok {
+		toolCall.ToggleExpanded()
+		cmds = append(cmds, m.listCmp.UpdateItem(toolCall.ID(), toolCall))
+	}
+	return tea.Sequence(cmds...)
+}
+
+// promptOf returns the text and the attachments of a user message, to send
+// it again.
+func promptOf(msg message.Message) (string, []message.Attachment) {
+	var attachments []message.Attachment
+	for _, content := range msg.BinaryContent() {
+		attachments = append(attachments, message.Attachment{
+			FilePath: content.Path,
+			FileName: filepath.Base(content.Path),
+			MimeType: content.MIMEType,
+			Content:  content.Data,
+		})
+	}
+	return msg.Content().Text, attachments
+}
+
 // GetSize returns the current width and height of the component.
 func (m *messageListCmp) GetSize() (int, int) {
 	return m.width, m.height
@@ -706,7 +796,7 @@ func (m *messageListCmp) IsFocused() bool {
 }
 
 func (m *messageListCmp) Bindings() []key.Binding {
-	return m.defaultListKeyMap.KeyBindings()
+	return append(m.defaultListKeyMap.KeyBindings(), m.keyMap.KeyBindings()...)
 }
 
 func (m *messageListCmp) GoToBottom() tea.Cmd {
@@ -1,14 +1,13 @@
 package editor
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"slices"
 	"strings"
 	"unicode"
@@ -17,6 +16,7 @@ import (
 	"charm.land/bubbles/v2/textarea"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/message"
@@ -42,6 +42,8 @@ type Editor interface {
 	SetSession(session session.Session) tea.Cmd
 	IsCompletionsOpen() bool
 	HasAttachments() bool
+	Attachments() []message.Attachment
+	IsVimInsertMode() bool
 	Cursor() *tea.Cursor
 }
 
@@ -49,6 +51,13 @@ type FileCompletionItem struct {
 	Path string // The file path
 }
 
+type ResourceCompletionItem struct {
+	MCPName  string // The MCP server providing the resource
+	URI      string // The resource URI
+	Name     string // The resource name
+	MimeType string // The resource MIME type, if known
+}
+
 type editorCmp struct {
 	width              int
 	height             int
@@ -61,7 +70,9 @@ type editorCmp struct {
 	readyPlaceholder   string
 	workingPlaceholder string
 
-	keyMap EditorKeyMap
+	keyMap  EditorKeyMap
+	vim     vimState
+	history *promptHistory
 
 	// File path completions
 	currentQuery          string
@@ -94,42 +105,12 @@ type OpenEditorMsg struct {
 }
 
 func (m *editorCmp) openEditor(value string) tea.Cmd {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		// Use platform-appropriate default editor
-		if runtime.GOOS == "windows" {
-			editor = "notepad"
-		} else {
-			editor = "nvim"
-		}
-	}
-
-	tmpfile, err := os.CreateTemp("", "msg_*.md")
-	if err != nil {
-		return util.ReportError(err)
-	}
-	defer tmpfile.Close() //nolint:errcheck
-	if _, err := tmpfile.WriteString(value); err != nil {
-		return util.ReportError(err)
-	}
-	c := exec.CommandContext(context.TODO(), editor, tmpfile.Name())
-	c.Stdin = os.Stdin
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	return tea.ExecProcess(c, func(err error) tea.Msg {
-		if err != nil {
-			return util.ReportError(err)
-		}
-		content, err := os.ReadFile(tmpfile.Name())
-		if err != nil {
-			return util.ReportError(err)
-		}
+	return util.OpenEditor("msg_*.md", value, func(content string) tea.Msg {
 		if len(content) == 0 {
-			return util.ReportWarn("Message is empty")
+			return util.ReportWarn("Message is empty")()
 		}
-		os.Remove(tmpfile.Name())
 		return OpenEditorMsg{
-			Text: strings.TrimSpace(string(content)),
+			Text: strings.TrimSpace(content),
 		}
 	})
 }
@@ -155,6 +136,7 @@ func (m *editorCmp) send() tea.Cmd {
 	if value == "" {
 		return nil
 	}
+	m.history.add(value)
 
 	// Change the placeholder when sending a new message.
 	m.randomizePlaceholders()
@@ -178,6 +160,9 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		return m, m.repositionCompletions
+	case styles.ThemeChangedMsg:
+		m.textarea.SetStyles(styles.CurrentTheme().S().TextArea)
+		return m, nil
 	case filepicker.FilePickedMsg:
 		if len(m.attachments) >= maxAttachments {
 			return m, util.ReportError(fmt.Errorf("cannot add more than %d images", maxAttachments))
@@ -210,15 +195,35 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				m.completionsStartIndex = 0
 			}
 		}
+		if item, ok := msg.Value.(ResourceCompletionItem); ok {
+			word := m.textarea.Word()
+			// Resources are attached rather than referenced by path
+			value := m.textarea.Value()
+			value = value[:m.completionsStartIndex] + // Remove the current query
+				value[m.completionsStartIndex+len(word):] // Append the rest of the value
+			m.textarea.SetValue(value)
+			m.textarea.MoveToEnd()
+			m.isCompletionsOpen = false
+			m.currentQuery = ""
+			m.completionsStartIndex = 0
+			return m, tea.Batch(
+				util.CmdHandler(completions.CloseCompletionsMsg{}),
+				attachResource(item),
+			)
+		}
 
 	case commands.OpenExternalEditorMsg:
-		if m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
+		if m.app.AgentCoordinator().IsSessionBusy(m.session.ID) {
 			return m, util.ReportWarn("Agent is working, please wait...")
 		}
 		return m, m.openEditor(m.textarea.Value())
 	case OpenEditorMsg:
 		m.textarea.SetValue(msg.Text)
 		m.textarea.MoveToEnd()
+	case chat.EditPromptMsg:
+		m.textarea.SetValue(msg.Text)
+		m.textarea.MoveToEnd()
+		m.attachments = msg.Attachments
 	case tea.PasteMsg:
 		path := strings.ReplaceAll(msg.Content, "\\ ", " ")
 		// try to get an image
@@ -261,6 +266,9 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		m.setEditorPrompt()
 		return m, nil
 	case tea.KeyPressMsg:
+		if m.vim.enabled && m.handleVimKey(msg) {
+			return m, nil
+		}
 		cur := m.textarea.Cursor()
 		curIdx := m.textarea.Width()*cur.Y + cur.X
 		switch {
@@ -303,7 +311,7 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			}
 		}
 		if key.Matches(msg, m.keyMap.OpenEditor) {
-			if m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
+			if m.app.AgentCoordinator().IsSessionBusy(m.session.ID) {
 				return m, util.ReportWarn("Agent is working, please wait...")
 			}
 			return m, m.openEditor(m.textarea.Value())
@@ -316,6 +324,23 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			m.textarea.InsertRune('\n')
 			cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 		}
+		// Recall the prompts sent before from the first and last lines.
+		if m.textarea.Focused() && !m.isCompletionsOpen {
+			switch {
+			case key.Matches(msg, m.keyMap.PreviousPrompt) && m.textarea.Line() == 0:
+				if prompt, ok := m.history.previous(m.textarea.Value()); ok {
+					m.textarea.SetValue(prompt)
+					m.textarea.MoveToEnd()
+				}
+				return m, nil
+			case key.Matches(msg, m.keyMap.NextPrompt) && m.textarea.Line() == m.textarea.LineCount()-1:
+				if prompt, ok := m.history.next(); ok {
+					m.textarea.SetValue(prompt)
+					m.textarea.MoveToEnd()
+				}
+				return m, nil
+			}
+		}
 		// Handle Enter key
 		if m.textarea.Focused() && key.Matches(msg, m.keyMap.SendMessage) {
 			value := m.textarea.Value()
@@ -371,6 +396,10 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 }
 
 func (m *editorCmp) setEditorPrompt() {
+	if m.vim.enabled && m.vim.normal {
+		m.textarea.SetPromptFunc(4, vimNormalPromptFunc)
+		return
+	}
 	if m.app.Permissions.SkipRequests() {
 		m.textarea.SetPromptFunc(4, yoloPromptFunc)
 		return
@@ -393,6 +422,9 @@ func (m *editorCmp) Cursor() *tea.Cursor {
 	if cursor != nil {
 		cursor.X = cursor.X + m.x + 1
 		cursor.Y = cursor.Y + m.y + 1 // adjust for padding
+		if m.IsVimInsertMode() {
+			cursor.Shape = tea.CursorBar
+		}
 	}
 	return cursor
 }
@@ -421,7 +453,7 @@ func (m *editorCmp) randomizePlaceholders() {
 func (m *editorCmp) View() string {
 	t := styles.CurrentTheme()
 	// Update placeholder
-	if m.app.AgentCoordinator != nil && m.app.AgentCoordinator.IsBusy() {
+	if m.app.AgentCoordinator() != nil && m.app.AgentCoordinator().IsSessionBusy(m.session.ID) {
 		m.textarea.Placeholder = m.workingPlaceholder
 	} else {
 		m.textarea.Placeholder = m.readyPlaceholder
@@ -501,6 +533,8 @@ func (m *editorCmp) startCompletions() tea.Msg {
 		})
 	}
 
+	completionItems = append(completionItems, resourceCompletions()...)
+
 	x, y := m.completionsPosition()
 	return completions.OpenCompletionsMsg{
 		Completions: completionItems,
@@ -510,6 +544,70 @@ func (m *editorCmp) startCompletions() tea.Msg {
 	}
 }
 
+// resourceCompletions returns the completion items for the MCP resources.
+func resourceCompletions() []completions.Completion {
+	var items []completions.Completion
+	for mcpName, resources := range mcp.Resources() {
+		for _, resource := range resources {
+			items = append(items, completions.Completion{
+				Title: mcpName + ":" + resource.URI,
+				Value: ResourceCompletionItem{
+					MCPName:  mcpName,
+					URI:      resource.URI,
+					Name:     cmp.Or(resource.Title, resource.Name),
+					MimeType: resource.MIMEType,
+				},
+			})
+		}
+	}
+	slices.SortFunc(items, func(a, b completions.Completion) int {
+		return strings.Compare(a.Title, b.Title)
+	})
+	return items
+}
+
+// attachResource reads an MCP resource and attaches it to the prompt.
+func attachResource(item ResourceCompletionItem) tea.Cmd {
+	return func() tea.Msg {
+		contents, err := mcp.ReadResource(context.Background(), item.MCPName, item.URI)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: fmt.Sprintf("failed to read resource: %v", err)}
+		}
+		if len(contents) == 0 {
+			return util.InfoMsg{Type: util.InfoTypeWarn, Msg: fmt.Sprintf("resource %s is empty", item.URI)}
+		}
+		return filepicker.FilePickedMsg{
+			Attachment: resourceAttachment(item, contents),
+		}
+	}
+}
+
+// resourceAttachment builds an attachment from the contents of a resource.
+// Text contents are joined together, otherwise the first blob is used.
+func resourceAttachment(item ResourceCompletionItem, contents []*mcp.ResourceContents) message.Attachment {
+	attachment := message.Attachment{
+		FilePath: item.URI,
+		FileName: cmp.Or(item.Name, item.URI),
+	}
+	var texts []string
+	for _, content := range contents {
+		if content.Text != "" {
+			texts = append(texts, content.Text)
+		}
+	}
+	if len(texts) > 0 || len(contents[0].Blob) == 0 {
+		attachment.MimeType = cmp.Or(item.MimeType, contents[0].MIMEType)
+		if !strings.HasPrefix(attachment.MimeType, "text/") {
+			attachment.MimeType = "text/plain"
+		}
+		attachment.Content = []byte(strings.Join(texts, "\n"))
+		return attachment
+	}
+	attachment.MimeType = cmp.Or(contents[0].MIMEType, item.MimeType, http.DetectContentType(contents[0].Blob))
+	attachment.Content = contents[0].Blob
+	return attachment
+}
+
 // Blur implements Container.
 func (c *editorCmp) Blur() tea.Cmd {
 	c.textarea.Blur()
@@ -546,6 +644,10 @@ func (c *editorCmp) HasAttachments() bool {
 	return len(c.attachments) > 0
 }
 
+func (c *editorCmp) Attachments() []message.Attachment {
+	return c.attachments
+}
+
 func normalPromptFunc(info textarea.PromptInfo) string {
 	t := styles.CurrentTheme()
 	if info.LineNumber == 0 {
@@ -586,6 +688,14 @@ func New(app *app.App) Editor {
 		textarea: ta,
 		keyMap:   DefaultEditorKeyMap(),
 	}
+	var dataDir string
+	if cfg := app.Config(); cfg != nil {
+		dataDir = cfg.Options.DataDirectory
+		if cfg.Options.TUI != nil {
+			e.vim.enabled = cfg.Options.TUI.VimMode
+		}
+	}
+	e.history = loadPromptHistory(dataDir)
 	e.setEditorPrompt()
 
 	e.randomizePlaceholders()
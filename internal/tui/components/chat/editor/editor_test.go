@@ -0,0 +1,96 @@
+package editor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceAttachment(t *testing.T) {
+	t.Parallel()
+
+	png := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+	tests := []struct {
+		name     string
+		item     ResourceCompletionItem
+		contents []*mcp.ResourceContents
+		want     message.Attachment
+	}{
+		{
+			name: "text",
+			item: ResourceCompletionItem{URI: "docs://readme", Name: "Readme", MimeType: "text/markdown"},
+			contents: []*mcp.ResourceContents{
+				{URI: "docs://readme", Text: "# Readme"},
+				{URI: "docs://readme", Text: "More"},
+			},
+			want: message.Attachment{FilePath: "docs://readme", FileName: "Readme", MimeType: "text/markdown", Content: []byte("# Readme\nMore")},
+		},
+		{
+			name: "text without a text mime type",
+			item: ResourceCompletionItem{URI: "db://schema"},
+			contents: []*mcp.ResourceContents{
+				{URI: "db://schema", MIMEType: "application/sql", Text: "create table t;"},
+			},
+			want: message.Attachment{FilePath: "db://schema", FileName: "db://schema", MimeType: "text/plain", Content: []byte("create table t;")},
+		},
+		{
+			name: "binary",
+			item: ResourceCompletionItem{URI: "img://logo", Name: "Logo"},
+			contents: []*mcp.ResourceContents{
+				{URI: "img://logo", MIMEType: "image/png", Blob: png},
+			},
+			want: message.Attachment{FilePath: "img://logo", FileName: "Logo", MimeType: "image/png", Content: png},
+		},
+		{
+			name: "binary without a mime type",
+			item: ResourceCompletionItem{URI: "img://logo"},
+			contents: []*mcp.ResourceContents{
+				{URI: "img://logo", Blob: png},
+			},
+			want: message.Attachment{FilePath: "img://logo", FileName: "img://logo", MimeType: "image/png", Content: png},
+		},
+		{
+			name: "text and binary",
+			item: ResourceCompletionItem{URI: "mixed://item"},
+			contents: []*mcp.ResourceContents{
+				{URI: "mixed://item", Blob: png},
+				{URI: "mixed://item", Text: "caption"},
+			},
+			want: message.Attachment{FilePath: "mixed://item", FileName: "mixed://item", MimeType: "text/plain", Content: []byte("caption")},
+		},
+		{
+			name:     "empty",
+			item:     ResourceCompletionItem{URI: "empty://item"},
+			contents: []*mcp.ResourceContents{{URI: "empty://item"}},
+			want:     message.Attachment{FilePath: "empty://item", FileName: "empty://item", MimeType: "text/plain", Content: []byte("")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := resourceAttachment(tt.item, tt.contents)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.want.MimeType != "image/png", got.IsText())
+		})
+	}
+}
+
+func TestAttachResourceError(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	_, err := config.Init(dir, filepath.Join(dir, "data"), "", false)
+	require.NoError(t, err)
+
+	msg := attachResource(ResourceCompletionItem{MCPName: "missing", URI: "docs://readme"})()
+	require.Equal(t, util.InfoMsg{
+		Type: util.InfoTypeError,
+		Msg:  "failed to read resource: mcp 'missing' not available",
+	}, msg)
+}
@@ -0,0 +1,148 @@
+package editor
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// historyFileName is the file, in the data directory, the prompts sent
+	// in the project are saved to.
+	historyFileName = "prompt_history.jsonl"
+	// maxHistory is the number of prompts kept in the history.
+	maxHistory = 1000
+)
+
+// promptHistory holds the prompts sent in the project, oldest first, without
+// duplicates, and the one being recalled in the editor.
+type promptHistory struct {
+	path    string
+	prompts []string
+
+	// index is the prompt being recalled, len(prompts) when none is.
+	index int
+	// draft is what was in the editor before recalling prompts.
+	draft string
+}
+
+// loadPromptHistory loads the prompt history from the given data directory.
+// A missing or unreadable file gives an empty history.
+func loadPromptHistory(dataDir string) *promptHistory {
+	h := &promptHistory{}
+	if dataDir == "" {
+		return h
+	}
+	h.path = filepath.Join(dataDir, historyFileName)
+
+	f, err := os.Open(h.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to open prompt history", "path", h.path, "error", err)
+		}
+		return h
+	}
+	defer f.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var prompt string
+		if err := json.Unmarshal(scanner.Bytes(), &prompt); err != nil {
+			continue
+		}
+		h.push(prompt)
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Warn("Failed to read prompt history", "path", h.path, "error", err)
+	}
+	h.index = len(h.prompts)
+	return h
+}
+
+// push adds a prompt as the most recent one, removing its older copy.
+func (h *promptHistory) push(prompt string) {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return
+	}
+	h.prompts = slices.DeleteFunc(h.prompts, func(p string) bool {
+		return p == prompt
+	})
+	h.prompts = append(h.prompts, prompt)
+	if len(h.prompts) > maxHistory {
+		h.prompts = h.prompts[len(h.prompts)-maxHistory:]
+	}
+}
+
+// add adds a sent prompt to the history, stops recalling prompts and saves
+// the history.
+func (h *promptHistory) add(prompt string) {
+	h.push(prompt)
+	h.reset()
+	if err := h.save(); err != nil {
+		slog.Warn("Failed to save prompt history", "path", h.path, "error", err)
+	}
+}
+
+// reset stops recalling prompts.
+func (h *promptHistory) reset() {
+	h.index = len(h.prompts)
+	h.draft = ""
+}
+
+// previous returns the prompt sent before the one being recalled. current is
+// what is in the editor, given back by next after the most recent prompt.
+func (h *promptHistory) previous(current string) (string, bool) {
+	if h.index == 0 {
+		return "", false
+	}
+	if h.index == len(h.prompts) {
+		h.draft = current
+	}
+	h.index--
+	return h.prompts[h.index], true
+}
+
+// next returns the prompt sent after the one being recalled, or the draft
+// after the most recent prompt.
+func (h *promptHistory) next() (string, bool) {
+	if h.index >= len(h.prompts) {
+		return "", false
+	}
+	h.index++
+	if h.index == len(h.prompts) {
+		draft := h.draft
+		h.draft = ""
+		return draft, true
+	}
+	return h.prompts[h.index], true
+}
+
+// save writes the history to its file, one JSON string per prompt.
+func (h *promptHistory) save() error {
+	if h.path == "" {
+		return nil
+	}
+	var b strings.Builder
+	for _, prompt := range h.prompts {
+		line, err := json.Marshal(prompt)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
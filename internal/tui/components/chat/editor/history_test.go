@@ -0,0 +1,51 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptHistory(t *testing.T) {
+	t.Parallel()
+
+	t.Run("recalls prompts and the draft", func(t *testing.T) {
+		t.Parallel()
+
+		h := loadPromptHistory("")
+		h.add("first")
+		h.add("second")
+
+		prompt, ok := h.previous("draft")
+		require.True(t, ok)
+		require.Equal(t, "second", prompt)
+		prompt, ok = h.previous(prompt)
+		require.True(t, ok)
+		require.Equal(t, "first", prompt)
+		_, ok = h.previous(prompt)
+		require.False(t, ok)
+
+		prompt, ok = h.next()
+		require.True(t, ok)
+		require.Equal(t, "second", prompt)
+		prompt, ok = h.next()
+		require.True(t, ok)
+		require.Equal(t, "draft", prompt)
+		_, ok = h.next()
+		require.False(t, ok)
+	})
+
+	t.Run("deduplicates and persists", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		h := loadPromptHistory(dir)
+		h.add("run the tests")
+		h.add("multi\nline")
+		h.add("  run the tests  ")
+		h.add("")
+
+		loaded := loadPromptHistory(dir)
+		require.Equal(t, []string{"multi\nline", "run the tests"}, loaded.prompts)
+	})
+}
@@ -2,17 +2,20 @@ package editor
 
 import (
 	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 )
 
 type EditorKeyMap struct {
-	AddFile     key.Binding
-	SendMessage key.Binding
-	OpenEditor  key.Binding
-	Newline     key.Binding
+	AddFile        key.Binding
+	SendMessage    key.Binding
+	OpenEditor     key.Binding
+	Newline        key.Binding
+	PreviousPrompt key.Binding
+	NextPrompt     key.Binding
 }
 
 func DefaultEditorKeyMap() EditorKeyMap {
-	return EditorKeyMap{
+	return keymap.Apply("editor", EditorKeyMap{
 		AddFile: key.NewBinding(
 			key.WithKeys("/"),
 			key.WithHelp("/", "add file"),
@@ -32,7 +35,15 @@ func DefaultEditorKeyMap() EditorKeyMap {
 			// to reflect that.
 			key.WithHelp("ctrl+j", "newline"),
 		),
-	}
+		PreviousPrompt: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "previous prompt"),
+		),
+		NextPrompt: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next prompt"),
+		),
+	})
 }
 
 // KeyBindings implements layout.KeyMapProvider
@@ -42,6 +53,8 @@ func (k EditorKeyMap) KeyBindings() []key.Binding {
 		k.SendMessage,
 		k.OpenEditor,
 		k.Newline,
+		k.PreviousPrompt,
+		k.NextPrompt,
 		AttachmentsKeyMaps.AttachmentDeleteMode,
 		AttachmentsKeyMaps.DeleteAllAttachments,
 		AttachmentsKeyMaps.Escape,
@@ -0,0 +1,168 @@
+package editor
+
+import (
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+)
+
+// vimState tracks vim-style modal editing, when enabled.
+type vimState struct {
+	enabled bool
+	normal  bool
+	// pending is an operator waiting for its motion, e.g. "d" in "dw".
+	pending string
+}
+
+// IsVimInsertMode reports whether vim mode is enabled and the editor is in
+// insert mode, where esc goes back to normal mode.
+func (m *editorCmp) IsVimInsertMode() bool {
+	return m.vim.enabled && !m.vim.normal
+}
+
+func (m *editorCmp) setVimNormal(normal bool) {
+	m.vim.normal = normal
+	m.vim.pending = ""
+	m.setEditorPrompt()
+}
+
+// handleVimKey handles key presses in vim mode. It returns false for keys
+// the editor should handle as usual.
+func (m *editorCmp) handleVimKey(msg tea.KeyPressMsg) bool {
+	if !m.vim.normal {
+		if msg.String() == "esc" && !m.isCompletionsOpen && !m.deleteMode {
+			m.setVimNormal(true)
+			return true
+		}
+		return false
+	}
+
+	k := msg.String()
+	if pending := m.vim.pending; pending != "" {
+		m.vim.pending = ""
+		return m.handleVimOperator(pending, k)
+	}
+
+	// Let sending, opening the editor and other modified keys through.
+	if key.Matches(msg, m.keyMap.SendMessage, m.keyMap.OpenEditor, m.keyMap.Newline) ||
+		len([]rune(k)) > 1 && !slices.Contains([]string{"left", "right", "up", "down"}, k) {
+		return false
+	}
+
+	switch k {
+	case "i":
+		m.setVimNormal(false)
+	case "a":
+		m.textareaKey(tea.KeyPressMsg{Code: tea.KeyRight})
+		m.setVimNormal(false)
+	case "I":
+		m.textarea.CursorStart()
+		m.setVimNormal(false)
+	case "A":
+		m.textarea.CursorEnd()
+		m.setVimNormal(false)
+	case "o":
+		m.textarea.CursorEnd()
+		m.textarea.InsertRune('\n')
+		m.setVimNormal(false)
+	case "O":
+		m.textarea.CursorStart()
+		m.textarea.InsertRune('\n')
+		m.textarea.CursorUp()
+		m.setVimNormal(false)
+	case "h", "left":
+		m.textareaKey(tea.KeyPressMsg{Code: tea.KeyLeft})
+	case "l", "right":
+		m.textareaKey(tea.KeyPressMsg{Code: tea.KeyRight})
+	case "j", "down":
+		m.textarea.CursorDown()
+	case "k", "up":
+		m.textarea.CursorUp()
+	case "w", "e":
+		m.textareaKey(tea.KeyPressMsg{Code: tea.KeyRight, Mod: tea.ModAlt})
+	case "b":
+		m.textareaKey(tea.KeyPressMsg{Code: tea.KeyLeft, Mod: tea.ModAlt})
+	case "0", "^":
+		m.textarea.CursorStart()
+	case "$":
+		m.textarea.CursorEnd()
+	case "G":
+		m.textarea.MoveToEnd()
+	case "x":
+		m.textareaKey(tea.KeyPressMsg{Code: tea.KeyDelete})
+	case "X":
+		m.textareaKey(tea.KeyPressMsg{Code: tea.KeyBackspace})
+	case "D":
+		m.textareaKey(tea.KeyPressMsg{Code: 'k', Mod: tea.ModCtrl})
+	case "C":
+		m.textareaKey(tea.KeyPressMsg{Code: 'k', Mod: tea.ModCtrl})
+		m.setVimNormal(false)
+	case "S":
+		m.vimClearLine()
+		m.setVimNormal(false)
+	case "d", "c", "g":
+		m.vim.pending = k
+	}
+	// Other keys don't type anything in normal mode.
+	return true
+}
+
+// handleVimOperator applies an operator, e.g. "d", to the given motion.
+func (m *editorCmp) handleVimOperator(op, motion string) bool {
+	switch op + motion {
+	case "gg":
+		m.textarea.MoveToBegin()
+	case "dd":
+		m.vimDeleteLine()
+	case "cc":
+		m.vimClearLine()
+		m.setVimNormal(false)
+	case "dw", "de", "cw", "ce":
+		m.textareaKey(tea.KeyPressMsg{Code: 'd', Mod: tea.ModAlt})
+	case "db", "cb":
+		m.textareaKey(tea.KeyPressMsg{Code: tea.KeyBackspace, Mod: tea.ModAlt})
+	case "d$", "c$":
+		m.textareaKey(tea.KeyPressMsg{Code: 'k', Mod: tea.ModCtrl})
+	case "d0", "c0":
+		m.textareaKey(tea.KeyPressMsg{Code: 'u', Mod: tea.ModCtrl})
+	}
+	if op == "c" && motion != "c" {
+		m.setVimNormal(false)
+	}
+	return true
+}
+
+// textareaKey sends a key press to the textarea, to reuse its editing
+// commands.
+func (m *editorCmp) textareaKey(msg tea.KeyPressMsg) {
+	m.textarea, _ = m.textarea.Update(msg)
+}
+
+func (m *editorCmp) vimClearLine() {
+	m.textarea.CursorEnd()
+	m.textareaKey(tea.KeyPressMsg{Code: 'u', Mod: tea.ModCtrl})
+}
+
+func (m *editorCmp) vimDeleteLine() {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	lines = slices.Delete(lines, row, row+1)
+	m.textarea.SetValue(strings.Join(lines, "\n"))
+	m.textarea.MoveToBegin()
+	// Soft-wrapped lines take several rows, so move until the line changes.
+	for m.textarea.Line() < min(row, len(lines)-1) {
+		m.textarea.CursorDown()
+	}
+}
+
+func vimNormalPromptFunc(info textarea.PromptInfo) string {
+	t := styles.CurrentTheme()
+	if info.LineNumber == 0 {
+		return t.S().Base.Foreground(t.Secondary).Render("  : ")
+	}
+	return normalPromptFunc(info)
+}
@@ -0,0 +1,114 @@
+package editor
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func newVimEditor(t *testing.T, value string) *editorCmp {
+	t.Helper()
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetWidth(80)
+	ta.Focus()
+	m := &editorCmp{
+		app:      &app.App{Permissions: permission.NewPermissionService(t.TempDir(), false, nil)},
+		textarea: ta,
+		keyMap:   DefaultEditorKeyMap(),
+	}
+	m.vim.enabled = true
+	m.textarea.SetValue(value)
+	return m
+}
+
+func vimKey(k string) tea.KeyPressMsg {
+	switch k {
+	case "esc":
+		return tea.KeyPressMsg{Code: tea.KeyEscape}
+	case "enter":
+		return tea.KeyPressMsg{Code: tea.KeyEnter}
+	}
+	r := []rune(k)[0]
+	return tea.KeyPressMsg{Code: r, Text: k}
+}
+
+func TestVimMode(t *testing.T) {
+	t.Parallel()
+
+	// Each case starts in normal mode with the cursor at the end of the
+	// value. The cursor is shown as a | in the result.
+	for _, tc := range []struct {
+		name   string
+		value  string
+		keys   []string
+		want   string
+		normal bool
+	}{
+		{name: "i enters insert mode", value: "abc", keys: []string{"0", "i"}, want: "|abc"},
+		{name: "a appends after the cursor", value: "abc", keys: []string{"0", "a"}, want: "a|bc"},
+		{name: "I inserts at the start of the line", value: "abc", keys: []string{"I"}, want: "|abc"},
+		{name: "A appends at the end of the line", value: "abc", keys: []string{"0", "A"}, want: "abc|"},
+		{name: "o opens a line below", value: "abc", keys: []string{"0", "o"}, want: "abc\n|"},
+		{name: "O opens a line above", value: "abc", keys: []string{"O"}, want: "|\nabc"},
+		{name: "h and l move by character", value: "abc", keys: []string{"0", "l", "l", "h"}, want: "a|bc", normal: true},
+		{name: "j and k move by line", value: "ab\ncd", keys: []string{"k", "0", "j"}, want: "ab\n|cd", normal: true},
+		{name: "w and b move by word", value: "foo bar baz", keys: []string{"0", "w", "w", "b"}, want: "foo |bar baz", normal: true},
+		{name: "0 and $ move to the line ends", value: "abc", keys: []string{"0", "$"}, want: "abc|", normal: true},
+		{name: "gg and G move to the text ends", value: "a\nb\nc", keys: []string{"g", "g"}, want: "|a\nb\nc", normal: true},
+		{name: "G moves to the end", value: "a\nb\nc", keys: []string{"g", "g", "G"}, want: "a\nb\nc|", normal: true},
+		{name: "x deletes under the cursor", value: "abc", keys: []string{"0", "x"}, want: "|bc", normal: true},
+		{name: "X deletes before the cursor", value: "abc", keys: []string{"X"}, want: "ab|", normal: true},
+		{name: "D deletes to the end of the line", value: "abc", keys: []string{"0", "l", "D"}, want: "a|", normal: true},
+		{name: "C changes to the end of the line", value: "abc", keys: []string{"0", "l", "C"}, want: "a|"},
+		{name: "S changes the line", value: "ab\ncd", keys: []string{"S"}, want: "ab\n|"},
+		{name: "dd deletes the line", value: "a\nb\nc", keys: []string{"g", "g", "j", "d", "d"}, want: "a\n|c", normal: true},
+		{name: "dd on the last line", value: "a\nb", keys: []string{"d", "d"}, want: "|a", normal: true},
+		{name: "dw deletes a word", value: "foo bar", keys: []string{"0", "d", "w"}, want: "| bar", normal: true},
+		{name: "db deletes the word before", value: "foo bar", keys: []string{"d", "b"}, want: "foo |", normal: true},
+		{name: "d0 deletes to the start of the line", value: "foo bar", keys: []string{"d", "0"}, want: "|", normal: true},
+		{name: "cc changes the line", value: "ab\ncd", keys: []string{"c", "c"}, want: "ab\n|"},
+		{name: "cw changes a word", value: "foo bar", keys: []string{"0", "c", "w"}, want: "| bar"},
+		{name: "unknown operators are dropped", value: "abc", keys: []string{"d", "z", "X"}, want: "ab|", normal: true},
+		{name: "other keys type nothing", value: "abc", keys: []string{"z", "!"}, want: "abc|", normal: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			m := newVimEditor(t, tc.value)
+			m.setVimNormal(true)
+			for _, k := range tc.keys {
+				require.True(t, m.handleVimKey(vimKey(k)), "key %q", k)
+			}
+			require.Equal(t, tc.normal, m.vim.normal)
+			m.textarea.InsertString("|")
+			require.Equal(t, tc.want, m.textarea.Value())
+		})
+	}
+}
+
+func TestVimModeSwitch(t *testing.T) {
+	t.Parallel()
+
+	m := newVimEditor(t, "abc")
+	require.True(t, m.IsVimInsertMode())
+
+	// Insert mode leaves typing to the editor.
+	require.False(t, m.handleVimKey(vimKey("x")))
+	require.True(t, m.handleVimKey(vimKey("esc")))
+	require.False(t, m.IsVimInsertMode())
+
+	// Normal mode lets sending through.
+	require.False(t, m.handleVimKey(vimKey("enter")))
+	require.True(t, m.handleVimKey(vimKey("i")))
+	require.True(t, m.IsVimInsertMode())
+
+	// Esc closes the completions before leaving insert mode.
+	m.isCompletionsOpen = true
+	require.False(t, m.handleVimKey(vimKey("esc")))
+	require.True(t, m.IsVimInsertMode())
+}
@@ -88,9 +88,9 @@ func (h *header) View() string {
 		rightPadding
 
 	if remainingWidth > 0 {
-		b.WriteString(t.S().Base.Foreground(t.Primary).Render(
-			strings.Repeat(diag, max(minDiags, remainingWidth)),
-		))
+		if diags := styles.Rule(diag, max(minDiags, remainingWidth)); diags != "" {
+			b.WriteString(t.S().Base.Foreground(t.Primary).Render(diags))
+		}
 		b.WriteString(gap)
 	}
 
@@ -0,0 +1,58 @@
+package messages
+
+import (
+	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
+)
+
+// KeyMap holds the actions on the selected message or tool call of the
+// message list.
+type KeyMap struct {
+	Copy,
+	CopyCode,
+	EditPrompt,
+	RerunPrompt,
+	ToggleExpand,
+	ClearSelection key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return keymap.Apply("messages", KeyMap{
+		Copy: key.NewBinding(
+			key.WithKeys("c", "y"),
+			key.WithHelp("c/y", "copy"),
+		),
+		CopyCode: key.NewBinding(
+			key.WithKeys("C", "Y"),
+			key.WithHelp("C/Y", "copy code blocks"),
+		),
+		EditPrompt: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit prompt"),
+		),
+		RerunPrompt: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "re-run prompt"),
+		),
+		ToggleExpand: key.NewBinding(
+			key.WithKeys("enter", "o"),
+			key.WithHelp("enter", "expand/collapse output"),
+		),
+		ClearSelection: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "clear selection"),
+		),
+	})
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Copy,
+		k.CopyCode,
+		k.EditPrompt,
+		k.RerunPrompt,
+		k.ToggleExpand,
+		k.ClearSelection,
+	}
+}
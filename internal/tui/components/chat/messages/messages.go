@@ -6,7 +6,6 @@ import (
 	"strings"
 	"time"
 
-	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
@@ -26,12 +25,6 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/util"
 )
 
-// CopyKey is the key binding for copying message content to the clipboard.
-var CopyKey = key.NewBinding(key.WithKeys("c", "y", "C", "Y"), key.WithHelp("c/y", "copy"))
-
-// ClearSelectionKey is the key binding for clearing the current selection in the chat interface.
-var ClearSelectionKey = key.NewBinding(key.WithKeys("esc", "alt+esc"), key.WithHelp("esc", "clear selection"))
-
 // MessageCmp defines the interface for message components in the chat interface.
 // It combines standard UI model interfaces with message-specific functionality.
 type MessageCmp interface {
@@ -41,6 +34,8 @@ type MessageCmp interface {
 	GetMessage() message.Message    // Access to underlying message data
 	SetMessage(msg message.Message) // Update the message content
 	Spinning() bool                 // Animation state for loading messages
+	Copy() tea.Cmd                  // Copy the message text to the clipboard
+	CopyCode() tea.Cmd              // Copy the code blocks of the message to the clipboard
 	ID() string
 }
 
@@ -103,21 +98,71 @@ func (m *messageCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			m.anim = u.(*anim.Anim)
 			return m, cmd
 		}
-	case tea.KeyPressMsg:
-		if key.Matches(msg, CopyKey) {
-			return m, tea.Sequence(
-				tea.SetClipboard(m.message.Content().Text),
-				func() tea.Msg {
-					_ = clipboard.WriteAll(m.message.Content().Text)
-					return nil
-				},
-				util.ReportInfo("Message copied to clipboard"),
-			)
-		}
 	}
 	return m, nil
 }
 
+// Copy copies the message text to the clipboard.
+func (m *messageCmp) Copy() tea.Cmd {
+	return copyToClipboard(m.message.Content().Text, "Message copied to clipboard")
+}
+
+// CopyCode copies the code blocks of the message to the clipboard, separated
+// by blank lines.
+func (m *messageCmp) CopyCode() tea.Cmd {
+	blocks := codeBlocks(m.message.Content().Text)
+	if len(blocks) == 0 {
+		return util.ReportInfo("No code blocks in message")
+	}
+	info := "Code block copied to clipboard"
+	if len(blocks) > 1 {
+		info = fmt.Sprintf("%d code blocks copied to clipboard", len(blocks))
+	}
+	return copyToClipboard(strings.Join(blocks, "\n\n"), info)
+}
+
+// codeBlocks returns the contents of the fenced code blocks of a markdown
+// text.
+func codeBlocks(text string) []string {
+	var (
+		blocks []string
+		lines  []string
+		fence  string
+	)
+	for line := range strings.SplitSeq(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				lines = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+			fence = ""
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return blocks
+}
+
+// copyToClipboard copies text to the clipboard and reports it with the given
+// info message.
+func copyToClipboard(text, info string) tea.Cmd {
+	return tea.Sequence(
+		// We use both OSC 52 and native clipboard for compatibility with
+		// different terminal emulators and remote sessions.
+		tea.SetClipboard(text),
+		func() tea.Msg {
+			_ = clipboard.WriteAll(text)
+			return nil
+		},
+		util.ReportInfo(info),
+	)
+}
+
 // View renders the message component based on its current state.
 // Returns different views for spinning, user, and assistant messages.
 func (m *messageCmp) View() string {
@@ -162,6 +207,7 @@ func (msg *messageCmp) style() lipgloss.Style {
 	if msg.focused {
 		borderStyle = focusedMessageBorder
 	}
+	borderStyle = styles.Border(borderStyle)
 
 	style := t.S().Text
 	if msg.message.Role == message.User {
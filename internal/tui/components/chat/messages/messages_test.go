@@ -0,0 +1,18 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeBlocks(t *testing.T) {
+	t.Parallel()
+
+	text := "Run this:\n\n```bash\ngo test ./...\n```\n\nand then:\n\n~~~go\nfunc main() {\n\t```\n}\n~~~\n\n```\nunterminated"
+	require.Equal(t, []string{
+		"go test ./...",
+		"func main() {\n\t```\n}",
+	}, codeBlocks(text))
+	require.Empty(t, codeBlocks("no code here"))
+}
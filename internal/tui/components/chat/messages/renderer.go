@@ -19,8 +19,14 @@ import (
 	"github.com/charmbracelet/x/ansi"
 )
 
-// responseContextHeight limits the number of lines displayed in tool output
-const responseContextHeight = 10
+const (
+	// responseContextHeight limits the number of lines displayed in tool
+	// output
+	responseContextHeight = 10
+	// expandedResponseContextHeight limits the number of lines displayed in
+	// expanded tool output
+	expandedResponseContextHeight = 1000
+)
 
 // renderer defines the interface for tool-specific rendering implementations
 type renderer interface {
@@ -179,6 +185,8 @@ func init() {
 	registry.register(tools.LSToolName, func() renderer { return lsRenderer{} })
 	registry.register(tools.SourcegraphToolName, func() renderer { return sourcegraphRenderer{} })
 	registry.register(tools.DiagnosticsToolName, func() renderer { return diagnosticsRenderer{} })
+	registry.register(tools.CodeActionsToolName, func() renderer { return codeActionsRenderer{} })
+	registry.register(tools.MCPResourcesToolName, func() renderer { return mcpResourcesRenderer{} })
 	registry.register(agent.AgentToolName, func() renderer { return agentRenderer{} })
 }
 
@@ -456,16 +464,22 @@ func (er editRenderer) Render(v *toolCallCmp) string {
 		if v.textWidth() > 120 {
 			formatter = formatter.Split()
 		}
-		// add a message to the bottom if the content was truncated
+		// only render the lines that are shown and add a message to the
+		// bottom if the content was truncated
+		lineCount := formatter.LineCount()
+		maxLines := v.contextHeight()
+		if lineCount > maxLines {
+			formatter = formatter.Height(maxLines + 1)
+		}
 		formatted := formatter.String()
-		if lipgloss.Height(formatted) > responseContextHeight {
+		if lineCount > maxLines {
 			contentLines := strings.Split(formatted, "\n")
 			truncateMessage := t.S().Muted.
 				Background(t.BgBaseLighter).
 				PaddingLeft(2).
 				Width(v.textWidth() - 2).
-				Render(fmt.Sprintf("… (%d lines)", len(contentLines)-responseContextHeight))
-			formatted = strings.Join(contentLines[:responseContextHeight], "\n") + "\n" + truncateMessage
+				Render(fmt.Sprintf("… (%d lines)", lineCount-maxLines))
+			formatted = strings.Join(contentLines[:maxLines], "\n") + "\n" + truncateMessage
 		}
 		return formatted
 	})
@@ -507,16 +521,22 @@ func (mer multiEditRenderer) Render(v *toolCallCmp) string {
 		if v.textWidth() > 120 {
 			formatter = formatter.Split()
 		}
-		// add a message to the bottom if the content was truncated
+		// only render the lines that are shown and add a message to the
+		// bottom if the content was truncated
+		lineCount := formatter.LineCount()
+		maxLines := v.contextHeight()
+		if lineCount > maxLines {
+			formatter = formatter.Height(maxLines + 1)
+		}
 		formatted := formatter.String()
-		if lipgloss.Height(formatted) > responseContextHeight {
+		if lineCount > maxLines {
 			contentLines := strings.Split(formatted, "\n")
 			truncateMessage := t.S().Muted.
 				Background(t.BgBaseLighter).
 				PaddingLeft(2).
 				Width(v.textWidth() - 4).
-				Render(fmt.Sprintf("… (%d lines)", len(contentLines)-responseContextHeight))
-			formatted = strings.Join(contentLines[:responseContextHeight], "\n") + "\n" + truncateMessage
+				Render(fmt.Sprintf("… (%d lines)", lineCount-maxLines))
+			formatted = strings.Join(contentLines[:maxLines], "\n") + "\n" + truncateMessage
 		}
 
 		// Add failed edits warning if any exist
@@ -850,6 +870,66 @@ func (dr diagnosticsRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Code actions renderer
+// -----------------------------------------------------------------------------
+
+// codeActionsRenderer handles listing and applying LSP quick-fixes
+type codeActionsRenderer struct {
+	baseRenderer
+}
+
+// Render displays the file and quick-fix with the resulting diff, if any
+func (cr codeActionsRenderer) Render(v *toolCallCmp) string {
+	var params tools.CodeActionsParams
+	if err := cr.unmarshalParams(v.call.Input, &params); err != nil {
+		return cr.renderError(v, "Invalid code actions parameters")
+	}
+
+	args := newParamBuilder().
+		addMain(fsext.PrettyPath(params.FilePath)).
+		addKeyValue("line", formatNonZero(params.Line)).
+		addKeyValue("apply", params.Apply).
+		build()
+
+	return cr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		var meta tools.CodeActionsResponseMetadata
+		if err := cr.unmarshalParams(v.result.Metadata, &meta); err != nil || meta.Diff == "" {
+			return renderPlainContent(v, v.result.Content)
+		}
+		return renderPlainContent(v, meta.Diff)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  MCP resources renderer
+// -----------------------------------------------------------------------------
+
+// mcpResourcesRenderer handles listing and reading MCP resources
+type mcpResourcesRenderer struct {
+	baseRenderer
+}
+
+// Render displays the resource URI, or the server when listing, and the output
+func (mr mcpResourcesRenderer) Render(v *toolCallCmp) string {
+	var params tools.MCPResourcesParams
+	if err := mr.unmarshalParams(v.call.Input, &params); err != nil {
+		return mr.renderError(v, "Invalid MCP resources parameters")
+	}
+
+	builder := newParamBuilder()
+	if params.URI != "" {
+		builder.addMain(params.URI).addKeyValue("mcp", params.MCPName)
+	} else {
+		builder.addMain(cmp.Or(params.MCPName, "all"))
+	}
+	args := builder.build()
+
+	return mr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Task renderer
 // -----------------------------------------------------------------------------
@@ -867,8 +947,11 @@ func RoundedEnumeratorWithWidth(lPadding, width int) tree.Enumerator {
 		lPadding = 1
 	}
 	return func(children tree.Children, index int) string {
-		line := strings.Repeat("─", width)
 		padding := strings.Repeat(" ", lPadding)
+		if styles.ScreenReader() {
+			return padding + "-"
+		}
+		line := strings.Repeat("─", width)
 		if children.Length()-1 == index {
 			return padding + "╰" + line
 		}
@@ -1012,6 +1095,7 @@ func joinHeaderBody(header, body string) string {
 
 func renderPlainContent(v *toolCallCmp, content string) string {
 	t := styles.CurrentTheme()
+	maxLines := v.contextHeight()
 	content = strings.ReplaceAll(content, "\r\n", "\n") // Normalize line endings
 	content = strings.ReplaceAll(content, "\t", "    ") // Replace tabs with spaces
 	content = strings.TrimSpace(content)
@@ -1020,7 +1104,7 @@ func renderPlainContent(v *toolCallCmp, content string) string {
 	width := v.textWidth() - 2
 	var out []string
 	for i, ln := range lines {
-		if i >= responseContextHeight {
+		if i >= maxLines {
 			break
 		}
 		ln = ansiext.Escape(ln)
@@ -1034,11 +1118,11 @@ func renderPlainContent(v *toolCallCmp, content string) string {
 			Render(ln))
 	}
 
-	if len(lines) > responseContextHeight {
+	if len(lines) > maxLines {
 		out = append(out, t.S().Muted.
 			Background(t.BgBaseLighter).
 			Width(width).
-			Render(fmt.Sprintf("… (%d lines)", len(lines)-responseContextHeight)))
+			Render(fmt.Sprintf("… (%d lines)", len(lines)-maxLines)))
 	}
 
 	return strings.Join(out, "\n")
@@ -1046,6 +1130,7 @@ func renderPlainContent(v *toolCallCmp, content string) string {
 
 func renderMarkdownContent(v *toolCallCmp, content string) string {
 	t := styles.CurrentTheme()
+	maxLines := v.contextHeight()
 	content = strings.ReplaceAll(content, "\r\n", "\n")
 	content = strings.ReplaceAll(content, "\t", "    ")
 	content = strings.TrimSpace(content)
@@ -1063,17 +1148,17 @@ func renderMarkdownContent(v *toolCallCmp, content string) string {
 
 	var out []string
 	for i, ln := range lines {
-		if i >= responseContextHeight {
+		if i >= maxLines {
 			break
 		}
 		out = append(out, ln)
 	}
 
 	style := t.S().Muted.Background(t.BgBaseLighter)
-	if len(lines) > responseContextHeight {
+	if len(lines) > maxLines {
 		out = append(out, style.
 			Width(width-2).
-			Render(fmt.Sprintf("… (%d lines)", len(lines)-responseContextHeight)))
+			Render(fmt.Sprintf("… (%d lines)", len(lines)-maxLines)))
 	}
 
 	return style.Render(strings.Join(out, "\n"))
@@ -1098,9 +1183,10 @@ func getDigits(n int) int {
 
 func renderCodeContent(v *toolCallCmp, path, content string, offset int) string {
 	t := styles.CurrentTheme()
+	maxLines := v.contextHeight()
 	content = strings.ReplaceAll(content, "\r\n", "\n") // Normalize line endings
 	content = strings.ReplaceAll(content, "\t", "    ") // Replace tabs with spaces
-	truncated := truncateHeight(content, responseContextHeight)
+	truncated := truncateHeight(content, maxLines)
 
 	lines := strings.Split(truncated, "\n")
 	for i, ln := range lines {
@@ -1111,10 +1197,10 @@ func renderCodeContent(v *toolCallCmp, path, content string, offset int) string
 	highlighted, _ := highlight.SyntaxHighlight(strings.Join(lines, "\n"), path, bg)
 	lines = strings.Split(highlighted, "\n")
 
-	if len(strings.Split(content, "\n")) > responseContextHeight {
+	if len(strings.Split(content, "\n")) > maxLines {
 		lines = append(lines, t.S().Muted.
 			Background(bg).
-			Render(fmt.Sprintf(" …(%d lines)", len(strings.Split(content, "\n"))-responseContextHeight)))
+			Render(fmt.Sprintf(" …(%d lines)", len(strings.Split(content, "\n"))-maxLines)))
 	}
 
 	maxLineNumber := len(lines) + offset
@@ -1193,6 +1279,10 @@ func prettifyToolName(name string) string {
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
+	case tools.CodeActionsToolName:
+		return "Quick Fix"
+	case tools.MCPResourcesToolName:
+		return "MCP Resources"
 	default:
 		return name
 	}
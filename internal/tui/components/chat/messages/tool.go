@@ -7,10 +7,8 @@ import (
 	"strings"
 	"time"
 
-	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/diff"
@@ -43,6 +41,8 @@ type ToolCallCmp interface {
 	ID() string
 	SetPermissionRequested() // Mark permission request
 	SetPermissionGranted()   // Mark permission granted
+	Copy() tea.Cmd           // Copy the tool call and its result to the clipboard
+	ToggleExpanded()         // Show the whole output or only its first lines
 }
 
 // toolCallCmp implements the ToolCallCmp interface for displaying tool calls.
@@ -59,6 +59,7 @@ type toolCallCmp struct {
 	cancelled           bool               // Whether the tool call was cancelled
 	permissionRequested bool
 	permissionGranted   bool
+	expanded            bool // Whether the whole output is shown
 
 	// Animation state for pending tool calls
 	spinning bool       // Whether to show loading animation
@@ -164,10 +165,6 @@ func (m *toolCallCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 		return m, tea.Batch(cmds...)
-	case tea.KeyPressMsg:
-		if key.Matches(msg, CopyKey) {
-			return m, m.copyTool()
-		}
 	}
 	return m, nil
 }
@@ -196,16 +193,23 @@ func (m *toolCallCmp) SetCancelled() {
 	m.cancelled = true
 }
 
-func (m *toolCallCmp) copyTool() tea.Cmd {
-	content := m.formatToolForCopy()
-	return tea.Sequence(
-		tea.SetClipboard(content),
-		func() tea.Msg {
-			_ = clipboard.WriteAll(content)
-			return nil
-		},
-		util.ReportInfo("Tool content copied to clipboard"),
-	)
+// Copy copies the tool call and its result to the clipboard.
+func (m *toolCallCmp) Copy() tea.Cmd {
+	return copyToClipboard(m.formatToolForCopy(), "Tool content copied to clipboard")
+}
+
+// ToggleExpanded shows the whole output of the tool call, or only its first
+// lines again.
+func (m *toolCallCmp) ToggleExpanded() {
+	m.expanded = !m.expanded
+}
+
+// contextHeight returns the number of output lines to show.
+func (m *toolCallCmp) contextHeight() int {
+	if m.expanded {
+		return expandedResponseContextHeight
+	}
+	return responseContextHeight
 }
 
 func (m *toolCallCmp) formatToolForCopy() string {
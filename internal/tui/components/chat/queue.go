@@ -20,7 +20,7 @@ func queuePill(queue int, t *styles.Theme) string {
 	allTriangles := strings.Join(triangles, "")
 
 	return t.S().Base.
-		BorderStyle(lipgloss.RoundedBorder()).
+		BorderStyle(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BgOverlay).
 		PaddingLeft(1).
 		PaddingRight(1).
@@ -141,10 +141,11 @@ func (m *sidebarCmp) View() string {
 	}
 
 	if !m.compactMode {
-		parts = append(parts,
-			m.cwd,
-			"",
-		)
+		parts = append(parts, m.cwd)
+		if profile := config.Get().Profile(); profile != "" {
+			parts = append(parts, t.S().Subtle.Render("Profile: "+profile))
+		}
+		parts = append(parts, "")
 	}
 	parts = append(parts,
 		m.currentModelBlock(),
@@ -313,10 +314,16 @@ func (m *sidebarCmp) calculateAvailableHeight() int {
 
 	if !m.compactMode {
 		usedHeight += 1 // CWD line
+		if config.Get().Profile() != "" {
+			usedHeight += 1 // Profile line
+		}
 		usedHeight += 1 // Empty line after CWD
 	}
 
 	usedHeight += 2 // Model info
+	if hasCacheUsage(m.session) {
+		usedHeight += 1 // Cache usage
+	}
 
 	usedHeight += 6 // 3 sections × 2 lines each (header + empty line)
 
@@ -505,9 +512,8 @@ func (m *sidebarCmp) mcpBlock() string {
 	}, true)
 }
 
-func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
-	t := styles.CurrentTheme()
-	// Format tokens in human-readable format (e.g., 110K, 1.2M)
+// formatTokens formats tokens in human-readable format (e.g., 110K, 1.2M).
+func formatTokens(tokens int64) string {
 	var formattedTokens string
 	switch {
 	case tokens >= 1_000_000:
@@ -525,6 +531,12 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	if strings.HasSuffix(formattedTokens, ".0M") {
 		formattedTokens = strings.Replace(formattedTokens, ".0M", "M", 1)
 	}
+	return formattedTokens
+}
+
+func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
+	t := styles.CurrentTheme()
+	formattedTokens := formatTokens(tokens)
 
 	percentage := (float64(tokens) / float64(contextWindow)) * 100
 
@@ -543,6 +555,26 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	return fmt.Sprintf("%s %s", formattedTokens, formattedCost)
 }
 
+// formatCache shows the prompt tokens the last call read from and wrote to
+// the cache, and what caching saved over the session.
+func formatCache(read, written int64, savings float64) string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	usage := baseStyle.Foreground(t.FgMuted).Render(fmt.Sprintf("Cache %s read, %s written", formatTokens(read), formatTokens(written)))
+	saved := fmt.Sprintf("saved $%.2f", savings)
+	if savings < 0 {
+		saved = fmt.Sprintf("cost $%.2f", -savings)
+	}
+	return fmt.Sprintf("%s %s", usage, baseStyle.Foreground(t.FgSubtle).Render(saved))
+}
+
+// hasCacheUsage reports whether the session read from or wrote to the cache
+// of the provider.
+func hasCacheUsage(s session.Session) bool {
+	return s.CacheReadTokens > 0 || s.CacheWriteTokens > 0 || s.CacheSavings != 0
+}
+
 func (s *sidebarCmp) currentModelBlock() string {
 	cfg := config.Get()
 	agentCfg := cfg.Agents[config.AgentCoder]
@@ -588,6 +620,9 @@ func (s *sidebarCmp) currentModelBlock() string {
 				s.session.Cost,
 			),
 		)
+		if hasCacheUsage(s.session) {
+			parts = append(parts, "  "+formatCache(s.session.CacheReadTokens, s.session.CacheWriteTokens, s.session.CacheSavings))
+		}
 	}
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
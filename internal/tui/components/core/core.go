@@ -45,8 +45,8 @@ func Section(text string, width int) string {
 	length := lipgloss.Width(text) + 1
 	remainingWidth := width - length
 	lineStyle := t.S().Base.Foreground(t.Border)
-	if remainingWidth > 0 {
-		text = text + " " + lineStyle.Render(strings.Repeat(char, remainingWidth))
+	if line := styles.Rule(char, remainingWidth); line != "" {
+		text = text + " " + lineStyle.Render(line)
 	}
 	return text
 }
@@ -61,8 +61,10 @@ func SectionWithInfo(text string, width int, info string) string {
 		remainingWidth -= lipgloss.Width(info) + 1 // 1 for the space before info
 	}
 	lineStyle := t.S().Base.Foreground(t.Border)
-	if remainingWidth > 0 {
-		text = text + " " + lineStyle.Render(strings.Repeat(char, remainingWidth)) + " " + info
+	if line := styles.Rule(char, remainingWidth); line != "" {
+		text = text + " " + lineStyle.Render(line) + " " + info
+	} else if remainingWidth > 0 && info != "" {
+		text = text + " " + info
 	}
 	return text
 }
@@ -73,8 +75,7 @@ func Title(title string, width int) string {
 	length := lipgloss.Width(title) + 1
 	remainingWidth := width - length
 	titleStyle := t.S().Base.Foreground(t.Primary)
-	if remainingWidth > 0 {
-		lines := strings.Repeat(char, remainingWidth)
+	if lines := styles.Rule(char, remainingWidth); lines != "" {
 		lines = styles.ApplyForegroundGrad(lines, t.Primary, t.Secondary)
 		title = titleStyle.Render(title) + " " + lines
 	}
@@ -198,10 +199,13 @@ func SelectableButtonsVertical(buttons []ButtonOpts, spacing int) string {
 	return lipgloss.JoinVertical(lipgloss.Center, parts...)
 }
 
+// LargeHunkLines is the number of lines above which diff hunks start folded.
+const LargeHunkLines = 200
+
 func DiffFormatter() *diffview.DiffView {
 	t := styles.CurrentTheme()
 	formatDiff := diffview.New()
 	style := chroma.MustNewStyle("crush", styles.GetChromaTheme())
-	diff := formatDiff.ChromaStyle(style).Style(t.S().Diff).TabWidth(4)
+	diff := formatDiff.ChromaStyle(style).Style(t.S().Diff).TabWidth(4).WordDiff(true)
 	return diff
 }
@@ -211,7 +211,7 @@ func (c *commandArgumentsDialogCmp) View() string {
 	content := lipgloss.JoinVertical(lipgloss.Left, elements...)
 
 	return baseStyle.Padding(1, 1, 0, 1).
-		Border(lipgloss.RoundedBorder()).
+		Border(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BorderFocus).
 		Width(c.width).
 		Render(content)
@@ -14,13 +14,16 @@ import (
 
 	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 )
@@ -83,9 +86,26 @@ type (
 	OpenReasoningDialogMsg struct{}
 	OpenExternalEditorMsg  struct{}
 	ToggleYoloModeMsg      struct{}
+	OpenMCPDialogMsg       struct{}
+	OpenContextUsageMsg    struct{}
+	SwitchThemeMsg         struct{}
 	CompactMsg             struct {
 		SessionID string
 	}
+	ReviewChangesMsg struct {
+		SessionID string
+	}
+	RestartLSPMsg struct {
+		Name string
+	}
+	StopLSPMsg struct {
+		Name string
+	}
+	// SwitchProfileMsg asks to switch to the named configuration profile,
+	// or to none when empty.
+	SwitchProfileMsg struct {
+		Name string
+	}
 )
 
 func NewCommandDialog(sessionID string) CommandsDialog {
@@ -105,6 +125,7 @@ func NewCommandDialog(sessionID string) CommandsDialog {
 			list.WithKeyMap(listKeyMap),
 			list.WithWrapNavigation(),
 			list.WithResizeByList(),
+			list.WithMouseWheelSelection(),
 		),
 	)
 	help := help.New()
@@ -149,6 +170,10 @@ func (c *commandDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			}
 			return c, nil
 		}
+	case tea.MouseWheelMsg:
+		u, cmd := c.commandList.Update(msg)
+		c.commandList = u.(listModel)
+		return c, cmd
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, c.keyMap.Select):
@@ -301,7 +326,7 @@ func (c *commandDialogCmp) style() lipgloss.Style {
 	t := styles.CurrentTheme()
 	return t.S().Base.
 		Width(c.width).
-		Border(lipgloss.RoundedBorder()).
+		Border(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BorderFocus)
 }
 
@@ -318,7 +343,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "new_session",
 			Title:       "New Session",
 			Description: "start a new session",
-			Shortcut:    "ctrl+n",
+			Shortcut:    keymap.Shortcut("chat.new_session", "ctrl+n"),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(NewSessionsMsg{})
 			},
@@ -327,7 +352,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "switch_session",
 			Title:       "Switch Session",
 			Description: "Switch to a different session",
-			Shortcut:    "ctrl+s",
+			Shortcut:    keymap.Shortcut("app.sessions", "ctrl+s"),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(SwitchSessionsMsg{})
 			},
@@ -336,11 +361,19 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "switch_model",
 			Title:       "Switch Model",
 			Description: "Switch to a different model",
-			Shortcut:    "ctrl+l",
+			Shortcut:    keymap.Shortcut("app.models", "ctrl+l"),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(SwitchModelMsg{})
 			},
 		},
+		{
+			ID:          "switch_theme",
+			Title:       "Switch Theme",
+			Description: "Preview and switch the color theme",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(SwitchThemeMsg{})
+			},
+		},
 	}
 
 	// Only show compact command if there's an active session
@@ -357,6 +390,29 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 
+	commands = append(commands, Command{
+		ID:          "context_usage",
+		Title:       "View Context Usage",
+		Description: "Break down the context window the next message takes",
+		Handler: func(cmd Command) tea.Cmd {
+			return util.CmdHandler(OpenContextUsageMsg{})
+		},
+	})
+
+	// Only show review command if there's an active session
+	if c.sessionID != "" {
+		commands = append(commands, Command{
+			ID:          "review_changes",
+			Title:       "Review Changes",
+			Description: "Review, revert and export the files changed in the session",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ReviewChangesMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+	}
+
 	// Add reasoning toggle for models that support it
 	cfg := config.Get()
 	if agentCfg, ok := cfg.Agents[config.AgentCoder]; ok {
@@ -412,7 +468,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			commands = append(commands, Command{
 				ID:          "file_picker",
 				Title:       "Open File Picker",
-				Shortcut:    "ctrl+f",
+				Shortcut:    keymap.Shortcut("chat.add_attachment", "ctrl+f"),
 				Description: "Open file picker",
 				Handler: func(cmd Command) tea.Cmd {
 					return util.CmdHandler(OpenFilePickerMsg{})
@@ -426,7 +482,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		commands = append(commands, Command{
 			ID:          "open_external_editor",
 			Title:       "Open External Editor",
-			Shortcut:    "ctrl+o",
+			Shortcut:    keymap.Shortcut("editor.open_editor", "ctrl+o"),
 			Description: "Open external editor to compose message",
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(OpenExternalEditorMsg{})
@@ -434,6 +490,20 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 
+	commands = append(commands, lspCommands()...)
+	commands = append(commands, profileCommands()...)
+
+	if len(config.Get().MCP) > 0 {
+		commands = append(commands, Command{
+			ID:          "mcp_servers",
+			Title:       "Manage MCP Servers",
+			Description: "Reconnect, disable or enable MCP servers",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenMCPDialogMsg{})
+			},
+		})
+	}
+
 	return append(commands, []Command{
 		{
 			ID:          "toggle_yolo",
@@ -446,7 +516,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
-			Shortcut:    "ctrl+g",
+			Shortcut:    keymap.Shortcut("app.help", "ctrl+g"),
 			Description: "Toggle help",
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(ToggleHelpMsg{})
@@ -470,7 +540,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "quit",
 			Title:       "Quit",
 			Description: "Quit",
-			Shortcut:    "ctrl+c",
+			Shortcut:    keymap.Shortcut("app.quit", "ctrl+c"),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(QuitMsg{})
 			},
@@ -478,6 +548,84 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 	}...)
 }
 
+// lspCommands returns the commands to restart and stop or start each
+// enabled LSP server.
+func lspCommands() []Command {
+	var commands []Command
+	states := app.GetLSPStates()
+	for _, l := range config.Get().LSP.Sorted() {
+		if l.LSP.Disabled {
+			continue
+		}
+		info, ok := states[l.Name]
+		if !ok || info.State == lsp.StateDisabled {
+			continue
+		}
+		name := l.Name
+		if info.State == lsp.StateStopped || info.State == lsp.StateIdle {
+			commands = append(commands, Command{
+				ID:          "start_lsp_" + name,
+				Title:       "Start LSP: " + name,
+				Description: fmt.Sprintf("Start the %s language server", name),
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(RestartLSPMsg{Name: name})
+				},
+			})
+			continue
+		}
+		commands = append(commands,
+			Command{
+				ID:          "restart_lsp_" + name,
+				Title:       "Restart LSP: " + name,
+				Description: fmt.Sprintf("Restart the %s language server", name),
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(RestartLSPMsg{Name: name})
+				},
+			},
+			Command{
+				ID:          "stop_lsp_" + name,
+				Title:       "Stop LSP: " + name,
+				Description: fmt.Sprintf("Stop the %s language server", name),
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(StopLSPMsg{Name: name})
+				},
+			},
+		)
+	}
+	return commands
+}
+
+// profileCommands lists a command switching to each profile but the one in
+// use, and one switching back to no profile when one is in use.
+func profileCommands() []Command {
+	cfg := config.Get()
+	var commands []Command
+	for _, name := range cfg.ProfileNames() {
+		if name == cfg.Profile() {
+			continue
+		}
+		commands = append(commands, Command{
+			ID:          "switch_profile_" + name,
+			Title:       "Switch Profile: " + name,
+			Description: fmt.Sprintf("Reload the configuration with the %s profile", name),
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(SwitchProfileMsg{Name: name})
+			},
+		})
+	}
+	if cfg.Profile() != "" {
+		commands = append(commands, Command{
+			ID:          "clear_profile",
+			Title:       "Clear Profile",
+			Description: fmt.Sprintf("Reload the configuration without the %s profile", cfg.Profile()),
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(SwitchProfileMsg{})
+			},
+		})
+	}
+	return commands
+}
+
 func (c *commandDialogCmp) ID() dialogs.DialogID {
 	return CommandsDialogID
 }
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 )
 
 type CommandsDialogKeyMap struct {
@@ -13,7 +14,7 @@ type CommandsDialogKeyMap struct {
 }
 
 func DefaultCommandsDialogKeyMap() CommandsDialogKeyMap {
-	return CommandsDialogKeyMap{
+	return keymap.Apply("commands", CommandsDialogKeyMap{
 		Select: key.NewBinding(
 			key.WithKeys("enter", "ctrl+y"),
 			key.WithHelp("enter", "confirm"),
@@ -34,7 +35,7 @@ func DefaultCommandsDialogKeyMap() CommandsDialogKeyMap {
 			key.WithKeys("esc", "alt+esc"),
 			key.WithHelp("esc", "cancel"),
 		),
-	}
+	})
 }
 
 // KeyBindings implements layout.KeyMapProvider
@@ -80,7 +81,7 @@ type ArgumentsDialogKeyMap struct {
 }
 
 func DefaultArgumentsDialogKeyMap() ArgumentsDialogKeyMap {
-	return ArgumentsDialogKeyMap{
+	return keymap.Apply("arguments", ArgumentsDialogKeyMap{
 		Confirm: key.NewBinding(
 			key.WithKeys("enter"),
 			key.WithHelp("enter", "confirm"),
@@ -98,7 +99,7 @@ func DefaultArgumentsDialogKeyMap() ArgumentsDialogKeyMap {
 			key.WithKeys("esc", "alt+esc"),
 			key.WithHelp("esc", "cancel"),
 		),
-	}
+	})
 }
 
 // KeyBindings implements layout.KeyMapProvider
@@ -0,0 +1,194 @@
+package contextusage
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const ContextUsageDialogID dialogs.DialogID = "context_usage"
+
+// maxItems is the number of items listed under each category.
+const maxItems = 5
+
+// ContextUsageDialog interface for the context usage dialog
+type ContextUsageDialog interface {
+	dialogs.DialogModel
+}
+
+type contextUsageDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+	offset  int
+	usage   agent.ContextUsage
+	keyMap  KeyMap
+	help    help.Model
+}
+
+// NewContextUsageDialogCmp creates a dialog that breaks down the context
+// window the next message takes.
+func NewContextUsageDialogCmp(usage agent.ContextUsage) ContextUsageDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &contextUsageDialogCmp{
+		usage:  usage,
+		keyMap: DefaultKeyMap(),
+		help:   help,
+	}
+}
+
+func (m *contextUsageDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *contextUsageDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		m.width = min(80, m.wWidth-8)
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, m.keyMap.Close):
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, m.keyMap.Down):
+			m.offset++
+		case key.Matches(msg, m.keyMap.Up):
+			m.offset = max(m.offset-1, 0)
+		}
+	case tea.MouseWheelMsg:
+		switch msg.Button {
+		case tea.MouseWheelDown:
+			m.offset++
+		case tea.MouseWheelUp:
+			m.offset = max(m.offset-1, 0)
+		}
+	}
+	return m, nil
+}
+
+func (m *contextUsageDialogCmp) View() string {
+	t := styles.CurrentTheme()
+
+	rows := m.rows()
+	// Room left by the title, the summary and the help.
+	height := max(m.wHeight/2, 5)
+	m.offset = min(m.offset, max(len(rows)-height, 0))
+	rows = rows[m.offset:min(m.offset+height, len(rows))]
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Context Usage", m.width-4)),
+		t.S().Base.PaddingLeft(1).Render(m.summary()),
+		"",
+		t.S().Base.PaddingLeft(1).Render(lipgloss.JoinVertical(lipgloss.Left, rows...)),
+		"",
+		t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(m.keyMap)),
+	)
+	return m.style().Render(content)
+}
+
+// summary shows the model, the share of its context window taken and the
+// tokenizer the tokens were counted with.
+func (m *contextUsageDialogCmp) summary() string {
+	t := styles.CurrentTheme()
+	tokens := m.usage.Tokens()
+	total := formatTokens(tokens)
+	if m.usage.ContextWindow > 0 {
+		percentage := float64(tokens) / float64(m.usage.ContextWindow) * 100
+		total = fmt.Sprintf("%s / %s tokens (%d%%)", total, formatTokens(m.usage.ContextWindow), int(percentage))
+		if percentage > 80 {
+			total = styles.WarningIcon + " " + total
+		}
+	} else {
+		total += " tokens"
+	}
+	line := t.S().Text.Render(m.usage.Model) + " " + t.S().Muted.Render(total)
+	note := t.S().Subtle.Render(fmt.Sprintf("Estimated locally with the %s tokenizer", m.usage.Tokenizer))
+	return lipgloss.JoinVertical(lipgloss.Left, ansi.Truncate(line, m.width-4, "…"), note)
+}
+
+// rows lists the categories with their largest items.
+func (m *contextUsageDialogCmp) rows() []string {
+	t := styles.CurrentTheme()
+	var rows []string
+	for _, c := range m.usage.Categories {
+		rows = append(rows, m.row(t.S().Text.Render(c.Name), c.Tokens, true))
+		for i, item := range c.Items {
+			if i == maxItems {
+				var rest int64
+				for _, item := range c.Items[i:] {
+					rest += item.Tokens
+				}
+				rows = append(rows, m.row(t.S().Subtle.Render(fmt.Sprintf("  …%d more", len(c.Items)-i)), rest, false))
+				break
+			}
+			rows = append(rows, m.row(t.S().Subtle.Render("  "+item.Name), item.Tokens, false))
+		}
+	}
+	return rows
+}
+
+// row renders the name with its tokens, and their share of the context
+// window for categories, aligned on the right.
+func (m *contextUsageDialogCmp) row(name string, tokens int64, category bool) string {
+	t := styles.CurrentTheme()
+	info := formatTokens(tokens)
+	if category && m.usage.ContextWindow > 0 {
+		info = fmt.Sprintf("%s %5.1f%%", info, float64(tokens)/float64(m.usage.ContextWindow)*100)
+	} else if category {
+		info += strings.Repeat(" ", 7)
+	}
+	width := m.width - 4
+	info = t.S().Muted.Render(info)
+	name = ansi.Truncate(name, width-lipgloss.Width(info)-1, "…")
+	gap := max(width-lipgloss.Width(name)-lipgloss.Width(info), 1)
+	return name + strings.Repeat(" ", gap) + info
+}
+
+func (m *contextUsageDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(m.width).
+		Border(styles.Border(lipgloss.RoundedBorder())).
+		BorderForeground(t.BorderFocus)
+}
+
+func (m *contextUsageDialogCmp) Position() (int, int) {
+	row := m.wHeight/4 - 2 // just a bit above the center
+	col := m.wWidth / 2
+	col -= m.width / 2
+	return row, col
+}
+
+// ID implements ContextUsageDialog.
+func (m *contextUsageDialogCmp) ID() dialogs.DialogID {
+	return ContextUsageDialogID
+}
+
+// formatTokens formats tokens in human-readable format (e.g., 110K, 1.2M).
+func formatTokens(tokens int64) string {
+	var formatted string
+	switch {
+	case tokens >= 1_000_000:
+		formatted = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		formatted = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+	formatted = strings.Replace(formatted, ".0K", "K", 1)
+	return strings.Replace(formatted, ".0M", "M", 1)
+}
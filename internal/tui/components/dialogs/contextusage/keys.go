@@ -0,0 +1,62 @@
+package contextusage
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
+)
+
+type KeyMap struct {
+	Down,
+	Up,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return keymap.Apply("context_usage", KeyMap{
+		Down: key.NewBinding(
+			key.WithKeys("down", "ctrl+n", "j"),
+			key.WithHelp("↓", "scroll down"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "ctrl+p", "k"),
+			key.WithHelp("↑", "scroll up"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc", "enter", "q"),
+			key.WithHelp("esc", "exit"),
+		),
+	})
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Down,
+		k.Up,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.KeyBindings()}
+}
+
+// ShortHelp implements help.KeyMap. Scrolling shows the keys configured for
+// up and down together.
+func (k KeyMap) ShortHelp() []key.Binding {
+	var shortcuts []string
+	for _, b := range []key.Binding{k.Up, k.Down} {
+		if b.Enabled() {
+			shortcuts = append(shortcuts, b.Help().Key)
+		}
+	}
+	scroll := key.NewBinding(
+		key.WithKeys(append(k.Up.Keys(), k.Down.Keys()...)...),
+		key.WithHelp(strings.Join(shortcuts, "/"), "scroll"),
+	)
+	scroll.SetEnabled(len(shortcuts) > 0)
+	return []key.Binding{scroll, k.Close}
+}
@@ -0,0 +1,24 @@
+package contextusage
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/tui/keymap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultKeyMap(t *testing.T) {
+	keymap.Set(map[string][]string{
+		"context_usage.down":  {"ctrl+j"},
+		"context_usage.close": {"x"},
+	})
+	t.Cleanup(func() { keymap.Set(nil) })
+
+	km := DefaultKeyMap()
+	require.Equal(t, []string{"ctrl+j"}, km.Down.Keys())
+	require.Equal(t, []string{"x"}, km.Close.Keys())
+
+	scroll := km.ShortHelp()[0]
+	require.Equal(t, "↑/ctrl+j", scroll.Help().Key)
+	require.Equal(t, []string{"up", "ctrl+p", "k", "ctrl+j"}, scroll.Keys())
+}
@@ -89,6 +89,17 @@ func (m *model) Init() tea.Cmd {
 }
 
 func (m *model) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	// The file picker only moves with keys, so the wheel moves like them.
+	if wheel, ok := msg.(tea.MouseWheelMsg); ok {
+		switch wheel.Button {
+		case tea.MouseWheelDown:
+			msg = tea.KeyPressMsg{Code: tea.KeyDown}
+		case tea.MouseWheelUp:
+			msg = tea.KeyPressMsg{Code: tea.KeyUp}
+		default:
+			return m, nil
+		}
+	}
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.wWidth = msg.Width
@@ -226,7 +237,7 @@ func (m *model) style() lipgloss.Style {
 	t := styles.CurrentTheme()
 	return t.S().Base.
 		Width(m.width).
-		Border(lipgloss.RoundedBorder()).
+		Border(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BorderFocus)
 }
 
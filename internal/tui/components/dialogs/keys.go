@@ -2,6 +2,7 @@ package dialogs
 
 import (
 	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 )
 
 // KeyMap defines keyboard bindings for dialog management.
@@ -10,11 +11,11 @@ type KeyMap struct {
 }
 
 func DefaultKeyMap() KeyMap {
-	return KeyMap{
+	return keymap.Apply("dialog", KeyMap{
 		Close: key.NewBinding(
 			key.WithKeys("esc", "alt+esc"),
 		),
-	}
+	})
 }
 
 // KeyBindings implements layout.KeyMapProvider
@@ -0,0 +1,191 @@
+package mcps
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const MCPsDialogID dialogs.DialogID = "mcps"
+
+type (
+	// ReconnectMCPMsg asks to reconnect, or re-enable, the named MCP.
+	ReconnectMCPMsg struct {
+		Name string
+	}
+	// DisableMCPMsg asks to disable the named MCP until it's reconnected.
+	DisableMCPMsg struct {
+		Name string
+	}
+)
+
+// MCPsDialog interface for the MCP servers dialog
+type MCPsDialog interface {
+	dialogs.DialogModel
+}
+
+type mcpsDialogCmp struct {
+	wWidth   int
+	wHeight  int
+	width    int
+	selected int
+	keyMap   KeyMap
+	help     help.Model
+}
+
+// NewMCPsDialogCmp creates a dialog that shows the state of each MCP server
+// and allows reconnecting, disabling and enabling them.
+func NewMCPsDialogCmp() MCPsDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &mcpsDialogCmp{
+		keyMap: DefaultKeyMap(),
+		help:   help,
+	}
+}
+
+func (m *mcpsDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *mcpsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		m.width = min(100, m.wWidth-8)
+	case tea.KeyPressMsg:
+		names := m.names()
+		switch {
+		case key.Matches(msg, m.keyMap.Close):
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case len(names) == 0:
+			return m, nil
+		case key.Matches(msg, m.keyMap.Next):
+			m.selected = (m.selected + 1) % len(names)
+		case key.Matches(msg, m.keyMap.Previous):
+			m.selected = (m.selected - 1 + len(names)) % len(names)
+		case key.Matches(msg, m.keyMap.Reconnect):
+			return m, util.CmdHandler(ReconnectMCPMsg{Name: names[m.selected]})
+		case key.Matches(msg, m.keyMap.Disable):
+			return m, util.CmdHandler(DisableMCPMsg{Name: names[m.selected]})
+		}
+	case tea.MouseWheelMsg:
+		names := m.names()
+		if len(names) == 0 {
+			return m, nil
+		}
+		switch msg.Button {
+		case tea.MouseWheelDown:
+			m.selected = min(m.selected+1, len(names)-1)
+		case tea.MouseWheelUp:
+			m.selected = max(m.selected-1, 0)
+		}
+	}
+	return m, nil
+}
+
+func (m *mcpsDialogCmp) names() []string {
+	var names []string
+	for _, l := range config.Get().MCP.Sorted() {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+func (m *mcpsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	names := m.names()
+	m.selected = min(m.selected, max(len(names)-1, 0))
+	states := mcp.GetStates()
+
+	rows := []string{}
+	for i, name := range names {
+		rows = append(rows, m.renderItem(name, states[name], i == m.selected))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, t.S().Subtle.Render("No MCP servers configured"))
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("MCP Servers", m.width-4)),
+		t.S().Base.PaddingLeft(1).Render(lipgloss.JoinVertical(lipgloss.Left, rows...)),
+		"",
+		t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(m.keyMap)),
+	)
+	return m.style().Render(content)
+}
+
+func (m *mcpsDialogCmp) renderItem(name string, info mcp.ClientInfo, selected bool) string {
+	t := styles.CurrentTheme()
+	icon := t.ItemOfflineIcon
+	var details []string
+	switch info.State {
+	case mcp.StateDisabled:
+		details = append(details, "disabled")
+	case mcp.StateStarting:
+		icon = t.ItemBusyIcon
+		details = append(details, "starting...")
+	case mcp.StateConnected:
+		icon = t.ItemOnlineIcon
+		details = append(details,
+			fmt.Sprintf("%d tools", info.Counts.Tools),
+			fmt.Sprintf("%d prompts", info.Counts.Prompts),
+			fmt.Sprintf("%d resources", info.Counts.Resources),
+		)
+		if !info.ConnectedAt.IsZero() {
+			details = append(details, "since "+info.ConnectedAt.Format("15:04:05"))
+		}
+	case mcp.StateError:
+		icon = t.ItemErrorIcon
+		details = append(details, "error")
+	}
+
+	cursor := "  "
+	title := t.S().Text
+	if selected {
+		cursor = t.S().Base.Foreground(t.Primary).Render("> ")
+		title = title.Foreground(t.Primary)
+	}
+	line := cursor + icon.String() + " " + title.Render(name) + " " + t.S().Subtle.Render(strings.Join(details, ", "))
+	line = ansi.Truncate(line, m.width-4, "…")
+
+	if info.State != mcp.StateError || info.Error == nil {
+		return line
+	}
+	errStyle := t.S().Base.Foreground(t.Error).PaddingLeft(4).Width(m.width - 4)
+	return lipgloss.JoinVertical(lipgloss.Left, line, errStyle.Render(info.Error.Error()))
+}
+
+func (m *mcpsDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(m.width).
+		Border(styles.Border(lipgloss.RoundedBorder())).
+		BorderForeground(t.BorderFocus)
+}
+
+func (m *mcpsDialogCmp) Position() (int, int) {
+	row := m.wHeight/4 - 2 // just a bit above the center
+	col := m.wWidth / 2
+	col -= m.width / 2
+	return row, col
+}
+
+// ID implements MCPsDialog.
+func (m *mcpsDialogCmp) ID() dialogs.DialogID {
+	return MCPsDialogID
+}
@@ -9,6 +9,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/secret"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 )
@@ -146,8 +147,11 @@ func (a *APIKeyInput) View() string {
 
 	dataPath := config.GlobalConfigData()
 	dataPath = home.Short(dataPath)
-	helpText := styles.CurrentTheme().S().Muted.
-		Render(fmt.Sprintf("This will be written to the global configuration: %s", dataPath))
+	help := fmt.Sprintf("This will be written to the global configuration: %s", dataPath)
+	if store := secret.Default(); store != nil {
+		help = fmt.Sprintf("This will be stored in the %s, referenced from: %s", store.Name(), dataPath)
+	}
+	helpText := styles.CurrentTheme().S().Muted.Render(help)
 
 	var content string
 	if a.showTitle && a.title != "" {
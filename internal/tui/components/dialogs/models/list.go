@@ -20,6 +20,33 @@ type ModelListComponent struct {
 	list      listModel
 	modelType int
 	providers []catwalk.Provider
+	// promptTokens is the estimated size of the system prompt and tools,
+	// zero when unknown.
+	promptTokens int64
+}
+
+// ContextTooSmall reports whether the context window of the model cannot
+// hold the system prompt and tools of the given size along with a response.
+func ContextTooSmall(model catwalk.Model, promptTokens int64) bool {
+	if promptTokens == 0 || model.ContextWindow == 0 {
+		return false
+	}
+	return model.ContextWindow < promptTokens+model.DefaultMaxTokens
+}
+
+// contextWarning returns the warning shown next to the large models whose
+// context window is too small, the small ones running without the tools.
+func (m *ModelListComponent) contextWarning(model catwalk.Model) string {
+	if m.modelType != LargeModelType || !ContextTooSmall(model, m.promptTokens) {
+		return ""
+	}
+	return styles.WarningIcon + " context too small"
+}
+
+// SetPromptTokens sets the estimated size of the system prompt and tools,
+// to warn about the models whose context window is too small for them.
+func (m *ModelListComponent) SetPromptTokens(tokens int64) {
+	m.promptTokens = tokens
 }
 
 func modelKey(providerID, modelID string) string {
@@ -35,6 +62,7 @@ func NewModelListComponent(keyMap list.KeyMap, inputPlaceholder string, shouldRe
 	options := []list.ListOption{
 		list.WithKeyMap(keyMap),
 		list.WithWrapNavigation(),
+		list.WithMouseWheelSelection(),
 	}
 	if shouldResize {
 		options = append(options, list.WithResizeByList())
@@ -127,6 +155,8 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 
 	configuredIcon := t.S().Base.Foreground(t.Success).Render(styles.CheckIcon)
 	configured := fmt.Sprintf("%s %s", configuredIcon, t.S().Subtle.Render("Configured"))
+	local := fmt.Sprintf("%s %s", configuredIcon, t.S().Subtle.Render("Local"))
+	unreachable := t.S().Subtle.Render("Unreachable")
 
 	// Create a map to track which providers we've already added
 	addedProviders := make(map[string]bool)
@@ -176,7 +206,13 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 				name = string(configProvider.ID)
 			}
 			section := list.NewItemSection(name)
-			section.SetInfo(configured)
+			if providerConfig.Unreachable {
+				section.SetInfo(unreachable)
+			} else if providerConfig.Discovered {
+				section.SetInfo(local)
+			} else {
+				section.SetInfo(configured)
+			}
 			group := list.Group[list.CompletionItem[ModelOption]]{
 				Section: section,
 			}
@@ -190,6 +226,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 					model.Name,
 					modelOption,
 					list.WithCompletionID(key),
+					list.WithCompletionShortcut(m.contextWarning(model)),
 				)
 				itemsByKey[key] = item
 
@@ -263,6 +300,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 				model.Name,
 				modelOption,
 				list.WithCompletionID(key),
+				list.WithCompletionShortcut(m.contextWarning(model)),
 			)
 			itemsByKey[key] = item
 			group.Items = append(group.Items, item)
@@ -292,6 +330,9 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 			if providerName == "" {
 				providerName = string(modelOption.Provider.ID)
 			}
+			if warning := m.contextWarning(modelOption.Model); warning != "" {
+				providerName += " " + warning
+			}
 			item := list.NewCompletionItem(
 				modelOption.Model.Name,
 				option.Value(),
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -69,7 +70,10 @@ type modelDialogCmp struct {
 	apiKeyValue       string
 }
 
-func NewModelDialogCmp() ModelDialog {
+// NewModelDialogCmp returns the dialog to select models, warning about the
+// models whose context window cannot hold the system prompt and tools of
+// promptTokens tokens.
+func NewModelDialogCmp(promptTokens int64) ModelDialog {
 	keyMap := DefaultKeyMap()
 
 	listKeyMap := list.DefaultKeyMap()
@@ -80,6 +84,7 @@ func NewModelDialogCmp() ModelDialog {
 
 	t := styles.CurrentTheme()
 	modelList := NewModelListComponent(listKeyMap, largeModelInputPlaceholder, true)
+	modelList.SetPromptTokens(promptTokens)
 	apiKeyInput := NewAPIKeyInput()
 	apiKeyInput.SetShowTitle(false)
 	help := help.New()
@@ -94,8 +99,21 @@ func NewModelDialogCmp() ModelDialog {
 	}
 }
 
+// localModelsDiscoveredMsg is sent when local models were added or removed
+// while the dialog was opening.
+type localModelsDiscoveredMsg struct{}
+
 func (m *modelDialogCmp) Init() tea.Cmd {
-	return tea.Batch(m.modelList.Init(), m.apiKeyInput.Init())
+	return tea.Batch(m.modelList.Init(), m.apiKeyInput.Init(), discoverLocalModels)
+}
+
+// discoverLocalModels looks for local models again, as local servers may have
+// started or stopped since Crush did.
+func discoverLocalModels() tea.Msg {
+	if config.Get().DiscoverLocalModels(context.Background()) {
+		return localModelsDiscoveredMsg{}
+	}
+	return nil
 }
 
 func (m *modelDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
@@ -106,6 +124,8 @@ func (m *modelDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		m.apiKeyInput.SetWidth(m.width - 2)
 		m.help.SetWidth(m.width - 2)
 		return m, m.modelList.SetSize(m.listWidth(), m.listHeight())
+	case localModelsDiscoveredMsg:
+		return m, m.modelList.SetModelType(m.modelList.GetModelType())
 	case APIKeyStateChangeMsg:
 		u, cmd := m.apiKeyInput.Update(msg)
 		m.apiKeyInput = u.(*APIKeyInput)
@@ -234,6 +254,13 @@ func (m *modelDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			m.modelList, cmd = m.modelList.Update(msg)
 			return m, cmd
 		}
+	case tea.MouseWheelMsg:
+		if m.needsAPIKey {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.modelList, cmd = m.modelList.Update(msg)
+		return m, cmd
 	case spinner.TickMsg:
 		u, cmd := m.apiKeyInput.Update(msg)
 		m.apiKeyInput = u.(*APIKeyInput)
@@ -295,7 +322,7 @@ func (m *modelDialogCmp) style() lipgloss.Style {
 	t := styles.CurrentTheme()
 	return t.S().Base.
 		Width(m.width).
-		Border(lipgloss.RoundedBorder()).
+		Border(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BorderFocus)
 }
 
@@ -2,6 +2,7 @@ package permissions
 
 import (
 	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -17,10 +18,15 @@ type KeyMap struct {
 	ScrollUp key.Binding
 	ScrollLeft,
 	ScrollRight key.Binding
+	NextHunk,
+	PrevHunk,
+	ToggleHunk,
+	FoldHunk,
+	OpenEditor key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
-	return KeyMap{
+	return keymap.Apply("permissions", KeyMap{
 		Left: key.NewBinding(
 			key.WithKeys("left", "h"),
 			key.WithHelp("←", "previous"),
@@ -69,7 +75,27 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("shift+right", "L"),
 			key.WithHelp("shift+→", "scroll right"),
 		),
-	}
+		NextHunk: key.NewBinding(
+			key.WithKeys("n", "]"),
+			key.WithHelp("n", "next hunk"),
+		),
+		PrevHunk: key.NewBinding(
+			key.WithKeys("p", "["),
+			key.WithHelp("p", "previous hunk"),
+		),
+		ToggleHunk: key.NewBinding(
+			key.WithKeys("space", "x"),
+			key.WithHelp("space", "accept/reject hunk"),
+		),
+		FoldHunk: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "fold/unfold hunk"),
+		),
+		OpenEditor: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit in $EDITOR"),
+		),
+	})
 }
 
 // KeyBindings implements layout.KeyMapProvider
@@ -87,6 +113,11 @@ func (k KeyMap) KeyBindings() []key.Binding {
 		k.ScrollUp,
 		k.ScrollLeft,
 		k.ScrollRight,
+		k.NextHunk,
+		k.PrevHunk,
+		k.ToggleHunk,
+		k.FoldHunk,
+		k.OpenEditor,
 	}
 }
 
@@ -109,5 +140,12 @@ func (k KeyMap) ShortHelp() []key.Binding {
 			key.WithKeys("shift+left", "shift+down", "shift+up", "shift+right"),
 			key.WithHelp("shift+←↓↑→", "scroll"),
 		),
+		key.NewBinding(
+			key.WithKeys("n", "p"),
+			key.WithHelp("n/p", "hunk"),
+		),
+		k.ToggleHunk,
+		k.FoldHunk,
+		k.OpenEditor,
 	}
 }
@@ -3,6 +3,8 @@ package permissions
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/help"
@@ -10,11 +12,13 @@ import (
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/aymanbagabas/go-udiff"
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/diffview"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/x/ansi"
@@ -35,6 +39,13 @@ const (
 type PermissionResponseMsg struct {
 	Permission permission.PermissionRequest
 	Action     PermissionAction
+	Content    *string // content the user changed while reviewing an edit, nil if unchanged
+}
+
+// editedContentMsg carries the proposed content after the user edited it in
+// $EDITOR.
+type editedContentMsg struct {
+	content string
 }
 
 // PermissionDialogCmp interface for permission dialog component
@@ -58,6 +69,12 @@ type permissionDialogCmp struct {
 	diffXOffset          int   // horizontal scroll offset
 	diffYOffset          int   // vertical scroll offset
 
+	// Review state
+	selectedHunk  int     // -1 means no hunk is selected
+	rejectedHunks []int   // hunks the user rejected
+	foldedHunks   []int   // hunks shown folded, nil means the large ones
+	editedContent *string // proposed content edited in $EDITOR, nil if not edited
+
 	// Caching
 	cachedContent string
 	contentDirty  bool
@@ -84,6 +101,7 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 		diffSplitMode:   opts.isSplitMode(),
 		keyMap:          DefaultKeyMap(),
 		contentDirty:    true, // Mark as dirty initially
+		selectedHunk:    -1,
 	}
 }
 
@@ -105,6 +123,14 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.contentDirty = true // Mark content as dirty on window resize
 		cmd := p.SetSize()
 		cmds = append(cmds, cmd)
+	case editedContentMsg:
+		p.editedContent = &msg.content
+		p.rejectedHunks = nil
+		p.foldedHunks = nil
+		p.selectedHunk = -1
+		p.diffYOffset = 0
+		p.contentDirty = true
+		return p, nil
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, p.keyMap.Right) || key.Matches(msg, p.keyMap.Tab):
@@ -115,20 +141,11 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		case key.Matches(msg, p.keyMap.Select):
 			return p, p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
-			return p, tea.Batch(
-				util.CmdHandler(dialogs.CloseDialogMsg{}),
-				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllow, Permission: p.permission}),
-			)
+			return p, p.respond(PermissionAllow)
 		case key.Matches(msg, p.keyMap.AllowSession):
-			return p, tea.Batch(
-				util.CmdHandler(dialogs.CloseDialogMsg{}),
-				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForSession, Permission: p.permission}),
-			)
+			return p, p.respond(PermissionAllowForSession)
 		case key.Matches(msg, p.keyMap.Deny):
-			return p, tea.Batch(
-				util.CmdHandler(dialogs.CloseDialogMsg{}),
-				util.CmdHandler(PermissionResponseMsg{Action: PermissionDeny, Permission: p.permission}),
-			)
+			return p, p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.ToggleDiffMode):
 			if p.supportsDiffView() {
 				if p.diffSplitMode == nil {
@@ -140,6 +157,30 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				p.contentDirty = true // Mark content as dirty when diff mode changes
 				return p, nil
 			}
+		case key.Matches(msg, p.keyMap.NextHunk):
+			if p.supportsDiffView() {
+				p.selectHunk(p.selectedHunk + 1)
+				return p, nil
+			}
+		case key.Matches(msg, p.keyMap.PrevHunk):
+			if p.supportsDiffView() {
+				p.selectHunk(p.selectedHunk - 1)
+				return p, nil
+			}
+		case key.Matches(msg, p.keyMap.ToggleHunk):
+			if p.supportsDiffView() {
+				p.toggleHunk()
+				return p, nil
+			}
+		case key.Matches(msg, p.keyMap.FoldHunk):
+			if p.supportsDiffView() {
+				p.foldHunk()
+				return p, nil
+			}
+		case key.Matches(msg, p.keyMap.OpenEditor):
+			if p.supportsDiffView() {
+				return p, p.openEditor()
+			}
 		case key.Matches(msg, p.keyMap.ScrollDown):
 			if p.supportsDiffView() {
 				p.scrollDown()
@@ -231,12 +272,145 @@ func (p *permissionDialogCmp) selectCurrentOption() tea.Cmd {
 		action = PermissionDeny
 	}
 
+	return p.respond(action)
+}
+
+// respond closes the dialog and responds to the permission request. When the
+// user changed the proposed content of an edit, it is sent along with it.
+func (p *permissionDialogCmp) respond(action PermissionAction) tea.Cmd {
+	msg := PermissionResponseMsg{Action: action, Permission: p.permission}
+	if action != PermissionDeny {
+		content, err := p.reviewedContent()
+		if err != nil {
+			return util.ReportError(err)
+		}
+		msg.Content = content
+	}
 	return tea.Batch(
-		util.CmdHandler(PermissionResponseMsg{Action: action, Permission: p.permission}),
+		util.CmdHandler(msg),
 		util.CmdHandler(dialogs.CloseDialogMsg{}),
 	)
 }
 
+// diffContents returns the path and the old and proposed content of the file
+// an edit permission is for. The proposed content includes the changes made
+// in $EDITOR.
+func (p *permissionDialogCmp) diffContents() (filePath, oldContent, newContent string, ok bool) {
+	switch pr := p.permission.Params.(type) {
+	case tools.EditPermissionsParams:
+		filePath, oldContent, newContent = pr.FilePath, pr.OldContent, pr.NewContent
+	case tools.WritePermissionsParams:
+		filePath, oldContent, newContent = pr.FilePath, pr.OldContent, pr.NewContent
+	case tools.MultiEditPermissionsParams:
+		filePath, oldContent, newContent = pr.FilePath, pr.OldContent, pr.NewContent
+	default:
+		return "", "", "", false
+	}
+	if p.editedContent != nil {
+		newContent = *p.editedContent
+	}
+	return filePath, oldContent, newContent, true
+}
+
+// reviewedContent returns the content to write after the user rejected hunks
+// or edited the proposed content, or nil if they did neither.
+func (p *permissionDialogCmp) reviewedContent() (*string, error) {
+	_, oldContent, newContent, ok := p.diffContents()
+	if !ok || (len(p.rejectedHunks) == 0 && p.editedContent == nil) {
+		return nil, nil
+	}
+	content, err := diffview.ApplyHunks(oldContent, newContent, udiff.DefaultContextLines, func(i int) bool {
+		return !slices.Contains(p.rejectedHunks, i)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// diffFormatter returns a diff view of the edit in the current diff mode,
+// with the review state applied.
+func (p *permissionDialogCmp) diffFormatter() *diffview.DiffView {
+	filePath, oldContent, newContent, _ := p.diffContents()
+	formatter := core.DiffFormatter().
+		Before(fsext.PrettyPath(filePath), oldContent).
+		After(fsext.PrettyPath(filePath), newContent).
+		SelectHunk(p.selectedHunk).
+		RejectHunks(p.rejectedHunks...)
+	if p.foldedHunks == nil {
+		p.foldedHunks = append([]int{}, formatter.LargeHunks(core.LargeHunkLines)...)
+	}
+	formatter = formatter.FoldHunks(p.foldedHunks...)
+	if p.useDiffSplitMode() {
+		return formatter.Split()
+	}
+	return formatter.Unified()
+}
+
+// selectHunk selects the hunk with the given index, wrapping around, and
+// scrolls to it.
+func (p *permissionDialogCmp) selectHunk(i int) {
+	formatter := p.diffFormatter()
+	count := formatter.HunkCount()
+	if count == 0 {
+		return
+	}
+	p.selectedHunk = (i + count) % count
+	p.diffYOffset = formatter.HunkLine(p.selectedHunk)
+	p.contentDirty = true
+}
+
+// toggleHunk rejects the selected hunk, or accepts it again if it was
+// rejected. With no hunk selected it selects the first one.
+func (p *permissionDialogCmp) toggleHunk() {
+	if p.selectedHunk < 0 {
+		p.selectHunk(0)
+		return
+	}
+	if i := slices.Index(p.rejectedHunks, p.selectedHunk); i >= 0 {
+		p.rejectedHunks = slices.Delete(p.rejectedHunks, i, i+1)
+	} else {
+		p.rejectedHunks = append(p.rejectedHunks, p.selectedHunk)
+	}
+	p.contentDirty = true
+}
+
+// foldHunk folds the selected hunk, or unfolds it if it was folded. With no
+// hunk selected it selects the first one.
+func (p *permissionDialogCmp) foldHunk() {
+	if p.selectedHunk < 0 {
+		p.selectHunk(0)
+		return
+	}
+	if i := slices.Index(p.foldedHunks, p.selectedHunk); i >= 0 {
+		p.foldedHunks = slices.Delete(p.foldedHunks, i, i+1)
+	} else {
+		p.foldedHunks = append(p.foldedHunks, p.selectedHunk)
+	}
+	p.contentDirty = true
+}
+
+// openEditor opens the proposed content, without the rejected hunks, in
+// $EDITOR so the user can tweak it before it is applied.
+func (p *permissionDialogCmp) openEditor() tea.Cmd {
+	filePath, _, content, ok := p.diffContents()
+	if !ok {
+		return nil
+	}
+	reviewed, err := p.reviewedContent()
+	if err != nil {
+		return util.ReportError(err)
+	}
+	if reviewed != nil {
+		content = *reviewed
+	}
+
+	// Keep the extension so the editor picks the right syntax.
+	return util.OpenEditor("edit_*"+filepath.Ext(filePath), content, func(edited string) tea.Msg {
+		return editedContentMsg{content: edited}
+	})
+}
+
 func (p *permissionDialogCmp) renderButtons() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base
@@ -506,48 +680,28 @@ func (p *permissionDialogCmp) generateBashContent() string {
 }
 
 func (p *permissionDialogCmp) generateEditContent() string {
-	if pr, ok := p.permission.Params.(tools.EditPermissionsParams); ok {
-		formatter := core.DiffFormatter().
-			Before(fsext.PrettyPath(pr.FilePath), pr.OldContent).
-			After(fsext.PrettyPath(pr.FilePath), pr.NewContent).
-			Height(p.contentViewPort.Height()).
-			Width(p.contentViewPort.Width()).
-			XOffset(p.diffXOffset).
-			YOffset(p.diffYOffset)
-		if p.useDiffSplitMode() {
-			formatter = formatter.Split()
-		} else {
-			formatter = formatter.Unified()
-		}
-
-		diff := formatter.String()
-		return diff
+	if _, ok := p.permission.Params.(tools.EditPermissionsParams); ok {
+		return p.generateDiffContent()
 	}
 	return ""
 }
 
 func (p *permissionDialogCmp) generateWriteContent() string {
-	if pr, ok := p.permission.Params.(tools.WritePermissionsParams); ok {
-		// Use the cache for diff rendering
-		formatter := core.DiffFormatter().
-			Before(fsext.PrettyPath(pr.FilePath), pr.OldContent).
-			After(fsext.PrettyPath(pr.FilePath), pr.NewContent).
-			Height(p.contentViewPort.Height()).
-			Width(p.contentViewPort.Width()).
-			XOffset(p.diffXOffset).
-			YOffset(p.diffYOffset)
-		if p.useDiffSplitMode() {
-			formatter = formatter.Split()
-		} else {
-			formatter = formatter.Unified()
-		}
-
-		diff := formatter.String()
-		return diff
+	if _, ok := p.permission.Params.(tools.WritePermissionsParams); ok {
+		return p.generateDiffContent()
 	}
 	return ""
 }
 
+func (p *permissionDialogCmp) generateDiffContent() string {
+	return p.diffFormatter().
+		Height(p.contentViewPort.Height()).
+		Width(p.contentViewPort.Width()).
+		XOffset(p.diffXOffset).
+		YOffset(p.diffYOffset).
+		String()
+}
+
 func (p *permissionDialogCmp) generateDownloadContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(t.BgSubtle)
@@ -567,23 +721,8 @@ func (p *permissionDialogCmp) generateDownloadContent() string {
 }
 
 func (p *permissionDialogCmp) generateMultiEditContent() string {
-	if pr, ok := p.permission.Params.(tools.MultiEditPermissionsParams); ok {
-		// Use the cache for diff rendering
-		formatter := core.DiffFormatter().
-			Before(fsext.PrettyPath(pr.FilePath), pr.OldContent).
-			After(fsext.PrettyPath(pr.FilePath), pr.NewContent).
-			Height(p.contentViewPort.Height()).
-			Width(p.contentViewPort.Width()).
-			XOffset(p.diffXOffset).
-			YOffset(p.diffYOffset)
-		if p.useDiffSplitMode() {
-			formatter = formatter.Split()
-		} else {
-			formatter = formatter.Unified()
-		}
-
-		diff := formatter.String()
-		return diff
+	if _, ok := p.permission.Params.(tools.MultiEditPermissionsParams); ok {
+		return p.generateDiffContent()
 	}
 	return ""
 }
@@ -781,7 +920,7 @@ func (p *permissionDialogCmp) render() string {
 
 	dialog := baseStyle.
 		Padding(0, 1).
-		Border(lipgloss.RoundedBorder()).
+		Border(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BorderFocus).
 		Width(p.width).
 		Render(
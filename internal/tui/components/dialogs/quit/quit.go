@@ -100,7 +100,7 @@ func (q *quitDialogCmp) View() string {
 
 	quitDialogStyle := baseStyle.
 		Padding(1, 2).
-		Border(lipgloss.RoundedBorder()).
+		Border(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BorderFocus)
 
 	return quitDialogStyle.Render(content)
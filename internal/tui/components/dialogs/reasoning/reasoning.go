@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 )
@@ -55,7 +56,7 @@ type ReasoningDialogKeyMap struct {
 }
 
 func DefaultReasoningDialogKeyMap() ReasoningDialogKeyMap {
-	return ReasoningDialogKeyMap{
+	return keymap.Apply("reasoning", ReasoningDialogKeyMap{
 		Next: key.NewBinding(
 			key.WithKeys("down", "j", "ctrl+n"),
 			key.WithHelp("↓/j/ctrl+n", "next"),
@@ -72,7 +73,7 @@ func DefaultReasoningDialogKeyMap() ReasoningDialogKeyMap {
 			key.WithKeys("esc", "ctrl+c"),
 			key.WithHelp("esc/ctrl+c", "close"),
 		),
-	}
+	})
 }
 
 func (k ReasoningDialogKeyMap) ShortHelp() []key.Binding {
@@ -103,6 +104,7 @@ func NewReasoningDialog() ReasoningDialog {
 			list.WithKeyMap(listKeyMap),
 			list.WithWrapNavigation(),
 			list.WithResizeByList(),
+			list.WithMouseWheelSelection(),
 		),
 	)
 	help := help.New()
@@ -174,6 +176,10 @@ func (r *reasoningDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		r.wWidth = msg.Width
 		r.wHeight = msg.Height
 		return r, r.effortList.SetSize(r.listWidth(), r.listHeight())
+	case tea.MouseWheelMsg:
+		u, cmd := r.effortList.Update(msg)
+		r.effortList = u.(listModel)
+		return r, cmd
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, r.keyMap.Select):
@@ -248,7 +254,7 @@ func (r *reasoningDialogCmp) style() lipgloss.Style {
 	t := styles.CurrentTheme()
 	return t.S().Base.
 		Width(r.width).
-		Border(lipgloss.RoundedBorder()).
+		Border(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BorderFocus)
 }
 
@@ -2,6 +2,7 @@ package sessions
 
 import (
 	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -12,7 +13,7 @@ type KeyMap struct {
 }
 
 func DefaultKeyMap() KeyMap {
-	return KeyMap{
+	return keymap.Apply("sessions", KeyMap{
 		Select: key.NewBinding(
 			key.WithKeys("enter", "tab", "ctrl+y"),
 			key.WithHelp("enter", "choose"),
@@ -29,7 +30,7 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("esc", "alt+esc"),
 			key.WithHelp("esc", "exit"),
 		),
-	}
+	})
 }
 
 // KeyBindings implements layout.KeyMapProvider
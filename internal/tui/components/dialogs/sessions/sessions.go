@@ -35,8 +35,9 @@ type sessionDialogCmp struct {
 	help              help.Model
 }
 
-// NewSessionDialogCmp creates a new session switching dialog
-func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionDialog {
+// NewSessionDialogCmp creates a new session switching dialog. status, when
+// not nil, describes what each session is doing.
+func NewSessionDialogCmp(sessions []session.Session, selectedID string, status func(sessionID string) string) SessionDialog {
 	t := styles.CurrentTheme()
 	listKeyMap := list.DefaultKeyMap()
 	keyMap := DefaultKeyMap()
@@ -48,7 +49,11 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 	items := make([]list.CompletionItem[session.Session], len(sessions))
 	if len(sessions) > 0 {
 		for i, session := range sessions {
-			items[i] = list.NewCompletionItem(session.Title, session, list.WithCompletionID(session.ID))
+			opts := []list.CompletionItemOption{list.WithCompletionID(session.ID)}
+			if status != nil {
+				opts = append(opts, list.WithCompletionShortcut(status(session.ID)))
+			}
+			items[i] = list.NewCompletionItem(session.Title, session, opts...)
 		}
 	}
 
@@ -60,6 +65,7 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 		list.WithFilterListOptions(
 			list.WithKeyMap(listKeyMap),
 			list.WithWrapNavigation(),
+			list.WithMouseWheelSelection(),
 		),
 	)
 	help := help.New()
@@ -94,6 +100,10 @@ func (s *sessionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			cmds = append(cmds, s.sessionsList.SetSelected(s.selectedSessionID))
 		}
 		return s, tea.Batch(cmds...)
+	case tea.MouseWheelMsg:
+		u, cmd := s.sessionsList.Update(msg)
+		s.sessionsList = u.(SessionsList)
+		return s, cmd
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, s.keyMap.Select):
@@ -148,7 +158,7 @@ func (s *sessionDialogCmp) style() lipgloss.Style {
 	t := styles.CurrentTheme()
 	return t.S().Base.
 		Width(s.width).
-		Border(lipgloss.RoundedBorder()).
+		Border(styles.Border(lipgloss.RoundedBorder())).
 		BorderForeground(t.BorderFocus)
 }
 
@@ -0,0 +1,265 @@
+package themes
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	ThemeDialogID dialogs.DialogID = "themes"
+
+	defaultWidth int = 50
+)
+
+type listModel = list.FilterableList[list.CompletionItem[string]]
+
+// ThemeSelectedMsg is sent when a theme is chosen, after it's applied.
+type ThemeSelectedMsg struct {
+	Name string
+}
+
+type ThemeDialog interface {
+	dialogs.DialogModel
+}
+
+type themeDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	themeList listModel
+	keyMap    ThemeDialogKeyMap
+	help      help.Model
+
+	// original is the theme to go back to if the dialog is dismissed.
+	original  string
+	previewed string
+	confirmed bool
+}
+
+type ThemeDialogKeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultThemeDialogKeyMap() ThemeDialogKeyMap {
+	return keymap.Apply("themes", ThemeDialogKeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓/ctrl+n", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑/ctrl+p", "previous"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "select"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "ctrl+c"),
+			key.WithHelp("esc/ctrl+c", "cancel"),
+		),
+	})
+}
+
+func (k ThemeDialogKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k ThemeDialogKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+// NewThemeDialogCmp creates a dialog to pick a theme, previewing each one as
+// it's highlighted.
+func NewThemeDialogCmp() ThemeDialog {
+	keyMap := DefaultThemeDialogKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	themeList := list.NewFilterableList(
+		[]list.CompletionItem[string]{},
+		list.WithFilterPlaceholder("Enter a theme name"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+			list.WithMouseWheelSelection(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &themeDialogCmp{
+		themeList: themeList,
+		width:     defaultWidth,
+		keyMap:    keyMap,
+		help:      help,
+		original:  t.Name,
+		previewed: t.Name,
+	}
+}
+
+func (r *themeDialogCmp) Init() tea.Cmd {
+	var items []list.CompletionItem[string]
+	for _, name := range styles.DefaultManager().List() {
+		opts := []list.CompletionItemOption{
+			list.WithCompletionID(name),
+		}
+		if name == r.original {
+			opts = append(opts, list.WithCompletionShortcut("current"))
+		}
+		items = append(items, list.NewCompletionItem(name, name, opts...))
+	}
+	return tea.Sequence(
+		r.themeList.SetItems(items),
+		r.themeList.SetSelected(r.original),
+		r.themeList.Focus(),
+	)
+}
+
+func (r *themeDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+		return r, r.themeList.SetSize(r.listWidth(), r.listHeight())
+	case styles.ThemeChangedMsg:
+		t := styles.CurrentTheme()
+		r.help.Styles = t.S().Help
+		u, cmd := r.themeList.Update(msg)
+		r.themeList = u.(listModel)
+		return r, cmd
+	case tea.MouseWheelMsg:
+		u, cmd := r.themeList.Update(msg)
+		r.themeList = u.(listModel)
+		return r, cmd
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Select):
+			selectedItem := r.themeList.SelectedItem()
+			if selectedItem == nil {
+				return r, nil
+			}
+			name := (*selectedItem).Value()
+			r.confirmed = true
+			return r, tea.Sequence(
+				r.preview(name),
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ThemeSelectedMsg{Name: name}),
+			)
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := r.themeList.Update(msg)
+			r.themeList = u.(listModel)
+			if selectedItem := r.themeList.SelectedItem(); selectedItem != nil {
+				cmd = tea.Batch(cmd, r.preview((*selectedItem).Value()))
+			}
+			return r, cmd
+		}
+	}
+	return r, nil
+}
+
+// preview switches to the given theme, if it's not the current one already.
+func (r *themeDialogCmp) preview(name string) tea.Cmd {
+	if name == r.previewed {
+		return nil
+	}
+	if err := styles.DefaultManager().SetTheme(name); err != nil {
+		return util.ReportError(err)
+	}
+	r.previewed = name
+	return util.CmdHandler(styles.ThemeChangedMsg{})
+}
+
+// Close implements dialogs.CloseCallback, going back to the original theme
+// unless a new one was chosen.
+func (r *themeDialogCmp) Close() tea.Cmd {
+	if r.confirmed {
+		return nil
+	}
+	return r.preview(r.original)
+}
+
+func (r *themeDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Switch Theme", r.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		r.themeList.View(),
+		"",
+		t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(r.keyMap)),
+	)
+	return r.style().Render(content)
+}
+
+func (r *themeDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := r.themeList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = r.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (r *themeDialogCmp) listWidth() int {
+	return r.width - 2 // 4 for padding
+}
+
+func (r *themeDialogCmp) listHeight() int {
+	listHeight := len(r.themeList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, r.wHeight/2)
+}
+
+func (r *themeDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := r.Position()
+	offset := row + 3
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+func (r *themeDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(r.width).
+		Border(styles.Border(lipgloss.RoundedBorder())).
+		BorderForeground(t.BorderFocus)
+}
+
+func (r *themeDialogCmp) Position() (int, int) {
+	row := r.wHeight/4 - 2 // just a bit above the center
+	col := r.wWidth / 2
+	col -= r.width / 2
+	return row, col
+}
+
+func (r *themeDialogCmp) ID() dialogs.DialogID {
+	return ThemeDialogID
+}
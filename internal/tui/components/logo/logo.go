@@ -41,6 +41,11 @@ func Render(version string, compact bool, o Opts) string {
 		return lipgloss.NewStyle().Foreground(c).Render(s)
 	}
 
+	// Screen readers get the name instead of the art.
+	if styles.ScreenReader() {
+		return fg(o.CharmColor, "Charm™") + " " + fg(o.TitleColorA, "Crush") + " " + fg(o.VersionColor, version)
+	}
+
 	// Title.
 	const spacing = 1
 	letterforms := []letterform{
@@ -122,8 +127,7 @@ func SmallRender(width int) string {
 	title := t.S().Base.Foreground(t.Secondary).Render("Charm™")
 	title = fmt.Sprintf("%s %s", title, styles.ApplyBoldForegroundGrad("Crush", t.Secondary, t.Primary))
 	remainingWidth := width - lipgloss.Width(title) - 1 // 1 for the space after "Crush"
-	if remainingWidth > 0 {
-		lines := strings.Repeat("╱", remainingWidth)
+	if lines := styles.Rule("╱", remainingWidth); lines != "" {
 		title = fmt.Sprintf("%s %s", title, t.S().Base.Foreground(t.Primary).Render(lines))
 	}
 	return title
@@ -128,6 +128,10 @@ func iconAndDescription(l config.LSP, t *styles.Theme, states map[string]app.LSP
 		return t.ItemErrorIcon, description
 	case lsp.StateDisabled:
 		return t.ItemOfflineIcon.Foreground(t.FgMuted), t.S().Subtle.Render("inactive")
+	case lsp.StateIdle:
+		return t.ItemOfflineIcon.Foreground(t.FgMuted), t.S().Subtle.Render("idle")
+	case lsp.StateStopped:
+		return t.ItemOfflineIcon, t.S().Subtle.Render("stopped")
 	default:
 		return t.ItemOfflineIcon, ""
 	}
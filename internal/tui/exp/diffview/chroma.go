@@ -14,44 +14,92 @@ import (
 var _ chroma.Formatter = chromaFormatter{}
 
 // chromaFormatter is a custom formatter for Chroma that uses Lip Gloss for
-// foreground styling, while keeping a forced background color.
+// foreground styling, while keeping a forced background color. The changed
+// words of the line, given as spans, get the emphasis background instead.
 type chromaFormatter struct {
-	bgColor color.Color
+	bgColor       color.Color
+	emphasisColor color.Color
+	spans         []span
 }
 
 // Format implements the chroma.Formatter interface.
 func (c chromaFormatter) Format(w io.Writer, style *chroma.Style, it chroma.Iterator) error {
+	offset := 0
 	for token := it(); token != chroma.EOF; token = it() {
-		value := strings.TrimRight(token.Value, "\n")
-		value = ansiext.Escape(value)
-
 		entry := style.Get(token.Type)
-		if entry.IsZero() {
-			if _, err := fmt.Fprint(w, value); err != nil {
+		for _, part := range splitBySpans(token.Value, offset, c.spans) {
+			if err := c.formatPart(w, entry, part); err != nil {
 				return err
 			}
-			continue
 		}
+		offset += len(token.Value)
+	}
+	return nil
+}
 
-		s := lipgloss.NewStyle().
-			Background(c.bgColor)
+func (c chromaFormatter) formatPart(w io.Writer, entry chroma.StyleEntry, part spanPart) error {
+	value := strings.TrimRight(part.value, "\n")
+	value = ansiext.Escape(value)
 
-		if entry.Bold == chroma.Yes {
-			s = s.Bold(true)
-		}
-		if entry.Underline == chroma.Yes {
-			s = s.Underline(true)
-		}
-		if entry.Italic == chroma.Yes {
-			s = s.Italic(true)
-		}
-		if entry.Colour.IsSet() {
-			s = s.Foreground(lipgloss.Color(entry.Colour.String()))
-		}
+	if entry.IsZero() && !part.emphasized {
+		_, err := fmt.Fprint(w, value)
+		return err
+	}
+
+	s := lipgloss.NewStyle().
+		Background(c.bgColor)
+	if part.emphasized {
+		s = s.Background(c.emphasisColor)
+	}
+
+	if entry.Bold == chroma.Yes {
+		s = s.Bold(true)
+	}
+	if entry.Underline == chroma.Yes {
+		s = s.Underline(true)
+	}
+	if entry.Italic == chroma.Yes {
+		s = s.Italic(true)
+	}
+	if entry.Colour.IsSet() {
+		s = s.Foreground(lipgloss.Color(entry.Colour.String()))
+	}
+
+	_, err := fmt.Fprint(w, s.Render(value))
+	return err
+}
 
-		if _, err := fmt.Fprint(w, s.Render(value)); err != nil {
-			return err
+// spanPart is a part of a string either inside or outside of the spans.
+type spanPart struct {
+	value      string
+	emphasized bool
+}
+
+// splitBySpans splits a string found at the given offset of a line into the
+// parts inside and outside of the spans of the line.
+func splitBySpans(value string, offset int, spans []span) []spanPart {
+	if len(spans) == 0 {
+		return []spanPart{{value: value}}
+	}
+	var parts []spanPart
+	start := 0
+	for start < len(value) {
+		pos := offset + start
+		end := len(value)
+		emphasized := false
+		for _, s := range spans {
+			if pos >= s.start && pos < s.end {
+				emphasized = true
+				end = min(end, s.end-offset)
+				break
+			}
+			if s.start > pos {
+				end = min(end, s.start-offset)
+				break
+			}
 		}
+		parts = append(parts, spanPart{value: value[start:end], emphasized: emphasized})
+		start = end
 	}
-	return nil
+	return parts
 }
@@ -46,6 +46,10 @@ type DiffView struct {
 	style           Style
 	tabWidth        int
 	chromaStyle     *chroma.Style
+	selectedHunk    int
+	rejectedHunks   []int
+	foldedHunks     []int
+	wordDiff        bool
 
 	isComputed bool
 	err        error
@@ -67,6 +71,9 @@ type DiffView struct {
 	// Cache highlighted lines to avoid re-highlighting the same content
 	// Key: hash of (content + background color), Value: highlighted string
 	syntaxCache map[string]string
+
+	// Cache the changed words of line pairs, keyed by the hash of the pair
+	wordDiffCache map[uint64]wordDiffSpans
 }
 
 // New creates a new DiffView with default settings.
@@ -76,6 +83,7 @@ func New() *DiffView {
 		contextLines: udiff.DefaultContextLines,
 		lineNumbers:  true,
 		tabWidth:     8,
+		selectedHunk: -1,
 		syntaxCache:  make(map[string]string),
 	}
 	dv.style = DefaultDarkStyle()
@@ -114,6 +122,7 @@ func (dv *DiffView) After(path, content string) *DiffView {
 func (dv *DiffView) clearCaches() {
 	dv.cachedLexer = nil
 	dv.clearSyntaxCache()
+	dv.wordDiffCache = nil
 	dv.isComputed = false
 }
 
@@ -304,12 +313,12 @@ func (dv *DiffView) detectTotalLines() {
 
 	switch dv.layout {
 	case layoutUnified:
-		for _, h := range dv.unified.Hunks {
-			dv.totalLines += 1 + len(h.Lines)
+		for i, h := range dv.unified.Hunks {
+			dv.totalLines += 1 + ternary(dv.isFolded(i), 0, len(h.Lines))
 		}
 	case layoutSplit:
-		for _, h := range dv.splitHunks {
-			dv.totalLines += 1 + len(h.lines)
+		for i, h := range dv.splitHunks {
+			dv.totalLines += 1 + ternary(dv.isFolded(i), 0, len(h.lines))
 		}
 	}
 }
@@ -402,9 +411,9 @@ func (dv *DiffView) renderUnified() string {
 	printedLines := -dv.yOffset
 	shouldWrite := func() bool { return printedLines >= 0 }
 
-	getContent := func(in string, ls LineStyle) (content string, leadingEllipsis bool) {
+	getContent := func(in string, ls LineStyle, spans []span) (content string, leadingEllipsis bool) {
 		content = strings.TrimSuffix(in, "\n")
-		content = dv.hightlightCode(content, ls.Code.GetBackground())
+		content = dv.hightlightCode(content, ls.Code.GetBackground(), ls.emphasisColor(), spans)
 		content = ansi.GraphemeWidth.Cut(content, dv.xOffset, len(content))
 		content = ansi.Truncate(content, dv.codeWidth, "…")
 		leadingEllipsis = dv.xOffset > 0 && strings.TrimSpace(content) != ""
@@ -413,21 +422,50 @@ func (dv *DiffView) renderUnified() string {
 
 outer:
 	for i, h := range dv.unified.Hunks {
+		// Only render the lines that are visible.
+		if dv.height > 0 && printedLines >= dv.height {
+			break
+		}
+		hunkHeight := 1 + ternary(dv.isFolded(i), 0, len(h.Lines))
+		if printedLines+hunkHeight <= 0 {
+			printedLines += hunkHeight
+			continue
+		}
+
 		if shouldWrite() {
 			ls := dv.style.DividerLine
 			if dv.lineNumbers {
 				b.WriteString(ls.LineNumber.Render(pad("…", dv.beforeNumDigits)))
 				b.WriteString(ls.LineNumber.Render(pad("…", dv.afterNumDigits)))
 			}
-			content := ansi.Truncate(dv.hunkLineFor(h), dv.fullCodeWidth, "…")
+			content := ansi.Truncate(dv.hunkHeaderFor(i, h), dv.fullCodeWidth, "…")
 			b.WriteString(ls.Code.Width(dv.fullCodeWidth).Render(content))
 			b.WriteString("\n")
 		}
 		printedLines++
+		if dv.isFolded(i) {
+			continue
+		}
 
 		beforeLine := h.FromLine
 		afterLine := h.ToLine
 
+		var pairs []int
+		if dv.wordDiff {
+			pairs = pairLines(h.Lines)
+		}
+		spansFor := func(j int) []span {
+			if pairs == nil || pairs[j] < 0 {
+				return nil
+			}
+			line := strings.TrimSuffix(h.Lines[j].Content, "\n")
+			other := strings.TrimSuffix(h.Lines[pairs[j]].Content, "\n")
+			if h.Lines[j].Kind == udiff.Delete {
+				return dv.lineSpans(line, other).before
+			}
+			return dv.lineSpans(other, line).after
+		}
+
 		for j, l := range h.Lines {
 			// print ellipis if we don't have enough space to print the rest of the diff
 			hasReachedHeight := dv.height > 0 && printedLines+1 == dv.height
@@ -452,7 +490,7 @@ outer:
 			case udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					content, leadingEllipsis := getContent(l.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
@@ -465,8 +503,8 @@ outer:
 				afterLine++
 			case udiff.Insert:
 				if shouldWrite() {
-					ls := dv.style.InsertLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					ls := dv.changeStyle(i, udiff.Insert)
+					content, leadingEllipsis := getContent(l.Content, ls, spansFor(j))
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
@@ -479,8 +517,8 @@ outer:
 				afterLine++
 			case udiff.Delete:
 				if shouldWrite() {
-					ls := dv.style.DeleteLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					ls := dv.changeStyle(i, udiff.Delete)
+					content, leadingEllipsis := getContent(l.Content, ls, spansFor(j))
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.afterNumDigits)))
@@ -512,9 +550,9 @@ func (dv *DiffView) renderSplit() string {
 	printedLines := -dv.yOffset
 	shouldWrite := func() bool { return printedLines >= 0 }
 
-	getContent := func(in string, ls LineStyle) (content string, leadingEllipsis bool) {
+	getContent := func(in string, ls LineStyle, spans []span) (content string, leadingEllipsis bool) {
 		content = strings.TrimSuffix(in, "\n")
-		content = dv.hightlightCode(content, ls.Code.GetBackground())
+		content = dv.hightlightCode(content, ls.Code.GetBackground(), ls.emphasisColor(), spans)
 		content = ansi.GraphemeWidth.Cut(content, dv.xOffset, len(content))
 		content = ansi.Truncate(content, dv.codeWidth, "…")
 		leadingEllipsis = dv.xOffset > 0 && strings.TrimSpace(content) != ""
@@ -523,12 +561,22 @@ func (dv *DiffView) renderSplit() string {
 
 outer:
 	for i, h := range dv.splitHunks {
+		// Only render the lines that are visible.
+		if dv.height > 0 && printedLines >= dv.height {
+			break
+		}
+		hunkHeight := 1 + ternary(dv.isFolded(i), 0, len(h.lines))
+		if printedLines+hunkHeight <= 0 {
+			printedLines += hunkHeight
+			continue
+		}
+
 		if shouldWrite() {
 			ls := dv.style.DividerLine
 			if dv.lineNumbers {
 				b.WriteString(ls.LineNumber.Render(pad("…", dv.beforeNumDigits)))
 			}
-			content := ansi.Truncate(dv.hunkLineFor(dv.unified.Hunks[i]), dv.fullCodeWidth, "…")
+			content := ansi.Truncate(dv.hunkHeaderFor(i, dv.unified.Hunks[i]), dv.fullCodeWidth, "…")
 			b.WriteString(ls.Code.Width(dv.fullCodeWidth).Render(content))
 			if dv.lineNumbers {
 				b.WriteString(ls.LineNumber.Render(pad("…", dv.afterNumDigits)))
@@ -537,11 +585,22 @@ outer:
 			b.WriteRune('\n')
 		}
 		printedLines++
+		if dv.isFolded(i) {
+			continue
+		}
 
 		beforeLine := h.fromLine
 		afterLine := h.toLine
 
 		for j, l := range h.lines {
+			var spans wordDiffSpans
+			if l.before != nil && l.after != nil && l.before.Kind == udiff.Delete && l.after.Kind == udiff.Insert {
+				spans = dv.lineSpans(
+					strings.TrimSuffix(l.before.Content, "\n"),
+					strings.TrimSuffix(l.after.Content, "\n"),
+				)
+			}
+
 			// print ellipis if we don't have enough space to print the rest of the diff
 			hasReachedHeight := dv.height > 0 && printedLines+1 == dv.height
 			isLastHunk := i+1 == len(dv.unified.Hunks)
@@ -587,7 +646,7 @@ outer:
 			case l.before.Kind == udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.before.Content, ls)
+					content, leadingEllipsis := getContent(l.before.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
@@ -598,8 +657,8 @@ outer:
 				beforeLine++
 			case l.before.Kind == udiff.Delete:
 				if shouldWrite() {
-					ls := dv.style.DeleteLine
-					content, leadingEllipsis := getContent(l.before.Content, ls)
+					ls := dv.changeStyle(i, udiff.Delete)
+					content, leadingEllipsis := getContent(l.before.Content, ls, spans.before)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
@@ -625,7 +684,7 @@ outer:
 			case l.after.Kind == udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.after.Content, ls)
+					content, leadingEllipsis := getContent(l.after.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
@@ -636,8 +695,8 @@ outer:
 				afterLine++
 			case l.after.Kind == udiff.Insert:
 				if shouldWrite() {
-					ls := dv.style.InsertLine
-					content, leadingEllipsis := getContent(l.after.Content, ls)
+					ls := dv.changeStyle(i, udiff.Insert)
+					content, leadingEllipsis := getContent(l.after.Content, ls, spans.after)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
@@ -703,13 +762,16 @@ func (dv *DiffView) lineStyleForType(t udiff.OpKind) LineStyle {
 	}
 }
 
-func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
+func (dv *DiffView) hightlightCode(source string, bgColor, emphasisColor color.Color, spans []span) string {
+	if emphasisColor == nil {
+		spans = nil
+	}
 	if dv.chromaStyle == nil {
-		return source
+		return emphasize(source, emphasisColor, spans)
 	}
 
-	// Create cache key from content and background color
-	cacheKey := dv.createSyntaxCacheKey(source, bgColor)
+	// Create cache key from content, background color and changed words
+	cacheKey := dv.createSyntaxCacheKey(source, bgColor, emphasisColor, spans)
 
 	// Check if we already have this highlighted
 	if cached, exists := dv.syntaxCache[cacheKey]; exists {
@@ -717,7 +779,7 @@ func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
 	}
 
 	l := dv.getChromaLexer()
-	f := dv.getChromaFormatter(bgColor)
+	f := dv.getChromaFormatter(bgColor, emphasisColor, spans)
 
 	it, err := l.Tokenise(nil, source)
 	if err != nil {
@@ -737,12 +799,17 @@ func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
 	return result
 }
 
-// createSyntaxCacheKey creates a cache key from source content and background color.
-// We use a simple hash to keep memory usage reasonable.
-func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color) string {
+// createSyntaxCacheKey creates a cache key from source content, background
+// color and changed words. We use a simple hash to keep memory usage
+// reasonable.
+func (dv *DiffView) createSyntaxCacheKey(source string, bgColor, emphasisColor color.Color, spans []span) string {
 	// Convert color to string representation
 	r, g, b, a := bgColor.RGBA()
 	colorStr := fmt.Sprintf("%d,%d,%d,%d", r, g, b, a)
+	if len(spans) > 0 {
+		r, g, b, a := emphasisColor.RGBA()
+		colorStr += fmt.Sprintf(";%d,%d,%d,%d;%v", r, g, b, a, spans)
+	}
 
 	// Create a hash of the content + color to use as cache key
 	h := xxh3.New()
@@ -767,8 +834,28 @@ func (dv *DiffView) getChromaLexer() chroma.Lexer {
 	return dv.cachedLexer
 }
 
-func (dv *DiffView) getChromaFormatter(bgColor color.Color) chroma.Formatter {
+func (dv *DiffView) getChromaFormatter(bgColor, emphasisColor color.Color, spans []span) chroma.Formatter {
 	return chromaFormatter{
-		bgColor: bgColor,
+		bgColor:       bgColor,
+		emphasisColor: emphasisColor,
+		spans:         spans,
 	}
 }
+
+// emphasize gives the changed words of a line without syntax highlighting the
+// emphasis background.
+func emphasize(source string, emphasisColor color.Color, spans []span) string {
+	if len(spans) == 0 {
+		return source
+	}
+	style := lipgloss.NewStyle().Background(emphasisColor)
+	var b strings.Builder
+	for _, part := range splitBySpans(source, 0, spans) {
+		if part.emphasized {
+			b.WriteString(style.Render(part.value))
+		} else {
+			b.WriteString(part.value)
+		}
+	}
+	return b.String()
+}
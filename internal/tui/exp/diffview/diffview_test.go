@@ -330,6 +330,52 @@ func TestDiffViewYOffsetInfinite(t *testing.T) {
 	}
 }
 
+func TestDiffViewWordDiff(t *testing.T) {
+	t.Parallel()
+
+	for layoutName, layoutFunc := range LayoutFuncs {
+		t.Run(layoutName, func(t *testing.T) {
+			t.Parallel()
+
+			dv := diffview.New().
+				Before("main.go", TestDefaultBefore).
+				After("main.go", TestDefaultAfter).
+				Style(diffview.DefaultLightStyle()).
+				ChromaStyle(styles.Get("catppuccin-latte")).
+				WordDiff(true)
+			dv = layoutFunc(dv)
+
+			output := dv.String()
+			golden.RequireEqual(t, []byte(output))
+		})
+	}
+}
+
+func TestDiffViewFoldHunks(t *testing.T) {
+	t.Parallel()
+
+	for layoutName, layoutFunc := range LayoutFuncs {
+		t.Run(layoutName, func(t *testing.T) {
+			t.Parallel()
+
+			dv := diffview.New().
+				Before("main.go", TestMultipleHunksBefore).
+				After("main.go", TestMultipleHunksAfter).
+				Style(diffview.DefaultLightStyle()).
+				ChromaStyle(styles.Get("catppuccin-latte")).
+				FoldHunks(0)
+			dv = layoutFunc(dv)
+
+			output := dv.String()
+			golden.RequireEqual(t, []byte(output))
+
+			if got := strings.Count(output, "\n") + 1; got != dv.LineCount() {
+				t.Errorf("expected %d lines, got %d", dv.LineCount(), got)
+			}
+		})
+	}
+}
+
 func assertLineWidth(t *testing.T, expected int, output string) {
 	var lineWidth int
 	for line := range strings.SplitSeq(output, "\n") {
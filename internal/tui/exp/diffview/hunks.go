@@ -0,0 +1,155 @@
+package diffview
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// SelectHunk highlights the header of the hunk with the given index. A
+// negative index selects none.
+func (dv *DiffView) SelectHunk(i int) *DiffView {
+	dv.selectedHunk = i
+	return dv
+}
+
+// RejectHunks marks the hunks with the given indexes as rejected, which greys
+// out their changes.
+func (dv *DiffView) RejectHunks(hunks ...int) *DiffView {
+	dv.rejectedHunks = hunks
+	return dv
+}
+
+// FoldHunks folds the hunks with the given indexes, which only shows their
+// header.
+func (dv *DiffView) FoldHunks(hunks ...int) *DiffView {
+	dv.foldedHunks = hunks
+	return dv
+}
+
+// LargeHunks returns the indexes of the hunks with more than the given number
+// of lines, e.g. to fold them.
+func (dv *DiffView) LargeHunks(maxLines int) []int {
+	var hunks []int
+	for i := range dv.HunkCount() {
+		if len(dv.unified.Hunks[i].Lines) > maxLines {
+			hunks = append(hunks, i)
+		}
+	}
+	return hunks
+}
+
+// LineCount returns the number of lines of the diff, without any height
+// limit.
+func (dv *DiffView) LineCount() int {
+	dv.HunkCount()
+	dv.convertDiffToSplit()
+	dv.detectTotalLines()
+	return dv.totalLines
+}
+
+// HunkCount returns the number of hunks in the diff.
+func (dv *DiffView) HunkCount() int {
+	dv.normalizeLineEndings()
+	dv.replaceTabs()
+	if err := dv.computeDiff(); err != nil {
+		return 0
+	}
+	return len(dv.unified.Hunks)
+}
+
+// HunkLine returns the line the header of the hunk with the given index is
+// rendered at, without the Y offset.
+func (dv *DiffView) HunkLine(i int) int {
+	line := 0
+	for j := range min(i, dv.HunkCount()) {
+		h := dv.unified.Hunks[j]
+		if dv.isFolded(j) {
+			line++
+			continue
+		}
+		switch dv.layout {
+		case layoutSplit:
+			line += 1 + len(hunkToSplit(h).lines)
+		default:
+			line += 1 + len(h.Lines)
+		}
+	}
+	return line
+}
+
+func (dv *DiffView) isRejected(hunk int) bool {
+	return slices.Contains(dv.rejectedHunks, hunk)
+}
+
+func (dv *DiffView) isFolded(hunk int) bool {
+	return slices.Contains(dv.foldedHunks, hunk)
+}
+
+// hunkHeaderFor formats the header line of a hunk, with its selection and
+// rejection and fold marks.
+func (dv *DiffView) hunkHeaderFor(i int, h *udiff.Hunk) string {
+	header := dv.hunkLineFor(h)
+	if i == dv.selectedHunk {
+		header = "▸ " + strings.TrimPrefix(header, "  ")
+	}
+	if dv.isFolded(i) {
+		header += fmt.Sprintf("(%d lines folded) ", len(h.Lines))
+	}
+	if dv.isRejected(i) {
+		header += "(rejected) "
+	}
+	return header
+}
+
+// changeStyle returns the style of an inserted or deleted line of the given
+// hunk.
+func (dv *DiffView) changeStyle(hunk int, kind udiff.OpKind) LineStyle {
+	if dv.isRejected(hunk) {
+		return dv.style.MissingLine
+	}
+	return dv.lineStyleForType(kind)
+}
+
+// ApplyHunks returns the before content with the changes of the hunks accept
+// returns true for. Hunks are numbered like the ones a diff view with the
+// given context lines shows.
+func ApplyHunks(before, after string, contextLines int, accept func(i int) bool) (string, error) {
+	edits := udiff.Strings(before, after)
+	unified, err := udiff.ToUnifiedDiff("", "", before, edits, contextLines)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.SplitAfter(before, "\n")
+	var b strings.Builder
+	pos := 0
+	for i, h := range unified.Hunks {
+		for ; pos < h.FromLine-1; pos++ {
+			b.WriteString(lines[pos])
+		}
+		apply := accept(i)
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case udiff.Equal:
+				b.WriteString(l.Content)
+				pos++
+			case udiff.Delete:
+				if !apply {
+					b.WriteString(l.Content)
+				}
+				pos++
+			case udiff.Insert:
+				if apply {
+					b.WriteString(l.Content)
+				}
+			}
+		}
+	}
+	for ; pos < len(lines); pos++ {
+		b.WriteString(lines[pos])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,55 @@
+package diffview_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/charmbracelet/crush/internal/tui/exp/diffview"
+)
+
+func TestApplyHunks(t *testing.T) {
+	var beforeLines, afterLines []string
+	for i := range 20 {
+		line := "line " + strings.Repeat("x", i)
+		beforeLines = append(beforeLines, line)
+		switch i {
+		case 1:
+			afterLines = append(afterLines, "first change")
+		case 18:
+			afterLines = append(afterLines, "second change")
+		default:
+			afterLines = append(afterLines, line)
+		}
+	}
+	before := strings.Join(beforeLines, "\n") + "\n"
+	after := strings.Join(afterLines, "\n") + "\n"
+
+	if count := diffview.New().Before("a", before).After("a", after).HunkCount(); count != 2 {
+		t.Fatalf("expected 2 hunks, got %d", count)
+	}
+
+	tests := []struct {
+		name     string
+		accepted []bool
+		expected string
+	}{
+		{"AcceptAll", []bool{true, true}, after},
+		{"RejectAll", []bool{false, false}, before},
+		{"AcceptFirst", []bool{true, false}, strings.Replace(before, beforeLines[1]+"\n", "first change\n", 1)},
+		{"AcceptSecond", []bool{false, true}, strings.Replace(before, beforeLines[18]+"\n", "second change\n", 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := diffview.ApplyHunks(before, after, udiff.DefaultContextLines, func(i int) bool {
+				return tt.accepted[i]
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
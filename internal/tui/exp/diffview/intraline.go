@@ -0,0 +1,187 @@
+package diffview
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/aymanbagabas/go-udiff/lcs"
+	"github.com/zeebo/xxh3"
+)
+
+// maxWordDiffRatio is the share of a line that can change for its changed
+// words to be highlighted. Above it the lines are too different for the
+// highlighting to help.
+const maxWordDiffRatio = 0.6
+
+// span is a byte range of a line.
+type span struct {
+	start, end int
+}
+
+// wordDiffSpans holds the changed ranges of a deleted line and the inserted
+// line that replaces it.
+type wordDiffSpans struct {
+	before, after []span
+}
+
+// WordDiff sets whether to highlight the changed words of a line replaced by
+// another one.
+func (dv *DiffView) WordDiff(wordDiff bool) *DiffView {
+	dv.wordDiff = wordDiff
+	return dv
+}
+
+// lineSpans returns the changed ranges of a deleted line and the inserted
+// line that replaces it.
+func (dv *DiffView) lineSpans(before, after string) wordDiffSpans {
+	if !dv.wordDiff {
+		return wordDiffSpans{}
+	}
+	key := xxh3.HashString(before + "\x00" + after)
+	if spans, ok := dv.wordDiffCache[key]; ok {
+		return spans
+	}
+	spans := wordDiff(before, after)
+	if dv.wordDiffCache == nil {
+		dv.wordDiffCache = make(map[uint64]wordDiffSpans)
+	}
+	dv.wordDiffCache[key] = spans
+	return spans
+}
+
+// wordDiff compares two lines word by word and returns the ranges that
+// changed on each side. It returns nothing when the lines are too different.
+func wordDiff(before, after string) wordDiffSpans {
+	beforeTokens := tokenize(before)
+	afterTokens := tokenize(after)
+
+	// Diff the tokens as runes, with equal tokens getting equal runes.
+	ids := make(map[string]rune)
+	toRunes := func(s string, tokens []span) []rune {
+		runes := make([]rune, len(tokens))
+		for i, t := range tokens {
+			word := s[t.start:t.end]
+			id, ok := ids[word]
+			if !ok {
+				id = rune(len(ids))
+				ids[word] = id
+			}
+			runes[i] = id
+		}
+		return runes
+	}
+	diffs := lcs.DiffRunes(toRunes(before, beforeTokens), toRunes(after, afterTokens))
+
+	var spans wordDiffSpans
+	for _, d := range diffs {
+		if d.Start < d.End {
+			spans.before = appendSpan(spans.before, span{beforeTokens[d.Start].start, beforeTokens[d.End-1].end})
+		}
+		if d.ReplStart < d.ReplEnd {
+			spans.after = appendSpan(spans.after, span{afterTokens[d.ReplStart].start, afterTokens[d.ReplEnd-1].end})
+		}
+	}
+
+	if changedRatio(before, spans.before) > maxWordDiffRatio || changedRatio(after, spans.after) > maxWordDiffRatio {
+		return wordDiffSpans{}
+	}
+	return spans
+}
+
+// appendSpan appends a span, merging it with the last one when they touch.
+func appendSpan(spans []span, s span) []span {
+	if n := len(spans); n > 0 && spans[n-1].end >= s.start {
+		spans[n-1].end = max(spans[n-1].end, s.end)
+		return spans
+	}
+	return append(spans, s)
+}
+
+// changedRatio returns the share of the non-space characters of the line
+// covered by the spans.
+func changedRatio(line string, spans []span) float64 {
+	total := countNonSpace(line)
+	if total == 0 {
+		return 0
+	}
+	changed := 0
+	for _, s := range spans {
+		changed += countNonSpace(line[s.start:s.end])
+	}
+	return float64(changed) / float64(total)
+}
+
+func countNonSpace(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// tokenize splits a line into words, runs of spaces and single other
+// characters.
+func tokenize(s string) []span {
+	var tokens []span
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		start := i
+		i += size
+		switch {
+		case isWordRune(r):
+			for i < len(s) {
+				r, size := utf8.DecodeRuneInString(s[i:])
+				if !isWordRune(r) {
+					break
+				}
+				i += size
+			}
+		case unicode.IsSpace(r):
+			for i < len(s) {
+				r, size := utf8.DecodeRuneInString(s[i:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				i += size
+			}
+		}
+		tokens = append(tokens, span{start, i})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// pairLines pairs each deleted line of a hunk with the inserted line that
+// replaces it, in the order they appear, and returns the index of the other
+// line of the pair for each line, or -1.
+func pairLines(lines []udiff.Line) []int {
+	pairs := make([]int, len(lines))
+	for i := range pairs {
+		pairs[i] = -1
+	}
+	for i := 0; i < len(lines); {
+		if lines[i].Kind != udiff.Delete {
+			i++
+			continue
+		}
+		deletes := i
+		for i < len(lines) && lines[i].Kind == udiff.Delete {
+			i++
+		}
+		inserts := i
+		for i < len(lines) && lines[i].Kind == udiff.Insert {
+			i++
+		}
+		for j := 0; deletes+j < inserts && inserts+j < i; j++ {
+			pairs[deletes+j] = inserts + j
+			pairs[inserts+j] = deletes + j
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,42 @@
+package diffview
+
+import (
+	"testing"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordDiff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changed word", func(t *testing.T) {
+		t.Parallel()
+		before := `    fmt.Println("Hello, world!")`
+		after := `    fmt.Println(content)`
+		spans := wordDiff(before, after)
+		require.Equal(t, []span{{16, 31}}, spans.before)
+		require.Equal(t, []span{{16, 23}}, spans.after)
+	})
+
+	t.Run("too different", func(t *testing.T) {
+		t.Parallel()
+		spans := wordDiff("return nil", "for i := range items {")
+		require.Empty(t, spans.before)
+		require.Empty(t, spans.after)
+	})
+}
+
+func TestPairLines(t *testing.T) {
+	t.Parallel()
+
+	lines := []udiff.Line{
+		{Kind: udiff.Equal},
+		{Kind: udiff.Delete},
+		{Kind: udiff.Delete},
+		{Kind: udiff.Insert},
+		{Kind: udiff.Equal},
+		{Kind: udiff.Insert},
+	}
+	require.Equal(t, []int{-1, 3, -1, 1, -1, -1}, pairLines(lines))
+}
@@ -1,6 +1,8 @@
 package diffview
 
 import (
+	"image/color"
+
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/x/exp/charmtone"
 )
@@ -10,6 +12,19 @@ type LineStyle struct {
 	LineNumber lipgloss.Style
 	Symbol     lipgloss.Style
 	Code       lipgloss.Style
+	// Emphasis is the background of the changed words of the line, with word
+	// diff on. Without a background, they aren't highlighted.
+	Emphasis lipgloss.Style
+}
+
+// emphasisColor returns the background of the changed words, or nil if they
+// aren't highlighted.
+func (ls LineStyle) emphasisColor() color.Color {
+	c := ls.Emphasis.GetBackground()
+	if _, ok := c.(lipgloss.NoColor); ok || c == nil {
+		return nil
+	}
+	return c
 }
 
 // Style defines the overall style for the diff view, including styles for
@@ -58,6 +73,8 @@ func DefaultLightStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Pepper).
 				Background(lipgloss.Color("#e8f5e9")),
+			Emphasis: lipgloss.NewStyle().
+				Background(lipgloss.Color("#b9e0bb")),
 		},
 		DeleteLine: LineStyle{
 			LineNumber: lipgloss.NewStyle().
@@ -69,6 +86,8 @@ func DefaultLightStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Pepper).
 				Background(lipgloss.Color("#ffebee")),
+			Emphasis: lipgloss.NewStyle().
+				Background(lipgloss.Color("#f9bfc6")),
 		},
 	}
 }
@@ -108,6 +127,8 @@ func DefaultDarkStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Salt).
 				Background(lipgloss.Color("#303a30")),
+			Emphasis: lipgloss.NewStyle().
+				Background(lipgloss.Color("#41573f")),
 		},
 		DeleteLine: LineStyle{
 			LineNumber: lipgloss.NewStyle().
@@ -119,6 +140,8 @@ func DefaultDarkStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Salt).
 				Background(lipgloss.Color("#3a3030")),
+			Emphasis: lipgloss.NewStyle().
+				Background(lipgloss.Color("#5c3d3b")),
 		},
 	}
 }
@@ -61,6 +61,7 @@ type List[T Item] interface {
 	SetItems([]T) tea.Cmd
 	SetSelected(string) tea.Cmd
 	SelectedItem() *T
+	ItemAt(line int) (T, bool)
 	Items() []T
 	UpdateItem(string, T) tea.Cmd
 	DeleteItem(string) tea.Cmd
@@ -106,6 +107,7 @@ type confOptions struct {
 	focused         bool
 	resize          bool
 	enableMouse     bool
+	wheelSelects    bool
 }
 
 type list[T Item] struct {
@@ -203,6 +205,15 @@ func WithEnableMouse() ListOption {
 	}
 }
 
+// WithMouseWheelSelection makes the mouse wheel select the item above or
+// below instead of scrolling, for lists used to pick an item.
+func WithMouseWheelSelection() ListOption {
+	return func(l *confOptions) {
+		l.enableMouse = true
+		l.wheelSelects = true
+	}
+}
+
 func New[T Item](items []T, opts ...ListOption) List[T] {
 	list := &list[T]{
 		confOptions: &confOptions{
@@ -250,6 +261,12 @@ func (l *list[T]) Init() tea.Cmd {
 // Update implements List.
 func (l *list[T]) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case styles.ThemeChangedMsg:
+		selectedID := ""
+		if l.selectedItemIdx >= 0 && l.selectedItemIdx < len(l.items) {
+			selectedID = l.items[l.selectedItemIdx].ID()
+		}
+		return l, l.reset(selectedID)
 	case tea.MouseWheelMsg:
 		if l.enableMouse {
 			return l.handleMouseWheel(msg)
@@ -325,6 +342,15 @@ func (l *list[T]) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 
 func (l *list[T]) handleMouseWheel(msg tea.MouseWheelMsg) (util.Model, tea.Cmd) {
 	var cmd tea.Cmd
+	if l.wheelSelects {
+		switch msg.Button {
+		case tea.MouseWheelDown:
+			cmd = l.SelectItemBelow()
+		case tea.MouseWheelUp:
+			cmd = l.SelectItemAbove()
+		}
+		return l, cmd
+	}
 	switch msg.Button {
 	case tea.MouseWheelDown:
 		cmd = l.MoveDown(ViewportDefaultScrollSize)
@@ -1404,6 +1430,23 @@ func (l *list[T]) SelectedItem() *T {
 	return &item
 }
 
+// ItemAt returns the item shown at the given line of the view.
+func (l *list[T]) ItemAt(line int) (T, bool) {
+	var zero T
+	start, end := l.viewPosition()
+	line += start
+	if line < start || line > end {
+		return zero, false
+	}
+	for _, item := range l.items {
+		rItem, ok := l.renderedItems[item.ID()]
+		if ok && line >= rItem.start && line <= rItem.end {
+			return item, true
+		}
+	}
+	return zero, false
+}
+
 // SetItems implements List.
 func (l *list[T]) SetItems(items []T) tea.Cmd {
 	l.items = items
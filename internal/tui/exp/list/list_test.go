@@ -563,6 +563,43 @@ func TestListMovement(t *testing.T) {
 		assert.Equal(t, 31, lipgloss.Height(l.rendered))
 		golden.RequireEqual(t, []byte(l.View()))
 	})
+	t.Run("should select items with the mouse wheel", func(t *testing.T) {
+		t.Parallel()
+		items := []Item{}
+		for i := range 5 {
+			items = append(items, NewSelectableItem(fmt.Sprintf("Item %d", i)))
+		}
+		l := New(items, WithDirectionForward(), WithSize(10, 20), WithMouseWheelSelection()).(*list[Item])
+		execCmd(l, l.Init())
+
+		_, cmd := l.Update(tea.MouseWheelMsg{Button: tea.MouseWheelDown})
+		execCmd(l, cmd)
+		assert.Equal(t, 1, l.selectedItemIdx)
+		assert.Equal(t, 0, l.offset)
+
+		_, cmd = l.Update(tea.MouseWheelMsg{Button: tea.MouseWheelUp})
+		execCmd(l, cmd)
+		assert.Equal(t, 0, l.selectedItemIdx)
+	})
+	t.Run("should find the item at a line of the view", func(t *testing.T) {
+		t.Parallel()
+		items := []Item{}
+		for i := range 30 {
+			items = append(items, NewSelectableItem(fmt.Sprintf("Item %d\nline 2", i)))
+		}
+		l := New(items, WithDirectionForward(), WithSize(10, 10)).(*list[Item])
+		execCmd(l, l.Init())
+		execCmd(l, l.MoveDown(4))
+
+		item, ok := l.ItemAt(0)
+		require.True(t, ok)
+		assert.Equal(t, items[2].ID(), item.ID())
+		item, ok = l.ItemAt(3)
+		require.True(t, ok)
+		assert.Equal(t, items[3].ID(), item.ID())
+		_, ok = l.ItemAt(10)
+		assert.False(t, ok)
+	})
 }
 
 type SelectableItem interface {
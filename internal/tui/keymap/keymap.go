@@ -0,0 +1,196 @@
+// Package keymap lets users remap the key bindings of the TUI.
+//
+// Each action is named after the key map it belongs to and its field, in
+// snake_case, e.g. "app.commands" for the Commands binding of the app key map.
+package keymap
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"charm.land/bubbles/v2/key"
+)
+
+var (
+	mu       sync.RWMutex
+	bindings map[string][]string
+)
+
+// Scope is a named key map whose bindings can be remapped.
+type Scope struct {
+	Name   string
+	KeyMap any
+}
+
+// Set configures the keys actions are bound to. An empty list of keys unbinds
+// the action.
+func Set(b map[string][]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	bindings = b
+}
+
+// Keys returns the keys configured for the given action, if any.
+func Keys(action string) ([]string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	keys, ok := bindings[action]
+	return keys, ok
+}
+
+// Bind returns the binding with the keys configured for the action, if any.
+// The help shows the first configured key.
+func Bind(action string, b key.Binding) key.Binding {
+	keys, ok := Keys(action)
+	if !ok {
+		return b
+	}
+	if len(keys) == 0 {
+		b.Unbind()
+		return b
+	}
+	b.SetKeys(keys...)
+	b.SetHelp(keys[0], b.Help().Desc)
+	return b
+}
+
+// Shortcut returns the key shown for the action: the first configured key,
+// or the given default. It's empty when the action is unbound.
+func Shortcut(action, def string) string {
+	keys, ok := Keys(action)
+	if !ok {
+		return def
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0]
+}
+
+// Apply binds each key.Binding field of the key map to the keys configured for
+// its action.
+func Apply[T any](scope string, km T) T {
+	v := reflect.ValueOf(&km).Elem()
+	for name, field := range bindingFields(scope, v) {
+		field.Set(reflect.ValueOf(Bind(name, field.Interface().(key.Binding))))
+	}
+	return km
+}
+
+// Actions returns the bindings of the key map by action name.
+func Actions(scope Scope) map[string]key.Binding {
+	v := reflect.ValueOf(scope.KeyMap)
+	actions := map[string]key.Binding{}
+	for name, field := range bindingFields(scope.Name, v) {
+		actions[name] = field.Interface().(key.Binding)
+	}
+	return actions
+}
+
+// Validate checks that the given bindings only remap actions of the scopes
+// and don't make two actions of a group share a key. Scopes in a group are
+// active at the same time. Only conflicts on keys the bindings add are errors,
+// so default bindings that share keys on purpose are left alone.
+func Validate(b map[string][]string, groups ...[]Scope) error {
+	known := map[string]bool{}
+	for _, group := range groups {
+		for _, scope := range group {
+			for name := range Actions(scope) {
+				known[name] = true
+			}
+		}
+	}
+
+	var errs []string
+	for _, action := range slices.Sorted(maps.Keys(b)) {
+		if !known[action] {
+			errs = append(errs, fmt.Sprintf("unknown action %q", action))
+			continue
+		}
+		for _, k := range b[action] {
+			if strings.TrimSpace(k) == "" {
+				errs = append(errs, fmt.Sprintf("empty key for action %q", action))
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, group := range groups {
+		byKey := map[string][]string{}
+		// added holds the keys each action gets from the given bindings.
+		added := map[string][]string{}
+		for _, scope := range group {
+			for name, binding := range Actions(scope) {
+				keys := binding.Keys()
+				if configured, ok := b[name]; ok {
+					for _, k := range configured {
+						if !slices.Contains(keys, k) {
+							added[name] = append(added[name], k)
+						}
+					}
+					keys = configured
+				}
+				for _, k := range keys {
+					if !slices.Contains(byKey[k], name) {
+						byKey[k] = append(byKey[k], name)
+					}
+				}
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(byKey)) {
+			actions := byKey[k]
+			if len(actions) < 2 || !slices.ContainsFunc(actions, func(a string) bool {
+				return slices.Contains(added[a], k)
+			}) {
+				continue
+			}
+			slices.Sort(actions)
+			msg := fmt.Sprintf("key %q is bound to %s", k, strings.Join(actions, " and "))
+			if !seen[msg] {
+				seen[msg] = true
+				errs = append(errs, msg)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid keybindings: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func bindingFields(scope string, v reflect.Value) map[string]reflect.Value {
+	fields := map[string]reflect.Value{}
+	bindingType := reflect.TypeFor[key.Binding]()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() || field.Type != bindingType {
+			continue
+		}
+		fields[scope+"."+snakeCase(field.Name)] = v.Field(i)
+	}
+	return fields
+}
+
+// snakeCase converts field names such as AddAttachment to add_attachment.
+func snakeCase(s string) string {
+	var sb strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,93 @@
+package keymap
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/key"
+	"github.com/stretchr/testify/require"
+)
+
+type testKeyMap struct {
+	NewSession key.Binding
+	Quit       key.Binding
+	OpenMCP    key.Binding
+
+	unexported key.Binding
+}
+
+func newTestKeyMap() testKeyMap {
+	return testKeyMap{
+		NewSession: key.NewBinding(key.WithKeys("ctrl+n"), key.WithHelp("ctrl+n", "new session")),
+		Quit:       key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+		OpenMCP:    key.NewBinding(key.WithKeys("ctrl+o"), key.WithHelp("ctrl+o", "mcps")),
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "new_session", snakeCase("NewSession"))
+	require.Equal(t, "quit", snakeCase("Quit"))
+	require.Equal(t, "open_mcp", snakeCase("OpenMCP"))
+	require.Equal(t, "mcp_list", snakeCase("MCPList"))
+}
+
+func TestActions(t *testing.T) {
+	t.Parallel()
+
+	actions := Actions(Scope{Name: "test", KeyMap: newTestKeyMap()})
+	require.Len(t, actions, 3)
+	require.Equal(t, []string{"ctrl+n"}, actions["test.new_session"].Keys())
+	require.Contains(t, actions, "test.open_mcp")
+}
+
+func TestApply(t *testing.T) {
+	// Not parallel: sets the global bindings.
+	t.Cleanup(func() { Set(nil) })
+	Set(map[string][]string{
+		"test.new_session": {"alt+n", "ctrl+t"},
+		"test.quit":        {},
+		"other.quit":       {"q"},
+	})
+
+	km := Apply("test", newTestKeyMap())
+	require.Equal(t, []string{"alt+n", "ctrl+t"}, km.NewSession.Keys())
+	require.Equal(t, key.Help{Key: "alt+n", Desc: "new session"}, km.NewSession.Help())
+	require.False(t, km.Quit.Enabled())
+	require.Equal(t, []string{"ctrl+o"}, km.OpenMCP.Keys())
+
+	require.Equal(t, "alt+n", Shortcut("test.new_session", "ctrl+n"))
+	require.Equal(t, "", Shortcut("test.quit", "ctrl+c"))
+	require.Equal(t, "ctrl+o", Shortcut("test.open_mcp", "ctrl+o"))
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	scope := Scope{Name: "test", KeyMap: newTestKeyMap()}
+	other := Scope{Name: "other", KeyMap: testKeyMap{
+		Quit: key.NewBinding(key.WithKeys("ctrl+c", "q")),
+	}}
+
+	require.NoError(t, Validate(nil, []Scope{scope}))
+	require.NoError(t, Validate(map[string][]string{
+		"test.new_session": {"ctrl+o"},
+		"test.open_mcp":    {},
+	}, []Scope{scope}))
+	// Scopes that aren't active together can share keys.
+	require.NoError(t, Validate(map[string][]string{
+		"test.new_session": {"q"},
+	}, []Scope{scope}, []Scope{other}))
+
+	err := Validate(map[string][]string{
+		"test.new_session": {"ctrl+o"},
+		"test.nope":        {"x"},
+		"test.quit":        {""},
+	}, []Scope{scope})
+	require.EqualError(t, err, `invalid keybindings: unknown action "test.nope"; empty key for action "test.quit"; key "ctrl+o" is bound to test.new_session and test.open_mcp`)
+
+	err = Validate(map[string][]string{
+		"test.new_session": {"q"},
+	}, []Scope{scope, other})
+	require.EqualError(t, err, `invalid keybindings: key "q" is bound to other.quit and test.new_session`)
+}
@@ -2,6 +2,26 @@ package tui
 
 import (
 	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/editor"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/messages"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/splash"
+	"github.com/charmbracelet/crush/internal/tui/components/completions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/contextusage"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/mcps"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/permissions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/reasoning"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/sessions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/themes"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
+	"github.com/charmbracelet/crush/internal/tui/page/chat"
+	"github.com/charmbracelet/crush/internal/tui/page/review"
 )
 
 type KeyMap struct {
@@ -16,7 +36,7 @@ type KeyMap struct {
 }
 
 func DefaultKeyMap() KeyMap {
-	return KeyMap{
+	return keymap.Apply("app", KeyMap{
 		Quit: key.NewBinding(
 			key.WithKeys("ctrl+c"),
 			key.WithHelp("ctrl+c", "quit"),
@@ -41,5 +61,45 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+s"),
 			key.WithHelp("ctrl+s", "sessions"),
 		),
+	})
+}
+
+// keyMapGroups lists the key maps users can remap, grouped by the ones that
+// are active at the same time.
+func keyMapGroups() [][]keymap.Scope {
+	app := keymap.Scope{Name: "app", KeyMap: DefaultKeyMap()}
+	chatPage := keymap.Scope{Name: "chat", KeyMap: chat.DefaultKeyMap()}
+	editorKeys := keymap.Scope{Name: "editor", KeyMap: editor.DefaultEditorKeyMap()}
+	dialog := keymap.Scope{Name: "dialog", KeyMap: dialogs.DefaultKeyMap()}
+	return [][]keymap.Scope{
+		{app, chatPage, editorKeys},
+		{app, chatPage, {Name: "list", KeyMap: list.DefaultKeyMap()}, {Name: "messages", KeyMap: messages.DefaultKeyMap()}},
+		{app, chatPage, {Name: "splash", KeyMap: splash.DefaultKeyMap()}},
+		{app, {Name: "review", KeyMap: review.DefaultKeyMap()}},
+		{editorKeys, {Name: "completions", KeyMap: completions.DefaultKeyMap()}},
+		{dialog, {Name: "commands", KeyMap: commands.DefaultCommandsDialogKeyMap()}},
+		{dialog, {Name: "arguments", KeyMap: commands.DefaultArgumentsDialogKeyMap()}},
+		{dialog, {Name: "models", KeyMap: models.DefaultKeyMap()}},
+		{dialog, {Name: "sessions", KeyMap: sessions.DefaultKeyMap()}},
+		{dialog, {Name: "mcps", KeyMap: mcps.DefaultKeyMap()}},
+		{dialog, {Name: "themes", KeyMap: themes.DefaultThemeDialogKeyMap()}},
+		{dialog, {Name: "reasoning", KeyMap: reasoning.DefaultReasoningDialogKeyMap()}},
+		{dialog, {Name: "context_usage", KeyMap: contextusage.DefaultKeyMap()}},
+		{dialog, {Name: "filepicker", KeyMap: filepicker.DefaultKeyMap()}},
+		{dialog, {Name: "permissions", KeyMap: permissions.DefaultKeyMap()}},
+		{dialog, {Name: "quit", KeyMap: quit.DefaultKeymap()}},
+	}
+}
+
+// LoadKeybindings validates the keybindings of the config and applies them to
+// the key maps created from then on.
+func LoadKeybindings(cfg *config.Config) error {
+	if cfg == nil || cfg.Options.TUI == nil || len(cfg.Options.TUI.Keybindings) == 0 {
+		return nil
+	}
+	if err := keymap.Validate(cfg.Options.TUI.Keybindings, keyMapGroups()...); err != nil {
+		return err
 	}
+	keymap.Set(cfg.Options.TUI.Keybindings)
+	return nil
 }
@@ -0,0 +1,25 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/tui/keymap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyMapGroups(t *testing.T) {
+	t.Parallel()
+
+	groups := keyMapGroups()
+	require.NoError(t, keymap.Validate(nil, groups...))
+	require.NoError(t, keymap.Validate(map[string][]string{
+		"app.commands":        {"alt+p"},
+		"app.sessions":        {},
+		"editor.send_message": {"enter", "ctrl+s"},
+		"permissions.allow":   {"y"},
+		"themes.next":         {"j"},
+	}, groups...))
+	require.ErrorContains(t, keymap.Validate(map[string][]string{
+		"app.commands": {"ctrl+n"},
+	}, groups...), `key "ctrl+n" is bound to app.commands and chat.new_session`)
+}
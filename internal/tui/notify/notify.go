@@ -0,0 +1,140 @@
+// Package notify tells the user the agent needs attention while the terminal
+// is not focused, with terminal notifications, the bell and desktop
+// notifications.
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Event is something the user is notified of.
+type Event int
+
+const (
+	// TurnComplete is sent when the agent finishes a turn.
+	TurnComplete Event = iota
+	// Error is sent when a turn ends with an error.
+	Error
+	// Permission is sent when a permission is requested.
+	Permission
+)
+
+const (
+	// appName is the title of the notifications.
+	appName = "Crush"
+	// maxBodyLength is the length notification bodies are truncated to.
+	maxBodyLength = 200
+	// desktopTimeout bounds the time notify-send can take.
+	desktopTimeout = 5 * time.Second
+)
+
+// Msg asks for a notification, for commands that find out about events in
+// the background. It is only sent if still wanted when it is handled.
+type Msg struct {
+	Event Event
+	Body  string
+}
+
+// Notifier sends notifications while the terminal is not focused.
+type Notifier struct {
+	cfg     config.Notifications
+	focused bool
+}
+
+// New returns a notifier for the given options. The terminal is considered
+// focused until it reports otherwise, so terminals that do not report focus
+// changes get no notifications.
+func New(cfg config.Notifications) *Notifier {
+	return &Notifier{cfg: cfg, focused: true}
+}
+
+// SetFocused records whether the terminal is focused.
+func (n *Notifier) SetFocused(focused bool) {
+	n.focused = focused
+}
+
+// Wants returns whether the event would be notified now.
+func (n *Notifier) Wants(event Event) bool {
+	if n == nil || n.focused {
+		return false
+	}
+	turnComplete, err, permission := n.cfg.Events()
+	switch event {
+	case TurnComplete:
+		return turnComplete
+	case Error:
+		return err
+	case Permission:
+		return permission
+	}
+	return false
+}
+
+// Notify returns a command sending the notification, or nil when the event
+// is not notified now.
+func (n *Notifier) Notify(event Event, body string) tea.Cmd {
+	if !n.Wants(event) {
+		return nil
+	}
+	return tea.Batch(n.commands(body)...)
+}
+
+// commands returns the commands sending a notification with the given body.
+func (n *Notifier) commands(body string) []tea.Cmd {
+	body = sanitize(body)
+	var cmds []tea.Cmd
+	switch n.cfg.TerminalSequence() {
+	case config.NotificationTerminalOSC9:
+		cmds = append(cmds, tea.Raw(ansi.Notify(appName+": "+body)))
+	case config.NotificationTerminalOSC777:
+		cmds = append(cmds, tea.Raw(ansi.URxvtExt("notify", appName, strings.ReplaceAll(body, ";", ","))))
+	}
+	if n.cfg.RingBell() {
+		cmds = append(cmds, tea.Raw(string(rune(ansi.BEL))))
+	}
+	if n.cfg.Desktop {
+		cmds = append(cmds, desktop(body))
+	}
+	return cmds
+}
+
+// desktop sends a desktop notification with notify-send, when it is
+// installed.
+func desktop(body string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			slog.Debug("notify-send not found, skipping desktop notification")
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), desktopTimeout)
+		defer cancel()
+		if err := exec.CommandContext(ctx, path, "--app-name="+appName, appName, body).Run(); err != nil {
+			slog.Warn("Failed to send desktop notification", "error", err)
+		}
+		return nil
+	}
+}
+
+// sanitize keeps the body on one line, without control characters that
+// would end the escape sequence early.
+func sanitize(body string) string {
+	body = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case r < 0x20 || r == 0x7f:
+			return -1
+		}
+		return r
+	}, body)
+	return ansi.Truncate(strings.TrimSpace(body), maxBodyLength, "…")
+}
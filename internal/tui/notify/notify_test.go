@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWants(t *testing.T) {
+	t.Parallel()
+
+	disabled := false
+	n := New(config.Notifications{Error: &disabled})
+	require.False(t, n.Wants(TurnComplete), "focused terminals get no notifications")
+
+	n.SetFocused(false)
+	require.True(t, n.Wants(TurnComplete))
+	require.True(t, n.Wants(Permission))
+	require.False(t, n.Wants(Error))
+	require.Nil(t, n.Notify(Error, "failed"))
+
+	var nilNotifier *Notifier
+	require.False(t, nilNotifier.Wants(TurnComplete))
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		cfg  config.Notifications
+		want []tea.Msg
+	}{
+		{
+			name: "default",
+			want: []tea.Msg{
+				tea.RawMsg{Msg: "\x1b]9;Crush: Finished: a b\x07"},
+				tea.RawMsg{Msg: "\a"},
+			},
+		},
+		{
+			name: "osc777",
+			cfg:  config.Notifications{Terminal: config.NotificationTerminalOSC777},
+			want: []tea.Msg{
+				tea.RawMsg{Msg: "\x1b]777;notify;Crush;Finished: a b\x07"},
+				tea.RawMsg{Msg: "\a"},
+			},
+		},
+		{
+			name: "no terminal nor bell",
+			cfg: config.Notifications{
+				Terminal: config.NotificationTerminalNone,
+				Bell:     new(bool),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []tea.Msg
+			for _, cmd := range New(tt.cfg).commands("Finished: a\n\x1b\x07b") {
+				got = append(got, cmd())
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"charm.land/bubbles/v2/help"
@@ -13,6 +14,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
@@ -30,9 +32,12 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/contextusage"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/permissions"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/reasoning"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -78,6 +83,7 @@ type ChatPage interface {
 	util.Model
 	layout.Help
 	IsChatFocused() bool
+	SessionStatus(sessionID string) SessionStatus
 }
 
 // cancelTimerCmd creates a command that expires the cancel timer
@@ -99,8 +105,16 @@ type chatPage struct {
 	focusedPane  PanelType
 
 	// Session
-	session session.Session
-	keyMap  KeyMap
+	session        session.Session
+	keyMap         KeyMap
+	messagesKeyMap messages.KeyMap
+
+	// Tabs are the sessions open side by side, in the order they were
+	// opened. A new session is not a tab until its first prompt is sent.
+	tabs []session.Session
+	// awaitingPermission maps the permission requests waiting for an answer
+	// to the open session they were made for.
+	awaitingPermission *csync.Map[string, string]
 
 	// Components
 	header  header.Header
@@ -119,20 +133,23 @@ type chatPage struct {
 
 func New(app *app.App) ChatPage {
 	return &chatPage{
-		app:         app,
-		keyMap:      DefaultKeyMap(),
-		header:      header.New(app.LSPClients),
-		sidebar:     sidebar.New(app.History, app.LSPClients, false),
-		chat:        chat.New(app),
-		editor:      editor.New(app),
-		splash:      splash.New(),
-		focusedPane: PanelTypeSplash,
+		app:                app,
+		keyMap:             DefaultKeyMap(),
+		messagesKeyMap:     messages.DefaultKeyMap(),
+		awaitingPermission: csync.NewMap[string, string](),
+		header:             header.New(app.LSPClients),
+		sidebar:            sidebar.New(app.History, app.LSPClients, false),
+		chat:               chat.New(app),
+		editor:             editor.New(app),
+		splash:             splash.New(),
+		focusedPane:        PanelTypeSplash,
 	}
 }
 
 func (p *chatPage) Init() tea.Cmd {
 	cfg := config.Get()
-	compact := cfg.Options.TUI.CompactMode
+	// Screen readers read one column: no sidebar next to the chat.
+	compact := cfg.Options.TUI.CompactMode || styles.ScreenReader()
 	p.compact = compact
 	p.forceCompact = compact
 	p.sidebar.SetCompactMode(p.compact)
@@ -170,6 +187,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.keyboardEnhancements = msg
 		return p, nil
 	case tea.MouseWheelMsg:
+		msg.Y -= p.tabBarHeight()
 		if p.compact {
 			msg.Y -= 1
 		}
@@ -183,6 +201,25 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if p.isOnboarding {
 			return p, nil
 		}
+		if msg.Y < p.tabBarHeight() {
+			if msg.Button == tea.MouseLeft {
+				return p, p.goToTab(p.tabAt(msg.X))
+			}
+			return p, nil
+		}
+		msg.Y -= p.tabBarHeight()
+		switch {
+		case p.showingDetails:
+			// Clicking closes the details opened from the header.
+			p.setShowDetails(false)
+			return p, nil
+		case p.compact && p.session.ID != "" && msg.Y < HeaderHeight:
+			p.toggleDetails()
+			return p, nil
+		case p.isMouseOverSidebar(msg.X, msg.Y):
+			// The sidebar only shows information, keep the focus where it is.
+			return p, nil
+		}
 		if p.compact {
 			msg.Y -= 1
 		}
@@ -199,6 +236,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.chat = u.(chat.MessageListCmp)
 		return p, cmd
 	case tea.MouseMotionMsg:
+		msg.Y -= p.tabBarHeight()
 		if p.compact {
 			msg.Y -= 1
 		}
@@ -212,6 +250,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if p.isOnboarding {
 			return p, nil
 		}
+		msg.Y -= p.tabBarHeight()
 		if p.compact {
 			msg.Y -= 1
 		}
@@ -232,12 +271,27 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case CancelTimerExpiredMsg:
 		p.isCanceling = false
 		return p, nil
+	case styles.ThemeChangedMsg:
+		u, cmd := p.editor.Update(msg)
+		p.editor = u.(editor.Editor)
+		cmds = append(cmds, cmd)
+		u, cmd = p.chat.Update(msg)
+		p.chat = u.(chat.MessageListCmp)
+		cmds = append(cmds, cmd)
+		return p, tea.Batch(cmds...)
 	case editor.OpenEditorMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
 	case chat.SendMsg:
 		return p, p.sendMessage(msg.Text, msg.Attachments)
+	case chat.EditPromptMsg:
+		if p.focusedPane == PanelTypeChat {
+			p.changeFocus()
+		}
+		u, cmd := p.editor.Update(msg)
+		p.editor = u.(editor.Editor)
+		return p, cmd
 	case chat.SessionSelectedMsg:
 		return p, p.setSession(msg)
 	case splash.SubmitAPIKeyMsg:
@@ -262,11 +316,14 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return p, p.openReasoningDialog()
 	case reasoning.ReasoningEffortSelectedMsg:
 		return p, p.handleReasoningEffortSelected(msg.Effort)
+	case commands.OpenContextUsageMsg:
+		return p, p.openContextUsageDialog()
 	case commands.OpenExternalEditorMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
 	case pubsub.Event[session.Session]:
+		cmds = append(cmds, p.updateTab(msg.Payload, msg.Type == pubsub.DeletedEvent))
 		u, cmd := p.header.Update(msg)
 		p.header = u.(header.Header)
 		cmds = append(cmds, cmd)
@@ -329,9 +386,19 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.chat = u.(chat.MessageListCmp)
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
+	case pubsub.Event[permission.PermissionRequest]:
+		return p, p.permissionRequested(msg.Payload)
+	case permissionSessionResolvedMsg:
+		if _, ok := p.awaitingPermission.Get(msg.requestID); ok {
+			p.awaitingPermission.Set(msg.requestID, msg.sessionID)
+		}
+		return p, nil
+	case permissions.PermissionResponseMsg:
+		p.awaitingPermission.Del(msg.Permission.ID)
+		return p, nil
 
 	case commands.CommandRunCustomMsg:
-		if p.app.AgentCoordinator.IsBusy() {
+		if p.app.AgentCoordinator().IsSessionBusy(p.session.ID) {
 			return p, util.ReportWarn("Agent is busy, please wait before executing a command...")
 		}
 
@@ -355,21 +422,23 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.focusedPane = PanelTypeEditor
 		return p, p.SetSize(p.width, p.height)
 	case commands.NewSessionsMsg:
-		if p.app.AgentCoordinator.IsBusy() {
-			return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
-		}
 		return p, p.newSession()
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, p.keyMap.NewSession):
 			// if we have no agent do nothing
-			if p.app.AgentCoordinator == nil {
+			if p.app.AgentCoordinator() == nil {
 				return p, nil
 			}
-			if p.app.AgentCoordinator.IsBusy() {
-				return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
-			}
 			return p, p.newSession()
+		case key.Matches(msg, p.keyMap.NextTab):
+			return p, p.switchTab(p.activeTab() + 1)
+		case key.Matches(msg, p.keyMap.PrevTab):
+			return p, p.switchTab(p.activeTab() - 1)
+		case key.Matches(msg, p.keyMap.CloseTab):
+			return p, p.closeTab()
+		case key.Matches(msg, p.keyMap.GoToTab):
+			return p, p.goToTab(slices.Index(p.keyMap.GoToTab.Keys(), msg.String()))
 		case key.Matches(msg, p.keyMap.AddAttachment):
 			agentCfg := config.Get().Agents[config.AgentCoder]
 			model := config.Get().GetModelByType(agentCfg.Model)
@@ -387,7 +456,11 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			p.changeFocus()
 			return p, nil
 		case key.Matches(msg, p.keyMap.Cancel):
-			if p.session.ID != "" && p.app.AgentCoordinator.IsBusy() {
+			// In vim insert mode, esc goes back to normal mode instead.
+			if p.focusedPane == PanelTypeEditor && p.editor.IsVimInsertMode() {
+				break
+			}
+			if p.session.ID != "" && p.app.AgentCoordinator().IsSessionBusy(p.session.ID) {
 				return p, p.cancel()
 			}
 		case key.Matches(msg, p.keyMap.Details):
@@ -489,6 +562,10 @@ func (p *chatPage) View() string {
 		}
 	}
 
+	if p.tabBarHeight() > 0 {
+		chatView = lipgloss.JoinVertical(lipgloss.Left, p.renderTabs(), chatView)
+	}
+
 	layers := []*lipgloss.Layer{
 		lipgloss.NewLayer(chatView).X(0).Y(0),
 	}
@@ -496,7 +573,7 @@ func (p *chatPage) View() string {
 	if p.showingDetails {
 		style := t.S().Base.
 			Width(p.detailsWidth).
-			Border(lipgloss.RoundedBorder()).
+			Border(styles.Border(lipgloss.RoundedBorder())).
 			BorderForeground(t.BorderFocus)
 		version := t.S().Base.Foreground(t.Border).Width(p.detailsWidth - 4).AlignHorizontal(lipgloss.Right).Render(version.Version)
 		details := style.Render(
@@ -506,7 +583,7 @@ func (p *chatPage) View() string {
 				version,
 			),
 		)
-		layers = append(layers, lipgloss.NewLayer(details).X(1).Y(1))
+		layers = append(layers, lipgloss.NewLayer(details).X(1).Y(1+p.tabBarHeight()))
 	}
 	canvas := lipgloss.NewCanvas(
 		layers...,
@@ -573,6 +650,25 @@ func (p *chatPage) openReasoningDialog() tea.Cmd {
 	}
 }
 
+// openContextUsageDialog breaks down the context the next message of the
+// session takes, the attachments of the editor included.
+func (p *chatPage) openContextUsageDialog() tea.Cmd {
+	sessionID := p.session.ID
+	attachments := slices.Clone(p.editor.Attachments())
+	return func() tea.Msg {
+		if p.app.AgentCoordinator() == nil {
+			return util.ReportError(fmt.Errorf("coder agent is not initialized"))()
+		}
+		usage, err := p.app.AgentCoordinator().ContextUsage(context.Background(), sessionID, attachments...)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return dialogs.OpenDialogMsg{
+			Model: contextusage.NewContextUsageDialogCmp(usage),
+		}
+	}
+}
+
 func (p *chatPage) handleReasoningEffortSelected(effort string) tea.Cmd {
 	return func() tea.Msg {
 		cfg := config.Get()
@@ -633,25 +729,27 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 	p.height = height
 	var cmds []tea.Cmd
 
+	// The tab bar is on top, the editor stays at the bottom.
+	contentHeight := height - p.tabBarHeight()
 	if p.session.ID == "" {
 		if p.splashFullScreen {
-			cmds = append(cmds, p.splash.SetSize(width, height))
+			cmds = append(cmds, p.splash.SetSize(width, contentHeight))
 		} else {
-			cmds = append(cmds, p.splash.SetSize(width, height-EditorHeight))
+			cmds = append(cmds, p.splash.SetSize(width, contentHeight-EditorHeight))
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
 			cmds = append(cmds, p.editor.SetPosition(0, height-EditorHeight))
 		}
 	} else {
 		if p.compact {
-			cmds = append(cmds, p.chat.SetSize(width, height-EditorHeight-HeaderHeight))
+			cmds = append(cmds, p.chat.SetSize(width, contentHeight-EditorHeight-HeaderHeight))
 			p.detailsWidth = width - DetailsPositioning
 			cmds = append(cmds, p.sidebar.SetSize(p.detailsWidth-LeftRightBorders, p.detailsHeight-TopBottomBorders))
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
 			cmds = append(cmds, p.header.SetWidth(width-BorderWidth))
 		} else {
-			cmds = append(cmds, p.chat.SetSize(width-SideBarWidth, height-EditorHeight))
+			cmds = append(cmds, p.chat.SetSize(width-SideBarWidth, contentHeight-EditorHeight))
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
-			cmds = append(cmds, p.sidebar.SetSize(SideBarWidth, height-EditorHeight))
+			cmds = append(cmds, p.sidebar.SetSize(SideBarWidth, contentHeight-EditorHeight))
 		}
 		cmds = append(cmds, p.editor.SetPosition(0, height-EditorHeight))
 	}
@@ -681,6 +779,7 @@ func (p *chatPage) setSession(session session.Session) tea.Cmd {
 
 	var cmds []tea.Cmd
 	p.session = session
+	p.openTab(session)
 
 	cmds = append(cmds, p.SetSize(p.width, p.height))
 	cmds = append(cmds, p.chat.SetSession(session))
@@ -710,14 +809,14 @@ func (p *chatPage) changeFocus() {
 func (p *chatPage) cancel() tea.Cmd {
 	if p.isCanceling {
 		p.isCanceling = false
-		if p.app.AgentCoordinator != nil {
-			p.app.AgentCoordinator.Cancel(p.session.ID)
+		if p.app.AgentCoordinator() != nil {
+			p.app.AgentCoordinator().Cancel(p.session.ID)
 		}
 		return nil
 	}
 
-	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.QueuedPrompts(p.session.ID) > 0 {
-		p.app.AgentCoordinator.ClearQueue(p.session.ID)
+	if p.app.AgentCoordinator() != nil && p.app.AgentCoordinator().QueuedPrompts(p.session.ID) > 0 {
+		p.app.AgentCoordinator().ClearQueue(p.session.ID)
 		return nil
 	}
 	p.isCanceling = true
@@ -750,12 +849,21 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 		session = newSession
 		cmds = append(cmds, util.CmdHandler(chat.SessionSelectedMsg(session)))
 	}
-	if p.app.AgentCoordinator == nil {
+	if p.app.AgentCoordinator() == nil {
 		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
 	}
+	if model := p.app.AgentCoordinator().Model(); !model.CatwalkCfg.SupportsImages {
+		kept := slices.DeleteFunc(slices.Clone(attachments), func(a message.Attachment) bool {
+			return !a.IsText()
+		})
+		if removed := len(attachments) - len(kept); removed > 0 {
+			cmds = append(cmds, util.ReportWarn(fmt.Sprintf("%s does not support images, removed %d image attachment(s)", model.CatwalkCfg.Name, removed)))
+		}
+		attachments = kept
+	}
 	cmds = append(cmds, p.chat.GoToBottom())
 	cmds = append(cmds, func() tea.Msg {
-		_, err := p.app.AgentCoordinator.Run(context.Background(), session.ID, text, attachments...)
+		_, err := p.app.AgentCoordinator().Run(context.Background(), session.ID, text, attachments...)
 		if err != nil {
 			isCancelErr := errors.Is(err, context.Canceled)
 			isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
@@ -777,13 +885,13 @@ func (p *chatPage) Bindings() []key.Binding {
 		p.keyMap.NewSession,
 		p.keyMap.AddAttachment,
 	}
-	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
+	if p.app.AgentCoordinator() != nil && p.app.AgentCoordinator().IsSessionBusy(p.session.ID) {
 		cancelBinding := p.keyMap.Cancel
 		if p.isCanceling {
-			cancelBinding = key.NewBinding(
+			cancelBinding = keymap.Bind("chat.cancel", key.NewBinding(
 				key.WithKeys("esc", "alt+esc"),
 				key.WithHelp("esc", "press again to cancel"),
-			)
+			))
 		}
 		bindings = append([]key.Binding{cancelBinding}, bindings...)
 	}
@@ -791,18 +899,18 @@ func (p *chatPage) Bindings() []key.Binding {
 	switch p.focusedPane {
 	case PanelTypeChat:
 		bindings = append([]key.Binding{
-			key.NewBinding(
+			keymap.Bind("chat.tab", key.NewBinding(
 				key.WithKeys("tab"),
 				key.WithHelp("tab", "focus editor"),
-			),
+			)),
 		}, bindings...)
 		bindings = append(bindings, p.chat.Bindings()...)
 	case PanelTypeEditor:
 		bindings = append([]key.Binding{
-			key.NewBinding(
+			keymap.Bind("chat.tab", key.NewBinding(
 				key.WithKeys("tab"),
 				key.WithHelp("tab", "focus chat"),
-			),
+			)),
 		}, bindings...)
 		bindings = append(bindings, p.editor.Bindings()...)
 	case PanelTypeSplash:
@@ -829,10 +937,10 @@ func (p *chatPage) Help() help.KeyMap {
 				key.WithHelp("enter", "accept"),
 			),
 			// Quit
-			key.NewBinding(
+			keymap.Bind("app.quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -857,10 +965,10 @@ func (p *chatPage) Help() help.KeyMap {
 		}
 		shortList = append(shortList,
 			// Quit
-			key.NewBinding(
+			keymap.Bind("app.quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -868,10 +976,10 @@ func (p *chatPage) Help() help.KeyMap {
 		}
 	case p.isProjectInit:
 		shortList = append(shortList,
-			key.NewBinding(
+			keymap.Bind("app.quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -898,22 +1006,19 @@ func (p *chatPage) Help() help.KeyMap {
 			}
 			return core.NewSimpleHelp(shortList, fullList)
 		}
-		if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
-			cancelBinding := key.NewBinding(
-				key.WithKeys("esc", "alt+esc"),
-				key.WithHelp("esc", "cancel"),
-			)
+		if p.app.AgentCoordinator() != nil && p.app.AgentCoordinator().IsSessionBusy(p.session.ID) {
+			cancelBinding := p.keyMap.Cancel
 			if p.isCanceling {
-				cancelBinding = key.NewBinding(
+				cancelBinding = keymap.Bind("chat.cancel", key.NewBinding(
 					key.WithKeys("esc", "alt+esc"),
 					key.WithHelp("esc", "press again to cancel"),
-				)
+				))
 			}
-			if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.QueuedPrompts(p.session.ID) > 0 {
-				cancelBinding = key.NewBinding(
+			if p.app.AgentCoordinator() != nil && p.app.AgentCoordinator().QueuedPrompts(p.session.ID) > 0 {
+				cancelBinding = keymap.Bind("chat.cancel", key.NewBinding(
 					key.WithKeys("esc", "alt+esc"),
 					key.WithHelp("esc", "clear queue"),
-				)
+				))
 			}
 			shortList = append(shortList, cancelBinding)
 			fullList = append(fullList,
@@ -925,23 +1030,23 @@ func (p *chatPage) Help() help.KeyMap {
 		globalBindings := []key.Binding{}
 		// we are in a session
 		if p.session.ID != "" {
-			tabKey := key.NewBinding(
+			tabKey := keymap.Bind("chat.tab", key.NewBinding(
 				key.WithKeys("tab"),
 				key.WithHelp("tab", "focus chat"),
-			)
+			))
 			if p.focusedPane == PanelTypeChat {
-				tabKey = key.NewBinding(
+				tabKey = keymap.Bind("chat.tab", key.NewBinding(
 					key.WithKeys("tab"),
 					key.WithHelp("tab", "focus editor"),
-				)
+				))
 			}
 			shortList = append(shortList, tabKey)
 			globalBindings = append(globalBindings, tabKey)
 		}
-		commandsBinding := key.NewBinding(
+		commandsBinding := keymap.Bind("app.commands", key.NewBinding(
 			key.WithKeys("ctrl+p"),
 			key.WithHelp("ctrl+p", "commands"),
-		)
+		))
 		modelsBinding := key.NewBinding(
 			key.WithKeys("ctrl+m", "ctrl+l"),
 			key.WithHelp("ctrl+l", "models"),
@@ -950,23 +1055,24 @@ func (p *chatPage) Help() help.KeyMap {
 			// non-zero flags mean we have at least key disambiguation
 			modelsBinding.SetHelp("ctrl+m", "models")
 		}
-		helpBinding := key.NewBinding(
+		modelsBinding = keymap.Bind("app.models", modelsBinding)
+		helpBinding := keymap.Bind("app.help", key.NewBinding(
 			key.WithKeys("ctrl+g"),
 			key.WithHelp("ctrl+g", "more"),
-		)
+		))
 		globalBindings = append(globalBindings, commandsBinding, modelsBinding)
 		globalBindings = append(globalBindings,
-			key.NewBinding(
+			keymap.Bind("app.sessions", key.NewBinding(
 				key.WithKeys("ctrl+s"),
 				key.WithHelp("ctrl+s", "sessions"),
-			),
+			)),
 		)
 		if p.session.ID != "" {
 			globalBindings = append(globalBindings,
-				key.NewBinding(
+				keymap.Bind("chat.new_session", key.NewBinding(
 					key.WithKeys("ctrl+n"),
 					key.WithHelp("ctrl+n", "new sessions"),
-				))
+				)))
 		}
 		shortList = append(shortList,
 			// Commands
@@ -974,6 +1080,14 @@ func (p *chatPage) Help() help.KeyMap {
 			modelsBinding,
 		)
 		fullList = append(fullList, globalBindings)
+		if p.tabCount() > 1 {
+			fullList = append(fullList, []key.Binding{
+				p.keyMap.NextTab,
+				p.keyMap.PrevTab,
+				p.keyMap.GoToTab,
+				p.keyMap.CloseTab,
+			})
+		}
 
 		switch p.focusedPane {
 		case PanelTypeChat:
@@ -982,7 +1096,12 @@ func (p *chatPage) Help() help.KeyMap {
 					key.WithKeys("up", "down"),
 					key.WithHelp("↑↓", "scroll"),
 				),
-				messages.CopyKey,
+				key.NewBinding(
+					key.WithKeys("shift+up", "shift+down"),
+					key.WithHelp("shift+↑↓", "select message"),
+				),
+				p.messagesKeyMap.Copy,
+				p.messagesKeyMap.ToggleExpand,
 			)
 			fullList = append(fullList,
 				[]key.Binding{
@@ -994,36 +1113,42 @@ func (p *chatPage) Help() help.KeyMap {
 						key.WithKeys("shift+up", "shift+down"),
 						key.WithHelp("shift+↑↓", "next/prev item"),
 					),
-					key.NewBinding(
+					keymap.Bind("list.page_up", key.NewBinding(
 						key.WithKeys("pgup", "b"),
 						key.WithHelp("b/pgup", "page up"),
-					),
-					key.NewBinding(
+					)),
+					keymap.Bind("list.page_down", key.NewBinding(
 						key.WithKeys("pgdown", " ", "f"),
 						key.WithHelp("f/pgdn", "page down"),
-					),
+					)),
 				},
 				[]key.Binding{
-					key.NewBinding(
+					keymap.Bind("list.half_page_up", key.NewBinding(
 						key.WithKeys("u"),
 						key.WithHelp("u", "half page up"),
-					),
-					key.NewBinding(
+					)),
+					keymap.Bind("list.half_page_down", key.NewBinding(
 						key.WithKeys("d"),
 						key.WithHelp("d", "half page down"),
-					),
-					key.NewBinding(
+					)),
+					keymap.Bind("list.home", key.NewBinding(
 						key.WithKeys("g", "home"),
 						key.WithHelp("g", "home"),
-					),
-					key.NewBinding(
+					)),
+					keymap.Bind("list.end", key.NewBinding(
 						key.WithKeys("G", "end"),
 						key.WithHelp("G", "end"),
-					),
+					)),
 				},
 				[]key.Binding{
-					messages.CopyKey,
-					messages.ClearSelectionKey,
+					p.messagesKeyMap.Copy,
+					p.messagesKeyMap.CopyCode,
+					p.messagesKeyMap.ClearSelection,
+				},
+				[]key.Binding{
+					p.messagesKeyMap.EditPrompt,
+					p.messagesKeyMap.RerunPrompt,
+					p.messagesKeyMap.ToggleExpand,
 				},
 			)
 		case PanelTypeEditor:
@@ -1038,22 +1163,23 @@ func (p *chatPage) Help() help.KeyMap {
 				// Non-zero flags mean we have at least key disambiguation.
 				newLineBinding.SetHelp("shift+enter", newLineBinding.Help().Desc)
 			}
+			newLineBinding = keymap.Bind("editor.newline", newLineBinding)
 			shortList = append(shortList, newLineBinding)
 			fullList = append(fullList,
 				[]key.Binding{
 					newLineBinding,
-					key.NewBinding(
+					keymap.Bind("chat.add_attachment", key.NewBinding(
 						key.WithKeys("ctrl+f"),
 						key.WithHelp("ctrl+f", "add image"),
-					),
+					)),
 					key.NewBinding(
 						key.WithKeys("@"),
 						key.WithHelp("@", "mention file"),
 					),
-					key.NewBinding(
+					keymap.Bind("editor.open_editor", key.NewBinding(
 						key.WithKeys("ctrl+o"),
 						key.WithHelp("ctrl+o", "open editor"),
-					),
+					)),
 				})
 
 			if p.editor.HasAttachments() {
@@ -1075,18 +1201,18 @@ func (p *chatPage) Help() help.KeyMap {
 		}
 		shortList = append(shortList,
 			// Quit
-			key.NewBinding(
+			keymap.Bind("app.quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 			// Help
 			helpBinding,
 		)
 		fullList = append(fullList, []key.Binding{
-			key.NewBinding(
+			keymap.Bind("app.help", key.NewBinding(
 				key.WithKeys("ctrl+g"),
 				key.WithHelp("ctrl+g", "less"),
-			),
+			)),
 		})
 	}
 
@@ -1112,15 +1238,24 @@ func (p *chatPage) isMouseOverChat(x, y int) bool {
 		chatX = 0
 		chatY = HeaderHeight
 		chatWidth = p.width
-		chatHeight = p.height - EditorHeight - HeaderHeight
+		chatHeight = p.height - p.tabBarHeight() - EditorHeight - HeaderHeight
 	} else {
 		// In non-compact mode: chat area spans from left edge to sidebar
 		chatX = 0
 		chatY = 0
 		chatWidth = p.width - SideBarWidth
-		chatHeight = p.height - EditorHeight
+		chatHeight = p.height - p.tabBarHeight() - EditorHeight
 	}
 
 	// Check if mouse coordinates are within chat bounds
 	return x >= chatX && x < chatX+chatWidth && y >= chatY && y < chatY+chatHeight
 }
+
+// isMouseOverSidebar checks if the given mouse coordinates are within the
+// sidebar shown next to the chat.
+func (p *chatPage) isMouseOverSidebar(x, y int) bool {
+	if p.session.ID == "" || p.compact {
+		return false
+	}
+	return x >= p.width-SideBarWidth && y >= 0 && y < p.height-p.tabBarHeight()-EditorHeight
+}
@@ -2,6 +2,7 @@ package chat
 
 import (
 	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -10,10 +11,14 @@ type KeyMap struct {
 	Cancel        key.Binding
 	Tab           key.Binding
 	Details       key.Binding
+	NextTab       key.Binding
+	PrevTab       key.Binding
+	CloseTab      key.Binding
+	GoToTab       key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
-	return KeyMap{
+	return keymap.Apply("chat", KeyMap{
 		NewSession: key.NewBinding(
 			key.WithKeys("ctrl+n"),
 			key.WithHelp("ctrl+n", "new session"),
@@ -34,5 +39,21 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+d"),
 			key.WithHelp("ctrl+d", "toggle details"),
 		),
-	}
+		NextTab: key.NewBinding(
+			key.WithKeys("alt+."),
+			key.WithHelp("alt+.", "next session tab"),
+		),
+		PrevTab: key.NewBinding(
+			key.WithKeys("alt+,"),
+			key.WithHelp("alt+,", "previous session tab"),
+		),
+		CloseTab: key.NewBinding(
+			key.WithKeys("alt+w"),
+			key.WithHelp("alt+w", "close session tab"),
+		),
+		GoToTab: key.NewBinding(
+			key.WithKeys("alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9"),
+			key.WithHelp("alt+1-9", "go to session tab"),
+		),
+	})
 }
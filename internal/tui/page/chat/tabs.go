@@ -0,0 +1,260 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/chat"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// TabBarHeight is the height of the tab bar, shown when more than one
+	// session is open.
+	TabBarHeight = 1
+	// maxTabTitleWidth is the width session titles are truncated to in the
+	// tab bar.
+	maxTabTitleWidth = 24
+	// maxParentSessions bounds the walk from a task session up to the
+	// session it was started from.
+	maxParentSessions = 8
+)
+
+// SessionStatus is what a session is doing.
+type SessionStatus struct {
+	Busy               bool
+	QueuedPrompts      int
+	AwaitingPermission bool
+}
+
+// String describes the status in a few words, or returns an empty string
+// for an idle session.
+func (s SessionStatus) String() string {
+	var parts []string
+	switch {
+	case s.AwaitingPermission:
+		parts = append(parts, "needs permission")
+	case s.Busy:
+		parts = append(parts, "working")
+	}
+	if s.QueuedPrompts > 0 {
+		parts = append(parts, fmt.Sprintf("%d queued", s.QueuedPrompts))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// permissionSessionResolvedMsg tells which open session a permission request
+// made by a task session belongs to.
+type permissionSessionResolvedMsg struct {
+	requestID string
+	sessionID string
+}
+
+// SessionStatus returns what the session is doing. It is safe to call from
+// any goroutine.
+func (p *chatPage) SessionStatus(sessionID string) SessionStatus {
+	var status SessionStatus
+	if p.app.AgentCoordinator() != nil {
+		status.Busy = p.app.AgentCoordinator().IsSessionBusy(sessionID)
+		status.QueuedPrompts = p.app.AgentCoordinator().QueuedPrompts(sessionID)
+	}
+	for id := range p.awaitingPermission.Seq() {
+		if id == sessionID {
+			status.AwaitingPermission = true
+			break
+		}
+	}
+	return status
+}
+
+// permissionRequested marks the session of the request as awaiting
+// permission, and resolves the session it was started from when the request
+// comes from a task session.
+func (p *chatPage) permissionRequested(req permission.PermissionRequest) tea.Cmd {
+	p.awaitingPermission.Set(req.ID, req.SessionID)
+	return func() tea.Msg {
+		sessionID := req.SessionID
+		for range maxParentSessions {
+			s, err := p.app.Sessions.Get(context.Background(), sessionID)
+			if err != nil || s.ParentSessionID == "" {
+				break
+			}
+			sessionID = s.ParentSessionID
+		}
+		if sessionID == req.SessionID {
+			return nil
+		}
+		return permissionSessionResolvedMsg{requestID: req.ID, sessionID: sessionID}
+	}
+}
+
+// openTab adds the session to the tabs, unless it is already open.
+func (p *chatPage) openTab(s session.Session) {
+	if p.tabIndex(s.ID) >= 0 {
+		return
+	}
+	p.tabs = append(p.tabs, s)
+}
+
+// updateTab refreshes the title of an open session, or closes it when it was
+// deleted.
+func (p *chatPage) updateTab(s session.Session, deleted bool) tea.Cmd {
+	i := p.tabIndex(s.ID)
+	if i < 0 {
+		return nil
+	}
+	if !deleted {
+		p.tabs[i] = s
+		return nil
+	}
+	p.tabs = slices.Delete(p.tabs, i, i+1)
+	if s.ID != p.session.ID {
+		return p.SetSize(p.width, p.height)
+	}
+	if len(p.tabs) == 0 {
+		return p.newSession()
+	}
+	return util.CmdHandler(chat.SessionSelectedMsg(p.tabs[min(i, len(p.tabs)-1)]))
+}
+
+// tabIndex returns the position of the session in the tabs, or -1.
+func (p *chatPage) tabIndex(sessionID string) int {
+	return slices.IndexFunc(p.tabs, func(s session.Session) bool {
+		return s.ID == sessionID
+	})
+}
+
+// activeTab returns the position of the shown session in the tabs, which is
+// len(tabs) for a new session.
+func (p *chatPage) activeTab() int {
+	if i := p.tabIndex(p.session.ID); i >= 0 {
+		return i
+	}
+	return len(p.tabs)
+}
+
+// tabCount returns the number of tabs, counting a new session.
+func (p *chatPage) tabCount() int {
+	if p.session.ID == "" {
+		return len(p.tabs) + 1
+	}
+	return len(p.tabs)
+}
+
+// tabBarHeight returns the height taken by the tab bar.
+func (p *chatPage) tabBarHeight() int {
+	if p.tabCount() > 1 {
+		return TabBarHeight
+	}
+	return 0
+}
+
+// switchTab shows the tab at the given position, wrapping around.
+func (p *chatPage) switchTab(i int) tea.Cmd {
+	n := p.tabCount()
+	if n < 2 {
+		return nil
+	}
+	return p.goToTab(((i % n) + n) % n)
+}
+
+// goToTab shows the tab at the given position, the last one being a new
+// session when one is shown.
+func (p *chatPage) goToTab(i int) tea.Cmd {
+	if i < 0 || i >= p.tabCount() || i == p.activeTab() {
+		return nil
+	}
+	if i == len(p.tabs) {
+		return p.newSession()
+	}
+	return util.CmdHandler(chat.SessionSelectedMsg(p.tabs[i]))
+}
+
+// closeTab closes the shown session and shows its neighbour. The agent keeps
+// working on it, and it can be opened again from the sessions dialog.
+func (p *chatPage) closeTab() tea.Cmd {
+	if p.tabCount() < 2 {
+		return nil
+	}
+	i := p.activeTab()
+	if i == len(p.tabs) {
+		// Closing a new session shows the last open one.
+		return util.CmdHandler(chat.SessionSelectedMsg(p.tabs[i-1]))
+	}
+	p.tabs = slices.Delete(p.tabs, i, i+1)
+	if len(p.tabs) == 0 {
+		return p.newSession()
+	}
+	return util.CmdHandler(chat.SessionSelectedMsg(p.tabs[min(i, len(p.tabs)-1)]))
+}
+
+// renderTabs renders the tab bar: the number, status and title of each open
+// session, the shown one highlighted.
+func (p *chatPage) renderTabs() string {
+	t := styles.CurrentTheme()
+	bar := strings.Join(p.tabLabels(), t.S().Subtle.Render(styles.BorderThin))
+	return lipgloss.NewStyle().MaxWidth(p.width).Render(bar)
+}
+
+// tabAt returns the position of the tab at the given column of the tab bar,
+// or -1.
+func (p *chatPage) tabAt(x int) int {
+	for i, label := range p.tabLabels() {
+		w := lipgloss.Width(label)
+		if x < w {
+			return i
+		}
+		// Skip the tab and the separator after it.
+		x -= w + lipgloss.Width(styles.BorderThin)
+		if x < 0 {
+			return -1
+		}
+	}
+	return -1
+}
+
+// tabLabels renders each tab of the tab bar.
+func (p *chatPage) tabLabels() []string {
+	t := styles.CurrentTheme()
+	active := p.activeTab()
+
+	labels := make([]string, 0, p.tabCount())
+	for i := range p.tabCount() {
+		title := "New Session"
+		var status SessionStatus
+		if i < len(p.tabs) {
+			title = p.tabs[i].Title
+			status = p.SessionStatus(p.tabs[i].ID)
+		}
+
+		style := t.S().Muted
+		if i == active {
+			style = t.S().TextSelected
+		}
+		var icon string
+		switch {
+		case status.AwaitingPermission:
+			icon = t.S().Warning.Inherit(style).Render(styles.WarningIcon) + style.Render(" ")
+		case status.Busy:
+			icon = t.ItemBusyIcon.Inherit(style).String() + style.Render(" ")
+		}
+		label := title
+		if i < 9 {
+			label = fmt.Sprintf("%d %s", i+1, title)
+		}
+		label = ansi.Truncate(label, maxTabTitleWidth, "…")
+		if status.QueuedPrompts > 0 {
+			label += fmt.Sprintf(" +%d", status.QueuedPrompts)
+		}
+		labels = append(labels, style.Render(" ")+icon+style.Render(label+" "))
+	}
+	return labels
+}
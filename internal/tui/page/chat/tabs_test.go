@@ -0,0 +1,55 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/chat"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStatusString(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", SessionStatus{}.String())
+	require.Equal(t, "working", SessionStatus{Busy: true}.String())
+	require.Equal(t, "needs permission, 2 queued", SessionStatus{
+		Busy:               true,
+		QueuedPrompts:      2,
+		AwaitingPermission: true,
+	}.String())
+}
+
+func TestTabs(t *testing.T) {
+	t.Parallel()
+
+	a := session.Session{ID: "a", Title: "A"}
+	b := session.Session{ID: "b", Title: "B"}
+	p := &chatPage{}
+
+	p.openTab(a)
+	p.session = a
+	require.Equal(t, 1, p.tabCount())
+	require.Equal(t, 0, p.tabBarHeight())
+
+	// A new session counts as a tab until its first prompt is sent.
+	p.session = session.Session{}
+	require.Equal(t, 2, p.tabCount())
+	require.Equal(t, 1, p.activeTab())
+	require.Equal(t, TabBarHeight, p.tabBarHeight())
+
+	p.openTab(b)
+	p.openTab(b)
+	p.session = b
+	require.Equal(t, []session.Session{a, b}, p.tabs)
+	require.Equal(t, 1, p.activeTab())
+
+	// Switching wraps around.
+	msg := p.switchTab(p.activeTab() + 1)()
+	require.Equal(t, chat.SessionSelectedMsg(a), msg)
+
+	// Closing a tab shows its neighbour.
+	msg = p.closeTab()()
+	require.Equal(t, chat.SessionSelectedMsg(a), msg)
+	require.Equal(t, []session.Session{a}, p.tabs)
+}
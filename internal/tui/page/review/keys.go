@@ -0,0 +1,98 @@
+package review
+
+import (
+	"charm.land/bubbles/v2/key"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
+)
+
+type KeyMap struct {
+	Up,
+	Down,
+	ToggleDiffMode,
+	ScrollDown,
+	ScrollUp,
+	ScrollLeft,
+	ScrollRight,
+	NextHunk,
+	PrevHunk,
+	FoldHunk,
+	Revert,
+	Export,
+	Back key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return keymap.Apply("review", KeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑", "previous file"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓", "next file"),
+		),
+		ToggleDiffMode: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "toggle diff mode"),
+		),
+		ScrollDown: key.NewBinding(
+			key.WithKeys("shift+down", "J"),
+			key.WithHelp("shift+↓", "scroll down"),
+		),
+		ScrollUp: key.NewBinding(
+			key.WithKeys("shift+up", "K"),
+			key.WithHelp("shift+↑", "scroll up"),
+		),
+		ScrollLeft: key.NewBinding(
+			key.WithKeys("shift+left", "H"),
+			key.WithHelp("shift+←", "scroll left"),
+		),
+		ScrollRight: key.NewBinding(
+			key.WithKeys("shift+right", "L"),
+			key.WithHelp("shift+→", "scroll right"),
+		),
+		NextHunk: key.NewBinding(
+			key.WithKeys("n", "]"),
+			key.WithHelp("n", "next hunk"),
+		),
+		PrevHunk: key.NewBinding(
+			key.WithKeys("p", "["),
+			key.WithHelp("p", "previous hunk"),
+		),
+		FoldHunk: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "fold/unfold hunk"),
+		),
+		Revert: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "revert file"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "export patch"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc", "q"),
+			key.WithHelp("esc", "back to chat"),
+		),
+	})
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Up,
+		k.Down,
+		k.ToggleDiffMode,
+		k.ScrollDown,
+		k.ScrollUp,
+		k.ScrollLeft,
+		k.ScrollRight,
+		k.NextHunk,
+		k.PrevHunk,
+		k.FoldHunk,
+		k.Revert,
+		k.Export,
+		k.Back,
+	}
+}
@@ -0,0 +1,510 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/tui/components/chat"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/exp/diffview"
+	"github.com/charmbracelet/crush/internal/tui/keymap"
+	"github.com/charmbracelet/crush/internal/tui/page"
+	chatPage "github.com/charmbracelet/crush/internal/tui/page/chat"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var ReviewPageID page.PageID = "review"
+
+const (
+	FileListMaxWidth     = 40 // Maximum width of the file list
+	RevertTimerDuration  = 2 * time.Second
+	SplitModeBreakpoint  = 140 // Diff width from which the split mode is the default
+	horizontalScrollStep = 5
+)
+
+type (
+	filesLoadedMsg struct {
+		sessionID string
+		files     []changedFile
+	}
+	RevertTimerExpiredMsg struct{}
+)
+
+// changedFile is a file changed in the session, from its first recorded
+// version to its latest one.
+type changedFile struct {
+	path      string // absolute path
+	relPath   string // path relative to the working directory
+	original  string // content of the first version, as recorded
+	created   bool   // the file was created in the session
+	before    string
+	after     string
+	additions int
+	deletions int
+}
+
+type ReviewPage interface {
+	util.Model
+	core.KeyMapHelp
+}
+
+// reviewPage is a full-screen page listing the files changed in a session
+// with their cumulative diff.
+type reviewPage struct {
+	width, height int
+	app           *app.App
+	keyMap        KeyMap
+
+	sessionID string
+	files     []changedFile
+	selected  int
+
+	diffSplitMode *bool // nil means split when the diff is wide enough
+	diffXOffset   int
+	diffYOffset   int
+	selectedHunk  int   // -1 means no hunk is selected
+	foldedHunks   []int // hunks shown folded, nil until the files are loaded
+
+	isReverting bool // the revert key was pressed once and waits for confirmation
+}
+
+func New(app *app.App) ReviewPage {
+	p := &reviewPage{
+		app:          app,
+		keyMap:       DefaultKeyMap(),
+		selectedHunk: -1,
+	}
+	if tui := config.Get().Options.TUI; tui != nil {
+		switch tui.DiffMode {
+		case "split":
+			split := true
+			p.diffSplitMode = &split
+		case "unified":
+			split := false
+			p.diffSplitMode = &split
+		}
+	}
+	return p
+}
+
+func (p *reviewPage) Init() tea.Cmd {
+	return nil
+}
+
+func (p *reviewPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		return p, p.SetSize(msg.Width, msg.Height)
+	case commands.ReviewChangesMsg:
+		p.sessionID = msg.SessionID
+		p.files = nil
+		p.selectFile(0)
+		return p, p.loadFiles
+	case filesLoadedMsg:
+		if msg.sessionID != p.sessionID {
+			return p, nil
+		}
+		p.files = msg.files
+		if p.selected >= len(p.files) {
+			p.selectFile(len(p.files) - 1)
+		} else if p.foldedHunks == nil {
+			p.foldedHunks = p.largeHunks()
+		}
+		return p, nil
+	case pubsub.Event[history.File]:
+		if msg.Payload.SessionID != p.sessionID {
+			return p, nil
+		}
+		return p, p.loadFiles
+	case RevertTimerExpiredMsg:
+		p.isReverting = false
+		return p, nil
+	case chat.SessionSelectedMsg, chat.SessionClearedMsg, chat.SendMsg, commands.NewSessionsMsg:
+		// These are for the chat page, go back to it and resend them.
+		return p, tea.Sequence(p.back(), util.CmdHandler(msg))
+	case tea.MouseWheelMsg:
+		switch msg.Button {
+		case tea.MouseWheelDown:
+			p.diffYOffset++
+		case tea.MouseWheelUp:
+			p.diffYOffset = max(0, p.diffYOffset-1)
+		case tea.MouseWheelLeft:
+			p.diffXOffset = max(0, p.diffXOffset-horizontalScrollStep)
+		case tea.MouseWheelRight:
+			p.diffXOffset += horizontalScrollStep
+		}
+		return p, nil
+	case tea.KeyPressMsg:
+		return p, p.handleKeyPressMsg(msg)
+	}
+	return p, nil
+}
+
+func (p *reviewPage) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
+	if !key.Matches(msg, p.keyMap.Revert) {
+		p.isReverting = false
+	}
+	switch {
+	case key.Matches(msg, p.keyMap.Back):
+		return p.back()
+	case key.Matches(msg, p.keyMap.Up):
+		p.selectFile(p.selected - 1)
+	case key.Matches(msg, p.keyMap.Down):
+		p.selectFile(p.selected + 1)
+	case key.Matches(msg, p.keyMap.ToggleDiffMode):
+		split := !p.useDiffSplitMode()
+		p.diffSplitMode = &split
+	case key.Matches(msg, p.keyMap.ScrollDown):
+		p.diffYOffset++
+	case key.Matches(msg, p.keyMap.ScrollUp):
+		p.diffYOffset = max(0, p.diffYOffset-1)
+	case key.Matches(msg, p.keyMap.ScrollLeft):
+		p.diffXOffset = max(0, p.diffXOffset-horizontalScrollStep)
+	case key.Matches(msg, p.keyMap.ScrollRight):
+		p.diffXOffset += horizontalScrollStep
+	case key.Matches(msg, p.keyMap.NextHunk):
+		p.selectHunk(p.selectedHunk + 1)
+	case key.Matches(msg, p.keyMap.PrevHunk):
+		p.selectHunk(p.selectedHunk - 1)
+	case key.Matches(msg, p.keyMap.FoldHunk):
+		p.foldHunk()
+	case key.Matches(msg, p.keyMap.Revert):
+		if len(p.files) == 0 {
+			return nil
+		}
+		if !p.isReverting {
+			p.isReverting = true
+			return tea.Tick(RevertTimerDuration, func(time.Time) tea.Msg {
+				return RevertTimerExpiredMsg{}
+			})
+		}
+		p.isReverting = false
+		return p.revert(p.files[p.selected])
+	case key.Matches(msg, p.keyMap.Export):
+		return p.export()
+	}
+	return nil
+}
+
+func (p *reviewPage) back() tea.Cmd {
+	return util.CmdHandler(page.PageChangeMsg{ID: chatPage.ChatPageID})
+}
+
+func (p *reviewPage) selectFile(i int) {
+	p.selected = max(0, min(i, len(p.files)-1))
+	p.diffXOffset = 0
+	p.diffYOffset = 0
+	p.selectedHunk = -1
+	p.foldedHunks = p.largeHunks()
+}
+
+// largeHunks returns the hunks of the selected file that are folded by
+// default, or nil if there is no file.
+func (p *reviewPage) largeHunks() []int {
+	if len(p.files) == 0 {
+		return nil
+	}
+	file := p.files[p.selected]
+	formatter := core.DiffFormatter().
+		Before(file.relPath, file.before).
+		After(file.relPath, file.after)
+	return append([]int{}, formatter.LargeHunks(core.LargeHunkLines)...)
+}
+
+// selectHunk selects the hunk of the selected file with the given index,
+// wrapping around, and scrolls to it.
+func (p *reviewPage) selectHunk(i int) {
+	if len(p.files) == 0 {
+		return
+	}
+	formatter := p.diffFormatter()
+	count := formatter.HunkCount()
+	if count == 0 {
+		return
+	}
+	p.selectedHunk = (i + count) % count
+	p.diffYOffset = formatter.HunkLine(p.selectedHunk)
+}
+
+// foldHunk folds the selected hunk, or unfolds it if it was folded. With no
+// hunk selected it selects the first one.
+func (p *reviewPage) foldHunk() {
+	if p.selectedHunk < 0 {
+		p.selectHunk(0)
+		return
+	}
+	if i := slices.Index(p.foldedHunks, p.selectedHunk); i >= 0 {
+		p.foldedHunks = slices.Delete(p.foldedHunks, i, i+1)
+	} else {
+		p.foldedHunks = append(p.foldedHunks, p.selectedHunk)
+	}
+}
+
+// loadFiles loads the files changed in the session from the file history.
+func (p *reviewPage) loadFiles() tea.Msg {
+	sessionID := p.sessionID
+	versions, err := p.app.History.ListBySession(context.Background(), sessionID)
+	if err != nil {
+		return util.InfoMsg{
+			Type: util.InfoTypeError,
+			Msg:  err.Error(),
+		}
+	}
+
+	// Versions are ordered from the oldest to the latest.
+	initial := make(map[string]history.File)
+	latest := make(map[string]history.File)
+	for _, version := range versions {
+		if _, ok := initial[version.Path]; !ok {
+			initial[version.Path] = version
+		}
+		latest[version.Path] = version
+	}
+
+	cwd := config.Get().WorkingDir()
+	var files []changedFile
+	for path, first := range initial {
+		relPath := path
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			relPath = rel
+		}
+		before, _ := fsext.ToUnixLineEndings(first.Content)
+		after, _ := fsext.ToUnixLineEndings(latest[path].Content)
+		_, additions, deletions := diff.GenerateDiff(before, after, relPath)
+		if additions == 0 && deletions == 0 {
+			continue
+		}
+		files = append(files, changedFile{
+			path:      path,
+			relPath:   relPath,
+			original:  first.Content,
+			created:   first.IsNew,
+			before:    before,
+			after:     after,
+			additions: additions,
+			deletions: deletions,
+		})
+	}
+	slices.SortFunc(files, func(a, b changedFile) int {
+		return strings.Compare(a.relPath, b.relPath)
+	})
+
+	return filesLoadedMsg{
+		sessionID: sessionID,
+		files:     files,
+	}
+}
+
+// revert restores the file to its first recorded version and records that as
+// its latest version. Files created in the session are removed instead.
+func (p *reviewPage) revert(file changedFile) tea.Cmd {
+	sessionID := p.sessionID
+	return func() tea.Msg {
+		var err error
+		if file.created {
+			err = os.Remove(file.path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.WriteFile(file.path, []byte(file.original), 0o644)
+		}
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: fmt.Sprintf("failed to revert %s: %v", file.relPath, err)}
+		}
+		if _, err := p.app.History.CreateVersion(context.Background(), sessionID, file.path, file.original); err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		return util.InfoMsg{Type: util.InfoTypeInfo, Msg: fmt.Sprintf("Reverted %s", file.relPath)}
+	}
+}
+
+// export writes the combined changes of the session as a unified diff to a
+// patch file in the working directory.
+func (p *reviewPage) export() tea.Cmd {
+	if len(p.files) == 0 {
+		return util.ReportWarn("No changes to export")
+	}
+	var patch strings.Builder
+	for _, file := range p.files {
+		if file.created {
+			// Created files have no old version for git to patch.
+			patch.WriteString(udiff.Unified("/dev/null", "b/"+filepath.ToSlash(file.relPath), "", file.after))
+			continue
+		}
+		fileDiff, _, _ := diff.GenerateDiff(file.before, file.after, filepath.ToSlash(file.relPath))
+		patch.WriteString(fileDiff)
+	}
+	name := "crush-" + p.sessionID
+	if len(name) > len("crush-")+8 {
+		name = name[:len("crush-")+8]
+	}
+	path := filepath.Join(config.Get().WorkingDir(), name+".patch")
+	if err := os.WriteFile(path, []byte(patch.String()), 0o644); err != nil {
+		return util.ReportError(fmt.Errorf("failed to export patch: %w", err))
+	}
+	return util.ReportInfo(fmt.Sprintf("Exported patch to %s", fsext.PrettyPath(path)))
+}
+
+func (p *reviewPage) useDiffSplitMode() bool {
+	if p.diffSplitMode != nil {
+		return *p.diffSplitMode
+	}
+	return p.diffWidth() >= SplitModeBreakpoint
+}
+
+func (p *reviewPage) fileListWidth() int {
+	return min(FileListMaxWidth, p.width/4)
+}
+
+func (p *reviewPage) diffWidth() int {
+	return max(0, p.width-p.fileListWidth()-3) // 3 for the padding around the panes
+}
+
+func (p *reviewPage) View() string {
+	t := styles.CurrentTheme()
+	title := core.Title("Review Changes", p.width-2)
+	bodyHeight := max(0, p.height-2) // 2 for the title and the blank line
+
+	if len(p.files) == 0 {
+		empty := t.S().Muted.Render("No files were changed in this session.")
+		return t.S().Base.Padding(0, 1).Width(p.width).Height(p.height).Render(
+			lipgloss.JoinVertical(lipgloss.Left, title, "", empty),
+		)
+	}
+
+	fileList := t.S().Base.
+		Width(p.fileListWidth()).
+		Height(bodyHeight).
+		MaxHeight(bodyHeight).
+		Render(p.renderFileList(bodyHeight))
+	diffView := t.S().Base.
+		PaddingLeft(1).
+		Render(p.renderDiff(bodyHeight))
+	body := lipgloss.JoinHorizontal(lipgloss.Top, fileList, diffView)
+
+	return t.S().Base.Padding(0, 1).Width(p.width).Height(p.height).Render(
+		lipgloss.JoinVertical(lipgloss.Left, title, "", body),
+	)
+}
+
+func (p *reviewPage) renderFileList(height int) string {
+	t := styles.CurrentTheme()
+	width := p.fileListWidth()
+	lines := []string{
+		core.Section(fmt.Sprintf("Files (%d)", len(p.files)), width),
+		"",
+	}
+
+	// Keep the selected file visible.
+	visible := max(1, height-len(lines))
+	start := max(0, p.selected-visible+1)
+	for i := start; i < len(p.files) && i < start+visible; i++ {
+		file := p.files[i]
+		extra := t.S().Base.Foreground(t.Success).Render(fmt.Sprintf("+%d", file.additions)) + " " +
+			t.S().Base.Foreground(t.Error).Render(fmt.Sprintf("-%d", file.deletions))
+		name := ansi.Truncate(file.relPath, width-lipgloss.Width(extra)-4, "…")
+		titleColor := t.FgMuted
+		icon := " "
+		if i == p.selected {
+			titleColor = t.FgBase
+			icon = t.S().Base.Foreground(t.Primary).Render("▸")
+		}
+		lines = append(lines, core.Status(core.StatusOpts{
+			Icon:         icon,
+			Title:        name,
+			TitleColor:   titleColor,
+			ExtraContent: extra,
+		}, width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diffFormatter returns a diff view of the selected file in the current diff
+// mode, with the selected and folded hunks.
+func (p *reviewPage) diffFormatter() *diffview.DiffView {
+	file := p.files[p.selected]
+	formatter := core.DiffFormatter().
+		Before(file.relPath, file.before).
+		After(file.relPath, file.after).
+		SelectHunk(p.selectedHunk).
+		FoldHunks(p.foldedHunks...)
+	if p.useDiffSplitMode() {
+		return formatter.Split()
+	}
+	return formatter.Unified()
+}
+
+func (p *reviewPage) renderDiff(height int) string {
+	return p.diffFormatter().
+		Width(p.diffWidth()).
+		Height(height).
+		XOffset(p.diffXOffset).
+		YOffset(p.diffYOffset).
+		String()
+}
+
+func (p *reviewPage) SetSize(width, height int) tea.Cmd {
+	p.width = width
+	p.height = height
+	return nil
+}
+
+func (p *reviewPage) GetSize() (int, int) {
+	return p.width, p.height
+}
+
+func (p *reviewPage) Help() help.KeyMap {
+	revert := p.keyMap.Revert
+	if p.isReverting {
+		revert = keymap.Bind("review.revert", key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "press again to revert"),
+		))
+	}
+	shortList := []key.Binding{
+		key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "choose file"),
+		),
+		p.keyMap.ToggleDiffMode,
+		key.NewBinding(
+			key.WithKeys("shift+left", "shift+down", "shift+up", "shift+right"),
+			key.WithHelp("shift+←↓↑→", "scroll"),
+		),
+		key.NewBinding(
+			key.WithKeys("n", "p"),
+			key.WithHelp("n/p", "hunk"),
+		),
+		p.keyMap.FoldHunk,
+		revert,
+		p.keyMap.Export,
+		p.keyMap.Back,
+	}
+	fullList := [][]key.Binding{
+		{p.keyMap.Up, p.keyMap.Down, p.keyMap.ToggleDiffMode},
+		{p.keyMap.ScrollUp, p.keyMap.ScrollDown, p.keyMap.ScrollLeft, p.keyMap.ScrollRight},
+		{p.keyMap.NextHunk, p.keyMap.PrevHunk, p.keyMap.FoldHunk},
+		{revert, p.keyMap.Export, p.keyMap.Back},
+	}
+	return core.NewSimpleHelp(shortList, fullList)
+}
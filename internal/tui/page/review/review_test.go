@@ -0,0 +1,224 @@
+package review
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/stretchr/testify/require"
+)
+
+type reviewEnv struct {
+	page      *reviewPage
+	history   history.Service
+	sessionID string
+	dir       string
+}
+
+func newReviewEnv(t *testing.T) reviewEnv {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, ".config"))
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	_, err := config.Init(dir, filepath.Join(dir, ".data"), "", false)
+	require.NoError(t, err)
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sess, err := session.NewService(q).Create(t.Context(), "review")
+	require.NoError(t, err)
+	hist := history.NewService(q, conn)
+
+	p := New(&app.App{History: hist}).(*reviewPage)
+	p.SetSize(120, 40)
+	return reviewEnv{page: p, history: hist, sessionID: sess.ID, dir: dir}
+}
+
+// load opens the review of the session and waits for its files.
+func (e reviewEnv) load(t *testing.T) {
+	_, cmd := e.page.Update(commands.ReviewChangesMsg{SessionID: e.sessionID})
+	require.NotNil(t, cmd)
+	_, _ = e.page.Update(cmd())
+}
+
+// write writes a file to disk and records its versions in the history. A
+// nil initial version records the file as created in the session.
+func (e reviewEnv) write(t *testing.T, name string, initial *string, latest string) string {
+	path := filepath.Join(e.dir, name)
+	if initial == nil {
+		_, err := e.history.CreateNew(t.Context(), e.sessionID, path)
+		require.NoError(t, err)
+	} else {
+		_, err := e.history.Create(t.Context(), e.sessionID, path, *initial)
+		require.NoError(t, err)
+	}
+	_, err := e.history.CreateVersion(t.Context(), e.sessionID, path, latest)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(latest), 0o644))
+	return path
+}
+
+func ptr(s string) *string { return &s }
+
+func pressKey(p *reviewPage, k string) tea.Cmd {
+	_, cmd := p.Update(tea.KeyPressMsg{Code: rune(k[0]), Text: k})
+	return cmd
+}
+
+func TestReviewLoadFiles(t *testing.T) {
+	env := newReviewEnv(t)
+	env.write(t, "b.go", ptr("package b\n"), "package b\n\nfunc B() {}\n")
+	env.write(t, "a.go", nil, "package a\n")
+	env.write(t, "same.go", ptr("package same\n"), "package same\n")
+	env.load(t)
+
+	files := env.page.files
+	require.Len(t, files, 2, "unchanged files shouldn't be listed")
+	require.Equal(t, "a.go", files[0].relPath)
+	require.True(t, files[0].created)
+	require.Equal(t, 1, files[0].additions)
+	require.Equal(t, "b.go", files[1].relPath)
+	require.False(t, files[1].created)
+	require.Equal(t, 2, files[1].additions)
+	require.Zero(t, files[1].deletions)
+
+	env.page.Update(filesLoadedMsg{sessionID: "other"})
+	require.Len(t, env.page.files, 2, "files of other sessions should be ignored")
+}
+
+func TestReviewRevert(t *testing.T) {
+	env := newReviewEnv(t)
+	created := env.write(t, "created.txt", nil, "new file\n")
+	empty := env.write(t, "empty.txt", ptr(""), "not empty anymore\n")
+	edited := env.write(t, "edited.txt", ptr("before\n"), "after\n")
+	env.load(t)
+	require.Len(t, env.page.files, 3)
+
+	revert := func(i int) {
+		t.Helper()
+		env.page.selectFile(i)
+		require.NotNil(t, pressKey(env.page, "r"), "first press should wait for confirmation")
+		require.True(t, env.page.isReverting)
+		cmd := pressKey(env.page, "r")
+		require.NotNil(t, cmd)
+		msg := cmd()
+		require.Equal(t, util.InfoTypeInfo, msg.(util.InfoMsg).Type, msg)
+	}
+
+	// Files are sorted: created.txt, edited.txt, empty.txt.
+	revert(0)
+	require.NoFileExists(t, created)
+
+	revert(1)
+	content, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "before\n", string(content))
+
+	revert(2)
+	require.FileExists(t, empty, "a file that existed empty must not be removed")
+	content, err = os.ReadFile(empty)
+	require.NoError(t, err)
+	require.Empty(t, content)
+
+	latest, err := env.history.GetByPathAndSession(t.Context(), edited, env.sessionID)
+	require.NoError(t, err)
+	require.Equal(t, "before\n", latest.Content, "reverting should be recorded in the history")
+}
+
+func TestReviewRevertNeedsConfirmation(t *testing.T) {
+	env := newReviewEnv(t)
+	edited := env.write(t, "edited.txt", ptr("before\n"), "after\n")
+	env.load(t)
+
+	pressKey(env.page, "r")
+	env.page.Update(RevertTimerExpiredMsg{})
+	require.False(t, env.page.isReverting)
+	pressKey(env.page, "r")
+	pressKey(env.page, "j")
+	require.False(t, env.page.isReverting, "other keys should cancel the revert")
+
+	content, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "after\n", string(content))
+}
+
+func TestReviewFoldedHunks(t *testing.T) {
+	env := newReviewEnv(t)
+	large := strings.Repeat("line\n", 300)
+	env.write(t, "large.txt", ptr(""), large)
+	env.write(t, "small.txt", ptr("a\n"), "b\n")
+	env.load(t)
+
+	require.Equal(t, []int{0}, env.page.foldedHunks, "large hunks should start folded")
+	_ = env.page.View()
+	require.Equal(t, []int{0}, env.page.foldedHunks, "rendering mustn't change the folds")
+
+	pressKey(env.page, "f")
+	require.Equal(t, 0, env.page.selectedHunk, "folding without a selected hunk selects the first one")
+	pressKey(env.page, "f")
+	require.Empty(t, env.page.foldedHunks)
+	pressKey(env.page, "f")
+	require.Equal(t, []int{0}, env.page.foldedHunks)
+
+	env.page.selectFile(1)
+	require.Empty(t, env.page.foldedHunks)
+	require.NotNil(t, env.page.foldedHunks)
+}
+
+func TestReviewExport(t *testing.T) {
+	env := newReviewEnv(t)
+	env.write(t, "edited.txt", ptr("before\n"), "after\n")
+	env.load(t)
+
+	msg := pressKey(env.page, "e")()
+	require.Equal(t, util.InfoTypeInfo, msg.(util.InfoMsg).Type, msg)
+
+	patch, err := os.ReadFile(filepath.Join(env.dir, "crush-"+env.sessionID[:8]+".patch"))
+	require.NoError(t, err)
+	require.Contains(t, string(patch), "-before\n+after\n")
+}
+
+func TestReviewExportApplies(t *testing.T) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not available")
+	}
+	env := newReviewEnv(t)
+	env.write(t, "edited.txt", ptr("before\n"), "after\n")
+	env.write(t, "sub/created.txt", nil, "new file\n")
+	env.load(t)
+	msg := pressKey(env.page, "e")()
+	require.Equal(t, util.InfoTypeInfo, msg.(util.InfoMsg).Type, msg)
+	patch := filepath.Join(env.dir, "crush-"+env.sessionID[:8]+".patch")
+	data, err := os.ReadFile(patch)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "--- /dev/null\n+++ b/sub/created.txt\n", "created files should have no old version")
+
+	// The patch applies to the files as they were before the session.
+	original := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(original, "edited.txt"), []byte("before\n"), 0o644))
+	for _, args := range [][]string{{"apply", "--check", patch}, {"apply", patch}} {
+		cmd := exec.CommandContext(t.Context(), git, args...)
+		cmd.Dir = original
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	for name, content := range map[string]string{"edited.txt": "after\n", "sub/created.txt": "new file\n"} {
+		data, err := os.ReadFile(filepath.Join(original, name))
+		require.NoError(t, err)
+		require.Equal(t, content, string(data))
+	}
+}
@@ -0,0 +1,41 @@
+package styles
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"charm.land/lipgloss/v2"
+)
+
+// screenReader is whether the TUI renders plain, linear output for screen
+// readers.
+var screenReader atomic.Bool
+
+// SetScreenReader sets whether the TUI renders for screen readers: without
+// animations, gradients, box-drawing or decorative lines.
+func SetScreenReader(enabled bool) {
+	screenReader.Store(enabled)
+}
+
+// ScreenReader returns whether the TUI renders for screen readers.
+func ScreenReader() bool {
+	return screenReader.Load()
+}
+
+// Border returns the given border, or a blank one of the same size when
+// rendering for screen readers.
+func Border(b lipgloss.Border) lipgloss.Border {
+	if ScreenReader() {
+		return lipgloss.HiddenBorder()
+	}
+	return b
+}
+
+// Rule repeats a decorative character to fill the given width. It returns
+// an empty string when rendering for screen readers.
+func Rule(char string, width int) string {
+	if ScreenReader() || width <= 0 {
+		return ""
+	}
+	return strings.Repeat(char, width)
+}
@@ -0,0 +1,21 @@
+package styles
+
+import (
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScreenReader(t *testing.T) {
+	t.Cleanup(func() { SetScreenReader(false) })
+
+	require.Equal(t, "───", Rule("─", 3))
+	require.Empty(t, Rule("─", 0))
+	require.Equal(t, lipgloss.RoundedBorder(), Border(lipgloss.RoundedBorder()))
+
+	SetScreenReader(true)
+	require.True(t, ScreenReader())
+	require.Empty(t, Rule("─", 3))
+	require.Equal(t, lipgloss.HiddenBorder(), Border(lipgloss.RoundedBorder()))
+}
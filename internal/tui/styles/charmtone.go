@@ -56,21 +56,57 @@ func NewCharmtoneTheme() *Theme {
 		RedDark:  charmtone.Sriracha,
 		RedLight: charmtone.Salmon,
 		Cherry:   charmtone.Cherry,
-	}
-
-	// Text selection.
-	t.TextSelection = lipgloss.NewStyle().Foreground(charmtone.Salt).Background(charmtone.Charple)
 
-	// LSP and MCP status.
-	t.ItemOfflineIcon = lipgloss.NewStyle().Foreground(charmtone.Squid).SetString("●")
-	t.ItemBusyIcon = t.ItemOfflineIcon.Foreground(charmtone.Citron)
-	t.ItemErrorIcon = t.ItemOfflineIcon.Foreground(charmtone.Coral)
-	t.ItemOnlineIcon = t.ItemOfflineIcon.Foreground(charmtone.Guac)
+		// Diffs
+		DiffInsert:         lipgloss.Color("#629657"),
+		DiffInsertBg:       lipgloss.Color("#323931"),
+		DiffInsertNumberBg: lipgloss.Color("#2b322a"),
+		DiffDelete:         lipgloss.Color("#a45c59"),
+		DiffDeleteBg:       lipgloss.Color("#383030"),
+		DiffDeleteNumberBg: lipgloss.Color("#312929"),
+		DiffInsertWordBg:   lipgloss.Color("#3f5a3b"),
+		DiffDeleteWordBg:   lipgloss.Color("#5a3a38"),
+	}
 
-	t.YoloIconFocused = lipgloss.NewStyle().Foreground(charmtone.Oyster).Background(charmtone.Citron).Bold(true).SetString(" ! ")
-	t.YoloIconBlurred = t.YoloIconFocused.Foreground(charmtone.Pepper).Background(charmtone.Squid)
-	t.YoloDotsFocused = lipgloss.NewStyle().Foreground(charmtone.Zest).SetString(":::")
-	t.YoloDotsBlurred = t.YoloDotsFocused.Foreground(charmtone.Squid)
+	markdown := newMarkdownStyle(markdownColors{
+		Text:      charmtone.Smoke,
+		Heading:   charmtone.Malibu,
+		H1:        charmtone.Zest,
+		H1Bg:      charmtone.Charple,
+		H6:        charmtone.Guac,
+		Rule:      charmtone.Charcoal,
+		Link:      charmtone.Zinc,
+		LinkText:  charmtone.Guac,
+		Image:     charmtone.Cheeky,
+		ImageText: charmtone.Squid,
+		Code:      charmtone.Coral,
+		CodeBg:    charmtone.Charcoal,
+
+		SyntaxError:     charmtone.Butter,
+		SyntaxErrorBg:   charmtone.Sriracha,
+		Comment:         charmtone.Oyster,
+		Preproc:         charmtone.Bengal,
+		Keyword:         charmtone.Malibu,
+		KeywordReserved: charmtone.Pony,
+		KeywordType:     charmtone.Guppy,
+		Operator:        charmtone.Salmon,
+		Punctuation:     charmtone.Zest,
+		Builtin:         charmtone.Cheeky,
+		Tag:             charmtone.Mauve,
+		Attribute:       charmtone.Hazy,
+		Class:           charmtone.Salt,
+		Decorator:       charmtone.Citron,
+		Function:        charmtone.Guac,
+		Number:          charmtone.Julep,
+		String:          charmtone.Cumin,
+		StringEscape:    charmtone.Bok,
+		Deleted:         charmtone.Coral,
+		Inserted:        charmtone.Guac,
+		Subheading:      charmtone.Squid,
+	})
+	t.Markdown = &markdown
+
+	t.setStatusStyles()
 
 	return t
 }
@@ -0,0 +1,74 @@
+package styles
+
+import (
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/exp/charmtone"
+)
+
+func NewHighContrastTheme() *Theme {
+	t := &Theme{
+		Name:   "high-contrast",
+		IsDark: true,
+
+		Primary:   charmtone.Sardine,
+		Secondary: charmtone.Dolly,
+		Tertiary:  charmtone.Bok,
+		Accent:    charmtone.Citron,
+
+		// Backgrounds
+		BgBase:        lipgloss.Color("#000000"),
+		BgBaseLighter: lipgloss.Color("#121212"),
+		BgSubtle:      lipgloss.Color("#262626"),
+		BgOverlay:     charmtone.Iron,
+
+		// Foregrounds
+		FgBase:      lipgloss.Color("#ffffff"),
+		FgMuted:     charmtone.Ash,
+		FgHalfMuted: charmtone.Salt,
+		FgSubtle:    charmtone.Smoke,
+		FgSelected:  lipgloss.Color("#000000"),
+
+		// Borders
+		Border:      charmtone.Squid,
+		BorderFocus: charmtone.Citron,
+
+		// Status
+		Success: charmtone.Julep,
+		Error:   charmtone.Salmon,
+		Warning: charmtone.Citron,
+		Info:    charmtone.Sardine,
+
+		// Colors
+		White: lipgloss.Color("#ffffff"),
+
+		BlueLight: charmtone.Lichen,
+		BlueDark:  charmtone.Anchovy,
+		Blue:      charmtone.Sardine,
+
+		Yellow: charmtone.Mustard,
+		Citron: charmtone.Citron,
+
+		Green:      charmtone.Julep,
+		GreenDark:  charmtone.Julep,
+		GreenLight: charmtone.Bok,
+
+		Red:      charmtone.Salmon,
+		RedDark:  charmtone.Coral,
+		RedLight: charmtone.Uni,
+		Cherry:   charmtone.Cherry,
+
+		// Diffs
+		DiffInsert:         charmtone.Julep,
+		DiffInsertBg:       lipgloss.Color("#003d2b"),
+		DiffInsertNumberBg: lipgloss.Color("#002a1e"),
+		DiffDelete:         charmtone.Salmon,
+		DiffDeleteBg:       lipgloss.Color("#4d0f1f"),
+		DiffDeleteNumberBg: lipgloss.Color("#360a16"),
+		DiffInsertWordBg:   lipgloss.Color("#00664a"),
+		DiffDeleteWordBg:   lipgloss.Color("#80183a"),
+	}
+
+	t.setStatusStyles()
+
+	return t
+}
@@ -0,0 +1,74 @@
+package styles
+
+import (
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/exp/charmtone"
+)
+
+func NewLightTheme() *Theme {
+	t := &Theme{
+		Name:   "light",
+		IsDark: false,
+
+		Primary:   charmtone.Charple,
+		Secondary: charmtone.Prince,
+		Tertiary:  charmtone.Damson,
+		Accent:    charmtone.Paprika,
+
+		// Backgrounds
+		BgBase:        charmtone.Butter,
+		BgBaseLighter: charmtone.Salt,
+		BgSubtle:      charmtone.Ash,
+		BgOverlay:     charmtone.Smoke,
+
+		// Foregrounds
+		FgBase:      charmtone.Pepper,
+		FgMuted:     charmtone.Oyster,
+		FgHalfMuted: charmtone.Iron,
+		FgSubtle:    charmtone.Squid,
+		FgSelected:  charmtone.Butter,
+
+		// Borders
+		Border:      charmtone.Ash,
+		BorderFocus: charmtone.Charple,
+
+		// Status
+		Success: charmtone.Pickle,
+		Error:   charmtone.Sriracha,
+		Warning: charmtone.Tang,
+		Info:    charmtone.Damson,
+
+		// Colors
+		White: charmtone.Butter,
+
+		BlueLight: charmtone.Thunder,
+		BlueDark:  charmtone.Oceania,
+		Blue:      charmtone.Damson,
+
+		Yellow: charmtone.Cumin,
+		Citron: charmtone.Tang,
+
+		Green:      charmtone.NeueGuac,
+		GreenDark:  charmtone.Pickle,
+		GreenLight: charmtone.NeueZinc,
+
+		Red:      charmtone.Sriracha,
+		RedDark:  charmtone.Pom,
+		RedLight: charmtone.Paprika,
+		Cherry:   charmtone.Chili,
+
+		// Diffs
+		DiffInsert:         charmtone.Pickle,
+		DiffInsertBg:       lipgloss.Color("#e3f4e9"),
+		DiffInsertNumberBg: lipgloss.Color("#d4eddd"),
+		DiffDelete:         charmtone.Pom,
+		DiffDeleteBg:       lipgloss.Color("#fbe6ea"),
+		DiffDeleteNumberBg: lipgloss.Color("#f5d7dd"),
+		DiffInsertWordBg:   lipgloss.Color("#c2e8cf"),
+		DiffDeleteWordBg:   lipgloss.Color("#f5c2cc"),
+	}
+
+	t.setStatusStyles()
+
+	return t
+}
@@ -203,3 +203,302 @@ func PlainMarkdownStyle() ansi.StyleConfig {
 		},
 	}
 }
+
+// markdownColors are the colors of the markdown and syntax highlighting styles.
+type markdownColors struct {
+	Text      color.Color
+	Heading   color.Color
+	H1        color.Color
+	H1Bg      color.Color
+	H6        color.Color
+	Rule      color.Color
+	Link      color.Color
+	LinkText  color.Color
+	Image     color.Color
+	ImageText color.Color
+	Code      color.Color
+	CodeBg    color.Color
+
+	SyntaxError     color.Color
+	SyntaxErrorBg   color.Color
+	Comment         color.Color
+	Preproc         color.Color
+	Keyword         color.Color
+	KeywordReserved color.Color
+	KeywordType     color.Color
+	Operator        color.Color
+	Punctuation     color.Color
+	Builtin         color.Color
+	Tag             color.Color
+	Attribute       color.Color
+	Class           color.Color
+	Decorator       color.Color
+	Function        color.Color
+	Number          color.Color
+	String          color.Color
+	StringEscape    color.Color
+	Deleted         color.Color
+	Inserted        color.Color
+	Subheading      color.Color
+}
+
+// markdown returns the markdown style of the theme, deriving one from its
+// colors if it doesn't have one.
+func (t *Theme) markdown() ansi.StyleConfig {
+	if t.Markdown != nil {
+		return *t.Markdown
+	}
+	return newMarkdownStyle(markdownColors{
+		Text:      t.FgHalfMuted,
+		Heading:   t.Blue,
+		H1:        t.Accent,
+		H1Bg:      t.Primary,
+		H6:        t.GreenDark,
+		Rule:      t.Border,
+		Link:      t.FgMuted,
+		LinkText:  t.GreenDark,
+		Image:     t.Secondary,
+		ImageText: t.FgMuted,
+		Code:      t.Red,
+		CodeBg:    t.BgSubtle,
+
+		SyntaxError:     t.White,
+		SyntaxErrorBg:   t.RedDark,
+		Comment:         t.FgSubtle,
+		Preproc:         t.Yellow,
+		Keyword:         t.Blue,
+		KeywordReserved: t.Primary,
+		KeywordType:     t.BlueLight,
+		Operator:        t.RedLight,
+		Punctuation:     t.Accent,
+		Builtin:         t.Secondary,
+		Tag:             t.Tertiary,
+		Attribute:       t.BlueDark,
+		Class:           t.FgBase,
+		Decorator:       t.Citron,
+		Function:        t.GreenDark,
+		Number:          t.Green,
+		String:          t.Yellow,
+		StringEscape:    t.GreenLight,
+		Deleted:         t.Red,
+		Inserted:        t.GreenDark,
+		Subheading:      t.FgMuted,
+	})
+}
+
+func newMarkdownStyle(c markdownColors) ansi.StyleConfig {
+	hex := func(c color.Color) *string {
+		if c == nil {
+			return nil
+		}
+		return stringPtr(lipglossColorToHex(c))
+	}
+	return ansi.StyleConfig{
+		Document: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				// BlockPrefix: "\n",
+				// BlockSuffix: "\n",
+				Color: hex(c.Text),
+			},
+			// Margin: uintPtr(defaultMargin),
+		},
+		BlockQuote: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{},
+			Indent:         uintPtr(1),
+			IndentToken:    stringPtr("│ "),
+		},
+		List: ansi.StyleList{
+			LevelIndent: defaultListIndent,
+		},
+		Heading: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				BlockSuffix: "\n",
+				Color:       hex(c.Heading),
+				Bold:        boolPtr(true),
+			},
+		},
+		H1: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				Prefix:          " ",
+				Suffix:          " ",
+				Color:           hex(c.H1),
+				BackgroundColor: hex(c.H1Bg),
+				Bold:            boolPtr(true),
+			},
+		},
+		H2: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				Prefix: "## ",
+			},
+		},
+		H3: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				Prefix: "### ",
+			},
+		},
+		H4: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				Prefix: "#### ",
+			},
+		},
+		H5: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				Prefix: "##### ",
+			},
+		},
+		H6: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				Prefix: "###### ",
+				Color:  hex(c.H6),
+				Bold:   boolPtr(false),
+			},
+		},
+		Strikethrough: ansi.StylePrimitive{
+			CrossedOut: boolPtr(true),
+		},
+		Emph: ansi.StylePrimitive{
+			Italic: boolPtr(true),
+		},
+		Strong: ansi.StylePrimitive{
+			Bold: boolPtr(true),
+		},
+		HorizontalRule: ansi.StylePrimitive{
+			Color:  hex(c.Rule),
+			Format: "\n--------\n",
+		},
+		Item: ansi.StylePrimitive{
+			BlockPrefix: "• ",
+		},
+		Enumeration: ansi.StylePrimitive{
+			BlockPrefix: ". ",
+		},
+		Task: ansi.StyleTask{
+			StylePrimitive: ansi.StylePrimitive{},
+			Ticked:         "[✓] ",
+			Unticked:       "[ ] ",
+		},
+		Link: ansi.StylePrimitive{
+			Color:     hex(c.Link),
+			Underline: boolPtr(true),
+		},
+		LinkText: ansi.StylePrimitive{
+			Color: hex(c.LinkText),
+			Bold:  boolPtr(true),
+		},
+		Image: ansi.StylePrimitive{
+			Color:     hex(c.Image),
+			Underline: boolPtr(true),
+		},
+		ImageText: ansi.StylePrimitive{
+			Color:  hex(c.ImageText),
+			Format: "Image: {{.text}} →",
+		},
+		Code: ansi.StyleBlock{
+			StylePrimitive: ansi.StylePrimitive{
+				Prefix:          " ",
+				Suffix:          " ",
+				Color:           hex(c.Code),
+				BackgroundColor: hex(c.CodeBg),
+			},
+		},
+		CodeBlock: ansi.StyleCodeBlock{
+			StyleBlock: ansi.StyleBlock{
+				StylePrimitive: ansi.StylePrimitive{
+					Color: hex(c.CodeBg),
+				},
+				Margin: uintPtr(defaultMargin),
+			},
+			Chroma: &ansi.Chroma{
+				Text: ansi.StylePrimitive{
+					Color: hex(c.Text),
+				},
+				Error: ansi.StylePrimitive{
+					Color:           hex(c.SyntaxError),
+					BackgroundColor: hex(c.SyntaxErrorBg),
+				},
+				Comment: ansi.StylePrimitive{
+					Color: hex(c.Comment),
+				},
+				CommentPreproc: ansi.StylePrimitive{
+					Color: hex(c.Preproc),
+				},
+				Keyword: ansi.StylePrimitive{
+					Color: hex(c.Keyword),
+				},
+				KeywordReserved: ansi.StylePrimitive{
+					Color: hex(c.KeywordReserved),
+				},
+				KeywordNamespace: ansi.StylePrimitive{
+					Color: hex(c.KeywordReserved),
+				},
+				KeywordType: ansi.StylePrimitive{
+					Color: hex(c.KeywordType),
+				},
+				Operator: ansi.StylePrimitive{
+					Color: hex(c.Operator),
+				},
+				Punctuation: ansi.StylePrimitive{
+					Color: hex(c.Punctuation),
+				},
+				Name: ansi.StylePrimitive{
+					Color: hex(c.Text),
+				},
+				NameBuiltin: ansi.StylePrimitive{
+					Color: hex(c.Builtin),
+				},
+				NameTag: ansi.StylePrimitive{
+					Color: hex(c.Tag),
+				},
+				NameAttribute: ansi.StylePrimitive{
+					Color: hex(c.Attribute),
+				},
+				NameClass: ansi.StylePrimitive{
+					Color:     hex(c.Class),
+					Underline: boolPtr(true),
+					Bold:      boolPtr(true),
+				},
+				NameDecorator: ansi.StylePrimitive{
+					Color: hex(c.Decorator),
+				},
+				NameFunction: ansi.StylePrimitive{
+					Color: hex(c.Function),
+				},
+				LiteralNumber: ansi.StylePrimitive{
+					Color: hex(c.Number),
+				},
+				LiteralString: ansi.StylePrimitive{
+					Color: hex(c.String),
+				},
+				LiteralStringEscape: ansi.StylePrimitive{
+					Color: hex(c.StringEscape),
+				},
+				GenericDeleted: ansi.StylePrimitive{
+					Color: hex(c.Deleted),
+				},
+				GenericEmph: ansi.StylePrimitive{
+					Italic: boolPtr(true),
+				},
+				GenericInserted: ansi.StylePrimitive{
+					Color: hex(c.Inserted),
+				},
+				GenericStrong: ansi.StylePrimitive{
+					Bold: boolPtr(true),
+				},
+				GenericSubheading: ansi.StylePrimitive{
+					Color: hex(c.Subheading),
+				},
+				Background: ansi.StylePrimitive{
+					BackgroundColor: hex(c.CodeBg),
+				},
+			},
+		},
+		Table: ansi.StyleTable{
+			StyleBlock: ansi.StyleBlock{
+				StylePrimitive: ansi.StylePrimitive{},
+			},
+		},
+		DefinitionDescription: ansi.StylePrimitive{
+			BlockPrefix: "\n ",
+		},
+	}
+}
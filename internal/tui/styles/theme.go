@@ -3,6 +3,7 @@ package styles
 import (
 	"fmt"
 	"image/color"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/filepicker"
@@ -13,7 +14,6 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/tui/exp/diffview"
 	"github.com/charmbracelet/glamour/v2/ansi"
-	"github.com/charmbracelet/x/exp/charmtone"
 	"github.com/lucasb-eyer/go-colorful"
 	"github.com/rivo/uniseg"
 )
@@ -76,6 +76,20 @@ type Theme struct {
 	RedLight color.Color
 	Cherry   color.Color
 
+	// Diffs
+	DiffInsert         color.Color
+	DiffInsertBg       color.Color
+	DiffInsertNumberBg color.Color
+	DiffDelete         color.Color
+	DiffDeleteBg       color.Color
+	DiffDeleteNumberBg color.Color
+	DiffInsertWordBg   color.Color
+	DiffDeleteWordBg   color.Color
+
+	// Markdown and syntax highlighting. Themes that don't set it get one
+	// derived from their colors.
+	Markdown *ansi.StyleConfig
+
 	// Text selection.
 	TextSelection lipgloss.Style
 
@@ -127,6 +141,24 @@ type Styles struct {
 	FilePicker filepicker.Styles
 }
 
+// setStatusStyles sets the text selection, status indicator and yolo mode
+// styles from the theme colors.
+func (t *Theme) setStatusStyles() {
+	// Text selection.
+	t.TextSelection = lipgloss.NewStyle().Foreground(t.FgSelected).Background(t.Primary)
+
+	// LSP and MCP status.
+	t.ItemOfflineIcon = lipgloss.NewStyle().Foreground(t.FgMuted).SetString("●")
+	t.ItemBusyIcon = t.ItemOfflineIcon.Foreground(t.Citron)
+	t.ItemErrorIcon = t.ItemOfflineIcon.Foreground(t.Red)
+	t.ItemOnlineIcon = t.ItemOfflineIcon.Foreground(t.GreenDark)
+
+	t.YoloIconFocused = lipgloss.NewStyle().Foreground(t.FgSubtle).Background(t.Citron).Bold(true).SetString(" ! ")
+	t.YoloIconBlurred = t.YoloIconFocused.Foreground(t.BgBase).Background(t.FgMuted)
+	t.YoloDotsFocused = lipgloss.NewStyle().Foreground(t.Accent).SetString(":::")
+	t.YoloDotsBlurred = t.YoloDotsFocused.Foreground(t.FgMuted)
+}
+
 func (t *Theme) S() *Styles {
 	if t.styles == nil {
 		t.styles = t.buildStyles()
@@ -210,214 +242,7 @@ func (t *Theme) buildStyles() *Styles {
 			},
 		},
 
-		Markdown: ansi.StyleConfig{
-			Document: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					// BlockPrefix: "\n",
-					// BlockSuffix: "\n",
-					Color: stringPtr(charmtone.Smoke.Hex()),
-				},
-				// Margin: uintPtr(defaultMargin),
-			},
-			BlockQuote: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{},
-				Indent:         uintPtr(1),
-				IndentToken:    stringPtr("│ "),
-			},
-			List: ansi.StyleList{
-				LevelIndent: defaultListIndent,
-			},
-			Heading: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					BlockSuffix: "\n",
-					Color:       stringPtr(charmtone.Malibu.Hex()),
-					Bold:        boolPtr(true),
-				},
-			},
-			H1: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					Prefix:          " ",
-					Suffix:          " ",
-					Color:           stringPtr(charmtone.Zest.Hex()),
-					BackgroundColor: stringPtr(charmtone.Charple.Hex()),
-					Bold:            boolPtr(true),
-				},
-			},
-			H2: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					Prefix: "## ",
-				},
-			},
-			H3: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					Prefix: "### ",
-				},
-			},
-			H4: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					Prefix: "#### ",
-				},
-			},
-			H5: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					Prefix: "##### ",
-				},
-			},
-			H6: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					Prefix: "###### ",
-					Color:  stringPtr(charmtone.Guac.Hex()),
-					Bold:   boolPtr(false),
-				},
-			},
-			Strikethrough: ansi.StylePrimitive{
-				CrossedOut: boolPtr(true),
-			},
-			Emph: ansi.StylePrimitive{
-				Italic: boolPtr(true),
-			},
-			Strong: ansi.StylePrimitive{
-				Bold: boolPtr(true),
-			},
-			HorizontalRule: ansi.StylePrimitive{
-				Color:  stringPtr(charmtone.Charcoal.Hex()),
-				Format: "\n--------\n",
-			},
-			Item: ansi.StylePrimitive{
-				BlockPrefix: "• ",
-			},
-			Enumeration: ansi.StylePrimitive{
-				BlockPrefix: ". ",
-			},
-			Task: ansi.StyleTask{
-				StylePrimitive: ansi.StylePrimitive{},
-				Ticked:         "[✓] ",
-				Unticked:       "[ ] ",
-			},
-			Link: ansi.StylePrimitive{
-				Color:     stringPtr(charmtone.Zinc.Hex()),
-				Underline: boolPtr(true),
-			},
-			LinkText: ansi.StylePrimitive{
-				Color: stringPtr(charmtone.Guac.Hex()),
-				Bold:  boolPtr(true),
-			},
-			Image: ansi.StylePrimitive{
-				Color:     stringPtr(charmtone.Cheeky.Hex()),
-				Underline: boolPtr(true),
-			},
-			ImageText: ansi.StylePrimitive{
-				Color:  stringPtr(charmtone.Squid.Hex()),
-				Format: "Image: {{.text}} →",
-			},
-			Code: ansi.StyleBlock{
-				StylePrimitive: ansi.StylePrimitive{
-					Prefix:          " ",
-					Suffix:          " ",
-					Color:           stringPtr(charmtone.Coral.Hex()),
-					BackgroundColor: stringPtr(charmtone.Charcoal.Hex()),
-				},
-			},
-			CodeBlock: ansi.StyleCodeBlock{
-				StyleBlock: ansi.StyleBlock{
-					StylePrimitive: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Charcoal.Hex()),
-					},
-					Margin: uintPtr(defaultMargin),
-				},
-				Chroma: &ansi.Chroma{
-					Text: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Smoke.Hex()),
-					},
-					Error: ansi.StylePrimitive{
-						Color:           stringPtr(charmtone.Butter.Hex()),
-						BackgroundColor: stringPtr(charmtone.Sriracha.Hex()),
-					},
-					Comment: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Oyster.Hex()),
-					},
-					CommentPreproc: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Bengal.Hex()),
-					},
-					Keyword: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Malibu.Hex()),
-					},
-					KeywordReserved: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Pony.Hex()),
-					},
-					KeywordNamespace: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Pony.Hex()),
-					},
-					KeywordType: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Guppy.Hex()),
-					},
-					Operator: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Salmon.Hex()),
-					},
-					Punctuation: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Zest.Hex()),
-					},
-					Name: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Smoke.Hex()),
-					},
-					NameBuiltin: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Cheeky.Hex()),
-					},
-					NameTag: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Mauve.Hex()),
-					},
-					NameAttribute: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Hazy.Hex()),
-					},
-					NameClass: ansi.StylePrimitive{
-						Color:     stringPtr(charmtone.Salt.Hex()),
-						Underline: boolPtr(true),
-						Bold:      boolPtr(true),
-					},
-					NameDecorator: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Citron.Hex()),
-					},
-					NameFunction: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Guac.Hex()),
-					},
-					LiteralNumber: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Julep.Hex()),
-					},
-					LiteralString: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Cumin.Hex()),
-					},
-					LiteralStringEscape: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Bok.Hex()),
-					},
-					GenericDeleted: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Coral.Hex()),
-					},
-					GenericEmph: ansi.StylePrimitive{
-						Italic: boolPtr(true),
-					},
-					GenericInserted: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Guac.Hex()),
-					},
-					GenericStrong: ansi.StylePrimitive{
-						Bold: boolPtr(true),
-					},
-					GenericSubheading: ansi.StylePrimitive{
-						Color: stringPtr(charmtone.Squid.Hex()),
-					},
-					Background: ansi.StylePrimitive{
-						BackgroundColor: stringPtr(charmtone.Charcoal.Hex()),
-					},
-				},
-			},
-			Table: ansi.StyleTable{
-				StyleBlock: ansi.StyleBlock{
-					StylePrimitive: ansi.StylePrimitive{},
-				},
-			},
-			DefinitionDescription: ansi.StylePrimitive{
-				BlockPrefix: "\n ",
-			},
-		},
+		Markdown: t.markdown(),
 
 		Help: help.Styles{
 			ShortKey:       base.Foreground(t.FgMuted),
@@ -454,23 +279,27 @@ func (t *Theme) buildStyles() *Styles {
 			},
 			InsertLine: diffview.LineStyle{
 				LineNumber: lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#629657")).
-					Background(lipgloss.Color("#2b322a")),
+					Foreground(t.DiffInsert).
+					Background(t.DiffInsertNumberBg),
 				Symbol: lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#629657")).
-					Background(lipgloss.Color("#323931")),
+					Foreground(t.DiffInsert).
+					Background(t.DiffInsertBg),
 				Code: lipgloss.NewStyle().
-					Background(lipgloss.Color("#323931")),
+					Background(t.DiffInsertBg),
+				Emphasis: lipgloss.NewStyle().
+					Background(t.DiffInsertWordBg),
 			},
 			DeleteLine: diffview.LineStyle{
 				LineNumber: lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#a45c59")).
-					Background(lipgloss.Color("#312929")),
+					Foreground(t.DiffDelete).
+					Background(t.DiffDeleteNumberBg),
 				Symbol: lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#a45c59")).
-					Background(lipgloss.Color("#383030")),
+					Foreground(t.DiffDelete).
+					Background(t.DiffDeleteBg),
 				Code: lipgloss.NewStyle().
-					Background(lipgloss.Color("#383030")),
+					Background(t.DiffDeleteBg),
+				Emphasis: lipgloss.NewStyle().
+					Background(t.DiffDeleteWordBg),
 			},
 		},
 		FilePicker: filepicker.Styles{
@@ -489,6 +318,10 @@ func (t *Theme) buildStyles() *Styles {
 	}
 }
 
+// ThemeChangedMsg is sent after the current theme changes, so components can
+// drop what they rendered with the previous one.
+type ThemeChangedMsg struct{}
+
 type Manager struct {
 	themes  map[string]*Theme
 	current *Theme
@@ -521,6 +354,8 @@ func NewManager() *Manager {
 
 	t := NewCharmtoneTheme() // default theme
 	m.Register(t)
+	m.Register(NewLightTheme())
+	m.Register(NewHighContrastTheme())
 	m.current = m.themes[t.Name]
 
 	return m
@@ -547,6 +382,7 @@ func (m *Manager) List() []string {
 	for name := range m.themes {
 		names = append(names, name)
 	}
+	slices.Sort(names)
 	return names
 }
 
@@ -611,6 +447,12 @@ func ForegroundGrad(input string, bold bool, color1, color2 color.Color) []strin
 	}
 
 	ramp := blendColors(len(clusters), color1, color2)
+	if ScreenReader() {
+		// No gradients for screen readers.
+		for i := range ramp {
+			ramp[i] = color1
+		}
+	}
 	for i, c := range ramp {
 		style := t.S().Base.Foreground(c)
 		if bold {
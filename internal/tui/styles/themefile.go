@@ -0,0 +1,228 @@
+package styles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/glamour/v2/ansi"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// themeFile is the format of user-defined themes, which can be written in
+// JSON or TOML.
+type themeFile struct {
+	// Name of the theme, defaults to the file name.
+	Name string `json:"name,omitempty"`
+	// Extends is the theme to start from, defaults to charmtone.
+	Extends string `json:"extends,omitempty"`
+	IsDark  *bool  `json:"is_dark,omitempty"`
+	// Colors overrides the palette, keyed by the snake_case field names of
+	// Theme, e.g. "primary" or "bg_base".
+	Colors map[string]string `json:"colors,omitempty"`
+	// Markdown overrides the glamour style of markdown, and Chroma the syntax
+	// highlighting of code blocks. When the palette changes, they start from
+	// styles derived from it.
+	Markdown json.RawMessage `json:"markdown,omitempty"`
+	Chroma   json.RawMessage `json:"chroma,omitempty"`
+}
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// LoadThemes registers the themes defined by the JSON and TOML files in the
+// given directory. A missing directory isn't an error.
+func (m *Manager) LoadThemes(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read themes: %w", err)
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".toml") {
+			continue
+		}
+		theme, err := m.LoadThemeFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.Register(theme)
+	}
+	return errors.Join(errs...)
+}
+
+// LoadThemeFile reads a theme from a JSON or TOML file. Themes it extends
+// must already be registered.
+func (m *Manager) LoadThemeFile(path string) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read theme: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		var values map[string]any
+		if err := toml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("parse theme %s: %w", path, err)
+		}
+		if data, err = json.Marshal(values); err != nil {
+			return nil, fmt.Errorf("parse theme %s: %w", path, err)
+		}
+	}
+
+	var file themeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse theme %s: %w", path, err)
+	}
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	theme, err := m.newTheme(file)
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %w", path, err)
+	}
+	return theme, nil
+}
+
+func (m *Manager) newTheme(file themeFile) (*Theme, error) {
+	baseName := file.Extends
+	if baseName == "" {
+		baseName = "charmtone"
+	}
+	base, ok := m.themes[baseName]
+	if !ok {
+		return nil, fmt.Errorf("theme %s not found", baseName)
+	}
+
+	theme := *base
+	theme.Name = file.Name
+	theme.styles = nil
+	if file.IsDark != nil {
+		theme.IsDark = *file.IsDark
+	}
+
+	fields := theme.colorFields()
+	for name, value := range file.Colors {
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %q", name)
+		}
+		c, err := parseColor(value)
+		if err != nil {
+			return nil, fmt.Errorf("color %q: %w", name, err)
+		}
+		*field = c
+	}
+	if len(file.Colors) > 0 {
+		theme.Markdown = nil
+	}
+
+	if len(file.Markdown) > 0 || len(file.Chroma) > 0 {
+		markdown, err := overrideMarkdown(theme.markdown(), file.Markdown, file.Chroma)
+		if err != nil {
+			return nil, err
+		}
+		theme.Markdown = &markdown
+	}
+
+	theme.setStatusStyles()
+	return &theme, nil
+}
+
+// overrideMarkdown applies the given markdown and chroma overrides on top of
+// a copy of the base style.
+func overrideMarkdown(base ansi.StyleConfig, markdown, chroma json.RawMessage) (ansi.StyleConfig, error) {
+	// Round-trip through JSON so the overrides don't write through the
+	// pointers the base style shares with its theme.
+	data, err := json.Marshal(base)
+	if err != nil {
+		return base, err
+	}
+	var result ansi.StyleConfig
+	if err := json.Unmarshal(data, &result); err != nil {
+		return base, err
+	}
+	if len(markdown) > 0 {
+		if err := json.Unmarshal(markdown, &result); err != nil {
+			return base, fmt.Errorf("markdown: %w", err)
+		}
+	}
+	if len(chroma) > 0 {
+		if result.CodeBlock.Chroma == nil {
+			result.CodeBlock.Chroma = &ansi.Chroma{}
+		}
+		if err := json.Unmarshal(chroma, result.CodeBlock.Chroma); err != nil {
+			return base, fmt.Errorf("chroma: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// parseColor parses a hex color, e.g. #ff00ff, or an ANSI color number.
+func parseColor(s string) (color.Color, error) {
+	if hexColorRe.MatchString(s) {
+		return lipgloss.Color(s), nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return lipgloss.Color(s), nil
+	}
+	return nil, fmt.Errorf("invalid color %q, use #rrggbb or an ANSI color number", s)
+}
+
+// colorFields maps the names used in theme files to the theme colors.
+func (t *Theme) colorFields() map[string]*color.Color {
+	return map[string]*color.Color{
+		"primary":               &t.Primary,
+		"secondary":             &t.Secondary,
+		"tertiary":              &t.Tertiary,
+		"accent":                &t.Accent,
+		"bg_base":               &t.BgBase,
+		"bg_base_lighter":       &t.BgBaseLighter,
+		"bg_subtle":             &t.BgSubtle,
+		"bg_overlay":            &t.BgOverlay,
+		"fg_base":               &t.FgBase,
+		"fg_muted":              &t.FgMuted,
+		"fg_half_muted":         &t.FgHalfMuted,
+		"fg_subtle":             &t.FgSubtle,
+		"fg_selected":           &t.FgSelected,
+		"border":                &t.Border,
+		"border_focus":          &t.BorderFocus,
+		"success":               &t.Success,
+		"error":                 &t.Error,
+		"warning":               &t.Warning,
+		"info":                  &t.Info,
+		"white":                 &t.White,
+		"blue_light":            &t.BlueLight,
+		"blue_dark":             &t.BlueDark,
+		"blue":                  &t.Blue,
+		"yellow":                &t.Yellow,
+		"citron":                &t.Citron,
+		"green":                 &t.Green,
+		"green_dark":            &t.GreenDark,
+		"green_light":           &t.GreenLight,
+		"red":                   &t.Red,
+		"red_dark":              &t.RedDark,
+		"red_light":             &t.RedLight,
+		"cherry":                &t.Cherry,
+		"diff_insert":           &t.DiffInsert,
+		"diff_insert_bg":        &t.DiffInsertBg,
+		"diff_insert_number_bg": &t.DiffInsertNumberBg,
+		"diff_delete":           &t.DiffDelete,
+		"diff_delete_bg":        &t.DiffDeleteBg,
+		"diff_delete_number_bg": &t.DiffDeleteNumberBg,
+		"diff_insert_word_bg":   &t.DiffInsertWordBg,
+		"diff_delete_word_bg":   &t.DiffDeleteWordBg,
+	}
+}
@@ -0,0 +1,100 @@
+package styles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/exp/charmtone"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinThemes(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager()
+	require.Equal(t, []string{"charmtone", "high-contrast", "light"}, m.List())
+	for _, name := range m.List() {
+		require.NoError(t, m.SetTheme(name))
+		theme := m.Current()
+		for field, c := range theme.colorFields() {
+			require.NotNil(t, *c, "%s: %s is not set", name, field)
+		}
+		require.NotNil(t, theme.S().Markdown.CodeBlock.Chroma)
+	}
+}
+
+func TestLoadThemes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dusk.toml"), []byte(`
+# A theme in TOML.
+extends = "light"
+is_dark = false
+
+[colors]
+primary = "#ff00ff"
+bg_base = "236"
+
+[chroma.keyword]
+color = "#00ff00"
+bold = true
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "night.json"), []byte(`{
+  "name": "Night",
+  "markdown": {"h1": {"background_color": "#123456"}}
+}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`not a theme`), 0o644))
+
+	m := NewManager()
+	require.NoError(t, m.LoadThemes(dir))
+	require.Equal(t, []string{"Night", "charmtone", "dusk", "high-contrast", "light"}, m.List())
+
+	require.NoError(t, m.SetTheme("dusk"))
+	dusk := m.Current()
+	require.False(t, dusk.IsDark)
+	require.Equal(t, lipgloss.Color("#ff00ff"), dusk.Primary)
+	require.Equal(t, lipgloss.Color("236"), dusk.BgBase)
+	require.Equal(t, NewLightTheme().FgBase, dusk.FgBase)
+	keyword := dusk.S().Markdown.CodeBlock.Chroma.Keyword
+	require.Equal(t, "#00ff00", *keyword.Color)
+	require.True(t, *keyword.Bold)
+	// The rest of the markdown is derived from the palette.
+	require.Equal(t, "#ff00ff", *dusk.S().Markdown.H1.BackgroundColor)
+
+	require.NoError(t, m.SetTheme("Night"))
+	night := m.Current()
+	require.Equal(t, "#123456", *night.S().Markdown.H1.BackgroundColor)
+	// Without colors, it keeps the markdown of the base theme.
+	require.Equal(t, lipglossColorToHex(charmtone.Zest), *night.S().Markdown.H1.Color)
+
+	// The base theme is untouched.
+	require.NoError(t, m.SetTheme("charmtone"))
+	require.Equal(t, lipglossColorToHex(charmtone.Charple), *m.Current().S().Markdown.H1.BackgroundColor)
+}
+
+func TestLoadThemesErrors(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager()
+	require.NoError(t, m.LoadThemes(filepath.Join(t.TempDir(), "missing")))
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown-color.json": `{"colors": {"purple": "#ff00ff"}}`,
+		"bad-color.json":     `{"colors": {"primary": "purple"}}`,
+		"bad-base.json":      `{"extends": "nope"}`,
+		"bad-syntax.toml":    `[colors`,
+		"ok.json":            `{}`,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	err := m.LoadThemes(dir)
+	require.ErrorContains(t, err, `unknown color "purple"`)
+	require.ErrorContains(t, err, `invalid color "purple"`)
+	require.ErrorContains(t, err, "theme nope not found")
+	require.ErrorContains(t, err, "parse theme "+filepath.Join(dir, "bad-syntax.toml"))
+	require.Contains(t, m.List(), "ok")
+}
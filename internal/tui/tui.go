@@ -3,6 +3,7 @@ package tui
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"slices"
 	"strings"
@@ -15,6 +16,8 @@ import (
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	cmpChat "github.com/charmbracelet/crush/internal/tui/components/chat"
@@ -26,12 +29,16 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/mcps"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/permissions"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/sessions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/themes"
+	"github.com/charmbracelet/crush/internal/tui/notify"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/page/chat"
+	"github.com/charmbracelet/crush/internal/tui/page/review"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"golang.org/x/text/cases"
@@ -77,6 +84,12 @@ type appModel struct {
 	// Chat Page Specific
 	selectedSessionID string // The ID of the currently selected session
 
+	// Notifications
+	notifier *notify.Notifier
+	// notifiedTurns holds the last message notified for each session, as
+	// finished messages can be updated again.
+	notifiedTurns map[string]string
+
 	// sendProgressBar instructs the TUI to send progress bar updates to the
 	// terminal.
 	sendProgressBar bool
@@ -114,6 +127,16 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	a.isConfigured = config.HasInitialDataConfig()
 
 	switch msg := msg.(type) {
+	case tea.FocusMsg:
+		a.notifier.SetFocused(true)
+		return a, nil
+	case tea.BlurMsg:
+		a.notifier.SetFocused(false)
+		return a, nil
+	case notify.Msg:
+		return a, a.notifier.Notify(msg.Event, msg.Body)
+	case pubsub.Event[message.Message]:
+		cmds = append(cmds, a.notifyTurnEnd(msg))
 	case tea.EnvMsg:
 		// Is this Windows Terminal?
 		if !a.sendProgressBar {
@@ -153,6 +176,12 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, handleMCPPromptsEvent(context.Background(), msg.Payload.Name)
 		case mcp.EventToolsListChanged:
 			return a, handleMCPToolsEvent(context.Background(), msg.Payload.Name)
+		case mcp.EventResourcesListChanged:
+			return a, handleMCPResourcesEvent(context.Background(), msg.Payload.Name)
+		case mcp.EventResourceUpdated:
+			return a, util.ReportInfo(fmt.Sprintf("MCP resource updated: %s", msg.Payload.URI))
+		case mcp.EventAuthorizationRequired:
+			return a, util.ReportInfo(fmt.Sprintf("Authorize MCP %s in your browser: %s", msg.Payload.Name, msg.Payload.URI))
 		}
 
 	// Completions messages
@@ -229,28 +258,112 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.selectedSessionID = ""
 	// Commands
 	case commands.SwitchSessionsMsg:
+		status := a.sessionStatus()
 		return a, func() tea.Msg {
 			allSessions, _ := a.app.Sessions.List(context.Background())
 			return dialogs.OpenDialogMsg{
-				Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID),
+				Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID, status),
 			}
 		}
 
 	case commands.SwitchModelMsg:
 		return a, util.CmdHandler(
 			dialogs.OpenDialogMsg{
-				Model: models.NewModelDialogCmp(),
+				Model: models.NewModelDialogCmp(a.promptTokens()),
 			},
 		)
 	// Compact
 	case commands.CompactMsg:
 		return a, func() tea.Msg {
-			err := a.app.AgentCoordinator.Summarize(context.Background(), msg.SessionID)
+			err := a.app.AgentCoordinator().Summarize(context.Background(), msg.SessionID)
 			if err != nil {
 				return util.ReportError(err)()
 			}
 			return nil
 		}
+	case commands.RestartLSPMsg:
+		return a, func() tea.Msg {
+			if err := a.app.RestartLSPClient(context.Background(), msg.Name); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo(fmt.Sprintf("Restarting LSP %s", msg.Name))()
+		}
+	case commands.StopLSPMsg:
+		return a, func() tea.Msg {
+			if err := a.app.StopLSPClient(context.Background(), msg.Name); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo(fmt.Sprintf("Stopped LSP %s", msg.Name))()
+		}
+	case commands.SwitchProfileMsg:
+		return a, func() tea.Msg {
+			if err := a.app.SwitchProfile(context.Background(), msg.Name); err != nil {
+				return util.ReportError(err)()
+			}
+			if msg.Name == "" {
+				return util.ReportInfo("Cleared the configuration profile")()
+			}
+			return util.ReportInfo(fmt.Sprintf("Switched to profile %s", msg.Name))()
+		}
+	case commands.OpenMCPDialogMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: mcps.NewMCPsDialogCmp(),
+		})
+	case mcps.ReconnectMCPMsg:
+		return a, tea.Sequence(
+			util.ReportInfo(fmt.Sprintf("Connecting to MCP %s", msg.Name)),
+			func() tea.Msg {
+				if err := a.app.ReconnectMCP(context.Background(), msg.Name); err != nil {
+					return util.ReportError(err)()
+				}
+				return util.ReportInfo(fmt.Sprintf("Connected to MCP %s", msg.Name))()
+			},
+		)
+	case mcps.DisableMCPMsg:
+		return a, func() tea.Msg {
+			if err := a.app.DisableMCP(context.Background(), msg.Name); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo(fmt.Sprintf("Disabled MCP %s", msg.Name))()
+		}
+	case commands.ReviewChangesMsg:
+		cmd := a.moveToPage(review.ReviewPageID)
+		if a.currentPage != review.ReviewPageID {
+			return a, cmd
+		}
+		updated, pageCmd := a.pages[review.ReviewPageID].Update(msg)
+		a.pages[review.ReviewPageID] = updated
+		return a, tea.Batch(cmd, pageCmd)
+	case pubsub.Event[history.File]:
+		// Both the sidebar of the chat page and the review page show the
+		// changed files.
+		for id, page := range a.pages {
+			updated, cmd := page.Update(msg)
+			a.pages[id] = updated
+			cmds = append(cmds, cmd)
+		}
+		return a, tea.Batch(cmds...)
+	case commands.SwitchThemeMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: themes.NewThemeDialogCmp(),
+		})
+	case themes.ThemeSelectedMsg:
+		cfg := config.Get()
+		cfg.Options.TUI.Theme = msg.Name
+		if err := cfg.SetConfigField("options.tui.theme", msg.Name); err != nil {
+			return a, util.ReportError(err)
+		}
+		return a, util.ReportInfo(fmt.Sprintf("Theme changed to %s", msg.Name))
+	case styles.ThemeChangedMsg:
+		for id, page := range a.pages {
+			updated, cmd := page.Update(msg)
+			a.pages[id] = updated
+			cmds = append(cmds, cmd)
+		}
+		u, dialogCmd := a.dialog.Update(msg)
+		a.dialog = u.(dialogs.DialogCmp)
+		cmds = append(cmds, dialogCmd)
+		return a, tea.Batch(cmds...)
 	case commands.QuitMsg:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: quit.NewQuitDialog(),
@@ -263,7 +376,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, a.handleWindowResize(a.wWidth, a.wHeight)
 	// Model Switch
 	case models.ModelSelectedMsg:
-		if a.app.AgentCoordinator.IsBusy() {
+		if a.app.AgentCoordinator().IsBusy() {
 			return a, util.ReportWarn("Agent is busy, please wait...")
 		}
 
@@ -278,6 +391,9 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.ModelType == config.SelectedModelTypeSmall {
 			modelTypeName = "small"
 		}
+		if model := cfg.GetModel(msg.Model.Provider, msg.Model.Model); msg.ModelType == config.SelectedModelTypeLarge && model != nil && models.ContextTooSmall(*model, a.promptTokens()) {
+			return a, util.ReportWarn(fmt.Sprintf("%s model changed to %s, its context window of %d tokens is too small for the system prompt and tools", modelTypeName, msg.Model.Model, model.ContextWindow))
+		}
 		return a, util.ReportInfo(fmt.Sprintf("%s model changed to %s", modelTypeName, msg.Model.Model))
 
 	// File Picker
@@ -304,12 +420,22 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		return a, itemCmd
 	case pubsub.Event[permission.PermissionRequest]:
-		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+		// The chat page shows which sessions await a permission.
+		updated, pageCmd := a.pages[chat.ChatPageID].Update(msg)
+		a.pages[chat.ChatPageID] = updated
+		notifyCmd := a.notifier.Notify(notify.Permission, "Permission needed: "+msg.Payload.Description)
+		return a, tea.Batch(pageCmd, notifyCmd, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
 				DiffMode: config.Get().Options.TUI.DiffMode,
 			}),
-		})
+		}))
 	case permissions.PermissionResponseMsg:
+		updated, pageCmd := a.pages[chat.ChatPageID].Update(msg)
+		a.pages[chat.ChatPageID] = updated
+		cmds = append(cmds, pageCmd)
+		if msg.Content != nil && msg.Action != permissions.PermissionDeny {
+			a.app.Permissions.Modify(msg.Permission, *msg.Content)
+		}
 		switch msg.Action {
 		case permissions.PermissionAllow:
 			a.app.Permissions.Grant(msg.Permission)
@@ -318,7 +444,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case permissions.PermissionDeny:
 			a.app.Permissions.Deny(msg.Permission)
 		}
-		return a, nil
+		return a, tea.Batch(cmds...)
 	case splash.OnboardingCompleteMsg:
 		item, ok := a.pages[a.currentPage]
 		if !ok {
@@ -335,6 +461,20 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyPressMsg:
 		return a, a.handleKeyPressMsg(msg)
 
+	case tea.MouseClickMsg, tea.MouseMotionMsg, tea.MouseReleaseMsg:
+		// Clicks do not reach the page behind a dialog.
+		if a.dialog.HasDialogs() {
+			u, dialogCmd := a.dialog.Update(msg)
+			a.dialog = u.(dialogs.DialogCmp)
+			return a, dialogCmd
+		}
+		item, ok := a.pages[a.currentPage]
+		if !ok {
+			return a, nil
+		}
+		updated, pageCmd := item.Update(msg)
+		a.pages[a.currentPage] = updated
+		return a, pageCmd
 	case tea.MouseWheelMsg:
 		if a.dialog.HasDialogs() {
 			u, dialogCmd := a.dialog.Update(msg)
@@ -407,6 +547,46 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, tea.Batch(cmds...)
 }
 
+// notifyTurnEnd notifies the end of a turn of a top-level session, when it
+// finished or failed.
+func (a *appModel) notifyTurnEnd(event pubsub.Event[message.Message]) tea.Cmd {
+	msg := event.Payload
+	if event.Type != pubsub.UpdatedEvent || msg.Role != message.Assistant {
+		return nil
+	}
+	finish := msg.FinishPart()
+	if finish == nil {
+		return nil
+	}
+	var notifyEvent notify.Event
+	switch finish.Reason {
+	case message.FinishReasonEndTurn, message.FinishReasonMaxTokens:
+		notifyEvent = notify.TurnComplete
+	case message.FinishReasonError:
+		notifyEvent = notify.Error
+	default:
+		return nil
+	}
+	if !a.notifier.Wants(notifyEvent) || a.notifiedTurns[msg.SessionID] == msg.ID {
+		return nil
+	}
+	a.notifiedTurns[msg.SessionID] = msg.ID
+
+	return func() tea.Msg {
+		// Tasks run by the agent are notified with the session that started
+		// them.
+		s, err := a.app.Sessions.Get(context.Background(), msg.SessionID)
+		if err != nil || s.ParentSessionID != "" {
+			return nil
+		}
+		body := "Finished: " + s.Title
+		if notifyEvent == notify.Error {
+			body = "Error in " + s.Title + ": " + finish.Message
+		}
+		return notify.Msg{Event: notifyEvent, Body: body}
+	}
+}
+
 // handleWindowResize processes window resize events and updates all components.
 func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 	var cmds []tea.Cmd
@@ -507,7 +687,7 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 			return nil
 		}
 		return util.CmdHandler(dialogs.OpenDialogMsg{
-			Model: models.NewModelDialogCmp(),
+			Model: models.NewModelDialogCmp(a.promptTokens()),
 		})
 	case key.Matches(msg, a.keyMap.Sessions):
 		// if the app is not configured show no sessions
@@ -520,18 +700,19 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 		if a.dialog.HasDialogs() && a.dialog.ActiveDialogID() != commands.CommandsDialogID {
 			return nil
 		}
+		status := a.sessionStatus()
 		var cmds []tea.Cmd
 		cmds = append(cmds,
 			func() tea.Msg {
 				allSessions, _ := a.app.Sessions.List(context.Background())
 				return dialogs.OpenDialogMsg{
-					Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID),
+					Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID, status),
 				}
 			},
 		)
 		return tea.Sequence(cmds...)
 	case key.Matches(msg, a.keyMap.Suspend):
-		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsBusy() {
+		if a.app.AgentCoordinator() != nil && a.app.AgentCoordinator().IsBusy() {
 			return util.ReportWarn("Agent is busy, please wait...")
 		}
 		return tea.Suspend
@@ -547,9 +728,30 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 	}
 }
 
+// promptTokens returns the estimated size of the system prompt and tools of
+// the coder agent, zero before it is ready.
+func (a *appModel) promptTokens() int64 {
+	if a.app.AgentCoordinator() == nil {
+		return 0
+	}
+	return a.app.AgentCoordinator().PromptTokens()
+}
+
+// sessionStatus returns a function describing what a session is doing, for
+// the sessions dialog. The function is safe to call from commands.
+func (a *appModel) sessionStatus() func(sessionID string) string {
+	chatPage, ok := a.pages[chat.ChatPageID].(chat.ChatPage)
+	if !ok {
+		return nil
+	}
+	return func(sessionID string) string {
+		return chatPage.SessionStatus(sessionID).String()
+	}
+}
+
 // moveToPage handles navigation between different pages in the application.
 func (a *appModel) moveToPage(pageID page.PageID) tea.Cmd {
-	if a.app.AgentCoordinator.IsBusy() {
+	if a.app.AgentCoordinator().IsBusy() && pageID != chat.ChatPageID {
 		// TODO: maybe remove this :  For now we don't move to any page if the agent is busy
 		return util.ReportWarn("Agent is busy, please wait...")
 	}
@@ -576,6 +778,7 @@ func (a *appModel) View() tea.View {
 	t := styles.CurrentTheme()
 	view.AltScreen = true
 	view.MouseMode = tea.MouseModeCellMotion
+	view.ReportFocus = true
 	view.BackgroundColor = t.BgBase
 	if a.wWidth < 25 || a.wHeight < 15 {
 		view.SetContent(
@@ -587,7 +790,7 @@ func (a *appModel) View() tea.View {
 							t.S().Base.
 								Padding(1, 4).
 								Foreground(t.White).
-								BorderStyle(lipgloss.RoundedBorder()).
+								BorderStyle(styles.Border(lipgloss.RoundedBorder())).
 								BorderForeground(t.Primary).
 								Render("Window too small!"),
 						),
@@ -651,7 +854,7 @@ func (a *appModel) View() tea.View {
 	view.Content = canvas
 	view.Cursor = cursor
 
-	if a.sendProgressBar && a.app != nil && a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsBusy() {
+	if a.sendProgressBar && a.app != nil && a.app.AgentCoordinator() != nil && a.app.AgentCoordinator().IsBusy() {
 		// HACK: use a random percentage to prevent ghostty from hiding it
 		// after a timeout.
 		view.ProgressBar = tea.NewProgressBar(tea.ProgressBarIndeterminate, rand.Intn(100))
@@ -661,7 +864,7 @@ func (a *appModel) View() tea.View {
 
 func (a *appModel) handleStateChanged(ctx context.Context) tea.Cmd {
 	return func() tea.Msg {
-		a.app.UpdateAgentModel(ctx)
+		a.app.RefreshAgentTools(ctx)
 		return nil
 	}
 }
@@ -680,12 +883,43 @@ func handleMCPToolsEvent(ctx context.Context, name string) tea.Cmd {
 	}
 }
 
+func handleMCPResourcesEvent(ctx context.Context, name string) tea.Cmd {
+	return func() tea.Msg {
+		mcp.RefreshResources(ctx, name)
+		return nil
+	}
+}
+
+// setupTheme loads the user-defined themes and switches to the configured
+// one.
+func setupTheme(cfg *config.Config) {
+	manager := styles.DefaultManager()
+	if err := manager.LoadThemes(config.GlobalThemesDir()); err != nil {
+		slog.Warn("Failed to load themes", "error", err)
+	}
+	if cfg == nil || cfg.Options.TUI == nil || cfg.Options.TUI.Theme == "" {
+		return
+	}
+	if err := manager.SetTheme(cfg.Options.TUI.Theme); err != nil {
+		slog.Warn("Failed to set theme", "error", err)
+	}
+}
+
 // New creates and initializes a new TUI application model.
 func New(app *app.App) *appModel {
+	setupTheme(app.Config())
+	if cfg := app.Config(); cfg != nil && cfg.Options.TUI != nil {
+		styles.SetScreenReader(cfg.Options.TUI.ScreenReader)
+	}
 	chatPage := chat.New(app)
 	keyMap := DefaultKeyMap()
 	keyMap.pageBindings = chatPage.Bindings()
 
+	var notifications config.Notifications
+	if cfg := app.Config(); cfg != nil && cfg.Options.TUI != nil {
+		notifications = cfg.Options.TUI.Notifications
+	}
+
 	model := &appModel{
 		currentPage: chat.ChatPageID,
 		app:         app,
@@ -694,11 +928,15 @@ func New(app *app.App) *appModel {
 		keyMap:      keyMap,
 
 		pages: map[page.PageID]util.Model{
-			chat.ChatPageID: chatPage,
+			chat.ChatPageID:     chatPage,
+			review.ReviewPageID: review.New(app),
 		},
 
 		dialog:      dialogs.NewDialogCmp(),
 		completions: completions.New(),
+
+		notifier:      notify.New(notifications),
+		notifiedTurns: make(map[string]string),
 	}
 
 	return model
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// Editor returns the editor set in $EDITOR, or the default editor of the
+// platform.
+func Editor() string {
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "nvim"
+}
+
+// OpenEditor writes the content to a temporary file named after the pattern,
+// as in [os.CreateTemp], and opens it in the editor. Once the editor exits,
+// done is called with the edited content to return the resulting message.
+// The file is removed afterwards.
+func OpenEditor(pattern, content string, done func(edited string) tea.Msg) tea.Cmd {
+	tmpfile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return ReportError(err)
+	}
+	defer tmpfile.Close() //nolint:errcheck
+	if _, err := tmpfile.WriteString(content); err != nil {
+		os.Remove(tmpfile.Name())
+		return ReportError(err)
+	}
+	c := exec.CommandContext(context.TODO(), Editor(), tmpfile.Name())
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		defer os.Remove(tmpfile.Name())
+		if err != nil {
+			return ReportError(err)()
+		}
+		edited, err := os.ReadFile(tmpfile.Name())
+		if err != nil {
+			return ReportError(err)()
+		}
+		return done(string(edited))
+	})
+}